@@ -3,24 +3,36 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/digest"
 	"github.com/garnizeh/englog/internal/handlers"
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/middleware"
 	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/vector"
 	"github.com/garnizeh/englog/internal/worker"
 )
 
 const (
-	defaultPort      = "8080"
-	defaultModelName = "deepseek-r1:1.5b"
-	defaultOllamaURL = "http://localhost:11434"
+	defaultPort       = "8080"
+	defaultQueueFile  = "jobs.json"
+	defaultIndexFile  = "vector_index.json"
+	defaultDigestFile = "digests.json"
+
+	defaultIndexSaveInterval = time.Minute
 )
 
 func main() {
@@ -29,50 +41,209 @@ func main() {
 	// Setup structured logging from environment
 	logger := logging.NewLoggerFromEnv()
 
-	// Initialize in-memory storage
-	store := storage.NewMemoryStore()
+	// Initialize journal storage (memory by default, file for durable storage)
+	store, err := storage.NewStoreFromEnv()
+	if err != nil {
+		logger.Error("Failed to create journal store", "error", err)
+		os.Exit(1)
+	}
+	storageBackend := store.GetStats().Backend
 
-	// Get ollama model name from environment or use default
-	modelName := os.Getenv("OLLAMA_MODEL_NAME")
-	if modelName == "" {
-		modelName = defaultModelName
+	// Initialize the AI provider selected by AI_PROVIDER (ollama by default)
+	provider, err := ai.NewProviderFromEnv(ctx, logger)
+	if err != nil {
+		logger.Error("Failed to create AI provider", "error", err, "available", ai.DefaultRegistry.Names())
+		os.Exit(1)
 	}
+	modelName := provider.Capabilities().Model
 
-	// Get ollama server URL from environment or use default
-	ollamaURL := os.Getenv("OLLAMA_SERVER_URL")
-	if ollamaURL == "" {
-		ollamaURL = defaultOllamaURL
+	// Load prompt templates: built-ins plus AI_PROMPTS_DIR, defaults per task
+	prompts, err := prompt.LoadFromEnv()
+	if err != nil {
+		logger.Error("Failed to load prompt templates", "error", err)
+		os.Exit(1)
+	}
+
+	// Generation options per task, deterministic for analysis by default
+	generationOptions, err := options.FromEnv()
+	if err != nil {
+		logger.Error("Failed to load generation options", "error", err)
+		os.Exit(1)
 	}
 
 	// Log startup configuration
 	logger.LogSystemEvent("application_startup", map[string]any{
 		"version":     "prototype-006",
-		"storage":     "memory",
-		"ai_provider": "ollama",
+		"storage":     storageBackend,
+		"ai_provider": provider.Name(),
 		"model_name":  modelName,
-		"ollama_url":  ollamaURL,
 		"log_level":   os.Getenv("LOG_LEVEL"),
 		"log_format":  os.Getenv("LOG_FORMAT"),
 	})
 
 	// Initialize AI service
-	aiService, err := ai.NewService(ctx, modelName, ollamaURL, logger)
-	if err != nil {
-		logger.Error("Failed to create AI service", "error", err)
-		os.Exit(1)
-	}
+	aiService := ai.NewService(provider, logger)
+	aiService.SetPrompts(prompts)
+	aiService.SetGenerationOptions(generationOptions)
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(store, aiService, logger)
 
-	// Initialize AI worker for synchronous processing
+	// Initialize AI worker and the pool that runs it in the background
 	aiWorker := worker.NewInMemoryWorker(aiService, logger)
+	if storeReasoning, _ := strconv.ParseBool(os.Getenv("AI_STORE_REASONING")); storeReasoning {
+		aiWorker.SetStoreReasoning(true)
+	}
+	if raw := os.Getenv("AI_EMOTION_ANALYSIS"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			logger.Error("Invalid AI_EMOTION_ANALYSIS", "value", raw, "error", err)
+			os.Exit(1)
+		}
+		aiWorker.SetEmotionAnalysis(enabled)
+	}
+	taskTimeouts, err := worker.TaskTimeoutsFromEnv()
+	if err != nil {
+		logger.Error("Failed to load AI task timeouts", "error", err)
+		os.Exit(1)
+	}
+	aiWorker.SetTaskTimeouts(taskTimeouts)
+	if raw := os.Getenv("AI_EXTRACTION"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			logger.Error("Invalid AI_EXTRACTION", "value", raw, "error", err)
+			os.Exit(1)
+		}
+		aiWorker.SetExtraction(enabled)
+	}
+
+	// Durable storage keeps the vector index next to its data; vectors of
+	// journals deleted or edited while the server was down are dropped, and
+	// completed journals without vectors are embedded again in the background
+	vectorIndexPath := os.Getenv("VECTOR_INDEX_PATH")
+	if vectorIndexPath == "" {
+		if dataDir, durable := storage.DataDirFromEnv(); durable {
+			vectorIndexPath = filepath.Join(dataDir, defaultIndexFile)
+		}
+	}
+	vectorIndex, err := vector.LoadIndex(vectorIndexPath)
+	if err != nil {
+		logger.Error("Failed to load vector index", "path", vectorIndexPath, "error", err)
+		os.Exit(1)
+	}
+	journals, err := store.GetAll()
+	if err != nil {
+		logger.Error("Failed to read journals for the vector index", "error", err)
+		os.Exit(1)
+	}
+	contentHashes := make(map[string]string, len(journals))
+	for _, journal := range journals {
+		contentHashes[journal.ID] = vector.ContentHash(journal.Content)
+	}
+	if dropped := vectorIndex.Retain(contentHashes); dropped > 0 {
+		logger.Info("Dropped outdated journal vectors", "count", dropped)
+	}
+	aiWorker.SetVectorIndex(vectorIndex)
+	if raw := os.Getenv("AI_EMBEDDINGS"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			logger.Error("Invalid AI_EMBEDDINGS", "value", raw, "error", err)
+			os.Exit(1)
+		}
+		aiWorker.SetEmbeddings(enabled)
+	}
+	indexSaveInterval := defaultIndexSaveInterval
+	if raw := os.Getenv("VECTOR_INDEX_SAVE_INTERVAL"); raw != "" {
+		indexSaveInterval, err = time.ParseDuration(raw)
+		if err != nil || indexSaveInterval <= 0 {
+			logger.Error("Invalid VECTOR_INDEX_SAVE_INTERVAL", "value", raw, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Backfill and periodic saves run until shutdown, so a crash loses at
+	// most one save interval of vectors, and those are rebuilt on restart
+	indexCtx, stopIndex := context.WithCancel(ctx)
+	var indexTasks sync.WaitGroup
+	indexTasks.Add(1)
+	go func() {
+		defer indexTasks.Done()
+		if embedded := aiWorker.EmbedMissing(indexCtx, journals); embedded > 0 {
+			logger.Info("Embedded journals missing from the vector index", "count", embedded)
+		}
+	}()
+	if vectorIndexPath != "" {
+		indexTasks.Add(1)
+		go func() {
+			defer indexTasks.Done()
+			ticker := time.NewTicker(indexSaveInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-indexCtx.Done():
+					return
+				case <-ticker.C:
+					if _, err := vectorIndex.SaveIfChanged(vectorIndexPath); err != nil {
+						logger.Error("Failed to save vector index", "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+	poolConfig, err := worker.PoolConfigFromEnv()
+	if err != nil {
+		logger.Error("Invalid worker pool configuration", "error", err)
+		os.Exit(1)
+	}
+	// Durable storage keeps the job queue next to its data so jobs survive restarts
+	if poolConfig.QueuePath == "" {
+		if dataDir, durable := storage.DataDirFromEnv(); durable {
+			poolConfig.QueuePath = filepath.Join(dataDir, defaultQueueFile)
+		}
+	}
+	pool, err := worker.NewPool(aiWorker, store, poolConfig, logger)
+	if err != nil {
+		logger.Error("Failed to start worker pool", "error", err)
+		os.Exit(1)
+	}
+
+	adminHandler := handlers.NewAdminHandler(pool, logger)
 
-	// Initialize journal handler with AI worker
-	journalHandler := handlers.NewJournalHandler(store, aiWorker, logger)
+	// Initialize journal handler with asynchronous AI processing
+	journalHandler := handlers.NewAsyncJournalHandler(store, pool, logger)
+	journalHandler.SetGenerator(aiService)
+	journalHandler.SetSemanticSearch(vectorIndex, aiService)
 
 	aiHandler := handlers.NewAIHandler(store, aiService, logger)
 
+	digestConfig, err := digest.ConfigFromEnv()
+	if err != nil {
+		logger.Error("Invalid digest configuration", "error", err)
+		os.Exit(1)
+	}
+	// Durable storage keeps digests next to the journals they summarize
+	if digestConfig.StorePath == "" {
+		if dataDir, durable := storage.DataDirFromEnv(); durable {
+			digestConfig.StorePath = filepath.Join(dataDir, defaultDigestFile)
+		}
+	}
+	digestStore, err := digest.NewStore(digestConfig.StorePath)
+	if err != nil {
+		logger.Error("Failed to open digest store", "path", digestConfig.StorePath, "error", err)
+		os.Exit(1)
+	}
+	digestBuilder := digest.NewBuilder(store, digestStore, aiService, digestConfig, logger)
+	summaryHandler := handlers.NewSummaryHandler(digestBuilder, digestStore, logger)
+
+	analyticsHandler := handlers.NewAnalyticsHandler(store, logger)
+
+	var digestScheduler *digest.Scheduler
+	if len(digestConfig.Schedule) > 0 {
+		digestScheduler = digest.NewScheduler(digestBuilder, digestConfig.Schedule, digestConfig.CheckInterval, logger)
+		digestScheduler.Start()
+	}
+
 	// Setup HTTP server and routes
 	mux := http.NewServeMux()
 
@@ -98,6 +269,17 @@ func main() {
 	mux.Handle("/ai/analyze-sentiment", aiHandler)
 	mux.Handle("/ai/generate-journal", aiHandler)
 	mux.Handle("/ai/health", aiHandler)
+	mux.Handle("/ai/prompts", aiHandler)
+
+	// Digest endpoints
+	mux.Handle("/summaries", summaryHandler)
+	mux.Handle("/summaries/", summaryHandler) // For /summaries/{id} paths
+
+	// Analytics endpoints
+	mux.Handle("/analytics/", analyticsHandler)
+
+	// Admin endpoints
+	mux.Handle("/admin/", adminHandler)
 
 	mux.Handle("/", http.HandlerFunc(defaultHandler))
 
@@ -107,13 +289,18 @@ func main() {
 		port = defaultPort
 	}
 
+	// Requests run on a context cancelled at shutdown, so event streams end
+	// instead of holding the server open until the shutdown timeout
+	baseCtx, cancelRequests := context.WithCancelCause(context.Background())
 	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      handler, // Use our middleware-wrapped handler
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 300 * time.Second,
 		IdleTimeout:  600 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
 	}
+	server.RegisterOnShutdown(func() { cancelRequests(handlers.ErrServerShutdown) })
 
 	// Channel to listen for interrupt signals
 	quit := make(chan os.Signal, 1)
@@ -124,11 +311,13 @@ func main() {
 		logger.WithContext(ctx).Info("Starting EngLog API server",
 			"port", port,
 			"version", "prototype-006",
-			"storage", "memory",
-			"ai_integration", "ollama",
-			"ollama_model", modelName,
-			"ollama_url", ollamaURL,
-			"features", []string{"synchronous_ai_processing", "sentiment_analysis", "structured_logging"})
+			"storage", storageBackend,
+			"ai_provider", provider.Name(),
+			"ai_model", modelName,
+			"worker_pool_size", poolConfig.Workers,
+			"worker_queue_size", poolConfig.QueueSize,
+			"worker_queue_path", poolConfig.QueuePath,
+			"features", []string{"asynchronous_ai_processing", "sentiment_analysis", "structured_logging"})
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Error("Server failed to start", "error", err)
@@ -146,11 +335,43 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", "error", err)
-		os.Exit(1)
+	// A server that does not stop in time still gets its jobs drained and its
+	// storage closed; only the exit status reports the forced shutdown
+	shutdownErr := server.Shutdown(ctx)
+	if shutdownErr != nil {
+		logger.Error("Server forced to shutdown", "error", shutdownErr)
+	}
+
+	if digestScheduler != nil {
+		if err := digestScheduler.Shutdown(ctx); err != nil {
+			logger.Error("Digest scheduler did not stop in time", "error", err)
+		}
 	}
 
+	// Let queued AI jobs finish and write their results before closing storage
+	logger.WithContext(ctx).Info("Draining AI processing queue", "pending", pool.Stats())
+	if err := pool.Shutdown(ctx); err != nil {
+		logger.Error("AI processing queue did not drain in time", "error", err)
+	}
+
+	stopIndex()
+	indexTasks.Wait()
+	if vectorIndexPath != "" {
+		if err := vectorIndex.Save(vectorIndexPath); err != nil {
+			logger.Error("Failed to save vector index", "error", err)
+		}
+	}
+
+	// Flush durable storage before exiting
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Error("Failed to close journal store", "error", err)
+		}
+	}
+
+	if shutdownErr != nil {
+		os.Exit(1)
+	}
 	logger.WithContext(ctx).Info("Server stopped gracefully")
 }
 
@@ -162,19 +383,49 @@ func defaultHandler(w http.ResponseWriter, r *http.Request) {
 		"status":  "active",
 		"features": []string{
 			"Journal CRUD operations",
-			"Synchronous AI sentiment analysis",
-			"In-memory storage",
-			"Ollama integration",
+			"Asynchronous AI sentiment analysis with a durable, retrying job queue",
+			"Multi-dimensional emotion analysis with valence, arousal and per-sentence breakdown",
+			"Entity and topic extraction with an entity index for filtering",
+			"Semantic search and similar journals over chunked embeddings",
+			"Daily, weekly and monthly digests, written on demand or on a schedule",
+			"Mood trend analytics bucketed by day, week or month in any time zone",
+			"Insights relating tags, locations, weekdays, times of day and metadata to sentiment",
+			"Pluggable storage (memory, file or sqlite backend)",
+			"Pluggable AI providers (Ollama by default) with fallback and circuit breakers",
+			"Versioned prompt templates selectable per deployment or per request",
+			"Reproducible analysis with recorded generation options",
+			"Streaming journal generation over Server-Sent Events",
 			"Structured logging and observability",
 		},
 		"endpoints": map[string]string{
-			"health":            "/health",
-			"create_journal":    "POST /journals",
-			"get_all_journals":  "GET /journals",
-			"get_journal_by_id": "GET /journals/{id}",
-			"ai_analyze":        "POST /ai/analyze-sentiment",
-			"ai_generate":       "POST /ai/generate-journal",
-			"ai_health":         "GET /ai/health",
+			"health":             "/health",
+			"create_journal":     "POST /journals",
+			"generate_journal":   "POST /journals/generate",
+			"get_all_journals":   "GET /journals",
+			"search_journals":    "GET /journals/search?q=",
+			"semantic_search":    "GET /journals/search/semantic?q=",
+			"similar_journals":   "GET /journals/{id}/similar",
+			"get_journal_by_id":  "GET /journals/{id}",
+			"update_journal":     "PUT /journals/{id}",
+			"patch_journal":      "PATCH /journals/{id}",
+			"delete_journal":     "DELETE /journals/{id}",
+			"journal_processing": "GET /journals/{id}/processing",
+			"journal_revisions":  "GET /journals/{id}/revisions",
+			"revision_diff":      "GET /journals/{id}/revisions/diff?from={n}&to={m}",
+			"restore_revision":   "POST /journals/{id}/revisions/{n}/restore",
+			"create_summary":     "POST /summaries",
+			"list_summaries":     "GET /summaries?period=",
+			"get_summary":        "GET /summaries/{id}",
+			"mood_analytics":     "GET /analytics/mood?bucket=&tz=",
+			"insights":           "GET /analytics/insights?tz=",
+			"ai_analyze":         "POST /ai/analyze-sentiment",
+			"ai_generate":        "POST /ai/generate-journal",
+			"ai_health":          "GET /ai/health",
+			"ai_prompts":         "GET /ai/prompts",
+			"ai_status":          "GET /status/ai",
+			"dead_letters":       "GET /admin/dlq",
+			"retry_dead_letter":  "POST /admin/dlq/{journal_id}/retry",
+			"purge_dead_letter":  "DELETE /admin/dlq/{journal_id}",
 		},
 		"documentation": "https://github.com/garnizeh/englog",
 	}
@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Calls flow normally
+	BreakerOpen     BreakerState = "open"      // Calls are rejected until the open timeout passes
+	BreakerHalfOpen BreakerState = "half_open" // A limited number of probe calls are let through
+)
+
+const (
+	defaultBreakerFailureThreshold = 3
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenMaxCalls = 1
+)
+
+// BreakerConfig configures a circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int           // Consecutive failures that open the breaker
+	OpenTimeout      time.Duration // How long the breaker stays open before probing
+	HalfOpenMaxCalls int           // Concurrent probe calls allowed while half-open
+}
+
+// DefaultBreakerConfig returns the breaker settings used when nothing is configured
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: defaultBreakerFailureThreshold,
+		OpenTimeout:      defaultBreakerOpenTimeout,
+		HalfOpenMaxCalls: defaultBreakerHalfOpenMaxCalls,
+	}
+}
+
+// BreakerConfigFromEnv reads AI_BREAKER_FAILURE_THRESHOLD,
+// AI_BREAKER_OPEN_TIMEOUT and AI_BREAKER_HALF_OPEN_MAX_CALLS
+func BreakerConfigFromEnv() (BreakerConfig, error) {
+	config := DefaultBreakerConfig()
+
+	for name, target := range map[string]*int{
+		"AI_BREAKER_FAILURE_THRESHOLD":   &config.FailureThreshold,
+		"AI_BREAKER_HALF_OPEN_MAX_CALLS": &config.HalfOpenMaxCalls,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return config, fmt.Errorf("invalid %s %q: must be a positive integer", name, raw)
+		}
+		*target = value
+	}
+
+	if raw := os.Getenv("AI_BREAKER_OPEN_TIMEOUT"); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil || value <= 0 {
+			return config, fmt.Errorf("invalid AI_BREAKER_OPEN_TIMEOUT %q: must be a positive duration", raw)
+		}
+		config.OpenTimeout = value
+	}
+
+	return config, nil
+}
+
+// BreakerSnapshot is a point-in-time view of a circuit breaker
+type BreakerSnapshot struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker stops calls to a backend after repeated failures
+//
+// Closed, it counts consecutive failures and opens at FailureThreshold.
+// Open, it rejects calls until OpenTimeout has passed, then turns half-open
+// and lets up to HalfOpenMaxCalls probes through: one success closes it
+// again and one failure reopens it.
+type CircuitBreaker struct {
+	config BreakerConfig
+
+	mu            sync.Mutex
+	state         BreakerState
+	failures      int
+	openedAt      time.Time
+	halfOpenCalls int
+	lastError     string
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+// Zero config fields fall back to the defaults.
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	defaults := DefaultBreakerConfig()
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+	if config.HalfOpenMaxCalls < 1 {
+		config.HalfOpenMaxCalls = defaults.HalfOpenMaxCalls
+	}
+
+	return &CircuitBreaker{config: config, state: BreakerClosed}
+}
+
+// Allow reports whether a call may proceed
+// Every allowed call must be followed by Success, Failure or Release.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.halfOpenCalls = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.halfOpenCalls >= b.config.HalfOpenMaxCalls {
+			return false
+		}
+		b.halfOpenCalls++
+	}
+
+	return true
+}
+
+// Success records a successful call and closes the breaker
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.halfOpenCalls = 0
+}
+
+// Failure records a failed call, opening the breaker when it trips
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.lastError = err.Error()
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.halfOpenCalls = 0
+	}
+}
+
+// Release gives back an allowed call that ended without a verdict, such as
+// one canceled by its caller
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.halfOpenCalls > 0 {
+		b.halfOpenCalls--
+	}
+}
+
+// Snapshot returns the current breaker state
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt.UTC()
+		retryAt := openedAt.Add(b.config.OpenTimeout)
+		snapshot.OpenedAt = &openedAt
+		snapshot.RetryAt = &retryAt
+	}
+
+	return snapshot
+}
@@ -0,0 +1,348 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// ErrNoProviderAvailable is returned when every provider in a chain failed or
+// was skipped by its circuit breaker
+var ErrNoProviderAvailable = errors.New("no AI provider available")
+
+// ProviderStatus describes one provider of a chain
+type ProviderStatus struct {
+	Name         string                `json:"name"`
+	Capabilities models.AICapabilities `json:"capabilities"`
+	Breaker      BreakerSnapshot       `json:"breaker"`
+}
+
+// chainLink is a provider guarded by its own circuit breaker
+type chainLink struct {
+	provider Provider
+	breaker  *CircuitBreaker
+}
+
+// Chain is a Provider that tries an ordered list of providers, skipping the
+// ones whose circuit breaker is open, and returns the first answer
+type Chain struct {
+	links          []*chainLink
+	attemptTimeout time.Duration
+	logger         *logging.Logger
+}
+
+// Ensure Chain implements every provider interface
+var (
+	_ Provider           = (*Chain)(nil)
+	_ StreamingProvider  = (*Chain)(nil)
+	_ EmotionProvider    = (*Chain)(nil)
+	_ ExtractionProvider = (*Chain)(nil)
+	_ SummaryProvider    = (*Chain)(nil)
+	_ EmbeddingProvider  = (*Chain)(nil)
+)
+
+// NewChain creates a fallback chain; every provider gets a breaker built from config
+func NewChain(providers []Provider, config BreakerConfig, logger *logging.Logger) (*Chain, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("provider chain needs at least one provider")
+	}
+
+	links := make([]*chainLink, len(providers))
+	for i, provider := range providers {
+		links[i] = &chainLink{provider: provider, breaker: NewCircuitBreaker(config)}
+	}
+
+	return &Chain{links: links, logger: logger}, nil
+}
+
+// SetAttemptTimeout caps how long a single provider may take before the chain
+// counts it as failed and moves on; zero leaves attempts bounded only by the
+// caller's deadline
+func (c *Chain) SetAttemptTimeout(timeout time.Duration) {
+	c.attemptTimeout = timeout
+}
+
+// AttemptTimeoutFromEnv reads AI_ATTEMPT_TIMEOUT; it returns zero when unset
+func AttemptTimeoutFromEnv() (time.Duration, error) {
+	raw := os.Getenv("AI_ATTEMPT_TIMEOUT")
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid AI_ATTEMPT_TIMEOUT %q: must be a positive duration", raw)
+	}
+	return value, nil
+}
+
+// Name returns the provider names in fallback order, e.g. "ollama,openai"
+func (c *Chain) Name() string {
+	names := make([]string, len(c.links))
+	for i, link := range c.links {
+		names[i] = link.provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Capabilities combines the capabilities of all providers
+// Model is the primary provider's model.
+func (c *Chain) Capabilities() models.AICapabilities {
+	caps := models.AICapabilities{Model: c.links[0].provider.Capabilities().Model}
+	for _, link := range c.links {
+		linkCaps := link.provider.Capabilities()
+		caps.Sentiment = caps.Sentiment || linkCaps.Sentiment
+		caps.Generation = caps.Generation || linkCaps.Generation
+		caps.Emotions = caps.Emotions || linkCaps.Emotions
+		caps.Extraction = caps.Extraction || linkCaps.Extraction
+		caps.Summaries = caps.Summaries || linkCaps.Summaries
+		caps.Embeddings = caps.Embeddings || linkCaps.Embeddings
+		caps.Streaming = caps.Streaming || linkCaps.Streaming
+		caps.StructuredOutput = caps.StructuredOutput || linkCaps.StructuredOutput
+	}
+	return caps
+}
+
+// Status returns each provider with its breaker state, in fallback order
+func (c *Chain) Status() []ProviderStatus {
+	statuses := make([]ProviderStatus, len(c.links))
+	for i, link := range c.links {
+		statuses[i] = ProviderStatus{
+			Name:         link.provider.Name(),
+			Capabilities: link.provider.Capabilities(),
+			Breaker:      link.breaker.Snapshot(),
+		}
+	}
+	return statuses
+}
+
+// AnalyzeSentiment asks each sentiment-capable provider in turn
+func (c *Chain) AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error) {
+	return runChain(ctx, c, "sentiment",
+		func(caps models.AICapabilities) bool { return caps.Sentiment },
+		func(ctx context.Context, provider Provider) (*models.SentimentResult, error) {
+			result, err := provider.AnalyzeSentiment(ctx, content)
+			if err == nil && result.Provider == "" {
+				result.Provider = provider.Name()
+			}
+			return result, err
+		})
+}
+
+// AnalyzeEmotions asks each provider that can analyze emotions in turn
+func (c *Chain) AnalyzeEmotions(ctx context.Context, content string) (*models.EmotionResult, error) {
+	return runChain(ctx, c, "emotion",
+		func(caps models.AICapabilities) bool { return caps.Emotions },
+		func(ctx context.Context, provider Provider) (*models.EmotionResult, error) {
+			analyzer, ok := provider.(EmotionProvider)
+			if !ok {
+				return nil, fmt.Errorf("%s reports emotion support but does not implement it", provider.Name())
+			}
+			result, err := analyzer.AnalyzeEmotions(ctx, content)
+			if err == nil && result.Provider == "" {
+				result.Provider = provider.Name()
+			}
+			return result, err
+		})
+}
+
+// ExtractEntities asks each provider that can extract entities in turn
+func (c *Chain) ExtractEntities(ctx context.Context, content string) (*models.ExtractionResult, error) {
+	return runChain(ctx, c, "extraction",
+		func(caps models.AICapabilities) bool { return caps.Extraction },
+		func(ctx context.Context, provider Provider) (*models.ExtractionResult, error) {
+			extractor, ok := provider.(ExtractionProvider)
+			if !ok {
+				return nil, fmt.Errorf("%s reports extraction support but does not implement it", provider.Name())
+			}
+			result, err := extractor.ExtractEntities(ctx, content)
+			if err == nil && result.Provider == "" {
+				result.Provider = provider.Name()
+			}
+			return result, err
+		})
+}
+
+// Summarize asks each provider that can summarize periods in turn
+func (c *Chain) Summarize(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+	return runChain(ctx, c, "summary",
+		func(caps models.AICapabilities) bool { return caps.Summaries },
+		func(ctx context.Context, provider Provider) (*models.SummaryResult, error) {
+			summarizer, ok := provider.(SummaryProvider)
+			if !ok {
+				return nil, fmt.Errorf("%s reports summary support but does not implement it", provider.Name())
+			}
+			result, err := summarizer.Summarize(ctx, req)
+			if err == nil && result.Provider == "" {
+				result.Provider = provider.Name()
+			}
+			return result, err
+		})
+}
+
+// Embed asks each provider that can embed text in turn
+// Providers may use different embedding models; the result names the one used.
+func (c *Chain) Embed(ctx context.Context, texts []string) (*models.Embeddings, error) {
+	return runChain(ctx, c, "embedding",
+		func(caps models.AICapabilities) bool { return caps.Embeddings },
+		func(ctx context.Context, provider Provider) (*models.Embeddings, error) {
+			embedder, ok := provider.(EmbeddingProvider)
+			if !ok {
+				return nil, fmt.Errorf("%s reports embedding support but does not implement it", provider.Name())
+			}
+			return embedder.Embed(ctx, texts)
+		})
+}
+
+// GenerateJournal asks each generation-capable provider in turn
+func (c *Chain) GenerateJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+	return runChain(ctx, c, "generation",
+		func(caps models.AICapabilities) bool { return caps.Generation },
+		func(ctx context.Context, provider Provider) (*models.GeneratedJournal, error) {
+			return provider.GenerateJournal(ctx, req)
+		})
+}
+
+// GenerateJournalStream asks each generation-capable provider in turn,
+// streaming from those that support it; the others answer in one piece
+// Once a provider has streamed text the chain stops there, since the caller
+// has already shown that text.
+func (c *Chain) GenerateJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error) {
+	return runChain(ctx, c, "generation",
+		func(caps models.AICapabilities) bool { return caps.Generation },
+		func(ctx context.Context, provider Provider) (*models.GeneratedJournal, error) {
+			streamer, ok := provider.(StreamingProvider)
+			if !ok {
+				return provider.GenerateJournal(ctx, req)
+			}
+
+			streamed := false
+			result, err := streamer.GenerateJournalStream(ctx, req, func(token string) {
+				streamed = true
+				onToken(token)
+			})
+			if err != nil && streamed {
+				return nil, &haltError{err: err}
+			}
+			return result, err
+		})
+}
+
+// haltError ends a chain after a provider failed in a way the next provider
+// cannot make up for
+type haltError struct {
+	err error
+}
+
+func (e *haltError) Error() string { return e.err.Error() }
+func (e *haltError) Unwrap() error { return e.err }
+
+// HealthCheck succeeds when at least one provider is healthy
+func (c *Chain) HealthCheck(ctx context.Context) error {
+	var errs []error
+	for _, link := range c.links {
+		err := link.provider.HealthCheck(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", link.provider.Name(), err))
+	}
+	return fmt.Errorf("%w: %w", ErrNoProviderAvailable, errors.Join(errs...))
+}
+
+// runChain calls each capable provider whose breaker allows it until one succeeds
+//
+// Each attempt runs under its own context from attemptContext, so a provider
+// that hangs fails on its own deadline and leaves time for the next one.
+// Answers that fail validation fall through to the next provider without
+// counting against the breaker: the provider responded, and the breaker is
+// shared by every task.
+func runChain[T any](ctx context.Context, c *Chain, task string, capable func(models.AICapabilities) bool, call func(context.Context, Provider) (T, error)) (T, error) {
+	var (
+		zero    T
+		errs    []error
+		skipped []string
+	)
+
+	var links []*chainLink
+	for _, link := range c.links {
+		if capable(link.provider.Capabilities()) {
+			links = append(links, link)
+		}
+	}
+
+	for i, link := range links {
+		name := link.provider.Name()
+		if !link.breaker.Allow() {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		attemptCtx, cancel := c.attemptContext(ctx, len(links)-i)
+		result, err := call(attemptCtx, link.provider)
+		timedOut := attemptCtx.Err() != nil
+		cancel()
+		if err == nil {
+			link.breaker.Success()
+			return result, nil
+		}
+
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about the provider
+			link.breaker.Release()
+			return zero, err
+		}
+
+		if errors.Is(err, models.ErrInvalidAIResponse) {
+			link.breaker.Release()
+		} else {
+			link.breaker.Failure(err)
+		}
+
+		var halt *haltError
+		if errors.As(err, &halt) {
+			return zero, fmt.Errorf("%s: %w", name, halt.err)
+		}
+
+		c.logger.Warn("AI provider failed, trying next",
+			"task", task,
+			"provider", name,
+			"breaker_state", link.breaker.Snapshot().State,
+			"timed_out", timedOut,
+			"error", err,
+		)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	if len(skipped) > 0 {
+		errs = append(errs, fmt.Errorf("circuit open for %s", strings.Join(skipped, ", ")))
+	}
+	if len(errs) == 0 {
+		return zero, fmt.Errorf("%w: no provider supports %s", ErrNoProviderAvailable, task)
+	}
+
+	return zero, fmt.Errorf("%w for %s: %w", ErrNoProviderAvailable, task, errors.Join(errs...))
+}
+
+// attemptContext bounds one provider attempt, with remaining providers left
+// to try including this one
+// When the caller has a deadline it is shared evenly among those providers,
+// so the last one always gets the rest; the attempt timeout, if set, caps
+// every attempt.
+func (c *Chain) attemptContext(ctx context.Context, remaining int) (context.Context, context.CancelFunc) {
+	timeout := c.attemptTimeout
+	if deadline, ok := ctx.Deadline(); ok && remaining > 1 {
+		if share := time.Until(deadline) / time.Duration(remaining); timeout <= 0 || share < timeout {
+			timeout = share
+		}
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
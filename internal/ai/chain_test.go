@@ -0,0 +1,516 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// namedProvider is a mock provider reporting a custom name
+type namedProvider struct {
+	*ai.MockAIProvider
+	name         string
+	noGeneration bool
+	noEmotions   bool
+	noExtraction bool
+	noEmbeddings bool
+	noSummaries  bool
+	calls        atomic.Int32
+}
+
+func (p *namedProvider) Name() string { return p.name }
+
+func (p *namedProvider) Capabilities() models.AICapabilities {
+	caps := p.MockAIProvider.Capabilities()
+	caps.Generation = !p.noGeneration
+	caps.Emotions = !p.noEmotions
+	caps.Extraction = !p.noExtraction
+	caps.Embeddings = !p.noEmbeddings
+	caps.Summaries = !p.noSummaries
+	return caps
+}
+
+func (p *namedProvider) AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error) {
+	p.calls.Add(1)
+	return p.MockAIProvider.AnalyzeSentiment(ctx, content)
+}
+
+// newNamedProvider creates a provider that fails with err, or succeeds when err is nil
+func newNamedProvider(name string, err error) *namedProvider {
+	mock := ai.NewMockAIProvider()
+	if err != nil {
+		mock.ProcessJournalSentimentFunc = func(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+			return nil, err
+		}
+	}
+	return &namedProvider{MockAIProvider: mock, name: name}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := ai.NewCircuitBreaker(ai.BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+		HalfOpenMaxCalls: 1,
+	})
+	failure := errors.New("connection refused")
+
+	if !breaker.Allow() {
+		t.Fatal("Expected closed breaker to allow calls")
+	}
+	breaker.Failure(failure)
+	if state := breaker.Snapshot().State; state != ai.BreakerClosed {
+		t.Fatalf("Expected closed after one failure, got %s", state)
+	}
+
+	breaker.Allow()
+	breaker.Failure(failure)
+	snapshot := breaker.Snapshot()
+	if snapshot.State != ai.BreakerOpen || snapshot.RetryAt == nil || snapshot.LastError != failure.Error() {
+		t.Fatalf("Expected open breaker with retry time and last error, got %+v", snapshot)
+	}
+	if breaker.Allow() {
+		t.Fatal("Expected open breaker to reject calls")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if !breaker.Allow() {
+		t.Fatal("Expected a probe after the open timeout")
+	}
+	if state := breaker.Snapshot().State; state != ai.BreakerHalfOpen {
+		t.Fatalf("Expected half-open, got %s", state)
+	}
+	if breaker.Allow() {
+		t.Fatal("Expected only one concurrent probe")
+	}
+
+	// A failed probe reopens immediately
+	breaker.Failure(failure)
+	if state := breaker.Snapshot().State; state != ai.BreakerOpen {
+		t.Fatalf("Expected reopened breaker, got %s", state)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// A released probe frees its slot without a verdict
+	breaker.Allow()
+	breaker.Release()
+	if !breaker.Allow() {
+		t.Fatal("Expected released probe slot to be reusable")
+	}
+
+	breaker.Success()
+	snapshot = breaker.Snapshot()
+	if snapshot.State != ai.BreakerClosed || snapshot.ConsecutiveFailures != 0 || snapshot.OpenedAt != nil {
+		t.Errorf("Expected closed breaker after a successful probe, got %+v", snapshot)
+	}
+}
+
+func TestBreakerConfigFromEnv(t *testing.T) {
+	t.Setenv("AI_BREAKER_FAILURE_THRESHOLD", "5")
+	t.Setenv("AI_BREAKER_OPEN_TIMEOUT", "1m")
+
+	config, err := ai.BreakerConfigFromEnv()
+	if err != nil {
+		t.Fatalf("BreakerConfigFromEnv failed: %v", err)
+	}
+	want := ai.BreakerConfig{FailureThreshold: 5, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1}
+	if config != want {
+		t.Errorf("Expected %+v, got %+v", want, config)
+	}
+
+	t.Setenv("AI_BREAKER_OPEN_TIMEOUT", "soon")
+	if _, err := ai.BreakerConfigFromEnv(); err == nil {
+		t.Error("Expected error for invalid duration")
+	}
+}
+
+func TestChain_FallsBackAndRecordsProvider(t *testing.T) {
+	primary := newNamedProvider("primary", errors.New("connection refused"))
+	secondary := newNamedProvider("secondary", nil)
+
+	chain, err := ai.NewChain([]ai.Provider{primary, secondary}, ai.BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Hour,
+	}, logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+	service := ai.NewService(chain, logger())
+
+	journal := &models.Journal{ID: "j1", Content: "A calm and pleasant afternoon."}
+	for range 3 {
+		result, err := service.ProcessJournalSentiment(context.Background(), journal)
+		if err != nil {
+			t.Fatalf("Expected fallback to succeed, got %v", err)
+		}
+		if result.Provider != "secondary" {
+			t.Errorf("Expected result from secondary, got %q", result.Provider)
+		}
+	}
+
+	// The primary breaker opens after two failures and the third call skips it
+	if calls := primary.calls.Load(); calls != 2 {
+		t.Errorf("Expected primary to be called twice, got %d", calls)
+	}
+
+	statuses := service.ProviderStatus()
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 provider statuses, got %d", len(statuses))
+	}
+	if statuses[0].Name != "primary" || statuses[0].Breaker.State != ai.BreakerOpen {
+		t.Errorf("Expected primary breaker open, got %+v", statuses[0])
+	}
+	if statuses[1].Breaker.State != ai.BreakerClosed {
+		t.Errorf("Expected secondary breaker closed, got %+v", statuses[1])
+	}
+
+	if chain.Name() != "primary,secondary" {
+		t.Errorf("Unexpected chain name %q", chain.Name())
+	}
+}
+
+func TestChain_AllProvidersFail(t *testing.T) {
+	invalid := newNamedProvider("primary", models.ErrInvalidAIResponse)
+	down := newNamedProvider("secondary", errors.New("connection refused"))
+
+	chain, err := ai.NewChain([]ai.Provider{invalid, down}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	_, err = chain.AnalyzeSentiment(context.Background(), "content")
+	if !errors.Is(err, ai.ErrNoProviderAvailable) {
+		t.Errorf("Expected ErrNoProviderAvailable, got %v", err)
+	}
+	if !errors.Is(err, models.ErrInvalidAIResponse) {
+		t.Errorf("Expected provider errors to be wrapped, got %v", err)
+	}
+}
+
+func TestChain_CanceledCallDoesNotTripBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	provider := newNamedProvider("primary", context.Canceled)
+	chain, err := ai.NewChain([]ai.Provider{provider}, ai.BreakerConfig{FailureThreshold: 1}, logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	if _, err := chain.AnalyzeSentiment(ctx, "content"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if state := chain.Status()[0].Breaker.State; state != ai.BreakerClosed {
+		t.Errorf("Expected breaker to stay closed, got %s", state)
+	}
+}
+
+func TestChain_HungProviderFallsBackWithinDeadline(t *testing.T) {
+	hung := newNamedProvider("ollama", nil)
+	hung.ProcessJournalSentimentFunc = func(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	fallback := newNamedProvider("lexicon", nil)
+
+	chain, err := ai.NewChain([]ai.Provider{hung, fallback}, ai.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}, logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	// The hung provider gets half of the caller's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	result, err := chain.AnalyzeSentiment(ctx, "A calm and pleasant afternoon.")
+	if err != nil {
+		t.Fatalf("Expected the fallback to answer before the deadline, got %v", err)
+	}
+	if result.Provider != "lexicon" {
+		t.Errorf("Expected result from the fallback, got %q", result.Provider)
+	}
+	if snapshot := chain.Status()[0].Breaker; snapshot.State != ai.BreakerOpen || !strings.Contains(snapshot.LastError, "deadline exceeded") {
+		t.Errorf("Expected the timeout to trip the breaker, got %+v", snapshot)
+	}
+
+	// Without a caller deadline, the attempt timeout bounds the hung provider
+	chain, _ = ai.NewChain([]ai.Provider{hung, fallback}, ai.DefaultBreakerConfig(), logger())
+	chain.SetAttemptTimeout(50 * time.Millisecond)
+	if result, err := chain.AnalyzeSentiment(context.Background(), "A calm and pleasant afternoon."); err != nil || result.Provider != "lexicon" {
+		t.Errorf("Expected the fallback after the attempt timeout, got %+v, %v", result, err)
+	}
+}
+
+func TestChain_InvalidResponseDoesNotTripBreaker(t *testing.T) {
+	primary := newNamedProvider("ollama", nil)
+	primary.ProcessJournalExtractionFunc = func(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error) {
+		return nil, fmt.Errorf("failed to parse response: %w", models.ErrInvalidAIResponse)
+	}
+	secondary := newNamedProvider("openai", nil)
+
+	chain, err := ai.NewChain([]ai.Provider{primary, secondary}, ai.BreakerConfig{FailureThreshold: 1}, logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	result, err := chain.ExtractEntities(context.Background(), "Pairing with Alice")
+	if err != nil || result.Provider != "openai" {
+		t.Fatalf("Expected the next provider to answer, got %+v, %v", result, err)
+	}
+	// A bad extraction must not keep sentiment analysis away from the provider
+	if snapshot := chain.Status()[0].Breaker; snapshot.State != ai.BreakerClosed || snapshot.ConsecutiveFailures != 0 {
+		t.Errorf("Expected the breaker to stay closed, got %+v", snapshot)
+	}
+	if result, err := chain.AnalyzeSentiment(context.Background(), "A calm day"); err != nil || result.Provider != "ollama" {
+		t.Errorf("Expected the primary to analyze sentiment, got %+v, %v", result, err)
+	}
+}
+
+func TestAttemptTimeoutFromEnv(t *testing.T) {
+	if timeout, err := ai.AttemptTimeoutFromEnv(); err != nil || timeout != 0 {
+		t.Errorf("Expected no attempt timeout by default, got %s, %v", timeout, err)
+	}
+
+	t.Setenv("AI_ATTEMPT_TIMEOUT", "10s")
+	if timeout, err := ai.AttemptTimeoutFromEnv(); err != nil || timeout != 10*time.Second {
+		t.Errorf("Expected 10s, got %s, %v", timeout, err)
+	}
+
+	t.Setenv("AI_ATTEMPT_TIMEOUT", "-1s")
+	if _, err := ai.AttemptTimeoutFromEnv(); err == nil {
+		t.Error("Expected error for a negative duration")
+	}
+}
+
+func TestChain_SkipsIncapableProviders(t *testing.T) {
+	if _, err := ai.NewChain(nil, ai.DefaultBreakerConfig(), logger()); err == nil {
+		t.Error("Expected error for empty chain")
+	}
+
+	lexicon := newNamedProvider("lexicon", nil)
+	lexicon.noGeneration = true
+	lexicon.GenerateStructuredJournalFunc = func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+		t.Error("Generation must not be routed to a provider without the capability")
+		return nil, errors.New("unsupported")
+	}
+
+	chain, err := ai.NewChain([]ai.Provider{lexicon}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+	if _, err := chain.GenerateJournal(context.Background(), &models.PromptRequest{Prompt: "Write about today"}); !errors.Is(err, ai.ErrNoProviderAvailable) {
+		t.Errorf("Expected ErrNoProviderAvailable, got %v", err)
+	}
+
+	chain, err = ai.NewChain([]ai.Provider{lexicon, newNamedProvider("llm", nil)}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+	if _, err := chain.GenerateJournal(context.Background(), &models.PromptRequest{Prompt: "Write about today"}); err != nil {
+		t.Errorf("GenerateJournal failed: %v", err)
+	}
+	if caps := chain.Capabilities(); !caps.Generation || caps.Model != ai.MockProviderName {
+		t.Errorf("Unexpected chain capabilities: %+v", caps)
+	}
+}
+
+func TestChain_AnalyzeEmotions(t *testing.T) {
+	lexicon := newNamedProvider("lexicon", nil)
+	lexicon.noEmotions = true
+	lexicon.ProcessJournalEmotionsFunc = func(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+		t.Error("Emotion analysis must not be routed to a provider without the capability")
+		return nil, errors.New("unsupported")
+	}
+	failing := newNamedProvider("ollama", nil)
+	failing.ProcessJournalEmotionsFunc = func(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	chain, err := ai.NewChain([]ai.Provider{lexicon, failing, newNamedProvider("openai", nil)}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	result, err := chain.AnalyzeEmotions(context.Background(), "A bright morning")
+	if err != nil {
+		t.Fatalf("AnalyzeEmotions failed: %v", err)
+	}
+	if result.Provider != "openai" || result.DominantEmotion != models.EmotionJoy {
+		t.Errorf("Expected the fallback's result, got %+v", result)
+	}
+	if !chain.Capabilities().Emotions {
+		t.Error("Expected the chain to report emotion support")
+	}
+
+	chain, _ = ai.NewChain([]ai.Provider{lexicon}, ai.DefaultBreakerConfig(), logger())
+	if _, err := chain.AnalyzeEmotions(context.Background(), "A bright morning"); !errors.Is(err, ai.ErrNoProviderAvailable) {
+		t.Errorf("Expected ErrNoProviderAvailable, got %v", err)
+	}
+}
+
+func TestChain_ExtractEntities(t *testing.T) {
+	lexicon := newNamedProvider("lexicon", nil)
+	lexicon.noExtraction = true
+	lexicon.ProcessJournalExtractionFunc = func(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error) {
+		t.Error("Extraction must not be routed to a provider without the capability")
+		return nil, errors.New("unsupported")
+	}
+
+	chain, err := ai.NewChain([]ai.Provider{lexicon, newNamedProvider("ollama", nil)}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	result, err := chain.ExtractEntities(context.Background(), "Pairing with Alice")
+	if err != nil {
+		t.Fatalf("ExtractEntities failed: %v", err)
+	}
+	if result.Provider != "ollama" || len(result.People) != 1 {
+		t.Errorf("Expected the capable provider's result, got %+v", result)
+	}
+	if !chain.Capabilities().Extraction {
+		t.Error("Expected the chain to report extraction support")
+	}
+
+	chain, _ = ai.NewChain([]ai.Provider{lexicon}, ai.DefaultBreakerConfig(), logger())
+	if _, err := chain.ExtractEntities(context.Background(), "Pairing with Alice"); !errors.Is(err, ai.ErrNoProviderAvailable) {
+		t.Errorf("Expected ErrNoProviderAvailable, got %v", err)
+	}
+}
+
+func TestChain_Summarize(t *testing.T) {
+	lexicon := newNamedProvider("lexicon", nil)
+	lexicon.noSummaries = true
+	lexicon.SummarizeFunc = func(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+		t.Error("Summaries must not be routed to a provider without the capability")
+		return nil, errors.New("unsupported")
+	}
+
+	chain, err := ai.NewChain([]ai.Provider{lexicon, newNamedProvider("ollama", nil)}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	req := &models.SummaryRequest{Period: models.DigestDaily, Entries: []string{"Morning run"}}
+	result, err := chain.Summarize(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Provider != "ollama" || len(result.NotableEvents) != 1 {
+		t.Errorf("Expected the capable provider's result, got %+v", result)
+	}
+	if !chain.Capabilities().Summaries {
+		t.Error("Expected the chain to report summary support")
+	}
+
+	chain, _ = ai.NewChain([]ai.Provider{lexicon}, ai.DefaultBreakerConfig(), logger())
+	if _, err := chain.Summarize(context.Background(), req); !errors.Is(err, ai.ErrNoProviderAvailable) {
+		t.Errorf("Expected ErrNoProviderAvailable, got %v", err)
+	}
+}
+
+func TestChain_Embed(t *testing.T) {
+	lexicon := newNamedProvider("lexicon", nil)
+	lexicon.noEmbeddings = true
+	lexicon.EmbedFunc = func(ctx context.Context, texts []string) (*models.Embeddings, error) {
+		t.Error("Embedding must not be routed to a provider without the capability")
+		return nil, errors.New("unsupported")
+	}
+
+	chain, err := ai.NewChain([]ai.Provider{lexicon, newNamedProvider("ollama", nil)}, ai.DefaultBreakerConfig(), logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+
+	result, err := chain.Embed(context.Background(), []string{"Morning run", "Evening run"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if result.Model != ai.MockEmbeddingModel || len(result.Vectors) != 2 {
+		t.Errorf("Expected the capable provider's vectors, got %+v", result)
+	}
+	if !chain.Capabilities().Embeddings {
+		t.Error("Expected the chain to report embedding support")
+	}
+
+	chain, _ = ai.NewChain([]ai.Provider{lexicon}, ai.DefaultBreakerConfig(), logger())
+	if _, err := chain.Embed(context.Background(), []string{"Morning run"}); !errors.Is(err, ai.ErrNoProviderAvailable) {
+		t.Errorf("Expected ErrNoProviderAvailable, got %v", err)
+	}
+}
+
+// streamingProvider streams tokens before answering with err, or with the
+// mock journal when err is nil
+type streamingProvider struct {
+	*namedProvider
+	tokens []string
+	err    error
+}
+
+func (p *streamingProvider) GenerateJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error) {
+	p.calls.Add(1)
+	for _, token := range p.tokens {
+		onToken(token)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.GenerateJournal(ctx, req)
+}
+
+func TestChain_GenerateJournalStream(t *testing.T) {
+	req := &models.PromptRequest{Prompt: "Write about today"}
+
+	t.Run("streams from a streaming provider", func(t *testing.T) {
+		primary := &streamingProvider{namedProvider: newNamedProvider("ollama", nil), tokens: []string{"{", "}"}}
+		chain, _ := ai.NewChain([]ai.Provider{primary}, ai.DefaultBreakerConfig(), logger())
+
+		var tokens []string
+		result, err := chain.GenerateJournalStream(context.Background(), req, func(token string) { tokens = append(tokens, token) })
+		if err != nil || result == nil {
+			t.Fatalf("GenerateJournalStream failed: %v", err)
+		}
+		if len(tokens) != 2 {
+			t.Errorf("Expected 2 tokens, got %v", tokens)
+		}
+	})
+
+	t.Run("falls back before any token", func(t *testing.T) {
+		primary := &streamingProvider{namedProvider: newNamedProvider("ollama", nil), err: errors.New("connection refused")}
+		secondary := newNamedProvider("openai", nil)
+		chain, _ := ai.NewChain([]ai.Provider{primary, secondary}, ai.DefaultBreakerConfig(), logger())
+
+		result, err := chain.GenerateJournalStream(context.Background(), req, func(string) {
+			t.Error("Expected no tokens from a provider that cannot stream")
+		})
+		if err != nil || result == nil {
+			t.Fatalf("Expected the fallback provider to answer, got %v", err)
+		}
+	})
+
+	t.Run("stops after streamed tokens", func(t *testing.T) {
+		interrupted := errors.New("stream ended before the response was done")
+		primary := &streamingProvider{namedProvider: newNamedProvider("ollama", nil), tokens: []string{"{\"content\""}, err: interrupted}
+		secondary := newNamedProvider("openai", nil)
+		secondary.GenerateStructuredJournalFunc = func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+			t.Error("Expected the chain to stop once text was streamed")
+			return nil, errors.New("unexpected call")
+		}
+		chain, _ := ai.NewChain([]ai.Provider{primary, secondary}, ai.DefaultBreakerConfig(), logger())
+
+		_, err := chain.GenerateJournalStream(context.Background(), req, func(string) {})
+		if !errors.Is(err, interrupted) {
+			t.Errorf("Expected the streaming provider's error, got %v", err)
+		}
+		if state := chain.Status()[0].Breaker.ConsecutiveFailures; state != 1 {
+			t.Errorf("Expected the failure to count against the breaker, got %d", state)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package lexicon
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+//go:embed emotions.tsv
+var bundledEmotions string
+
+const (
+	// emotionDensityScale turns the share of words carrying an emotion into
+	// the density its intensity saturates on: one such word in ten gives 0.5
+	emotionDensityScale = 10.0
+
+	// emotionBoost is added to (or, for dampeners, removed from) the weight
+	// of an emotion word after an intensifier or written in capitals
+	emotionBoost = 0.5
+
+	// exclamationArousal is added to arousal per "!", up to maxExclamations
+	exclamationArousal = 0.05
+)
+
+// arousalWeights place each emotion on the calm (0) to activated (1) axis of
+// the circumplex model
+var arousalWeights = map[string]float64{
+	models.EmotionAnger:        0.9,
+	models.EmotionFear:         0.85,
+	models.EmotionSurprise:     0.8,
+	models.EmotionJoy:          0.7,
+	models.EmotionAnticipation: 0.65,
+	models.EmotionDisgust:      0.6,
+	models.EmotionTrust:        0.35,
+	models.EmotionSadness:      0.3,
+}
+
+// AnalyzeEmotions rates content against the emotion lexicon, as a whole and
+// sentence by sentence
+//
+// An emotion's intensity grows with the share of words carrying it, so a
+// single word weighs more in a short sentence than in a long entry. Negated
+// words carry no emotion. Valence is the sentiment score; arousal averages
+// the arousal of the emotions present, scaled by the strongest of them, and
+// rises with exclamation marks. Sentences are quoted without the punctuation
+// that ends them.
+func (a *Analyzer) AnalyzeEmotions(ctx context.Context, content string) (*models.EmotionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		total        = make(map[string]float64)
+		words        int
+		exclamations int
+		sentences    []models.SentenceEmotions
+	)
+
+	for _, s := range splitSentences(content) {
+		weights, n := a.emotionWeights(s)
+		for name, w := range weights {
+			total[name] += w
+		}
+		words += n
+		exclamations += s.exclamations
+
+		emotions := intensities(weights, n)
+		sentences = append(sentences, models.SentenceEmotions{
+			Text:     strings.TrimSpace(s.text),
+			Emotions: emotions,
+			Valence:  round(a.scoreSentence(s).score),
+			Arousal:  arousal(emotions, s.exclamations),
+		})
+	}
+
+	emotions := intensities(total, words)
+	valence, _ := a.Score(content)
+
+	return &models.EmotionResult{
+		Emotions:        emotions,
+		Valence:         valence,
+		Arousal:         arousal(emotions, exclamations),
+		Sentences:       sentences,
+		DominantEmotion: emotions.Dominant(),
+		ProcessedAt:     time.Now(),
+		Provider:        ProviderName,
+	}, nil
+}
+
+// ProcessJournalEmotions rates a journal's content
+func (a *Analyzer) ProcessJournalEmotions(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+	if journal == nil {
+		return nil, fmt.Errorf("journal cannot be nil")
+	}
+	return a.AnalyzeEmotions(ctx, journal.Content)
+}
+
+// emotionWeights sums the weight of each emotion in a sentence and returns
+// it with the number of tokens considered
+func (a *Analyzer) emotionWeights(s sentence) (map[string]float64, int) {
+	tokens := tokenize(s.text)
+	shouting := allCaps(tokens)
+	weights := make(map[string]float64)
+
+	for i, tok := range tokens {
+		names := a.lookupEmotions(tok)
+		if len(names) == 0 || negated(tokens, i) {
+			continue
+		}
+
+		w := 1.0
+		for j := i - 1; j >= 0 && j >= i-2; j-- {
+			if boost, ok := intensifiers[strings.ToLower(tokens[j])]; ok {
+				w += boost * emotionBoost
+			}
+		}
+		if !shouting && isUpperWord(tok) {
+			w += emotionBoost
+		}
+
+		for _, name := range names {
+			weights[name] += w
+		}
+	}
+
+	return weights, len(tokens)
+}
+
+// lookupEmotions finds a token's emotions, falling back to the singular of plurals
+func (a *Analyzer) lookupEmotions(token string) []string {
+	if names, ok := a.emotions[token]; ok {
+		return names
+	}
+
+	word := strings.ToLower(token)
+	if names, ok := a.emotions[word]; ok {
+		return names
+	}
+	if strings.HasSuffix(word, "s") && len(word) > 3 {
+		return a.emotions[strings.TrimSuffix(word, "s")]
+	}
+
+	return nil
+}
+
+// intensities converts emotion weights over a number of words into
+// intensities in [0, 1]
+func intensities(weights map[string]float64, words int) models.Emotions {
+	var emotions models.Emotions
+	if words == 0 {
+		return emotions
+	}
+
+	for name, w := range weights {
+		density := emotionDensityScale * max(w, 0) / float64(words)
+		emotions.Set(name, round(density/(density+1)))
+	}
+	return emotions
+}
+
+// arousal derives arousal in [0, 1] from emotion intensities and exclamation marks
+func arousal(emotions models.Emotions, exclamations int) float64 {
+	var sum, weighted, strongest float64
+	for _, name := range models.EmotionNames {
+		intensity, _ := emotions.Intensity(name)
+		sum += intensity
+		weighted += intensity * arousalWeights[name]
+		strongest = max(strongest, intensity)
+	}
+
+	var level float64
+	if sum > 0 {
+		level = weighted / sum * strongest
+	}
+	level += float64(min(exclamations, maxExclamations)) * exclamationArousal
+
+	return round(min(level, 1))
+}
+
+// parseEmotions reads "token<TAB>emotion,emotion" lines, ignoring blanks and comments
+func parseEmotions(data string) (map[string][]string, error) {
+	emotions := make(map[string][]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		token, raw, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected token and emotions separated by a tab", line)
+		}
+
+		var names []string
+		for name := range strings.SplitSeq(raw, ",") {
+			name = strings.TrimSpace(name)
+			if _, known := (models.Emotions{}).Intensity(name); !known {
+				return nil, fmt.Errorf("line %d: unknown emotion %q", line, name)
+			}
+			names = append(names, name)
+		}
+		emotions[strings.TrimSpace(token)] = names
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return emotions, nil
+}
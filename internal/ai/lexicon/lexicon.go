@@ -0,0 +1,461 @@
+// Package lexicon implements an offline, deterministic sentiment and emotion
+// analyzer backed by bundled valence and emotion lexicons. It needs no model
+// server, which makes it suitable for CI, air-gapped installs and as the last
+// link of a provider fallback chain.
+package lexicon
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// ProviderName identifies the lexicon analyzer in configuration
+const ProviderName = "lexicon"
+
+// Model names the bundled lexicons; bump it when valence.tsv or emotions.tsv changes
+const Model = "valence-lexicon-v2"
+
+// ErrGenerationUnsupported is returned by GenerateJournal
+var ErrGenerationUnsupported = errors.New("lexicon analyzer does not support journal generation")
+
+//go:embed valence.tsv
+var bundledLexicon string
+
+const (
+	// negationFactor flips and dampens a negated valence ("not good" is less
+	// negative than "bad")
+	negationFactor = -0.74
+
+	// negationWindow is how many preceding tokens are searched for a negator
+	negationWindow = 3
+
+	// intensifierBoost is added to (or, for dampeners, removed from) the
+	// magnitude of the valence that follows
+	intensifierBoost = 0.293
+
+	// capsBoost emphasizes words written in capitals within mixed-case text
+	capsBoost = 0.733
+
+	// exclamationBoost per "!" in a sentence, up to maxExclamations
+	exclamationBoost = 0.292
+	maxExclamations  = 4
+
+	// butWeightBefore and butWeightAfter shift weight to the clause after "but"
+	butWeightBefore = 0.5
+	butWeightAfter  = 1.5
+
+	// normalizationAlpha controls how quickly sentence sums approach ±1
+	normalizationAlpha = 15.0
+
+	// neutralThreshold is the score magnitude below which the label is neutral
+	neutralThreshold = 0.05
+
+	// Confidence grows linearly with coverage up to fullCoverage
+	minConfidence = 0.1
+	maxConfidence = 0.95
+	fullCoverage  = 0.25
+)
+
+var negators = map[string]bool{
+	"not": true, "no": true, "never": true, "none": true, "nobody": true,
+	"nothing": true, "neither": true, "nor": true, "nowhere": true,
+	"without": true, "hardly": true, "barely": true, "cannot": true,
+}
+
+// intensifiers map to +1 for boosters and -1 for dampeners
+var intensifiers = map[string]float64{
+	"absolutely": 1, "completely": 1, "deeply": 1, "especially": 1,
+	"extremely": 1, "incredibly": 1, "really": 1, "so": 1, "super": 1,
+	"totally": 1, "truly": 1, "very": 1, "most": 1, "too": 1,
+	"quite": -1, "slightly": -1, "somewhat": -1, "kinda": -1,
+	"little": -1, "marginally": -1, "partly": -1,
+}
+
+// Analyzer scores text against a valence lexicon and an emotion lexicon
+// It implements ai.Provider and worker.AIProcessor.
+type Analyzer struct {
+	valence  map[string]float64
+	emotions map[string][]string
+}
+
+// New creates an analyzer using the bundled lexicons
+func New() (*Analyzer, error) {
+	valence, err := parseLexicon(bundledLexicon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundled lexicon: %w", err)
+	}
+	emotions, err := parseEmotions(bundledEmotions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundled emotion lexicon: %w", err)
+	}
+	return &Analyzer{valence: valence, emotions: emotions}, nil
+}
+
+// Name returns the provider name
+func (a *Analyzer) Name() string {
+	return ProviderName
+}
+
+// Capabilities reports that the analyzer does sentiment and emotions
+func (a *Analyzer) Capabilities() models.AICapabilities {
+	return models.AICapabilities{
+		Sentiment: true,
+		Emotions:  true,
+		Model:     Model,
+	}
+}
+
+// AnalyzeSentiment scores content
+func (a *Analyzer) AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	score, confidence := a.Score(content)
+
+	return &models.SentimentResult{
+		Score:       score,
+		Label:       label(score),
+		Confidence:  confidence,
+		ProcessedAt: time.Now(),
+		Provider:    ProviderName,
+	}, nil
+}
+
+// ProcessJournalSentiment scores a journal's content
+func (a *Analyzer) ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+	if journal == nil {
+		return nil, fmt.Errorf("journal cannot be nil")
+	}
+	return a.AnalyzeSentiment(ctx, journal.Content)
+}
+
+// GenerateJournal is not supported
+func (a *Analyzer) GenerateJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+	return nil, ErrGenerationUnsupported
+}
+
+// HealthCheck always succeeds; the analyzer has no external dependencies
+func (a *Analyzer) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// Score returns the sentiment score in [-1, 1] and a confidence in [0, 1]
+//
+// Each sentence is scored separately and normalized; the document score is
+// the mean of sentence scores weighted by how many lexicon words each one
+// contains, so sentences without sentiment do not dilute the result.
+// Confidence is derived from coverage: the share of words found in the lexicon.
+func (a *Analyzer) Score(text string) (score, confidence float64) {
+	var (
+		weighted float64
+		hits     int
+		words    int
+	)
+
+	for _, sentence := range splitSentences(text) {
+		s := a.scoreSentence(sentence)
+		weighted += s.score * float64(s.hits)
+		hits += s.hits
+		words += s.words
+	}
+
+	if hits == 0 || words == 0 {
+		return 0, minConfidence
+	}
+
+	score = round(weighted / float64(hits))
+	coverage := float64(hits) / float64(words)
+	confidence = round(minConfidence + (maxConfidence-minConfidence)*min(coverage/fullCoverage, 1))
+
+	return score, confidence
+}
+
+// sentence is the text of one sentence and the "!" that ended it
+type sentence struct {
+	text         string
+	exclamations int
+}
+
+// sentenceScore is the normalized score of a sentence
+type sentenceScore struct {
+	score float64
+	hits  int // tokens found in the lexicon
+	words int // tokens considered
+}
+
+// scoreSentence sums token valences with negation, intensifier, capitalization,
+// "but" and exclamation rules applied, then normalizes the sum to [-1, 1]
+func (a *Analyzer) scoreSentence(s sentence) sentenceScore {
+	tokens := tokenize(s.text)
+	shouting := allCaps(tokens)
+
+	valences := make([]float64, len(tokens))
+	result := sentenceScore{words: len(tokens)}
+	butAt := -1
+
+	for i, tok := range tokens {
+		word := strings.ToLower(tok)
+		if word == "but" && butAt < 0 {
+			butAt = i
+		}
+
+		v, ok := a.lookup(tok)
+		if !ok {
+			continue
+		}
+		result.hits++
+
+		// Intensifiers directly before the word scale its magnitude
+		for j := i - 1; j >= 0 && j >= i-2; j-- {
+			if boost, ok := intensifiers[strings.ToLower(tokens[j])]; ok {
+				v += boost * math.Copysign(intensifierBoost, v)
+			}
+		}
+
+		if !shouting && isUpperWord(tok) {
+			v += math.Copysign(capsBoost, v)
+		}
+
+		if negated(tokens, i) {
+			v *= negationFactor
+		}
+
+		valences[i] = v
+	}
+
+	if result.hits == 0 {
+		return result
+	}
+
+	var sum float64
+	for i, v := range valences {
+		switch {
+		case butAt < 0:
+		case i < butAt:
+			v *= butWeightBefore
+		case i > butAt:
+			v *= butWeightAfter
+		}
+		sum += v
+	}
+
+	if sum != 0 {
+		sum += math.Copysign(float64(min(s.exclamations, maxExclamations))*exclamationBoost, sum)
+	}
+
+	result.score = sum / math.Sqrt(sum*sum+normalizationAlpha)
+	return result
+}
+
+// lookup finds a token's valence, falling back to the singular of plurals
+func (a *Analyzer) lookup(token string) (float64, bool) {
+	if v, ok := a.valence[token]; ok {
+		return v, true
+	}
+
+	word := strings.ToLower(token)
+	if v, ok := a.valence[word]; ok {
+		return v, true
+	}
+	if strings.HasSuffix(word, "s") && len(word) > 3 {
+		if v, ok := a.valence[strings.TrimSuffix(word, "s")]; ok {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
+
+// negated reports whether one of the tokens shortly before i negates it
+func negated(tokens []string, i int) bool {
+	for j := i - 1; j >= 0 && j >= i-negationWindow; j-- {
+		word := strings.ToLower(tokens[j])
+		if negators[word] || strings.HasSuffix(word, "n't") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSentences splits text on sentence-ending punctuation and newlines,
+// counting the exclamation marks that close each sentence
+func splitSentences(text string) []sentence {
+	var (
+		sentences []sentence
+		current   strings.Builder
+		bangs     int
+	)
+
+	flush := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			sentences = append(sentences, sentence{text: current.String(), exclamations: bangs})
+		}
+		current.Reset()
+		bangs = 0
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case r == '!':
+			bangs++
+		case r == '.' && isDecimalPoint(runes, i):
+			current.WriteRune(r)
+		case r == '.' || r == '?' || r == '\n':
+			flush()
+		default:
+			if bangs > 0 {
+				flush()
+			}
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return sentences
+}
+
+// isDecimalPoint reports whether the '.' at i sits between two digits, as in "2.5"
+func isDecimalPoint(runes []rune, i int) bool {
+	return i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])
+}
+
+// tokenize splits a sentence into words, emoticons and emoji
+func tokenize(text string) []string {
+	var tokens []string
+
+	for field := range strings.FieldsSeq(strings.ReplaceAll(text, "’", "'")) {
+		if isEmoticon(strings.TrimRight(field, ",;")) {
+			tokens = append(tokens, strings.TrimRight(field, ",;"))
+			continue
+		}
+
+		var word strings.Builder
+		flushWord := func() {
+			if w := strings.Trim(word.String(), "'"); w != "" {
+				tokens = append(tokens, w)
+			}
+			word.Reset()
+		}
+
+		for _, r := range field {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+				word.WriteRune(r)
+			case isEmojiModifier(r):
+				// Variation selectors and skin tones belong to the previous emoji
+			case unicode.Is(unicode.So, r):
+				flushWord()
+				tokens = append(tokens, string(r))
+			default:
+				flushWord()
+			}
+		}
+		flushWord()
+	}
+
+	return tokens
+}
+
+// emoticons recognised by tokenize; their valences live in the lexicon
+var emoticons = map[string]bool{
+	":)": true, ":-)": true, ":D": true, ":-D": true, ";)": true, "<3": true,
+	":(": true, ":-(": true, ":'(": true, ">:(": true,
+}
+
+func isEmoticon(s string) bool {
+	return emoticons[s]
+}
+
+func isEmojiModifier(r rune) bool {
+	return r == '\uFE0F' || r == '\u200D' || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// isUpperWord reports whether a word of two or more letters is all capitals
+func isUpperWord(token string) bool {
+	letters := 0
+	for _, r := range token {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if !unicode.IsUpper(r) {
+			return false
+		}
+		letters++
+	}
+	return letters > 1
+}
+
+// allCaps reports whether every word in the sentence is in capitals, in which
+// case capitals carry no extra emphasis
+func allCaps(tokens []string) bool {
+	words := 0
+	for _, tok := range tokens {
+		if !strings.ContainsFunc(tok, unicode.IsLetter) {
+			continue
+		}
+		if !isUpperWord(tok) {
+			return false
+		}
+		words++
+	}
+	return words > 0
+}
+
+// label maps a score to positive, negative or neutral
+func label(score float64) string {
+	switch {
+	case score >= neutralThreshold:
+		return "positive"
+	case score <= -neutralThreshold:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
+
+// round keeps three decimals so results are stable across platforms
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// parseLexicon reads "token<TAB>valence" lines, ignoring blanks and comments
+func parseLexicon(data string) (map[string]float64, error) {
+	valence := make(map[string]float64)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		token, raw, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected token and valence separated by a tab", line)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || v < -4 || v > 4 {
+			return nil, fmt.Errorf("line %d: invalid valence %q", line, raw)
+		}
+		valence[strings.TrimSpace(token)] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return valence, nil
+}
@@ -0,0 +1,244 @@
+package lexicon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/garnizeh/englog/internal/ai/lexicon"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/worker"
+)
+
+// Ensure the analyzer can be handed straight to the worker
+var _ worker.AIProcessor = (*lexicon.Analyzer)(nil)
+
+func newAnalyzer(t *testing.T) *lexicon.Analyzer {
+	t.Helper()
+
+	analyzer, err := lexicon.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return analyzer
+}
+
+func TestAnalyzer_Labels(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	tests := []struct {
+		name    string
+		content string
+		label   string
+	}{
+		{"positive", "Today was a wonderful day and I feel grateful.", "positive"},
+		{"negative", "I feel exhausted and lonely after this awful week.", "negative"},
+		{"neutral without sentiment words", "I went to the store and bought milk.", "neutral"},
+		{"negation flips", "The meeting was not good.", "negative"},
+		{"contraction negation", "I don't feel happy about it.", "negative"},
+		{"negated negative", "Honestly, it wasn't bad at all.", "positive"},
+		{"emoji", "Finished the marathon 🎉😄", "positive"},
+		{"emoji with variation selector", "Missing home today 💔", "negative"},
+		{"emoticon", "Long day at work :(", "negative"},
+		{"but shifts weight", "The trip was fun but the flight home was horrible and exhausting.", "negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeSentiment(context.Background(), tt.content)
+			if err != nil {
+				t.Fatalf("AnalyzeSentiment failed: %v", err)
+			}
+			if result.Label != tt.label {
+				t.Errorf("Expected %s, got %s (score %.3f)", tt.label, result.Label, result.Score)
+			}
+			if result.Score < -1 || result.Score > 1 || result.Confidence < 0 || result.Confidence > 1 {
+				t.Errorf("Score or confidence out of range: %+v", result)
+			}
+			if result.Provider != lexicon.ProviderName {
+				t.Errorf("Expected provider %s, got %q", lexicon.ProviderName, result.Provider)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_Modifiers(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	score := func(text string) float64 {
+		s, _ := analyzer.Score(text)
+		return s
+	}
+
+	if plain, boosted := score("The food was good."), score("The food was very good."); boosted <= plain {
+		t.Errorf("Expected intensifier to raise the score: %.3f <= %.3f", boosted, plain)
+	}
+	if plain, dampened := score("The food was good."), score("The food was slightly good."); dampened >= plain {
+		t.Errorf("Expected dampener to lower the score: %.3f >= %.3f", dampened, plain)
+	}
+	if plain, shouted := score("The food was good."), score("The food was GOOD."); shouted <= plain {
+		t.Errorf("Expected capitals to add emphasis: %.3f <= %.3f", shouted, plain)
+	}
+	if plain, excited := score("The food was good."), score("The food was good!!!"); excited <= plain {
+		t.Errorf("Expected exclamations to add emphasis: %.3f <= %.3f", excited, plain)
+	}
+	if bad, notGood := score("It was bad."), score("It was not good."); notGood <= bad {
+		t.Errorf("Expected negated positive to be milder than a negative: %.3f <= %.3f", notGood, bad)
+	}
+}
+
+func TestAnalyzer_SentenceAggregation(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	mixed, _ := analyzer.Score("I loved the concert. The parking was terrible.")
+	positive, _ := analyzer.Score("I loved the concert.")
+	if mixed >= positive {
+		t.Errorf("Expected a negative sentence to pull the score down: %.3f >= %.3f", mixed, positive)
+	}
+
+	// Sentences without sentiment words do not dilute the score
+	padded, _ := analyzer.Score("I loved the concert. We took the bus. It left at 9.30 from the station.")
+	if padded != positive {
+		t.Errorf("Expected neutral sentences not to change the score: %.3f != %.3f", padded, positive)
+	}
+}
+
+func TestAnalyzer_Confidence(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	_, none := analyzer.Score("We drove to the office and parked the car.")
+	_, sparse := analyzer.Score("We drove to the office, parked the car, walked to the third floor and had a good meeting.")
+	_, dense := analyzer.Score("Happy, grateful and proud!")
+
+	if !(none < sparse && sparse < dense) {
+		t.Errorf("Expected confidence to grow with coverage: none=%.3f sparse=%.3f dense=%.3f", none, sparse, dense)
+	}
+}
+
+func TestAnalyzer_Emotions(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	tests := []struct {
+		name     string
+		content  string
+		dominant string
+	}{
+		{"joy", "We celebrated with friends and laughed all night.", models.EmotionJoy},
+		{"sadness", "I miss her so much and cried myself to sleep.", models.EmotionSadness},
+		{"anger", "I was furious that they yelled at me in front of everyone.", models.EmotionAnger},
+		{"fear", "I'm anxious and scared about the diagnosis.", models.EmotionFear},
+		{"negation cancels", "I wasn't scared at all, just happy.", models.EmotionJoy},
+		{"no emotion words", "We drove to the office and parked the car.", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeEmotions(context.Background(), tt.content)
+			if err != nil {
+				t.Fatalf("AnalyzeEmotions failed: %v", err)
+			}
+			if result.DominantEmotion != tt.dominant {
+				t.Errorf("Expected %q to dominate, got %q (%+v)", tt.dominant, result.DominantEmotion, result.Emotions)
+			}
+			for _, name := range models.EmotionNames {
+				if intensity, _ := result.Emotions.Intensity(name); intensity < 0 || intensity > 1 {
+					t.Errorf("%s intensity out of range: %.3f", name, intensity)
+				}
+			}
+			if result.Valence < -1 || result.Valence > 1 || result.Arousal < 0 || result.Arousal > 1 {
+				t.Errorf("Valence or arousal out of range: %+v", result)
+			}
+			if result.Provider != lexicon.ProviderName {
+				t.Errorf("Expected provider %s, got %q", lexicon.ProviderName, result.Provider)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_EmotionSentences(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	result, err := analyzer.AnalyzeEmotions(context.Background(), "The results came back clear! I called my family and we celebrated. Then I slept.")
+	if err != nil {
+		t.Fatalf("AnalyzeEmotions failed: %v", err)
+	}
+	if len(result.Sentences) != 3 || result.Sentences[1].Text != "I called my family and we celebrated" {
+		t.Fatalf("Unexpected sentences %+v", result.Sentences)
+	}
+
+	celebrated, slept := result.Sentences[1], result.Sentences[2]
+	if celebrated.Emotions.Joy == 0 || celebrated.Emotions.Trust == 0 || celebrated.Valence <= 0 {
+		t.Errorf("Expected joy, trust and a positive valence, got %+v", celebrated)
+	}
+	if slept.Emotions.Dominant() != "" || slept.Arousal != 0 {
+		t.Errorf("Expected an emotionless, calm sentence, got %+v", slept)
+	}
+
+	// An emotion word weighs more in a short sentence than in the whole entry
+	if result.Emotions.Joy >= celebrated.Emotions.Joy {
+		t.Errorf("Expected the entry to dilute joy: %.3f >= %.3f", result.Emotions.Joy, celebrated.Emotions.Joy)
+	}
+
+	calm, _ := analyzer.AnalyzeEmotions(context.Background(), "I feel calm and safe with my family.")
+	furious, _ := analyzer.AnalyzeEmotions(context.Background(), "I am FURIOUS and scared!!")
+	if calm.Arousal >= furious.Arousal {
+		t.Errorf("Expected anger and fear to be more arousing than trust: %.3f >= %.3f", calm.Arousal, furious.Arousal)
+	}
+}
+
+func TestAnalyzer_Deterministic(t *testing.T) {
+	analyzer := newAnalyzer(t)
+	content := "I was nervous at first, but the presentation went really well and everyone was supportive 😊"
+
+	first, firstConfidence := analyzer.Score(content)
+	for range 10 {
+		score, confidence := analyzer.Score(content)
+		if score != first || confidence != firstConfidence {
+			t.Fatalf("Expected identical results, got %.3f/%.3f and %.3f/%.3f", first, firstConfidence, score, confidence)
+		}
+	}
+}
+
+func TestAnalyzer_Provider(t *testing.T) {
+	analyzer := newAnalyzer(t)
+
+	if caps := analyzer.Capabilities(); !caps.Sentiment || !caps.Emotions || caps.Generation || caps.Model != lexicon.Model {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+	if err := analyzer.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected healthy analyzer, got %v", err)
+	}
+	if _, err := analyzer.GenerateJournal(context.Background(), &models.PromptRequest{Prompt: "Write"}); !errors.Is(err, lexicon.ErrGenerationUnsupported) {
+		t.Errorf("Expected ErrGenerationUnsupported, got %v", err)
+	}
+	if _, err := analyzer.ProcessJournalSentiment(context.Background(), nil); err == nil {
+		t.Error("Expected error for nil journal")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := analyzer.AnalyzeSentiment(ctx, "A good day"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAnalyzer_ProcessesJournalsInWorker(t *testing.T) {
+	w := worker.NewInMemoryWorker(newAnalyzer(t), logging.NewLogger(logging.Config{Level: logging.DebugLevel, Format: "json"}))
+
+	journal := &models.Journal{ID: "j1", Content: "A calm, peaceful morning with friends."}
+	if err := w.ProcessJournal(context.Background(), journal); err != nil {
+		t.Fatalf("ProcessJournal failed: %v", err)
+	}
+
+	result := journal.ProcessingResult
+	if result.Status != models.ProcessingStatusCompleted || result.SentimentResult.Label != "positive" {
+		t.Errorf("Unexpected processing result: %+v", result)
+	}
+	if result.Provider != lexicon.ProviderName {
+		t.Errorf("Expected provider %s, got %q", lexicon.ProviderName, result.Provider)
+	}
+	if result.EmotionResult == nil || result.EmotionResult.DominantEmotion != models.EmotionTrust {
+		t.Errorf("Expected the worker to record emotions, got %+v", result.EmotionResult)
+	}
+}
@@ -2,22 +2,157 @@ package ai
 
 import (
 	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/garnizeh/englog/internal/models"
 )
 
-// MockAIProvider is a mock implementation of AIService for testing
+// MockAIProvider is a mock implementation of AIService and Provider for testing
 type MockAIProvider struct {
 	ProcessJournalSentimentFunc   func(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error)
+	ProcessJournalEmotionsFunc    func(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error)
+	ProcessJournalExtractionFunc  func(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error)
+	EmbedFunc                     func(ctx context.Context, texts []string) (*models.Embeddings, error)
+	SummarizeFunc                 func(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error)
 	GenerateStructuredJournalFunc func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error)
 	ValidateJournalContentFunc    func(content string) error
 	ValidatePromptRequestFunc     func(req *models.PromptRequest) error
 	HealthCheckFunc               func(ctx context.Context) error
 }
 
-// Ensure MockAIProvider implements AIService interface
-var _ AIService = (*MockAIProvider)(nil)
+// Ensure MockAIProvider implements AIService and the provider interfaces
+var (
+	_ AIService          = (*MockAIProvider)(nil)
+	_ Provider           = (*MockAIProvider)(nil)
+	_ EmotionProvider    = (*MockAIProvider)(nil)
+	_ ExtractionProvider = (*MockAIProvider)(nil)
+	_ SummaryProvider    = (*MockAIProvider)(nil)
+	_ EmbeddingProvider  = (*MockAIProvider)(nil)
+)
+
+// MockProviderName identifies the mock provider
+const MockProviderName = "mock"
+
+// Mock embedding settings: the fake embedder hashes words into
+// MockEmbeddingDimensions buckets, so texts sharing words are similar
+const (
+	MockEmbeddingModel      = "mock-embedding"
+	MockEmbeddingDimensions = 64
+)
+
+// Name returns the mock provider name
+func (m *MockAIProvider) Name() string {
+	return MockProviderName
+}
+
+// Capabilities reports that the mock supports every capability but streaming
+func (m *MockAIProvider) Capabilities() models.AICapabilities {
+	return models.AICapabilities{
+		Sentiment:  true,
+		Generation: true,
+		Emotions:   true,
+		Extraction: true,
+		Embeddings: true,
+		Summaries:  true,
+		Model:      MockProviderName,
+	}
+}
+
+// AnalyzeSentiment mocks provider sentiment analysis
+func (m *MockAIProvider) AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error) {
+	return m.ProcessJournalSentiment(ctx, &models.Journal{Content: content})
+}
+
+// AnalyzeEmotions mocks provider emotion analysis
+func (m *MockAIProvider) AnalyzeEmotions(ctx context.Context, content string) (*models.EmotionResult, error) {
+	return m.ProcessJournalEmotions(ctx, &models.Journal{Content: content})
+}
+
+// ExtractEntities mocks provider entity and topic extraction
+func (m *MockAIProvider) ExtractEntities(ctx context.Context, content string) (*models.ExtractionResult, error) {
+	return m.ProcessJournalExtraction(ctx, &models.Journal{Content: content})
+}
+
+// Summarize mocks period summaries
+// The default summary counts what it was given and lists the first line of
+// each entry or part as a notable event.
+func (m *MockAIProvider) Summarize(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+	if m.SummarizeFunc != nil {
+		return m.SummarizeFunc(ctx, req)
+	}
+
+	items, kind := req.Entries, "entries"
+	if len(items) == 0 {
+		items, kind = req.Summaries, "parts"
+	}
+
+	events := make([]string, len(items))
+	for i, item := range items {
+		events[i], _, _ = strings.Cut(strings.TrimSpace(item), "\n")
+	}
+
+	result := &models.SummaryResult{
+		Summary:       fmt.Sprintf("A %s summary of %d %s.", req.Period, len(items), kind),
+		Moods:         []string{"joy"},
+		Themes:        []string{"work"},
+		NotableEvents: events,
+		ProcessedAt:   time.Now(),
+	}
+	if req.Previous != "" {
+		result.Change = "Compared with: " + req.Previous
+	}
+	return result, nil
+}
+
+// Embed returns deterministic fake embeddings: each word of at least three
+// letters adds ±1 to a bucket picked by its hash
+func (m *MockAIProvider) Embed(ctx context.Context, texts []string) (*models.Embeddings, error) {
+	if m.EmbedFunc != nil {
+		return m.EmbedFunc(ctx, texts)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = hashEmbedding(text)
+	}
+	return &models.Embeddings{Model: MockEmbeddingModel, Vectors: vectors}, nil
+}
+
+// hashEmbedding hashes the words of text into a MockEmbeddingDimensions vector
+// Text without a word long enough is hashed whole, so no text embeds as zero.
+func hashEmbedding(text string) []float32 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words = slices.DeleteFunc(words, func(word string) bool { return len(word) < 3 })
+	if len(words) == 0 {
+		words = []string{text}
+	}
+
+	vector := make([]float32, MockEmbeddingDimensions)
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		sum := h.Sum32()
+
+		sign := float32(1)
+		if sum&(1<<31) != 0 {
+			sign = -1
+		}
+		vector[sum%MockEmbeddingDimensions] += sign
+	}
+	return vector
+}
+
+// GenerateJournal mocks provider journal generation
+func (m *MockAIProvider) GenerateJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+	return m.GenerateStructuredJournal(ctx, req)
+}
 
 // ProcessJournalSentiment mocks sentiment analysis
 func (m *MockAIProvider) ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
@@ -34,6 +169,44 @@ func (m *MockAIProvider) ProcessJournalSentiment(ctx context.Context, journal *m
 	}, nil
 }
 
+// ProcessJournalEmotions mocks emotion analysis
+func (m *MockAIProvider) ProcessJournalEmotions(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+	if m.ProcessJournalEmotionsFunc != nil {
+		return m.ProcessJournalEmotionsFunc(ctx, journal)
+	}
+
+	// Default mock response: joyful and fairly energetic throughout
+	emotions := models.Emotions{Joy: 0.7, Trust: 0.4, Anticipation: 0.5, Surprise: 0.2}
+	return &models.EmotionResult{
+		Emotions: emotions,
+		Valence:  0.6,
+		Arousal:  0.55,
+		Sentences: []models.SentenceEmotions{
+			{Text: journal.Content, Emotions: emotions, Valence: 0.6, Arousal: 0.55},
+		},
+		DominantEmotion: emotions.Dominant(),
+		ProcessedAt:     time.Now(),
+	}, nil
+}
+
+// ProcessJournalExtraction mocks entity and topic extraction
+func (m *MockAIProvider) ProcessJournalExtraction(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error) {
+	if m.ProcessJournalExtractionFunc != nil {
+		return m.ProcessJournalExtractionFunc(ctx, journal)
+	}
+
+	// Default mock response: a working day with a colleague
+	return &models.ExtractionResult{
+		People:        []models.ExtractedItem{{Name: "Alice", Confidence: 0.9}},
+		Places:        []models.ExtractedItem{{Name: "office", Confidence: 0.6}},
+		Organizations: []models.ExtractedItem{},
+		Activities:    []models.ExtractedItem{{Name: "coding", Confidence: 0.8}},
+		Themes:        []models.ExtractedItem{{Name: "work", Confidence: 0.9}},
+		Tags:          []models.ExtractedItem{{Name: "work", Confidence: 0.9}, {Name: "productivity", Confidence: 0.7}},
+		ProcessedAt:   time.Now(),
+	}, nil
+}
+
 // GenerateStructuredJournal mocks journal generation
 func (m *MockAIProvider) GenerateStructuredJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
 	if m.GenerateStructuredJournalFunc != nil {
@@ -4,25 +4,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
+	"io"
+	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/parse"
+	"github.com/garnizeh/englog/internal/ai/prompt"
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/models"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/ollama"
 )
 
-// TODO: change this to an interface to also accommodate other AI providers in the future
-
 // Request represents the request structure for Ollama API
 type Request struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
+	System string `json:"system,omitempty"`
 	Stream bool   `json:"stream"`
+
+	// Format constrains the output: a JSON schema, or "json" for any JSON
+	Format json.RawMessage `json:"format,omitempty"`
+
+	// Options are the sampling parameters; unset ones use the model defaults
+	Options *models.GenerationOptions `json:"options,omitempty"`
 }
 
 // Response represents the response structure from Ollama API
@@ -38,14 +48,50 @@ type Response struct {
 	PromptEvalDuration int64     `json:"prompt_eval_duration,omitempty"`
 	EvalCount          int       `json:"eval_count,omitempty"`
 	EvalDuration       int64     `json:"eval_duration,omitempty"`
+
+	// Error is set on a streamed chunk when generation fails midway
+	Error string `json:"error,omitempty"`
 }
 
+// EmbedRequest is the body of a call to /api/embed
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+
+	// Truncate cuts inputs longer than the model's context instead of failing
+	Truncate bool `json:"truncate"`
+}
+
+// EmbedResponse is the answer of /api/embed
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// ProviderName identifies the Ollama provider in configuration
+const ProviderName = "ollama"
+
+// maxCorrections is how many times a response that fails validation is sent
+// back to the model with the validation message before giving up
+const maxCorrections = 2
+
+// jsonFormat is the plain JSON mode used by servers without schema support
+var jsonFormat = json.RawMessage(`"json"`)
+
 // Client implements AI client using Ollama with langchaingo
+// Structured calls go to /api/generate directly, since langchaingo can only
+// send a string format and schemas are objects.
 type Client struct {
-	baseURL   string
-	modelName string
-	llm       llms.Model
-	logger    *logging.Logger
+	baseURL        string
+	modelName      string
+	embeddingModel string
+	llm            llms.Model
+	httpClient     *http.Client
+	logger         *logging.Logger
+
+	// noSchema is set once the server rejects a schema format (Ollama before
+	// 0.5); later calls fall back to plain JSON mode
+	noSchema atomic.Bool
 }
 
 // New creates a new Ollama client instance using langchaingo
@@ -82,13 +128,39 @@ func New(ctx context.Context, modelName, baseURL string) (*Client, error) {
 	)
 
 	return &Client{
-		baseURL:   baseURL,
-		modelName: modelName,
-		llm:       llm,
-		logger:    logger,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		modelName:  modelName,
+		llm:        llm,
+		httpClient: &http.Client{},
+		logger:     logger,
 	}, nil
 }
 
+// Name returns the provider name
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// SetEmbeddingModel sets the model Embed runs, e.g. "nomic-embed-text"
+// Embeddings are unsupported until it is set.
+func (c *Client) SetEmbeddingModel(model string) {
+	c.embeddingModel = model
+}
+
+// Capabilities reports what the Ollama client supports
+func (c *Client) Capabilities() models.AICapabilities {
+	return models.AICapabilities{
+		Sentiment:  true,
+		Generation: true,
+		Emotions:   true,
+		Extraction: true,
+		Summaries:  true,
+		Embeddings: c.embeddingModel != "",
+		Streaming:  true,
+		Model:      c.modelName,
+	}
+}
+
 // AnalyzeSentiment performs sentiment analysis on journal content
 func (c *Client) AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error) {
 	start := time.Now()
@@ -98,9 +170,8 @@ func (c *Client) AnalyzeSentiment(ctx context.Context, content string) (*models.
 		"model", c.modelName,
 	)
 
-	prompt := c.buildSentimentPrompt(content)
-
-	response, err := c.callOllamaWithRetry(ctx, prompt, 3)
+	opts := options.Select(ctx, prompt.TaskSentiment)
+	result, ref, err := generate(ctx, c, prompt.TaskSentiment, map[string]any{"Content": content}, opts, parse.Sentiment)
 	if err != nil {
 		duration := time.Since(start)
 		c.logger.Error("Sentiment analysis failed",
@@ -111,17 +182,9 @@ func (c *Client) AnalyzeSentiment(ctx context.Context, content string) (*models.
 		return nil, fmt.Errorf("sentiment analysis failed: %w", err)
 	}
 
-	result, err := c.parseSentimentResponse(response)
-	if err != nil {
-		c.logger.Error("Failed to parse sentiment response",
-			"error", err,
-			"response", response,
-			"response_length", len(response),
-		)
-		return nil, fmt.Errorf("failed to parse sentiment response: %w", err)
-	}
-
 	result.ProcessedAt = time.Now()
+	result.Prompt = &ref
+	result.Options = recorded(opts)
 	duration := time.Since(start)
 
 	c.logger.Info("Sentiment analysis completed",
@@ -129,11 +192,171 @@ func (c *Client) AnalyzeSentiment(ctx context.Context, content string) (*models.
 		"score", result.Score,
 		"label", result.Label,
 		"confidence", result.Confidence,
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// AnalyzeEmotions rates the emotions, valence and arousal of journal content,
+// as a whole and sentence by sentence
+func (c *Client) AnalyzeEmotions(ctx context.Context, content string) (*models.EmotionResult, error) {
+	start := time.Now()
+
+	c.logger.Info("Starting emotion analysis",
+		"content_length", len(content),
+		"model", c.modelName,
+	)
+
+	opts := options.Select(ctx, prompt.TaskEmotion)
+	result, ref, err := generate(ctx, c, prompt.TaskEmotion, map[string]any{"Content": content}, opts, parse.Emotions)
+	if err != nil {
+		c.logger.Error("Emotion analysis failed",
+			"error", err,
+			"duration", time.Since(start),
+			"content_length", len(content),
+		)
+		return nil, fmt.Errorf("emotion analysis failed: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &ref
+	result.Options = recorded(opts)
+
+	c.logger.Info("Emotion analysis completed",
+		"duration", time.Since(start),
+		"dominant_emotion", result.DominantEmotion,
+		"valence", result.Valence,
+		"arousal", result.Arousal,
+		"sentences", len(result.Sentences),
+		"prompt", result.Prompt,
 	)
 
 	return result, nil
 }
 
+// ExtractEntities finds the people, places, organizations, activities,
+// themes and suggested tags of journal content
+func (c *Client) ExtractEntities(ctx context.Context, content string) (*models.ExtractionResult, error) {
+	start := time.Now()
+
+	c.logger.Info("Starting extraction",
+		"content_length", len(content),
+		"model", c.modelName,
+	)
+
+	opts := options.Select(ctx, prompt.TaskExtraction)
+	result, ref, err := generate(ctx, c, prompt.TaskExtraction, map[string]any{"Content": content}, opts, parse.Extraction)
+	if err != nil {
+		c.logger.Error("Extraction failed",
+			"error", err,
+			"duration", time.Since(start),
+			"content_length", len(content),
+		)
+		return nil, fmt.Errorf("extraction failed: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &ref
+	result.Options = recorded(opts)
+
+	c.logger.Info("Extraction completed",
+		"duration", time.Since(start),
+		"entities", len(result.Entities()),
+		"themes", len(result.Themes),
+		"tags", len(result.Tags),
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// Summarize writes the digest of a period, or of part of one
+func (c *Client) Summarize(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+	start := time.Now()
+
+	c.logger.Info("Starting summary",
+		"period", req.Period,
+		"entries", len(req.Entries),
+		"summaries", len(req.Summaries),
+		"model", c.modelName,
+	)
+
+	opts := options.Select(ctx, prompt.TaskSummary)
+	result, ref, err := generate(ctx, c, prompt.TaskSummary, prompt.SummaryVars(req), opts, parse.Summary)
+	if err != nil {
+		c.logger.Error("Summary failed",
+			"error", err,
+			"duration", time.Since(start),
+			"period", req.Period,
+		)
+		return nil, fmt.Errorf("summary failed: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &ref
+	result.Options = recorded(opts)
+
+	c.logger.Info("Summary completed",
+		"duration", time.Since(start),
+		"themes", len(result.Themes),
+		"notable_events", len(result.NotableEvents),
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// Embed turns texts into vectors with the embedding model, in one call to /api/embed
+func (c *Client) Embed(ctx context.Context, texts []string) (*models.Embeddings, error) {
+	if c.embeddingModel == "" {
+		return nil, fmt.Errorf("embedding: %w", errors.ErrUnsupported)
+	}
+
+	start := time.Now()
+
+	body, err := json.Marshal(EmbedRequest{Model: c.embeddingModel, Input: texts, Truncate: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embed request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embed request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Ollama embed API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embed response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp.StatusCode, data)
+	}
+
+	var result EmbedResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode embed response: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+
+	c.logger.Debug("Embedding completed",
+		"texts", len(texts),
+		"model", c.embeddingModel,
+		"duration", time.Since(start),
+	)
+
+	return &models.Embeddings{Model: c.embeddingModel, Vectors: result.Embeddings}, nil
+}
+
 // GenerateJournal generates a structured journal entry from a prompt
 func (c *Client) GenerateJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
 	start := time.Now()
@@ -144,12 +367,9 @@ func (c *Client) GenerateJournal(ctx context.Context, req *models.PromptRequest)
 		"model", c.modelName,
 	)
 
-	prompt := c.buildGenerationPrompt(req)
-	c.logger.Debug("Journal generation prompt",
-		"full_prompt", prompt,
-	)
-
-	response, err := c.callOllamaWithRetry(ctx, prompt, 3)
+	vars := map[string]any{"Prompt": req.Prompt, "Context": req.Context}
+	opts := options.Select(ctx, prompt.TaskGeneration)
+	result, ref, err := generate(ctx, c, prompt.TaskGeneration, vars, opts, parse.GeneratedJournal)
 	if err != nil {
 		duration := time.Since(start)
 		c.logger.Error("Journal generation failed",
@@ -160,31 +380,186 @@ func (c *Client) GenerateJournal(ctx context.Context, req *models.PromptRequest)
 		return nil, fmt.Errorf("journal generation failed: %w", err)
 	}
 
-	result, err := c.parseGenerationResponse(response)
+	result.GeneratedAt = time.Now()
+	result.Prompt = &ref
+	result.Options = recorded(opts)
+	duration := time.Since(start)
+
+	c.logger.Info("Journal generation completed",
+		"duration", duration,
+		"content_length", len(result.Content),
+		"themes_count", len(result.Metadata.Themes),
+		"tags_count", len(result.Metadata.Tags),
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// GenerateJournalStream generates a structured journal like GenerateJournal,
+// passing the response text to onToken as the model produces it
+//
+// Tokens that have been streamed cannot be taken back, so the call is made
+// once: transport failures are not retried and an answer that fails
+// validation is reported instead of being sent back for correction.
+func (c *Client) GenerateJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error) {
+	start := time.Now()
+
+	c.logger.Info("Starting streamed journal generation",
+		"prompt", req.Prompt,
+		"context", req.Context,
+		"model", c.modelName,
+	)
+
+	opts := options.Select(ctx, prompt.TaskGeneration)
+	request, rendered, err := c.newRequest(ctx, prompt.TaskGeneration, map[string]any{"Prompt": req.Prompt, "Context": req.Context}, opts)
 	if err != nil {
-		c.logger.Error("Failed to parse generation response",
+		return nil, fmt.Errorf("journal generation failed: %w", err)
+	}
+
+	response, err := c.callOllama(ctx, request, onToken)
+	if err != nil {
+		c.logger.Error("Streamed journal generation failed",
+			"error", err,
+			"duration", time.Since(start),
+		)
+		return nil, fmt.Errorf("journal generation failed: %w", err)
+	}
+
+	result, err := parse.GeneratedJournal(response)
+	if err != nil {
+		c.logger.Error("Failed to parse streamed response",
 			"error", err,
-			"response", response,
 			"response_length", len(response),
 		)
-		return nil, fmt.Errorf("failed to parse generation response: %w", err)
+		return nil, fmt.Errorf("journal generation failed: %w", err)
 	}
 
 	result.GeneratedAt = time.Now()
-	duration := time.Since(start)
+	result.Prompt = &rendered.Ref
+	result.Options = recorded(opts)
 
-	c.logger.Info("Journal generation completed",
-		"duration", duration,
+	c.logger.Info("Streamed journal generation completed",
+		"duration", time.Since(start),
 		"content_length", len(result.Content),
-		"themes_count", len(result.Metadata.Themes),
-		"tags_count", len(result.Metadata.Tags),
+		"prompt", result.Prompt,
 	)
 
 	return result, nil
 }
 
+// newRequest renders the task's prompt template with vars into a request
+// constrained to the template's schema
+func (c *Client) newRequest(ctx context.Context, task prompt.Task, vars map[string]any, opts models.GenerationOptions) (Request, *prompt.Rendered, error) {
+	tmpl, err := prompt.Select(ctx, task)
+	if err != nil {
+		return Request{}, nil, err
+	}
+	rendered, err := tmpl.Render(vars)
+	if err != nil {
+		return Request{}, nil, err
+	}
+
+	c.logger.Debug("Rendered prompt",
+		"template", rendered.Ref,
+		"system", rendered.System,
+		"full_prompt", rendered.Text(),
+	)
+
+	return Request{
+		Model:   c.modelName,
+		Prompt:  rendered.Text(),
+		System:  rendered.System,
+		Format:  tmpl.Schema,
+		Options: recorded(opts),
+	}, rendered, nil
+}
+
+// generate renders the task's prompt template with vars, sends it constrained
+// to the template's schema with opts and parses the response; the template
+// version used is returned with the result
+//
+// Transport failures are retried by callOllamaWithRetry. A response that
+// fails parsing or validation is not retried blindly: the model is shown its
+// answer and the validation message and asked to correct it, up to
+// maxCorrections times.
+func generate[T any](ctx context.Context, c *Client, task prompt.Task, vars map[string]any, opts models.GenerationOptions, parseResponse func(string) (*T, error)) (*T, models.PromptRef, error) {
+	req, rendered, err := c.newRequest(ctx, task, vars, opts)
+	if err != nil {
+		return nil, models.PromptRef{}, err
+	}
+
+	for correction := 0; ; correction++ {
+		response, err := c.callOllamaWithRetry(ctx, req, 3)
+		if err != nil {
+			return nil, rendered.Ref, err
+		}
+
+		result, err := parseResponse(response)
+		if err == nil {
+			if correction > 0 {
+				c.logger.Info("Corrected response accepted",
+					"corrections", correction,
+					"model", c.modelName,
+				)
+			}
+			return result, rendered.Ref, nil
+		}
+
+		if !errors.Is(err, models.ErrInvalidAIResponse) || correction == maxCorrections {
+			c.logger.Error("Failed to parse response",
+				"error", err,
+				"corrections", correction,
+				"response", response,
+				"response_length", len(response),
+			)
+			return nil, rendered.Ref, fmt.Errorf("failed to parse response after %d corrections: %w", correction, err)
+		}
+
+		c.logger.Warn("Response failed validation, asking the model to correct it",
+			"error", err,
+			"correction", correction+1,
+			"max_corrections", maxCorrections,
+		)
+		req.Prompt = buildCorrectionPrompt(rendered.Text(), response, err)
+	}
+}
+
+// recorded returns opts for requests and results, or nil when none are set
+func recorded(opts models.GenerationOptions) *models.GenerationOptions {
+	if opts.IsZero() {
+		return nil
+	}
+	return &opts
+}
+
+// buildCorrectionPrompt repeats the original prompt with the rejected answer
+// and the reason it was rejected
+func buildCorrectionPrompt(prompt, response string, err error) string {
+	reason := err.Error()
+	var parseErr *parse.Error
+	if errors.As(err, &parseErr) {
+		reason = parseErr.Reason
+		if parseErr.Err != nil {
+			reason += ": " + parseErr.Err.Error()
+		}
+	}
+
+	answer, _ := parse.SplitReasoning(response)
+
+	return fmt.Sprintf(`%s
+
+Your previous response could not be used:
+%s
+
+Previous response:
+%s
+
+Respond again with ONLY a JSON object that fixes this problem.`, prompt, reason, answer)
+}
+
 // callOllamaWithRetry calls Ollama API with retry mechanism
-func (c *Client) callOllamaWithRetry(ctx context.Context, prompt string, maxRetries int) (string, error) {
+func (c *Client) callOllamaWithRetry(ctx context.Context, req Request, maxRetries int) (string, error) {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
@@ -195,7 +570,7 @@ func (c *Client) callOllamaWithRetry(ctx context.Context, prompt string, maxRetr
 		default:
 		}
 
-		response, err := c.callOllama(ctx, prompt)
+		response, err := c.callOllama(ctx, req, nil)
 		if err == nil {
 			c.logger.Debug("Ollama call succeeded",
 				"attempt", attempt,
@@ -237,19 +612,36 @@ func (c *Client) callOllamaWithRetry(ctx context.Context, prompt string, maxRetr
 }
 
 // callOllama makes a single call to Ollama API
-func (c *Client) callOllama(ctx context.Context, prompt string) (string, error) {
+// The response is constrained to req.Format, which drops to plain JSON once
+// the server has rejected a schema. With onToken set the response is
+// streamed to it as it is generated.
+func (c *Client) callOllama(ctx context.Context, req Request, onToken func(string)) (string, error) {
 	// Create a timeout context for this attempt
 	timeoutCtx, cancel := context.WithTimeout(ctx, 300*time.Second)
 	defer cancel()
 
+	if c.noSchema.Load() {
+		req.Format = jsonFormat
+	}
+
 	c.logger.Debug("Calling Ollama API",
 		"model", c.modelName,
 		"timeout", "300s",
-		"prompt_length", len(prompt),
+		"prompt_length", len(req.Prompt),
+		"schema", !c.noSchema.Load(),
 	)
 
-	// Use langchaingo GenerateFromSinglePrompt method
-	response, err := llms.GenerateFromSinglePrompt(timeoutCtx, c.llm, prompt)
+	response, err := c.generateOnce(timeoutCtx, req, onToken)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.rejectsFormat() && !c.noSchema.Load() {
+		c.logger.Warn("Ollama rejected the output schema, falling back to JSON mode",
+			"error", err,
+			"model", c.modelName,
+		)
+		c.noSchema.Store(true)
+		req.Format = jsonFormat
+		response, err = c.generateOnce(timeoutCtx, req, onToken)
+	}
 	if err != nil {
 		c.logger.Error("Failed to call Ollama API",
 			"error", err,
@@ -266,104 +658,102 @@ func (c *Client) callOllama(ctx context.Context, prompt string) (string, error)
 	return response, nil
 }
 
-// buildSentimentPrompt creates a prompt for sentiment analysis
-func (c *Client) buildSentimentPrompt(content string) string {
-	return fmt.Sprintf(`Analyze the sentiment of the following journal entry and respond ONLY with valid JSON in this exact format:
-{
-  "score": <float between -1.0 and 1.0>,
-  "label": "<positive|negative|neutral>",
-  "confidence": <float between 0.0 and 1.0>
+// statusError is a non-200 answer from the Ollama API
+type statusError struct {
+	StatusCode int
+	Message    string
 }
 
-Journal entry to analyze:
-%s
-
-Remember: Respond ONLY with the JSON object, no additional text or explanation.`, content)
+// newStatusError reads the error message of a non-200 answer from its body
+func newStatusError(statusCode int, body []byte) *statusError {
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		message = apiErr.Error
+	}
+	return &statusError{StatusCode: statusCode, Message: message}
 }
 
-// buildGenerationPrompt creates a prompt for journal generation
-func (c *Client) buildGenerationPrompt(req *models.PromptRequest) string {
-	context := ""
-	if req.Context != "" {
-		context = fmt.Sprintf("\nContext: %s", req.Context)
-	}
-
-	return fmt.Sprintf(`You are a journal writing assistant. Write a detailed journal entry and provide metadata in JSON format.
-
-User prompt: %s%s
-
-Respond with ONLY valid JSON in this exact structure (no extra text, no markdown, no explanations):
-
-{
-  "content": "Write a detailed journal entry here (3-5 sentences about the experience, emotions, and thoughts)",
-  "metadata": {
-    "mood": "overall mood assessment",
-    "emotional_context": "detailed emotional state description",
-    "themes": ["theme1", "theme2", "theme3"],
-    "entities": ["entity1", "entity2"],
-    "key_phrases": ["phrase1", "phrase2", "phrase3"],
-    "tags": ["tag1", "tag2", "tag3"]
-  },
-  "semantic_markers": ["marker1", "marker2", "marker3"],
-  "processing_hints": {
-    "emotional_intensity": "low",
-    "complexity": "moderate",
-    "future_analysis_priority": "medium"
-  }
+func (e *statusError) Error() string {
+	return fmt.Sprintf("ollama returned status %d: %s", e.StatusCode, e.Message)
 }
 
-Important: Return only the JSON object. No other text.`, req.Prompt, context)
+// rejectsFormat reports whether the server refused the format parameter
+func (e *statusError) rejectsFormat() bool {
+	return e.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(e.Message), "format")
 }
 
-// parseSentimentResponse parses the sentiment analysis response
-func (c *Client) parseSentimentResponse(response string) (*models.SentimentResult, error) {
-	var result models.SentimentResult
-
-	// Clean the response (remove any potential markdown formatting)
-	cleaned := cleanJSONResponse(response)
+// generateOnce posts a request to /api/generate, streaming the response to
+// onToken when it is set
+func (c *Client) generateOnce(ctx context.Context, req Request, onToken func(string)) (string, error) {
+	req.Stream = onToken != nil
 
-	if err := json.Unmarshal([]byte(cleaned), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse sentiment JSON: %w", err)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
 	}
 
-	// Validate the parsed result
-	if result.Score < -1.0 || result.Score > 1.0 {
-		return nil, fmt.Errorf("invalid sentiment score: %f (must be between -1.0 and 1.0)", result.Score)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	if result.Confidence < 0.0 || result.Confidence > 1.0 {
-		return nil, fmt.Errorf("invalid confidence: %f (must be between 0.0 and 1.0)", result.Confidence)
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	validLabels := map[string]bool{"positive": true, "negative": true, "neutral": true}
-	if !validLabels[result.Label] {
-		return nil, fmt.Errorf("invalid sentiment label: %s (must be positive, negative, or neutral)", result.Label)
+	if resp.StatusCode == http.StatusOK && req.Stream {
+		return readStream(resp.Body, onToken)
 	}
 
-	return &result, nil
-}
-
-// parseGenerationResponse parses the journal generation response
-func (c *Client) parseGenerationResponse(response string) (*models.GeneratedJournal, error) {
-	var result models.GeneratedJournal
-
-	// Clean the response (remove any potential markdown formatting)
-	cleaned := cleanJSONResponse(response)
-
-	if err := json.Unmarshal([]byte(cleaned), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse generation JSON: %w", err)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Validate the parsed result
-	if result.Content == "" {
-		return nil, fmt.Errorf("generated content cannot be empty")
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError(resp.StatusCode, data)
 	}
 
-	if len(result.Metadata.Themes) == 0 {
-		return nil, fmt.Errorf("generated metadata must include at least one theme")
+	var result Response
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &result, nil
+	return result.Response, nil
+}
+
+// readStream reads the newline-delimited chunks of a streamed response,
+// passing each piece of text to onToken, and returns the whole text
+func readStream(body io.Reader, onToken func(string)) (string, error) {
+	var text strings.Builder
+	decoder := json.NewDecoder(body)
+
+	for {
+		var chunk Response
+		if err := decoder.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("stream ended before the response was done")
+			}
+			return "", fmt.Errorf("failed to decode stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("stream failed: %s", chunk.Error)
+		}
+
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			onToken(chunk.Response)
+		}
+		if chunk.Done {
+			return text.String(), nil
+		}
+	}
 }
 
 // HealthCheck performs a health check on the AI client using a simple prompt
@@ -410,104 +800,3 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 
 	return nil
 }
-
-// cleanJSONResponse removes markdown formatting and extracts JSON
-func cleanJSONResponse(response string) string {
-	// Remove common markdown formatting
-	cleaned := response
-
-	// Remove ```json and ``` markers
-	if bytes.Contains([]byte(cleaned), []byte("```json")) {
-		start := bytes.Index([]byte(cleaned), []byte("```json")) + 7
-		end := bytes.LastIndex([]byte(cleaned), []byte("```"))
-		if start < end {
-			cleaned = string([]byte(cleaned)[start:end])
-		}
-	} else if bytes.Contains([]byte(cleaned), []byte("```")) {
-		start := bytes.Index([]byte(cleaned), []byte("```")) + 3
-		end := bytes.LastIndex([]byte(cleaned), []byte("```"))
-		if start < end {
-			cleaned = string([]byte(cleaned)[start:end])
-		}
-	}
-
-	// Find JSON object boundaries
-	start := bytes.Index([]byte(cleaned), []byte("{"))
-	end := bytes.LastIndex([]byte(cleaned), []byte("}"))
-
-	if start >= 0 && end > start {
-		cleaned = string([]byte(cleaned)[start : end+1])
-	}
-
-	// Fix common JSON formatting issues from LLM responses
-	cleaned = fixMalformedJSON(cleaned)
-
-	return cleaned
-}
-
-// fixMalformedJSON fixes common JSON formatting issues from LLM responses
-func fixMalformedJSON(jsonStr string) string {
-	// First, handle the nested JSON string in content field
-	// Pattern: "content": "{\"key\": \"value\"}" -> "content": "escaped content"
-
-	// Handle trailing commas before closing braces/brackets
-	re := regexp.MustCompile(`,(\s*[}\]])`)
-	jsonStr = re.ReplaceAllString(jsonStr, "$1")
-
-	// Handle missing quotes around string values
-	re = regexp.MustCompile(`:\s*([^"{\[\]\s,}]+)(\s*[,}])`)
-	jsonStr = re.ReplaceAllStringFunc(jsonStr, func(match string) string {
-		// Don't quote numeric values or booleans
-		parts := strings.SplitN(match, ":", 2)
-		if len(parts) == 2 {
-			value := strings.TrimSpace(parts[1])
-			ending := ""
-			if strings.HasSuffix(value, ",") {
-				ending = ","
-				value = strings.TrimSuffix(value, ",")
-			} else if strings.HasSuffix(value, "}") {
-				ending = "}"
-				value = strings.TrimSuffix(value, "}")
-			}
-			value = strings.TrimSpace(value)
-
-			// Check if it's a number, boolean, or null
-			if isNumeric(value) || value == "true" || value == "false" || value == "null" {
-				return parts[0] + ": " + value + ending
-			}
-			// Quote string values
-			return parts[0] + ": \"" + strings.ReplaceAll(value, "\"", "\\\"") + "\"" + ending
-		}
-		return match
-	})
-
-	// Fix duplicate colons
-	re = regexp.MustCompile(`::`)
-	jsonStr = re.ReplaceAllString(jsonStr, ":")
-
-	// Handle escaped quotes in metadata field
-	if strings.Contains(jsonStr, `"metadata"`) {
-		// Find the metadata value and escape it properly
-		re = regexp.MustCompile(`"metadata":\s*"([^"]*(?:\\"[^"]*)*)"`)
-		jsonStr = re.ReplaceAllStringFunc(jsonStr, func(match string) string {
-			// Extract the content between quotes
-			parts := strings.SplitN(match, `"metadata":`, 2)
-			if len(parts) == 2 {
-				content := strings.TrimSpace(parts[1])
-				content = strings.Trim(content, `"`)
-				// Escape internal quotes
-				content = strings.ReplaceAll(content, `"`, `\"`)
-				return `"metadata": "` + content + `"`
-			}
-			return match
-		})
-	}
-
-	return jsonStr
-}
-
-// isNumeric checks if a string represents a numeric value
-func isNumeric(s string) bool {
-	_, err := strconv.ParseFloat(s, 64)
-	return err == nil
-}
@@ -0,0 +1,431 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/ollama"
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// server fakes /api/generate, answering each request with the next reply
+// and recording the requests it received
+type server struct {
+	mu       sync.Mutex
+	replies  []func(w http.ResponseWriter)
+	requests []ollama.Request
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var req ollama.Request
+	if r.URL.Path != "/api/generate" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, `{"error": "bad request"}`, http.StatusBadRequest)
+		return
+	}
+	s.requests = append(s.requests, req)
+
+	reply := s.replies[min(len(s.requests), len(s.replies))-1]
+	reply(w)
+}
+
+func answer(response string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		json.NewEncoder(w).Encode(ollama.Response{Model: "test-model", Response: response, Done: true})
+	}
+}
+
+func newClient(t *testing.T, replies ...func(w http.ResponseWriter)) (*ollama.Client, *server) {
+	t.Helper()
+
+	s := &server{replies: replies}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	client, err := ollama.New(context.Background(), "test-model", ts.URL)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return client, s
+}
+
+func TestClient_SendsSchemaFormat(t *testing.T) {
+	client, s := newClient(t,
+		answer(`{"score": 0.6, "label": "positive", "confidence": 0.8}`),
+		answer(`{"content": "A calm day.", "metadata": {"themes": ["rest"]}}`),
+	)
+
+	if _, err := client.AnalyzeSentiment(context.Background(), "A calm and happy day"); err != nil {
+		t.Fatalf("AnalyzeSentiment failed: %v", err)
+	}
+	if _, err := client.GenerateJournal(context.Background(), &models.PromptRequest{Prompt: "A calm day"}); err != nil {
+		t.Fatalf("GenerateJournal failed: %v", err)
+	}
+
+	if len(s.requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(s.requests))
+	}
+	sentiment, journal := string(s.requests[0].Format), string(s.requests[1].Format)
+	if !strings.Contains(sentiment, `"enum":["positive","negative","neutral"]`) || strings.Contains(sentiment, "processed_at") {
+		t.Errorf("Unexpected sentiment schema %s", sentiment)
+	}
+	if !strings.Contains(journal, `"content":{"type":"string","minLength":1}`) {
+		t.Errorf("Unexpected journal schema %s", journal)
+	}
+	if s.requests[0].Model != "test-model" || s.requests[0].Stream {
+		t.Errorf("Unexpected request %+v", s.requests[0])
+	}
+}
+
+func TestClient_SendsGenerationOptions(t *testing.T) {
+	client, s := newClient(t,
+		answer(`{"score": 0.6, "label": "positive", "confidence": 0.8}`),
+		answer(`{"content": "A calm day.", "metadata": {"themes": ["rest"]}}`),
+	)
+
+	sentiment, err := client.AnalyzeSentiment(context.Background(), "A calm and happy day")
+	if err != nil {
+		t.Fatalf("AnalyzeSentiment failed: %v", err)
+	}
+
+	// Sentiment defaults to deterministic sampling, recorded with the result
+	sent := s.requests[0].Options
+	if sent == nil || *sent.Temperature != 0 || *sent.Seed != options.DeterministicSeed {
+		t.Fatalf("Expected deterministic options, got %+v", sent)
+	}
+	if sentiment.Options == nil || *sentiment.Options.Seed != *sent.Seed {
+		t.Errorf("Expected the options to be recorded, got %+v", sentiment.Options)
+	}
+
+	numCtx, seed := 8192, 7
+	ctx := options.WithOptions(context.Background(), models.GenerationOptions{NumCtx: &numCtx, Seed: &seed, Stop: []string{"###"}})
+	journal, err := client.GenerateJournal(ctx, &models.PromptRequest{Prompt: "A calm day"})
+	if err != nil {
+		t.Fatalf("GenerateJournal failed: %v", err)
+	}
+
+	sent = s.requests[1].Options
+	if sent == nil || *sent.NumCtx != 8192 || *sent.Seed != 7 || sent.Temperature != nil || sent.Stop[0] != "###" {
+		t.Errorf("Expected the options from the context, got %+v", sent)
+	}
+	if journal.Options == nil || *journal.Options.NumCtx != 8192 {
+		t.Errorf("Expected the options to be recorded, got %+v", journal.Options)
+	}
+}
+
+func TestClient_OmitsUnsetOptions(t *testing.T) {
+	client, s := newClient(t, answer(`{"content": "A calm day.", "metadata": {"themes": ["rest"]}}`))
+
+	journal, err := client.GenerateJournal(context.Background(), &models.PromptRequest{Prompt: "A calm day"})
+	if err != nil {
+		t.Fatalf("GenerateJournal failed: %v", err)
+	}
+	if s.requests[0].Options != nil || journal.Options != nil {
+		t.Errorf("Expected model defaults for generation, got %+v", s.requests[0].Options)
+	}
+}
+
+func TestClient_CorrectsInvalidResponse(t *testing.T) {
+	client, s := newClient(t,
+		answer(`<think>strong feelings</think>{"score": 4, "label": "positive", "confidence": 0.9}`),
+		answer(`{"score": 0.9, "label": "positive", "confidence": 0.9}`),
+	)
+
+	result, err := client.AnalyzeSentiment(context.Background(), "Best day of my life")
+	if err != nil {
+		t.Fatalf("AnalyzeSentiment failed: %v", err)
+	}
+	if result.Score != 0.9 {
+		t.Errorf("Expected corrected score, got %+v", result)
+	}
+
+	if len(s.requests) != 2 {
+		t.Fatalf("Expected one correction, got %d requests", len(s.requests))
+	}
+	correction := s.requests[1].Prompt
+	if !strings.HasPrefix(correction, s.requests[0].Prompt) {
+		t.Error("Expected the correction to repeat the original prompt")
+	}
+	if !strings.Contains(correction, "invalid sentiment score: 4.000000") || !strings.Contains(correction, `{"score": 4,`) {
+		t.Errorf("Expected the validation message and previous answer, got %q", correction)
+	}
+	if strings.Contains(correction, "strong feelings") {
+		t.Error("Expected reasoning to be left out of the correction")
+	}
+}
+
+func TestClient_GivesUpAfterCorrections(t *testing.T) {
+	client, s := newClient(t, answer(`{"score": 0.5, "label": "happy", "confidence": 0.9}`))
+
+	_, err := client.AnalyzeSentiment(context.Background(), "Some entry")
+	if !errors.Is(err, models.ErrInvalidAIResponse) {
+		t.Fatalf("Expected ErrInvalidAIResponse, got %v", err)
+	}
+	if len(s.requests) != 3 {
+		t.Errorf("Expected the original call and 2 corrections, got %d requests", len(s.requests))
+	}
+}
+
+func TestClient_FallsBackToJSONMode(t *testing.T) {
+	rejected := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "json: cannot unmarshal object into Go struct field GenerateRequest.format of type string"}`))
+	}
+	client, s := newClient(t,
+		rejected,
+		answer(`{"score": 0.1, "label": "neutral", "confidence": 0.5}`),
+	)
+
+	for range 2 {
+		if _, err := client.AnalyzeSentiment(context.Background(), "Some entry"); err != nil {
+			t.Fatalf("AnalyzeSentiment failed: %v", err)
+		}
+	}
+
+	var formats []string
+	for _, req := range s.requests {
+		formats = append(formats, string(req.Format))
+	}
+	if len(formats) != 3 || formats[0][0] != '{' || formats[1] != `"json"` || formats[2] != `"json"` {
+		t.Errorf("Expected schema then JSON mode, got %v", formats)
+	}
+}
+
+func TestClient_RendersTemplateFromContext(t *testing.T) {
+	client, s := newClient(t, answer(`{"score": 0.2, "label": "neutral", "confidence": 0.6}`))
+
+	tmpl, err := prompt.Default().Resolve(prompt.TaskSentiment, "sentiment@v2")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	result, err := client.AnalyzeSentiment(prompt.WithTemplate(context.Background(), tmpl), "An ordinary Tuesday")
+	if err != nil {
+		t.Fatalf("AnalyzeSentiment failed: %v", err)
+	}
+	if result.Prompt == nil || result.Prompt.String() != "sentiment@v2" {
+		t.Errorf("Expected the template to be recorded, got %v", result.Prompt)
+	}
+
+	req := s.requests[0]
+	if req.System == "" {
+		t.Error("Expected the system prompt to be sent")
+	}
+	if !strings.HasPrefix(req.Prompt, "Example 1 input:") || !strings.HasSuffix(req.Prompt, "An ordinary Tuesday") {
+		t.Errorf("Expected the examples followed by the entry, got %q", req.Prompt)
+	}
+}
+
+func TestClient_AnalyzeEmotions(t *testing.T) {
+	client, s := newClient(t,
+		// Nested objects alone must not pass as an analysis
+		answer(`{"emotions": {"fear": 0.7}, "valence": -0.4, "arousal": 0.7, "sentences": []}`),
+		answer(`{"emotions": {"joy": 0, "sadness": 0.2, "anger": 0, "fear": 0.7, "surprise": 0, "disgust": 0, "trust": 0, "anticipation": 0.5},
+			"valence": -0.4, "arousal": 0.7,
+			"sentences": [{"text": "The interview is tomorrow.", "emotions": {"fear": 0.7, "anticipation": 0.5}, "valence": -0.4, "arousal": 0.7}]}`),
+	)
+
+	result, err := client.AnalyzeEmotions(context.Background(), "The interview is tomorrow.")
+	if err != nil {
+		t.Fatalf("AnalyzeEmotions failed: %v", err)
+	}
+	if result.DominantEmotion != models.EmotionFear || len(result.Sentences) != 1 || result.ProcessedAt.IsZero() {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.Prompt == nil || result.Prompt.String() != "emotion@v1" {
+		t.Errorf("Expected the emotion template to be recorded, got %v", result.Prompt)
+	}
+
+	if len(s.requests) != 2 || !strings.Contains(s.requests[1].Prompt, "at least one sentence") {
+		t.Fatalf("Expected one correction, got %d requests", len(s.requests))
+	}
+	format := string(s.requests[0].Format)
+	if !strings.Contains(format, `"anticipation":{"type":"number","minimum":0,"maximum":1}`) || strings.Contains(format, "dominant_emotion") {
+		t.Errorf("Unexpected emotion schema %s", format)
+	}
+	if sent := s.requests[0].Options; sent == nil || *sent.Temperature != 0 || *sent.Seed != options.DeterministicSeed {
+		t.Errorf("Expected deterministic options, got %+v", sent)
+	}
+}
+
+func TestClient_ExtractEntities(t *testing.T) {
+	client, s := newClient(t,
+		answer(`{"people": [{"name": "Alice", "confidence": 0.9}]}`),
+		answer(`{"people": [{"name": "Alice", "confidence": 0.9}], "places": [], "organizations": [{"name": "Acme", "confidence": 0.8}],
+			"activities": [{"name": "lunch", "confidence": 0.7}], "themes": [{"name": "work", "confidence": 0.8}], "tags": [{"name": "Work", "confidence": 0.8}]}`),
+	)
+
+	result, err := client.ExtractEntities(context.Background(), "Lunch with Alice from Acme.")
+	if err != nil {
+		t.Fatalf("ExtractEntities failed: %v", err)
+	}
+	if len(result.Entities()) != 2 || result.Tags[0].Name != "work" || result.ProcessedAt.IsZero() {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.Prompt == nil || result.Prompt.String() != "extraction@v1" {
+		t.Errorf("Expected the extraction template to be recorded, got %v", result.Prompt)
+	}
+
+	if len(s.requests) != 2 || !strings.Contains(s.requests[1].Prompt, "themes array") {
+		t.Fatalf("Expected one correction, got %d requests", len(s.requests))
+	}
+	if format := string(s.requests[0].Format); !strings.Contains(format, `"organizations"`) || strings.Contains(format, "processed_at") {
+		t.Errorf("Unexpected extraction schema %s", format)
+	}
+}
+
+func TestClient_Summarize(t *testing.T) {
+	client, s := newClient(t,
+		answer(`{"summary": "", "moods": ["joy"]}`),
+		answer(`{"summary": "A productive week.", "moods": ["joy"], "themes": ["work"], "notable_events": ["Shipped the release"], "change": ""}`),
+	)
+
+	from := time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)
+	result, err := client.Summarize(context.Background(), &models.SummaryRequest{
+		Period:  models.DigestWeekly,
+		From:    from,
+		To:      from.AddDate(0, 0, 7),
+		Entries: []string{"2025-08-05 09:00 | sentiment: positive (0.8)\nShipped the release."},
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Summary != "A productive week." || result.ProcessedAt.IsZero() || result.Prompt.String() != "summary@v1" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if len(s.requests) != 2 || !strings.Contains(s.requests[1].Prompt, "summary cannot be empty") {
+		t.Fatalf("Expected one correction, got %d requests", len(s.requests))
+	}
+	if !strings.Contains(s.requests[0].Prompt, "weekly period from 2025-08-04 to 2025-08-10") || !strings.Contains(s.requests[0].Prompt, "Shipped the release.") {
+		t.Errorf("Expected the entries in the prompt, got %q", s.requests[0].Prompt)
+	}
+}
+
+func TestClient_Embed(t *testing.T) {
+	var received ollama.EmbedRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" || json.NewDecoder(r.Body).Decode(&received) != nil {
+			http.Error(w, `{"error": "bad request"}`, http.StatusBadRequest)
+			return
+		}
+		if received.Model != "nomic-embed-text" {
+			http.Error(w, `{"error": "model \"`+received.Model+`\" not found, try pulling it first"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(ollama.EmbedResponse{Model: received.Model, Embeddings: [][]float32{{0.1, 0.2}, {0.3, 0.4}}})
+	}))
+	defer ts.Close()
+
+	client, err := ollama.New(context.Background(), "test-model", ts.URL)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := client.Embed(context.Background(), []string{"a"}); !errors.Is(err, errors.ErrUnsupported) || client.Capabilities().Embeddings {
+		t.Errorf("Expected embeddings to be unsupported without a model, got %v", err)
+	}
+
+	client.SetEmbeddingModel("nomic-embed-text")
+	result, err := client.Embed(context.Background(), []string{"first chunk", "second chunk"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if result.Model != "nomic-embed-text" || len(result.Vectors) != 2 || result.Vectors[1][1] != 0.4 {
+		t.Errorf("Unexpected embeddings %+v", result)
+	}
+	if len(received.Input) != 2 || !received.Truncate {
+		t.Errorf("Unexpected embed request %+v", received)
+	}
+
+	client.SetEmbeddingModel("missing-model")
+	if _, err := client.Embed(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("Expected the server's error message, got %v", err)
+	}
+}
+
+// chunks streams response as newline-delimited chunks, ending with done
+func chunks(parts ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		encoder := json.NewEncoder(w)
+		for _, part := range parts {
+			encoder.Encode(ollama.Response{Model: "test-model", Response: part})
+		}
+		encoder.Encode(ollama.Response{Model: "test-model", Done: true})
+	}
+}
+
+func TestClient_GenerateJournalStream(t *testing.T) {
+	client, s := newClient(t, chunks(`{"content": "A calm`, ` day.", "metadata": `, `{"themes": ["rest"]}}`))
+
+	var tokens []string
+	result, err := client.GenerateJournalStream(context.Background(), &models.PromptRequest{Prompt: "A calm day"}, func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatalf("GenerateJournalStream failed: %v", err)
+	}
+
+	if len(tokens) != 3 || tokens[1] != ` day.", "metadata": ` {
+		t.Errorf("Expected each chunk as a token, got %q", tokens)
+	}
+	if result.Content != "A calm day." || result.Prompt == nil || result.GeneratedAt.IsZero() {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if !s.requests[0].Stream || len(s.requests[0].Format) == 0 {
+		t.Errorf("Expected a streamed, schema-constrained request, got %+v", s.requests[0])
+	}
+}
+
+func TestClient_GenerateJournalStream_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply func(w http.ResponseWriter)
+		want  string
+	}{
+		{
+			name:  "invalid answer is not corrected",
+			reply: chunks(`{"content": "", "metadata": {"themes": []}}`),
+			want:  models.ErrInvalidAIResponse.Error(),
+		},
+		{
+			name: "error chunk",
+			reply: func(w http.ResponseWriter) {
+				json.NewEncoder(w).Encode(ollama.Response{Response: `{"content"`})
+				w.Write([]byte(`{"error": "model runner has unexpectedly stopped"}` + "\n"))
+			},
+			want: "model runner has unexpectedly stopped",
+		},
+		{
+			name: "truncated stream",
+			reply: func(w http.ResponseWriter) {
+				json.NewEncoder(w).Encode(ollama.Response{Response: `{"content"`})
+			},
+			want: "stream ended before the response was done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, s := newClient(t, tt.reply)
+
+			_, err := client.GenerateJournalStream(context.Background(), &models.PromptRequest{Prompt: "A calm day"}, func(string) {})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if len(s.requests) != 1 {
+				t.Errorf("Expected a single request, got %d", len(s.requests))
+			}
+		})
+	}
+}
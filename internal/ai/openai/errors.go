@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// Sentinel errors an APIError unwraps to
+var (
+	ErrRateLimited  = errors.New("rate limited by AI provider")
+	ErrUnauthorized = errors.New("AI provider rejected credentials")
+	ErrServer       = errors.New("AI provider server error")
+)
+
+// RateLimit holds the x-ratelimit-* headers of a response
+// Fields are zero when the server does not send the corresponding header.
+type RateLimit struct {
+	LimitRequests     int           `json:"limit_requests,omitempty"`
+	RemainingRequests int           `json:"remaining_requests,omitempty"`
+	ResetRequests     time.Duration `json:"reset_requests,omitempty"`
+	LimitTokens       int           `json:"limit_tokens,omitempty"`
+	RemainingTokens   int           `json:"remaining_tokens,omitempty"`
+	ResetTokens       time.Duration `json:"reset_tokens,omitempty"`
+}
+
+// APIError is a non-200 response from the chat completions API
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	RetryAfter *time.Duration // nil when the server sent no usable Retry-After
+	RateLimit  RateLimit
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("AI provider returned status %d", e.StatusCode)
+	if e.Type != "" {
+		msg += " (" + e.Type + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap maps the status code to a sentinel error
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// unsupportedResponseFormat reports whether the server rejected JSON mode
+func (e *APIError) unsupportedResponseFormat() bool {
+	if e.StatusCode != http.StatusBadRequest && e.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	return strings.Contains(strings.ToLower(e.Message), "response_format")
+}
+
+// newAPIError builds an APIError from a failed response
+// Both the OpenAI error envelope and plain-text bodies are understood.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	apiErr.RateLimit, _ = parseRateLimit(resp.Header)
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		apiErr.RetryAfter = &d
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    any    `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		apiErr.Message = envelope.Error.Message
+		apiErr.Type = envelope.Error.Type
+		if envelope.Error.Code != nil {
+			apiErr.Code = fmt.Sprint(envelope.Error.Code)
+		}
+		return apiErr
+	}
+
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
+
+// retryable reports whether a failed request is worth repeating
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// Transport failures are retried; unusable answers are left to the caller
+	return !errors.Is(err, models.ErrInvalidAIResponse)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// parseRateLimit reads the x-ratelimit-* headers; ok is false when none are set
+func parseRateLimit(header http.Header) (RateLimit, bool) {
+	var limit RateLimit
+	found := false
+
+	readInt := func(name string, dst *int) {
+		if v, err := strconv.Atoi(header.Get(name)); err == nil {
+			*dst = v
+			found = true
+		}
+	}
+	readDuration := func(name string, dst *time.Duration) {
+		if d, ok := parseResetDuration(header.Get(name)); ok {
+			*dst = d
+			found = true
+		}
+	}
+
+	readInt("X-Ratelimit-Limit-Requests", &limit.LimitRequests)
+	readInt("X-Ratelimit-Remaining-Requests", &limit.RemainingRequests)
+	readDuration("X-Ratelimit-Reset-Requests", &limit.ResetRequests)
+	readInt("X-Ratelimit-Limit-Tokens", &limit.LimitTokens)
+	readInt("X-Ratelimit-Remaining-Tokens", &limit.RemainingTokens)
+	readDuration("X-Ratelimit-Reset-Tokens", &limit.ResetTokens)
+
+	return limit, found
+}
+
+// parseResetDuration parses reset values such as "1s", "6m0s" or "20ms"
+// Bare numbers are taken as seconds.
+func parseResetDuration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, true
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,553 @@
+// Package openai implements an AI provider for servers that speak the OpenAI
+// chat completions protocol, such as llama.cpp server, vLLM, LM Studio and
+// LocalAI, as well as hosted OpenAI-compatible APIs.
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/parse"
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// ProviderName identifies the OpenAI-compatible provider in configuration
+const ProviderName = "openai"
+
+const (
+	maxAttempts    = 3
+	requestTimeout = 300 * time.Second
+	maxRetryWait   = 30 * time.Second
+	maxErrorBody   = 64 << 10
+)
+
+// Message is a single chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ResponseFormat asks the server to constrain its output
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// ChatRequest is the body of POST /chat/completions
+type ChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	TopP           *float64        `json:"top_p,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+	MaxTokens      *int            `json:"max_tokens,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream"`
+}
+
+// ChatResponse is the body returned by POST /chat/completions
+type ChatResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index        int     `json:"index"`
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Client talks to any server implementing the OpenAI chat completions API
+type Client struct {
+	baseURL    string
+	modelName  string
+	apiKey     string
+	httpClient *http.Client
+	logger     *logging.Logger
+
+	// jsonMode is cleared the first time the server rejects response_format
+	jsonMode atomic.Bool
+
+	mu        sync.Mutex
+	rateLimit RateLimit
+}
+
+// New creates a client for the server at baseURL
+// Both "http://host:8000" and "http://host:8000/v1" are accepted.
+func New(modelName, baseURL, apiKey string, logger *logging.Logger) (*Client, error) {
+	if modelName == "" {
+		return nil, fmt.Errorf("openai model cannot be empty")
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai base URL cannot be empty")
+	}
+
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+
+	logger.Info("Creating OpenAI-compatible client",
+		"base_url", baseURL,
+		"model", modelName,
+		"authenticated", apiKey != "",
+	)
+
+	c := &Client{
+		baseURL:    baseURL,
+		modelName:  modelName,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		logger:     logger,
+	}
+	c.jsonMode.Store(true)
+
+	return c, nil
+}
+
+// Name returns the provider name
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// Capabilities reports what the client supports
+func (c *Client) Capabilities() models.AICapabilities {
+	return models.AICapabilities{
+		Sentiment:  true,
+		Generation: true,
+		Emotions:   true,
+		Extraction: true,
+		Summaries:  true,
+		Model:      c.modelName,
+	}
+}
+
+// RateLimit returns the rate-limit state from the most recent response
+func (c *Client) RateLimit() RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rateLimit
+}
+
+// AnalyzeSentiment performs sentiment analysis on journal content
+func (c *Client) AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error) {
+	start := time.Now()
+
+	rendered, err := render(ctx, prompt.TaskSentiment, map[string]any{"Content": content})
+	if err != nil {
+		return nil, fmt.Errorf("sentiment analysis failed: %w", err)
+	}
+
+	response, err := c.complete(ctx, rendered)
+	if err != nil {
+		return nil, fmt.Errorf("sentiment analysis failed: %w", err)
+	}
+
+	result, err := parse.Sentiment(response)
+	if err != nil {
+		c.logger.Error("Failed to parse sentiment response",
+			"error", err,
+			"response", response,
+			"response_length", len(response),
+		)
+		return nil, fmt.Errorf("failed to parse sentiment response: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &rendered.ref
+	result.Options = rendered.options
+
+	c.logger.Info("Sentiment analysis completed",
+		"duration", time.Since(start),
+		"score", result.Score,
+		"label", result.Label,
+		"confidence", result.Confidence,
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// AnalyzeEmotions rates the emotions, valence and arousal of journal content,
+// as a whole and sentence by sentence
+func (c *Client) AnalyzeEmotions(ctx context.Context, content string) (*models.EmotionResult, error) {
+	start := time.Now()
+
+	rendered, err := render(ctx, prompt.TaskEmotion, map[string]any{"Content": content})
+	if err != nil {
+		return nil, fmt.Errorf("emotion analysis failed: %w", err)
+	}
+
+	response, err := c.complete(ctx, rendered)
+	if err != nil {
+		return nil, fmt.Errorf("emotion analysis failed: %w", err)
+	}
+
+	result, err := parse.Emotions(response)
+	if err != nil {
+		c.logger.Error("Failed to parse emotion response",
+			"error", err,
+			"response", response,
+			"response_length", len(response),
+		)
+		return nil, fmt.Errorf("failed to parse emotion response: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &rendered.ref
+	result.Options = rendered.options
+
+	c.logger.Info("Emotion analysis completed",
+		"duration", time.Since(start),
+		"dominant_emotion", result.DominantEmotion,
+		"valence", result.Valence,
+		"arousal", result.Arousal,
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// ExtractEntities finds the people, places, organizations, activities,
+// themes and suggested tags of journal content
+func (c *Client) ExtractEntities(ctx context.Context, content string) (*models.ExtractionResult, error) {
+	start := time.Now()
+
+	rendered, err := render(ctx, prompt.TaskExtraction, map[string]any{"Content": content})
+	if err != nil {
+		return nil, fmt.Errorf("extraction failed: %w", err)
+	}
+
+	response, err := c.complete(ctx, rendered)
+	if err != nil {
+		return nil, fmt.Errorf("extraction failed: %w", err)
+	}
+
+	result, err := parse.Extraction(response)
+	if err != nil {
+		c.logger.Error("Failed to parse extraction response",
+			"error", err,
+			"response", response,
+			"response_length", len(response),
+		)
+		return nil, fmt.Errorf("failed to parse extraction response: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &rendered.ref
+	result.Options = rendered.options
+
+	c.logger.Info("Extraction completed",
+		"duration", time.Since(start),
+		"entities", len(result.Entities()),
+		"themes", len(result.Themes),
+		"tags", len(result.Tags),
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// Summarize writes the digest of a period, or of part of one
+func (c *Client) Summarize(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+	start := time.Now()
+
+	rendered, err := render(ctx, prompt.TaskSummary, prompt.SummaryVars(req))
+	if err != nil {
+		return nil, fmt.Errorf("summary failed: %w", err)
+	}
+
+	response, err := c.complete(ctx, rendered)
+	if err != nil {
+		return nil, fmt.Errorf("summary failed: %w", err)
+	}
+
+	result, err := parse.Summary(response)
+	if err != nil {
+		c.logger.Error("Failed to parse summary response",
+			"error", err,
+			"response", response,
+			"response_length", len(response),
+		)
+		return nil, fmt.Errorf("failed to parse summary response: %w", err)
+	}
+
+	result.ProcessedAt = time.Now()
+	result.Prompt = &rendered.ref
+	result.Options = rendered.options
+
+	c.logger.Info("Summary completed",
+		"duration", time.Since(start),
+		"period", req.Period,
+		"themes", len(result.Themes),
+		"notable_events", len(result.NotableEvents),
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// GenerateJournal generates a structured journal entry from a prompt
+func (c *Client) GenerateJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+	start := time.Now()
+
+	rendered, err := render(ctx, prompt.TaskGeneration, map[string]any{"Prompt": req.Prompt, "Context": req.Context})
+	if err != nil {
+		return nil, fmt.Errorf("journal generation failed: %w", err)
+	}
+
+	response, err := c.complete(ctx, rendered)
+	if err != nil {
+		return nil, fmt.Errorf("journal generation failed: %w", err)
+	}
+
+	result, err := parse.GeneratedJournal(response)
+	if err != nil {
+		c.logger.Error("Failed to parse generation response",
+			"error", err,
+			"response", response,
+			"response_length", len(response),
+		)
+		return nil, fmt.Errorf("failed to parse generation response: %w", err)
+	}
+
+	result.GeneratedAt = time.Now()
+	result.Prompt = &rendered.ref
+	result.Options = rendered.options
+
+	c.logger.Info("Journal generation completed",
+		"duration", time.Since(start),
+		"content_length", len(result.Content),
+		"themes_count", len(result.Metadata.Themes),
+		"prompt", result.Prompt,
+	)
+
+	return result, nil
+}
+
+// HealthCheck verifies the server is reachable by listing its models
+func (c *Client) HealthCheck(ctx context.Context) error {
+	healthCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := c.newRequest(healthCtx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// complete sends a chat completion, retrying rate limits, server errors and
+// transport failures, and returns the first choice's content
+func (c *Client) complete(ctx context.Context, rendered *renderedPrompt) (string, error) {
+	var lastErr error
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		content, err := c.completeOnce(ctx, rendered)
+		if err == nil {
+			return content, nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.unsupportedResponseFormat() && c.jsonMode.CompareAndSwap(true, false) {
+			// The server does not implement JSON mode; prompts still ask for JSON
+			c.logger.Warn("Server rejected response_format, falling back to prompt-only JSON",
+				"model", c.modelName,
+				"error", apiErr.Message,
+			)
+			attempt--
+			continue
+		}
+
+		if !retryable(err) || attempt == maxAttempts {
+			break
+		}
+
+		wait := time.Duration(attempt) * time.Second
+		if errors.As(err, &apiErr) && apiErr.RetryAfter != nil {
+			wait = min(*apiErr.RetryAfter, maxRetryWait)
+		}
+
+		c.logger.Warn("Chat completion failed, retrying",
+			"attempt", attempt,
+			"max_attempts", maxAttempts,
+			"wait", wait,
+			"error", err,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return "", lastErr
+}
+
+// completeOnce sends a single chat completion request
+func (c *Client) completeOnce(ctx context.Context, rendered *renderedPrompt) (string, error) {
+	body := ChatRequest{
+		Model:    c.modelName,
+		Messages: rendered.messages,
+	}
+	if opts := rendered.options; opts != nil {
+		body.Temperature = opts.Temperature
+		body.TopP = opts.TopP
+		body.Seed = opts.Seed
+		body.MaxTokens = opts.NumPredict
+		body.Stop = opts.Stop
+	}
+	if c.jsonMode.Load() {
+		body.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode chat request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/chat/completions", payload)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call chat completions API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	c.recordRateLimit(resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	var chat ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return "", fmt.Errorf("%w: failed to decode chat completion: %w", models.ErrInvalidAIResponse, err)
+	}
+	if len(chat.Choices) == 0 {
+		return "", fmt.Errorf("%w: chat completion has no choices", models.ErrInvalidAIResponse)
+	}
+
+	choice := chat.Choices[0]
+	if strings.TrimSpace(choice.Message.Content) == "" {
+		return "", fmt.Errorf("%w: chat completion is empty (finish_reason %q)", models.ErrInvalidAIResponse, choice.FinishReason)
+	}
+
+	c.logger.Debug("Chat completion succeeded",
+		"model", chat.Model,
+		"finish_reason", choice.FinishReason,
+		"prompt_tokens", chat.Usage.PromptTokens,
+		"completion_tokens", chat.Usage.CompletionTokens,
+	)
+
+	return choice.Message.Content, nil
+}
+
+// newRequest builds an authenticated request against the API base URL
+func (c *Client) newRequest(ctx context.Context, method, path string, payload []byte) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	return req, nil
+}
+
+// recordRateLimit remembers the rate-limit headers of a response, if any
+func (c *Client) recordRateLimit(header http.Header) {
+	limit, ok := parseRateLimit(header)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	c.rateLimit = limit
+	c.mu.Unlock()
+}
+
+// renderedPrompt is a prompt template rendered into chat messages, with the
+// generation options to send them with
+type renderedPrompt struct {
+	ref      models.PromptRef
+	messages []Message
+	options  *models.GenerationOptions
+}
+
+// render fills the task's prompt template and converts it to chat messages
+// Few-shot examples become user and assistant turns. The chat API has no
+// context window setting, so num_ctx is dropped from the options, and
+// num_predict is sent as max_tokens unless it is -1 (no limit).
+func render(ctx context.Context, task prompt.Task, vars map[string]any) (*renderedPrompt, error) {
+	tmpl, err := prompt.Select(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := tmpl.Render(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	for _, m := range rendered.Messages() {
+		messages = append(messages, Message{Role: m.Role, Content: m.Content})
+	}
+
+	opts := options.Select(ctx, task)
+	opts.NumCtx = nil
+	if opts.NumPredict != nil && *opts.NumPredict < 0 {
+		opts.NumPredict = nil
+	}
+
+	result := &renderedPrompt{ref: rendered.Ref, messages: messages}
+	if !opts.IsZero() {
+		result.options = &opts
+	}
+	return result, nil
+}
@@ -0,0 +1,464 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/openai"
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// chatReply writes a chat completion whose first choice carries content
+func chatReply(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":    "chatcmpl-test",
+		"model": "test-model",
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+	})
+}
+
+func newClient(t *testing.T, handler http.HandlerFunc) *openai.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := openai.New("test-model", server.URL, "secret", logger())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return client
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := openai.New("", "http://localhost", "", logger()); err == nil {
+		t.Error("Expected error for empty model")
+	}
+	if _, err := openai.New("model", "", "", logger()); err == nil {
+		t.Error("Expected error for empty base URL")
+	}
+}
+
+func TestClient_BaseURLNormalization(t *testing.T) {
+	for _, suffix := range []string{"", "/", "/v1", "/v1/"} {
+		t.Run("suffix "+suffix, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				chatReply(w, `{"score": 0.1, "label": "neutral", "confidence": 0.5}`)
+			}))
+			defer server.Close()
+
+			client, err := openai.New("test-model", server.URL+suffix, "", logger())
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if _, err := client.AnalyzeSentiment(context.Background(), "content"); err != nil {
+				t.Fatalf("AnalyzeSentiment failed: %v", err)
+			}
+			if path != "/v1/chat/completions" {
+				t.Errorf("Expected /v1/chat/completions, got %s", path)
+			}
+		})
+	}
+}
+
+func TestClient_AnalyzeSentiment(t *testing.T) {
+	var request openai.ChatRequest
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Expected bearer auth, got %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		// Models frequently wrap JSON in markdown fences even in JSON mode
+		chatReply(w, "```json\n{\"score\": 0.8, \"label\": \"positive\", \"confidence\": 0.9}\n```")
+	})
+
+	result, err := client.AnalyzeSentiment(context.Background(), "I had a wonderful day")
+	if err != nil {
+		t.Fatalf("AnalyzeSentiment failed: %v", err)
+	}
+
+	if result.Score != 0.8 || result.Label != "positive" || result.Confidence != 0.9 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.ProcessedAt.IsZero() {
+		t.Error("Expected ProcessedAt to be set")
+	}
+
+	if request.Model != "test-model" {
+		t.Errorf("Expected model test-model, got %s", request.Model)
+	}
+	// The default template sends a system prompt, few-shot turns, then the entry
+	messages := request.Messages
+	if len(messages) < 4 || messages[0].Role != "system" || messages[1].Role != "user" || messages[2].Role != "assistant" {
+		t.Fatalf("Expected system, example and user messages, got %+v", messages)
+	}
+	if last := messages[len(messages)-1]; last.Role != "user" || last.Content != "I had a wonderful day" {
+		t.Errorf("Expected journal content as the last user message, got %+v", last)
+	}
+	if result.Prompt == nil || result.Prompt.String() != "sentiment@v2" {
+		t.Errorf("Expected the prompt template to be recorded, got %v", result.Prompt)
+	}
+	if request.ResponseFormat == nil || request.ResponseFormat.Type != "json_object" {
+		t.Errorf("Expected JSON mode, got %+v", request.ResponseFormat)
+	}
+	// Sentiment is deterministic by default
+	if request.Temperature == nil || *request.Temperature != 0 || request.Seed == nil {
+		t.Errorf("Expected temperature 0 and a seed, got %v and %v", request.Temperature, request.Seed)
+	}
+	if result.Options == nil || *result.Options.Seed != *request.Seed {
+		t.Errorf("Expected the options to be recorded, got %+v", result.Options)
+	}
+}
+
+func TestClient_GenerateJournal(t *testing.T) {
+	var request openai.ChatRequest
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		chatReply(w, `{
+			"content": "Today I walked along the river.",
+			"metadata": {"mood": "calm", "themes": ["nature"], "tags": ["walk"]},
+			"semantic_markers": ["river"],
+			"processing_hints": {"emotional_intensity": "low"}
+		}`)
+	})
+
+	result, err := client.GenerateJournal(context.Background(), &models.PromptRequest{
+		Prompt:  "Write about a walk",
+		Context: "It was autumn",
+	})
+	if err != nil {
+		t.Fatalf("GenerateJournal failed: %v", err)
+	}
+
+	if result.Content != "Today I walked along the river." {
+		t.Errorf("Unexpected content: %q", result.Content)
+	}
+	if result.Metadata.Mood != "calm" || len(result.Metadata.Themes) != 1 {
+		t.Errorf("Unexpected metadata: %+v", result.Metadata)
+	}
+	if !strings.Contains(request.Messages[1].Content, "It was autumn") {
+		t.Errorf("Expected context in user message, got %q", request.Messages[1].Content)
+	}
+}
+
+func TestClient_AnalyzeEmotions(t *testing.T) {
+	var request openai.ChatRequest
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		chatReply(w, `{"emotions": {"joy": 0.6, "trust": 0.7}, "valence": 0.6, "arousal": 0.3,
+			"sentences": [{"text": "Dinner with old friends.", "emotions": {"joy": 0.6, "trust": 0.7}, "valence": 0.6, "arousal": 0.3}]}`)
+	})
+
+	result, err := client.AnalyzeEmotions(context.Background(), "Dinner with old friends.")
+	if err != nil {
+		t.Fatalf("AnalyzeEmotions failed: %v", err)
+	}
+	if result.DominantEmotion != models.EmotionTrust || result.Sentences[0].Emotions.Joy != 0.6 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.Prompt == nil || result.Prompt.String() != "emotion@v1" || result.Options == nil {
+		t.Errorf("Expected the template and options to be recorded, got %v and %+v", result.Prompt, result.Options)
+	}
+	if last := request.Messages[len(request.Messages)-1]; last.Content != "Dinner with old friends." {
+		t.Errorf("Expected journal content as the last user message, got %+v", last)
+	}
+}
+
+func TestClient_ExtractEntities(t *testing.T) {
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		chatReply(w, `{"people": [], "places": [{"name": "Porto", "confidence": 0.9}], "organizations": [],
+			"activities": [], "themes": [{"name": "travel", "confidence": 0.9}], "tags": [{"name": "travel", "confidence": 0.8}]}`)
+	})
+
+	result, err := client.ExtractEntities(context.Background(), "Landed in Porto.")
+	if err != nil {
+		t.Fatalf("ExtractEntities failed: %v", err)
+	}
+	if entities := result.Entities(); len(entities) != 1 || entities[0].Kind != models.EntityPlace {
+		t.Errorf("Unexpected entities %+v", entities)
+	}
+	if result.Prompt == nil || result.Prompt.String() != "extraction@v1" {
+		t.Errorf("Expected the extraction template to be recorded, got %v", result.Prompt)
+	}
+}
+
+func TestClient_Summarize(t *testing.T) {
+	var request openai.ChatRequest
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		chatReply(w, `{"summary": "Two calm days.", "moods": ["serenity"], "themes": ["rest"], "notable_events": [], "change": "Calmer than before."}`)
+	})
+
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	result, err := client.Summarize(context.Background(), &models.SummaryRequest{
+		Period:    models.DigestMonthly,
+		From:      from,
+		To:        from.AddDate(0, 1, 0),
+		Summaries: []string{"A calm first half.", "A calm second half."},
+		Previous:  "A hectic month.",
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Change != "Calmer than before." || result.Prompt == nil || result.Prompt.String() != "summary@v1" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if last := request.Messages[len(request.Messages)-1]; !strings.Contains(last.Content, "Part 2:\nA calm second half.") || !strings.Contains(last.Content, "A hectic month.") {
+		t.Errorf("Expected the part summaries and previous summary in the prompt, got %q", last.Content)
+	}
+}
+
+func TestClient_GenerationOptions(t *testing.T) {
+	var request openai.ChatRequest
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		chatReply(w, `{"content": "A walk.", "metadata": {"themes": ["nature"]}}`)
+	})
+
+	temperature, topP, numCtx, numPredict := 0.7, 0.9, 8192, 300
+	ctx := options.WithOptions(context.Background(), models.GenerationOptions{
+		Temperature: &temperature,
+		TopP:        &topP,
+		NumCtx:      &numCtx,
+		NumPredict:  &numPredict,
+		Stop:        []string{"###"},
+	})
+
+	result, err := client.GenerateJournal(ctx, &models.PromptRequest{Prompt: "Write about a walk"})
+	if err != nil {
+		t.Fatalf("GenerateJournal failed: %v", err)
+	}
+
+	if *request.Temperature != 0.7 || *request.TopP != 0.9 || *request.MaxTokens != 300 ||
+		request.Seed != nil || len(request.Stop) != 1 {
+		t.Errorf("Unexpected sampling parameters %+v", request)
+	}
+	// The chat API has no context window setting, so num_ctx is not recorded
+	if result.Options == nil || result.Options.NumCtx != nil || *result.Options.NumPredict != 300 {
+		t.Errorf("Expected the options sent to be recorded, got %+v", result.Options)
+	}
+}
+
+func TestClient_InvalidResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "not json",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				chatReply(w, "I cannot help with that.")
+			},
+		},
+		{
+			name: "score out of range",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				chatReply(w, `{"score": 3, "label": "positive", "confidence": 0.9}`)
+			},
+		},
+		{
+			name: "no choices",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id": "x", "choices": []}`)
+			},
+		},
+		{
+			name: "empty content",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				chatReply(w, "")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				tt.handler(w, r)
+			})
+
+			_, err := client.AnalyzeSentiment(context.Background(), "content")
+			if !errors.Is(err, models.ErrInvalidAIResponse) {
+				t.Errorf("Expected ErrInvalidAIResponse, got %v", err)
+			}
+			if calls.Load() != 1 {
+				t.Errorf("Expected invalid responses not to be retried, got %d calls", calls.Load())
+			}
+		})
+	}
+}
+
+func TestClient_RateLimited(t *testing.T) {
+	t.Run("retries then succeeds", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.Header().Set("X-Ratelimit-Remaining-Requests", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("X-Ratelimit-Limit-Requests", "100")
+			w.Header().Set("X-Ratelimit-Remaining-Requests", "99")
+			w.Header().Set("X-Ratelimit-Reset-Tokens", "6m0s")
+			chatReply(w, `{"score": 0, "label": "neutral", "confidence": 0.5}`)
+		})
+
+		if _, err := client.AnalyzeSentiment(context.Background(), "content"); err != nil {
+			t.Fatalf("AnalyzeSentiment failed: %v", err)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 calls, got %d", calls.Load())
+		}
+
+		limit := client.RateLimit()
+		if limit.LimitRequests != 100 || limit.RemainingRequests != 99 || limit.ResetTokens.Minutes() != 6 {
+			t.Errorf("Unexpected rate limit: %+v", limit)
+		}
+	})
+
+	t.Run("exhausts attempts", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "0")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`)
+		})
+
+		_, err := client.AnalyzeSentiment(context.Background(), "content")
+		if !errors.Is(err, openai.ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		if errors.Is(err, models.ErrInvalidAIResponse) {
+			t.Error("Rate limits must not be reported as invalid responses")
+		}
+
+		var apiErr *openai.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected APIError, got %T", err)
+		}
+		if apiErr.Code != "rate_limit_exceeded" || apiErr.Message != "Rate limit reached" {
+			t.Errorf("Unexpected API error: %+v", apiErr)
+		}
+		if apiErr.RetryAfter == nil || *apiErr.RetryAfter != 0 {
+			t.Errorf("Expected Retry-After of 0, got %v", apiErr.RetryAfter)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", calls.Load())
+		}
+	})
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	var calls atomic.Int32
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"message": "Incorrect API key provided", "type": "invalid_request_error"}}`)
+	})
+
+	_, err := client.AnalyzeSentiment(context.Background(), "content")
+	if !errors.Is(err, openai.ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected no retries, got %d calls", calls.Load())
+	}
+}
+
+func TestClient_ResponseFormatFallback(t *testing.T) {
+	var withFormat, withoutFormat atomic.Int32
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatRequest
+		json.NewDecoder(r.Body).Decode(&request)
+
+		if request.ResponseFormat != nil {
+			withFormat.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "response_format is not supported", "type": "invalid_request_error"}}`)
+			return
+		}
+		withoutFormat.Add(1)
+		chatReply(w, `Sure! {"score": -0.4, "label": "negative", "confidence": 0.6}`)
+	})
+
+	for range 2 {
+		result, err := client.AnalyzeSentiment(context.Background(), "content")
+		if err != nil {
+			t.Fatalf("AnalyzeSentiment failed: %v", err)
+		}
+		if result.Label != "negative" {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+	}
+
+	if withFormat.Load() != 1 || withoutFormat.Load() != 2 {
+		t.Errorf("Expected JSON mode to be tried once, got %d with and %d without", withFormat.Load(), withoutFormat.Load())
+	}
+}
+
+func TestClient_HealthCheck(t *testing.T) {
+	healthy := true
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("Expected /v1/models, got %s", r.URL.Path)
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "test-model"}]}`)
+	})
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected healthy server, got %v", err)
+	}
+
+	healthy = false
+	if err := client.HealthCheck(context.Background()); !errors.Is(err, openai.ErrServer) {
+		t.Errorf("Expected ErrServer, got %v", err)
+	}
+}
+
+func TestClient_Capabilities(t *testing.T) {
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	if client.Name() != openai.ProviderName {
+		t.Errorf("Expected name %s, got %s", openai.ProviderName, client.Name())
+	}
+	caps := client.Capabilities()
+	if !caps.Sentiment || !caps.Generation || !caps.Emotions || !caps.Extraction || caps.Model != "test-model" {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+}
+
+func logger() *logging.Logger {
+	logConfig := logging.Config{
+		Level:  logging.DebugLevel,
+		Format: "json",
+	}
+
+	return logging.NewLogger(logConfig)
+}
@@ -0,0 +1,130 @@
+// Package options holds the generation options providers call models with.
+//
+// Each task has its own options: analysis tasks default to deterministic
+// sampling so the same entry gets the same score on every run, while
+// generation keeps the model's defaults. The AI service merges the task
+// options with per-request overrides and passes the result to providers
+// through the context.
+package options
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// DeterministicSeed is the seed analysis tasks use by default
+const DeterministicSeed = 42
+
+// Config maps each task to its generation options
+type Config map[prompt.Task]models.GenerationOptions
+
+// Defaults returns the built-in options: temperature 0 and a fixed seed for
+// sentiment, emotion and extraction analysis and for summaries, model
+// defaults for generation
+func Defaults() Config {
+	temperature, seed := 0.0, DeterministicSeed
+	return Config{
+		prompt.TaskSentiment: {
+			Temperature: &temperature,
+			Seed:        &seed,
+		},
+		prompt.TaskEmotion: {
+			Temperature: &temperature,
+			Seed:        &seed,
+		},
+		prompt.TaskExtraction: {
+			Temperature: &temperature,
+			Seed:        &seed,
+		},
+		prompt.TaskSummary: {
+			Temperature: &temperature,
+			Seed:        &seed,
+		},
+		prompt.TaskGeneration: {},
+	}
+}
+
+// For returns the options for task
+func (c Config) For(task prompt.Task) models.GenerationOptions {
+	return c[task]
+}
+
+// FromEnv returns the default options with overrides from the environment
+//
+// Each task reads AI_<TASK>_TEMPERATURE, AI_<TASK>_TOP_P, AI_<TASK>_SEED,
+// AI_<TASK>_NUM_CTX, AI_<TASK>_NUM_PREDICT and AI_<TASK>_STOP, where <TASK>
+// is SENTIMENT, EMOTION, EXTRACTION, SUMMARY or GENERATION and STOP is a comma-separated list.
+func FromEnv() (Config, error) {
+	config := Defaults()
+
+	for task, options := range config {
+		prefix := "AI_" + strings.ToUpper(string(task)) + "_"
+
+		for name, target := range map[string]**float64{
+			"TEMPERATURE": &options.Temperature,
+			"TOP_P":       &options.TopP,
+		} {
+			raw := os.Getenv(prefix + name)
+			if raw == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: must be a number", prefix+name, raw)
+			}
+			*target = &value
+		}
+
+		for name, target := range map[string]**int{
+			"SEED":        &options.Seed,
+			"NUM_CTX":     &options.NumCtx,
+			"NUM_PREDICT": &options.NumPredict,
+		} {
+			raw := os.Getenv(prefix + name)
+			if raw == "" {
+				continue
+			}
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: must be an integer", prefix+name, raw)
+			}
+			*target = &value
+		}
+
+		if raw := os.Getenv(prefix + "STOP"); raw != "" {
+			options.Stop = strings.Split(raw, ",")
+		}
+
+		if errs := options.Validate(""); errs.HasErrors() {
+			return nil, fmt.Errorf("invalid %s* options: %w", prefix, errs)
+		}
+		config[task] = options
+	}
+
+	return config, nil
+}
+
+// contextKey is a type for context keys to avoid collisions
+type contextKey string
+
+const optionsKey contextKey = "generation_options"
+
+// WithOptions returns a context carrying the options providers should use
+func WithOptions(ctx context.Context, options models.GenerationOptions) context.Context {
+	return context.WithValue(ctx, optionsKey, options)
+}
+
+// Select returns the options providers should use for task: the ones in ctx,
+// or the built-in defaults when the caller chose none
+func Select(ctx context.Context, task prompt.Task) models.GenerationOptions {
+	if options, ok := ctx.Value(optionsKey).(models.GenerationOptions); ok {
+		return options
+	}
+	return Defaults().For(task)
+}
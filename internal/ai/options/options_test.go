@@ -0,0 +1,98 @@
+package options_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func TestDefaults(t *testing.T) {
+	config := options.Defaults()
+
+	sentiment := config.For(prompt.TaskSentiment)
+	if sentiment.Temperature == nil || *sentiment.Temperature != 0 {
+		t.Errorf("Expected temperature 0 for sentiment, got %v", sentiment.Temperature)
+	}
+	if sentiment.Seed == nil || *sentiment.Seed != options.DeterministicSeed {
+		t.Errorf("Expected a fixed seed for sentiment, got %v", sentiment.Seed)
+	}
+
+	if emotion := config.For(prompt.TaskEmotion); emotion.Temperature == nil || *emotion.Temperature != 0 {
+		t.Errorf("Expected temperature 0 for emotion analysis, got %v", emotion.Temperature)
+	}
+	if extraction := config.For(prompt.TaskExtraction); extraction.Seed == nil || *extraction.Seed != options.DeterministicSeed {
+		t.Errorf("Expected a fixed seed for extraction, got %v", extraction.Seed)
+	}
+
+	if !config.For(prompt.TaskGeneration).IsZero() {
+		t.Errorf("Expected model defaults for generation, got %+v", config.For(prompt.TaskGeneration))
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("AI_SENTIMENT_SEED", "7")
+	t.Setenv("AI_SENTIMENT_NUM_CTX", "8192")
+	t.Setenv("AI_GENERATION_TEMPERATURE", "0.8")
+	t.Setenv("AI_GENERATION_TOP_P", "0.95")
+	t.Setenv("AI_GENERATION_NUM_PREDICT", "-1")
+	t.Setenv("AI_GENERATION_STOP", "</journal>,###")
+
+	config, err := options.FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv failed: %v", err)
+	}
+
+	sentiment := config.For(prompt.TaskSentiment)
+	if *sentiment.Temperature != 0 || *sentiment.Seed != 7 || *sentiment.NumCtx != 8192 {
+		t.Errorf("Expected overrides on top of the defaults, got %+v", sentiment)
+	}
+
+	generation := config.For(prompt.TaskGeneration)
+	if *generation.Temperature != 0.8 || *generation.TopP != 0.95 || *generation.NumPredict != -1 {
+		t.Errorf("Unexpected generation options %+v", generation)
+	}
+	if strings.Join(generation.Stop, "|") != "</journal>|###" {
+		t.Errorf("Unexpected stop sequences %v", generation.Stop)
+	}
+}
+
+func TestFromEnv_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"AI_SENTIMENT_TEMPERATURE", "cold", `invalid AI_SENTIMENT_TEMPERATURE "cold": must be a number`},
+		{"AI_GENERATION_SEED", "1.5", `invalid AI_GENERATION_SEED "1.5": must be an integer`},
+		{"AI_SENTIMENT_TEMPERATURE", "3", "invalid AI_SENTIMENT_* options"},
+		{"AI_GENERATION_NUM_CTX", "0", "num_ctx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+
+			_, err := options.FromEnv()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	if got := options.Select(context.Background(), prompt.TaskSentiment); got.Seed == nil {
+		t.Errorf("Expected the built-in sentiment defaults, got %+v", got)
+	}
+
+	temperature := 0.3
+	ctx := options.WithOptions(context.Background(), models.GenerationOptions{Temperature: &temperature})
+	got := options.Select(ctx, prompt.TaskSentiment)
+	if got.Temperature == nil || *got.Temperature != 0.3 || got.Seed != nil {
+		t.Errorf("Expected the options from the context, got %+v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package parse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// maxSegment bounds the excerpt of model output kept in an Error
+const maxSegment = 200
+
+// segmentContext is how many bytes around a syntax error are quoted
+const segmentContext = 40
+
+// Error reports model output that could not be turned into a result
+// It matches models.ErrInvalidAIResponse with errors.Is.
+type Error struct {
+	// Reason describes what went wrong, e.g. "failed to parse sentiment JSON"
+	Reason string
+
+	// Segment is the part of the answer the parser choked on, trimmed to a
+	// short excerpt around the error position when one is known
+	Segment string
+
+	// Offset is the byte offset of the error within the JSON candidate, or -1
+	Offset int64
+
+	// Reasoning is the chain of thought that preceded the answer, if any
+	Reasoning string
+
+	// Err is the underlying decoding error, if any
+	Err error
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %s", models.ErrInvalidAIResponse, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Segment != "" {
+		if e.Offset >= 0 {
+			msg += fmt.Sprintf(" (at offset %d near %q)", e.Offset, e.Segment)
+		} else {
+			msg += fmt.Sprintf(" (in %q)", e.Segment)
+		}
+	}
+	return msg
+}
+
+// ReasoningTrace returns the reasoning that preceded the unusable answer
+func (e *Error) ReasoningTrace() string {
+	return e.Reasoning
+}
+
+// Unwrap exposes both the sentinel and the underlying error
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{models.ErrInvalidAIResponse}
+	}
+	return []error{models.ErrInvalidAIResponse, e.Err}
+}
+
+// decodeError builds an Error for a failed json.Unmarshal of candidate,
+// quoting the bytes around the failure position
+func decodeError(reason, candidate, reasoning string, err error) *Error {
+	offset := int64(-1)
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+
+	segment := excerpt(candidate)
+	if offset >= 0 {
+		segment = around(candidate, int(offset))
+	}
+
+	return &Error{Reason: reason, Segment: segment, Offset: offset, Reasoning: reasoning, Err: err}
+}
+
+// validationError builds an Error for a decoded result that failed validation
+func validationError(reason, candidate, reasoning string) *Error {
+	return &Error{Reason: reason, Segment: excerpt(candidate), Offset: -1, Reasoning: reasoning}
+}
+
+// excerpt shortens s to maxSegment bytes
+func excerpt(s string) string {
+	if len(s) <= maxSegment {
+		return s
+	}
+	return s[:maxSegment] + "..."
+}
+
+// around returns the bytes of s within segmentContext of offset
+func around(s string, offset int) string {
+	start := max(offset-segmentContext, 0)
+	end := min(offset+segmentContext, len(s))
+	if start >= end {
+		return excerpt(s)
+	}
+
+	segment := s[start:end]
+	if start > 0 {
+		segment = "..." + segment
+	}
+	if end < len(s) {
+		segment += "..."
+	}
+	return segment
+}
@@ -0,0 +1,477 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxCandidates bounds how many '{' positions are tried as object starts,
+	// keeping extraction linear in the size of the response
+	maxCandidates = 32
+
+	// maxDepth bounds nesting so hostile input cannot exhaust the stack
+	maxDepth = 64
+)
+
+// Candidates returns every JSON object found in text, repaired into strict JSON
+//
+// Each '{' outside an already extracted object starts a candidate, and so
+// does each nested '{', so a wrapped answer such as {"result": {...}} yields
+// both the wrapper and the inner object. Candidates are ordered by position
+// and never contain duplicates. Repair is lenient about the mistakes models
+// make: single-quoted strings, unquoted keys and bare words, trailing or
+// missing commas, comments, raw newlines and stray quotes inside strings,
+// Python literals, and output truncated mid-object, which is closed off.
+// Valid JSON passes through unchanged apart from whitespace.
+func Candidates(text string) []string {
+	var repaired []string
+	for _, c := range candidates(text) {
+		repaired = append(repaired, c.json)
+	}
+	return repaired
+}
+
+// candidate is a repaired object together with the text it was read from
+type candidate struct {
+	json   string
+	source string
+}
+
+func candidates(text string) []candidate {
+	var (
+		found []candidate
+		seen  = make(map[string]bool)
+	)
+
+	offset := 0
+	for range maxCandidates {
+		start := strings.IndexByte(text[offset:], '{')
+		if start < 0 {
+			break
+		}
+		start += offset
+		offset = start + 1
+
+		repaired, n, ok := repair(text[start:])
+		if !ok || seen[repaired] {
+			continue
+		}
+		seen[repaired] = true
+		found = append(found, candidate{json: repaired, source: text[start : start+n]})
+	}
+
+	return found
+}
+
+// decode unmarshals the first candidate in text that decodes into T and
+// passes validate. When none does, the error of the most promising candidate
+// is returned: one that decoded but failed validation beats one that did not
+// decode at all. Validation errors quote the text as the model wrote it;
+// decode errors quote the repaired JSON, where their offsets point.
+func decode[T any](text, kind, reasoning string, validate func(*T) string) (*T, error) {
+	found := candidates(text)
+	if len(found) == 0 {
+		return nil, validationError(fmt.Sprintf("no JSON object found in %s response", kind), text, reasoning)
+	}
+
+	var decodeErr, invalidErr *Error
+	for _, c := range found {
+		var result T
+		if err := json.Unmarshal([]byte(c.json), &result); err != nil {
+			if decodeErr == nil {
+				decodeErr = decodeError(fmt.Sprintf("failed to parse %s JSON", kind), c.json, reasoning, err)
+			}
+			continue
+		}
+
+		if reason := validate(&result); reason != "" {
+			if invalidErr == nil {
+				invalidErr = validationError(reason, c.source, reasoning)
+			}
+			continue
+		}
+
+		return &result, nil
+	}
+
+	if invalidErr != nil {
+		return nil, invalidErr
+	}
+	return nil, decodeErr
+}
+
+// repair reads one JSON value starting at src[0] and returns it as strict JSON
+// along with the number of bytes consumed. ok is false when the value cannot
+// be salvaged at all.
+func repair(src string) (repaired string, n int, ok bool) {
+	r := &repairer{src: src}
+	if !r.value(0) {
+		return "", 0, false
+	}
+	return string(r.out), r.pos, true
+}
+
+// repairer is a tolerant recursive-descent JSON reader that writes strict JSON
+type repairer struct {
+	src string
+	pos int
+	out []byte
+}
+
+func (r *repairer) eof() bool {
+	return r.pos >= len(r.src)
+}
+
+func (r *repairer) peek() byte {
+	return r.src[r.pos]
+}
+
+// skipSpace skips whitespace and // or /* */ comments
+func (r *repairer) skipSpace() {
+	for !r.eof() {
+		switch c := r.peek(); {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			r.pos++
+		case strings.HasPrefix(r.src[r.pos:], "//"):
+			end := strings.IndexByte(r.src[r.pos:], '\n')
+			if end < 0 {
+				r.pos = len(r.src)
+			} else {
+				r.pos += end + 1
+			}
+		case strings.HasPrefix(r.src[r.pos:], "/*"):
+			end := strings.Index(r.src[r.pos+2:], "*/")
+			if end < 0 {
+				r.pos = len(r.src)
+			} else {
+				r.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// value reads any JSON value; it reports false when input ended before one began
+func (r *repairer) value(depth int) bool {
+	if depth > maxDepth {
+		return false
+	}
+
+	r.skipSpace()
+	if r.eof() {
+		return false
+	}
+
+	switch c := r.peek(); c {
+	case '{':
+		return r.object(depth + 1)
+	case '[':
+		return r.array(depth + 1)
+	case '"', '\'':
+		r.str(c)
+		return true
+	default:
+		return r.bare()
+	}
+}
+
+// object reads an object, dropping members that were cut off
+func (r *repairer) object(depth int) bool {
+	r.pos++ // '{'
+	r.out = append(r.out, '{')
+	members := 0
+
+	for {
+		r.skipSpace()
+		if r.eof() {
+			break
+		}
+
+		switch r.peek() {
+		case '}', ']':
+			r.pos++
+			r.out = append(r.out, '}')
+			return true
+		case ',':
+			r.pos++
+			continue
+		}
+
+		mark := len(r.out)
+		if members > 0 {
+			r.out = append(r.out, ',')
+		}
+
+		if !r.key() {
+			r.out = r.out[:mark]
+			continue
+		}
+
+		r.skipSpace()
+		if !r.eof() && (r.peek() == ':' || r.peek() == '=') {
+			r.pos++
+		} else if r.eof() || r.peek() == ',' || r.peek() == '}' {
+			// A key without a value is dropped
+			r.out = r.out[:mark]
+			continue
+		}
+		r.out = append(r.out, ':')
+
+		if !r.value(depth) {
+			r.out = r.out[:mark]
+			if depth > maxDepth {
+				return false
+			}
+			continue
+		}
+		members++
+	}
+
+	// Truncated: close the object
+	r.out = append(r.out, '}')
+	return true
+}
+
+// array reads an array, dropping elements that were cut off
+func (r *repairer) array(depth int) bool {
+	r.pos++ // '['
+	r.out = append(r.out, '[')
+	elements := 0
+
+	for {
+		r.skipSpace()
+		if r.eof() {
+			break
+		}
+
+		switch r.peek() {
+		case ']', '}':
+			r.pos++
+			r.out = append(r.out, ']')
+			return true
+		case ',':
+			r.pos++
+			continue
+		}
+
+		mark := len(r.out)
+		if elements > 0 {
+			r.out = append(r.out, ',')
+		}
+
+		start := r.pos
+		if !r.value(depth) {
+			r.out = r.out[:mark]
+			if depth > maxDepth {
+				return false
+			}
+			if r.pos == start {
+				break
+			}
+			continue
+		}
+		elements++
+	}
+
+	r.out = append(r.out, ']')
+	return true
+}
+
+// key reads a quoted or bare object key and writes it quoted
+func (r *repairer) key() bool {
+	if c := r.peek(); c == '"' || c == '\'' {
+		r.str(c)
+		return true
+	}
+
+	start := r.pos
+	for !r.eof() {
+		c := r.peek()
+		if c == ':' || c == '=' || c == ',' || c == '{' || c == '}' || c == '[' || c == ']' || c == '"' || c == '\'' || isSpace(c) {
+			break
+		}
+		r.pos++
+	}
+
+	if r.pos == start {
+		// Not a key at all; skip the character so the loop makes progress
+		r.pos++
+		return false
+	}
+
+	r.writeString(r.src[start:r.pos])
+	return true
+}
+
+// bare reads an unquoted value up to the next delimiter
+// A leading number or literal ends the value on its own, so a missing comma
+// after it is tolerated; anything else is read to the delimiter as a string.
+func (r *repairer) bare() bool {
+	start := r.pos
+	for !r.eof() && !isSpace(r.peek()) && !isDelimiter(r.peek()) {
+		r.pos++
+	}
+	if literal, ok := jsonLiteral(r.src[start:r.pos]); ok {
+		r.out = append(r.out, literal...)
+		return true
+	}
+
+	for !r.eof() && !isDelimiter(r.peek()) && r.peek() != '\n' && r.peek() != '\r' {
+		r.pos++
+	}
+
+	word := strings.TrimSpace(r.src[start:r.pos])
+	if word == "" {
+		r.out = append(r.out, "null"...)
+		return true
+	}
+	r.writeString(word)
+	return true
+}
+
+// jsonLiteral maps a bare token to its JSON form when it is a number or literal
+// Python's True, False and None are accepted too.
+func jsonLiteral(word string) (string, bool) {
+	switch word {
+	case "true", "false", "null":
+		return word, true
+	case "True", "False", "None":
+		return strings.ToLower(strings.Replace(word, "None", "null", 1)), true
+	}
+	if isJSONNumber(word) {
+		return word, true
+	}
+	return "", false
+}
+
+// str reads a string delimited by quote and writes it double-quoted
+//
+// A quote only ends the string when what follows looks like the end of a
+// value (a delimiter, a colon or the end of input); other quotes are taken
+// as part of the text, which rescues unescaped quotes and apostrophes.
+// A string cut off by the end of input is closed.
+func (r *repairer) str(quote byte) {
+	r.pos++ // opening quote
+	r.out = append(r.out, '"')
+
+	for !r.eof() {
+		c := r.peek()
+
+		switch {
+		case c == '\\':
+			r.pos++
+			if r.eof() {
+				continue
+			}
+			next := r.peek()
+			r.pos++
+			switch next {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				r.out = append(r.out, '\\', next)
+			case 'u':
+				if r.pos+4 <= len(r.src) && isHex(r.src[r.pos:r.pos+4]) {
+					r.out = append(r.out, '\\', 'u')
+					r.out = append(r.out, r.src[r.pos:r.pos+4]...)
+					r.pos += 4
+				} else {
+					r.out = append(r.out, '\\', '\\', 'u')
+				}
+			case '\'':
+				r.out = append(r.out, '\'')
+			default:
+				r.out = append(r.out, '\\', '\\')
+				r.pos-- // keep the character itself
+			}
+
+		case c == quote && r.closesString():
+			r.pos++
+			r.out = append(r.out, '"')
+			return
+
+		case c == '"':
+			r.pos++
+			r.out = append(r.out, '\\', '"')
+
+		case c < 0x20:
+			r.pos++
+			r.out = appendControl(r.out, c)
+
+		default:
+			_, size := utf8.DecodeRuneInString(r.src[r.pos:])
+			r.out = append(r.out, r.src[r.pos:r.pos+size]...)
+			r.pos += size
+		}
+	}
+
+	// Truncated: close the string
+	r.out = append(r.out, '"')
+}
+
+// closesString reports whether the quote at r.pos is followed by something
+// that can come after a string
+func (r *repairer) closesString() bool {
+	for i := r.pos + 1; i < len(r.src); i++ {
+		switch c := r.src[i]; {
+		case isSpace(c):
+			continue
+		case c == ',' || c == '}' || c == ']' || c == ':':
+			return true
+		case c == '/' && i+1 < len(r.src) && (r.src[i+1] == '/' || r.src[i+1] == '*'):
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writeString writes s as a JSON string
+func (r *repairer) writeString(s string) {
+	encoded, _ := json.Marshal(s)
+	r.out = append(r.out, encoded...)
+}
+
+// appendControl escapes a control character inside a string
+func appendControl(out []byte, c byte) []byte {
+	switch c {
+	case '\n':
+		return append(out, '\\', 'n')
+	case '\r':
+		return append(out, '\\', 'r')
+	case '\t':
+		return append(out, '\\', 't')
+	default:
+		return fmt.Appendf(out, `\u%04x`, c)
+	}
+}
+
+func isDelimiter(c byte) bool {
+	return c == ',' || c == '}' || c == ']'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isJSONNumber reports whether s is a number in JSON syntax
+func isJSONNumber(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	var v json.Number
+	return json.Unmarshal([]byte(s), &v) == nil
+}
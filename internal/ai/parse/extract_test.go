@@ -0,0 +1,153 @@
+package parse_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/garnizeh/englog/internal/ai/parse"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// responsesDir holds model outputs collected from real runs; files are named
+// after the parser they feed (sentiment_* or journal_*)
+const responsesDir = "testdata/responses"
+
+func TestCandidates_Repairs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"valid JSON", `{"a": 1, "b": [true, null]}`, `{"a":1,"b":[true,null]}`},
+		{"colons and URLs untouched", `{"content": "At 10:30 read https://x.io/a::b"}`, `{"content":"At 10:30 read https://x.io/a::b"}`},
+		{"bare value with colon", `{time: 10:30, url: http://x.io}`, `{"time":"10:30","url":"http://x.io"}`},
+		{"single quotes", `{'a': 'it\'s "fine"'}`, `{"a":"it's \"fine\""}`},
+		{"trailing commas", `{"a": [1, 2,], "b": 3,}`, `{"a":[1,2],"b":3}`},
+		{"unquoted keys and words", `{score: 0.5, label: positive}`, `{"score":0.5,"label":"positive"}`},
+		{"missing comma", "{\"a\": 1\n\"b\": 2}", `{"a":1,"b":2}`},
+		{"python literals", `{'ok': True, 'err': None}`, `{"ok":true,"err":null}`},
+		{"comments", "{\"a\": 1, // one\n/* two */ \"b\": 2}", `{"a":1,"b":2}`},
+		{"raw newline in string", "{\"a\": \"x\ny\"}", `{"a":"x\ny"}`},
+		{"unescaped quote", `{"a": "say "hi" now"}`, `{"a":"say \"hi\" now"}`},
+		{"truncated string", `{"a": 1, "b": "cut of`, `{"a":1,"b":"cut of"}`},
+		{"truncated key", `{"a": 1, "b`, `{"a":1}`},
+		{"truncated after colon", `{"a": [1, {"b": `, `{"a":[1,{}]}`},
+		{"mismatched bracket", `{"a": [1, 2}`, `{"a":[1,2]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := parse.Candidates(tt.input)
+			if len(candidates) == 0 {
+				t.Fatalf("Expected a candidate for %q", tt.input)
+			}
+			if candidates[0] != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, candidates[0])
+			}
+		})
+	}
+}
+
+func TestCandidates_Nested(t *testing.T) {
+	candidates := parse.Candidates(`Schema: {"score": 0} then {"result": {"score": 1}}`)
+
+	want := []string{`{"score":0}`, `{"result":{"score":1}}`, `{"score":1}`}
+	if strings.Join(candidates, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, candidates)
+	}
+
+	if candidates := parse.Candidates("no JSON here"); len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %v", candidates)
+	}
+}
+
+func TestSentiment_PicksValidCandidate(t *testing.T) {
+	// The echoed schema decodes but fails validation; the answer after it wins
+	result, err := parse.Sentiment(`Format: {"score": 2, "label": "happy"}. Answer: {"score": -0.4, "label": "negative", "confidence": 0.6}`)
+	if err != nil {
+		t.Fatalf("Sentiment failed: %v", err)
+	}
+	if result.Score != -0.4 || result.Label != "negative" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// With no valid candidate the validation failure is reported over decode errors
+	_, err = parse.Sentiment(`{"score": [1]} {"score": 3, "label": "positive", "confidence": 1}`)
+	if err == nil || !strings.Contains(err.Error(), "invalid sentiment score") {
+		t.Errorf("Expected the validation error, got %v", err)
+	}
+
+	_, err = parse.Sentiment("I cannot analyze this entry.")
+	if !errors.Is(err, models.ErrInvalidAIResponse) || !strings.Contains(err.Error(), "no JSON object found") {
+		t.Errorf("Expected no JSON object error, got %v", err)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	for name, response := range loadResponses(t) {
+		t.Run(name, func(t *testing.T) {
+			switch {
+			case strings.HasPrefix(name, "sentiment_"):
+				if _, err := parse.Sentiment(response); err != nil {
+					t.Errorf("Sentiment failed: %v", err)
+				}
+			case strings.HasPrefix(name, "journal_"):
+				result, err := parse.GeneratedJournal(response)
+				if err != nil {
+					t.Fatalf("GeneratedJournal failed: %v", err)
+				}
+				if strings.Contains(result.Content, `"metadata"`) {
+					t.Errorf("Content swallowed the metadata: %q", result.Content)
+				}
+			default:
+				t.Fatalf("Unknown response kind %s", name)
+			}
+		})
+	}
+}
+
+func FuzzCandidates(f *testing.F) {
+	for _, response := range loadResponses(f) {
+		f.Add(response)
+	}
+	f.Add(`{"a": "é\x", 'b': [1, {c: }]`)
+	f.Add(strings.Repeat("[{", 100))
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, candidate := range parse.Candidates(input) {
+			if !json.Valid([]byte(candidate)) {
+				t.Fatalf("Candidate is not valid JSON: %q from %q", candidate, input)
+			}
+		}
+
+		if _, err := parse.Sentiment(input); err != nil && !errors.Is(err, models.ErrInvalidAIResponse) {
+			t.Fatalf("Sentiment returned an unclassified error: %v", err)
+		}
+		if _, err := parse.GeneratedJournal(input); err != nil && !errors.Is(err, models.ErrInvalidAIResponse) {
+			t.Fatalf("GeneratedJournal returned an unclassified error: %v", err)
+		}
+	})
+}
+
+func loadResponses(tb testing.TB) map[string]string {
+	tb.Helper()
+
+	entries, err := os.ReadDir(responsesDir)
+	if err != nil {
+		tb.Fatalf("Failed to read %s: %v", responsesDir, err)
+	}
+
+	responses := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(responsesDir, entry.Name()))
+		if err != nil {
+			tb.Fatalf("Failed to read %s: %v", entry.Name(), err)
+		}
+		responses[strings.TrimSuffix(entry.Name(), ".txt")] = string(data)
+	}
+	return responses
+}
@@ -0,0 +1,250 @@
+// Package parse turns raw model output into validated results. Every
+// provider parses through it so they share the same guarantees; errors wrap
+// models.ErrInvalidAIResponse so callers can tell them from transport failures.
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// Sentiment parses and validates a sentiment analysis response
+// Reasoning blocks are split off first and returned in result.Reasoning. The
+// first JSON object in the answer that passes validation wins; see Candidates
+// for the malformed output that is repaired on the way.
+func Sentiment(response string) (*models.SentimentResult, error) {
+	answer, reasoning := SplitReasoning(response)
+
+	result, err := decode(answer, "sentiment", reasoning, validateSentiment)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Reasoning = reasoning
+
+	return result, nil
+}
+
+// GeneratedJournal parses and validates a journal generation response
+// Reasoning blocks are split off first and discarded.
+func GeneratedJournal(response string) (*models.GeneratedJournal, error) {
+	answer, reasoning := SplitReasoning(response)
+
+	return decode(answer, "generation", reasoning, validateGeneratedJournal)
+}
+
+// Emotions parses and validates an emotion analysis response and fills in
+// the dominant emotion
+// Reasoning blocks are split off first and discarded.
+func Emotions(response string) (*models.EmotionResult, error) {
+	answer, reasoning := SplitReasoning(response)
+
+	result, err := decode(answer, "emotion", reasoning, validateEmotions)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DominantEmotion = result.Emotions.Dominant()
+
+	return result, nil
+}
+
+// Extraction parses and validates an entity and topic extraction response
+// Reasoning blocks are split off first and discarded. Names are trimmed, tags
+// lower-cased, and repeated items within a group dropped.
+func Extraction(response string) (*models.ExtractionResult, error) {
+	answer, reasoning := SplitReasoning(response)
+
+	result, err := decode(answer, "extraction", reasoning, validateExtraction)
+	if err != nil {
+		return nil, err
+	}
+
+	result.People = normalizeItems(result.People, false)
+	result.Places = normalizeItems(result.Places, false)
+	result.Organizations = normalizeItems(result.Organizations, false)
+	result.Activities = normalizeItems(result.Activities, false)
+	result.Themes = normalizeItems(result.Themes, false)
+	result.Tags = normalizeItems(result.Tags, true)
+
+	return result, nil
+}
+
+// Summary parses and validates a period summary response
+// Reasoning blocks are split off first and discarded. Text is trimmed, and
+// blank or repeated list items dropped.
+func Summary(response string) (*models.SummaryResult, error) {
+	answer, reasoning := SplitReasoning(response)
+
+	result, err := decode(answer, "summary", reasoning, validateSummary)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Summary = strings.TrimSpace(result.Summary)
+	result.Change = strings.TrimSpace(result.Change)
+	result.Moods = normalizeStrings(result.Moods)
+	result.Themes = normalizeStrings(result.Themes)
+	result.NotableEvents = normalizeStrings(result.NotableEvents)
+
+	return result, nil
+}
+
+// validateSentiment returns why result is unusable, or "" if it is valid
+func validateSentiment(result *models.SentimentResult) string {
+	if result.Score < -1.0 || result.Score > 1.0 {
+		return fmt.Sprintf("invalid sentiment score: %f (must be between -1.0 and 1.0)", result.Score)
+	}
+
+	if result.Confidence < 0.0 || result.Confidence > 1.0 {
+		return fmt.Sprintf("invalid confidence: %f (must be between 0.0 and 1.0)", result.Confidence)
+	}
+
+	validLabels := map[string]bool{"positive": true, "negative": true, "neutral": true}
+	if !validLabels[result.Label] {
+		return fmt.Sprintf("invalid sentiment label: %s (must be positive, negative, or neutral)", result.Label)
+	}
+
+	return ""
+}
+
+// validateGeneratedJournal returns why result is unusable, or "" if it is valid
+func validateGeneratedJournal(result *models.GeneratedJournal) string {
+	if result.Content == "" {
+		return "generated content cannot be empty"
+	}
+
+	if len(result.Metadata.Themes) == 0 {
+		return "generated metadata must include at least one theme"
+	}
+
+	return ""
+}
+
+// validateEmotions returns why result is unusable, or "" if it is valid
+func validateEmotions(result *models.EmotionResult) string {
+	if reason := validateAffect("", result.Emotions, result.Valence, result.Arousal); reason != "" {
+		return reason
+	}
+
+	if len(result.Sentences) == 0 {
+		return "emotion analysis must include at least one sentence"
+	}
+
+	for i, sentence := range result.Sentences {
+		if strings.TrimSpace(sentence.Text) == "" {
+			return fmt.Sprintf("sentence %d: text cannot be empty", i+1)
+		}
+		if reason := validateAffect(fmt.Sprintf("sentence %d: ", i+1), sentence.Emotions, sentence.Valence, sentence.Arousal); reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+// validateAffect checks emotion intensities, valence and arousal, prefixing
+// the reason with where they were found
+func validateAffect(prefix string, emotions models.Emotions, valence, arousal float64) string {
+	for _, name := range models.EmotionNames {
+		if intensity, _ := emotions.Intensity(name); intensity < 0.0 || intensity > 1.0 {
+			return fmt.Sprintf("%sinvalid %s intensity: %f (must be between 0.0 and 1.0)", prefix, name, intensity)
+		}
+	}
+
+	if valence < -1.0 || valence > 1.0 {
+		return fmt.Sprintf("%sinvalid valence: %f (must be between -1.0 and 1.0)", prefix, valence)
+	}
+
+	if arousal < 0.0 || arousal > 1.0 {
+		return fmt.Sprintf("%sinvalid arousal: %f (must be between 0.0 and 1.0)", prefix, arousal)
+	}
+
+	return ""
+}
+
+// validateExtraction returns why result is unusable, or "" if it is valid
+func validateExtraction(result *models.ExtractionResult) string {
+	// An entry may have no clear theme, but a response without the themes
+	// array is not an extraction at all
+	if result.Themes == nil {
+		return "extraction must include a themes array"
+	}
+
+	for _, group := range []struct {
+		name  string
+		items []models.ExtractedItem
+	}{
+		{"people", result.People},
+		{"places", result.Places},
+		{"organizations", result.Organizations},
+		{"activities", result.Activities},
+		{"themes", result.Themes},
+		{"tags", result.Tags},
+	} {
+		for i, item := range group.items {
+			if strings.TrimSpace(item.Name) == "" {
+				return fmt.Sprintf("%s %d: name cannot be empty", group.name, i+1)
+			}
+			if item.Confidence < 0.0 || item.Confidence > 1.0 {
+				return fmt.Sprintf("%s %d: invalid confidence: %f (must be between 0.0 and 1.0)", group.name, i+1, item.Confidence)
+			}
+		}
+	}
+
+	return ""
+}
+
+// validateSummary returns why result is unusable, or "" if it is valid
+func validateSummary(result *models.SummaryResult) string {
+	if strings.TrimSpace(result.Summary) == "" {
+		return "summary cannot be empty"
+	}
+
+	return ""
+}
+
+// normalizeStrings trims items and drops blank ones and those repeating an
+// earlier item in a different case
+func normalizeStrings(items []string) []string {
+	normalized := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		key := strings.ToLower(item)
+		if item == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, item)
+	}
+
+	return normalized
+}
+
+// normalizeItems trims item names, lower-cases them if asked, and keeps the
+// most confident of items whose names differ only in case
+func normalizeItems(items []models.ExtractedItem, lower bool) []models.ExtractedItem {
+	normalized := make([]models.ExtractedItem, 0, len(items))
+	seen := make(map[string]int, len(items))
+
+	for _, item := range items {
+		item.Name = strings.TrimSpace(item.Name)
+		if lower {
+			item.Name = strings.ToLower(item.Name)
+		}
+
+		key := strings.ToLower(item.Name)
+		if i, ok := seen[key]; ok {
+			normalized[i].Confidence = max(normalized[i].Confidence, item.Confidence)
+			continue
+		}
+		seen[key] = len(normalized)
+		normalized = append(normalized, item)
+	}
+
+	return normalized
+}
@@ -0,0 +1,234 @@
+package parse_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/garnizeh/englog/internal/ai/parse"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func TestSplitReasoning(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		answer    string
+		reasoning string
+	}{
+		{
+			name:     "no reasoning",
+			response: `{"score": 0.5}`,
+			answer:   `{"score": 0.5}`,
+		},
+		{
+			name:      "think block",
+			response:  "<think>\nThe user seems happy.\n</think>\n\n{\"score\": 0.5}",
+			answer:    `{"score": 0.5}`,
+			reasoning: "The user seems happy.",
+		},
+		{
+			name:      "upper case and alternative tag",
+			response:  "<THINKING>hmm</THINKING>{\"score\": 0.5}<reflection>checked</reflection>",
+			answer:    `{"score": 0.5}`,
+			reasoning: "hmm\n\nchecked",
+		},
+		{
+			name:      "closing tag only",
+			response:  "Okay, let me weigh this.\n</think>\n{\"score\": 0.5}",
+			answer:    `{"score": 0.5}`,
+			reasoning: "Okay, let me weigh this.",
+		},
+		{
+			name:      "unclosed block",
+			response:  "{\"score\": 0.5}\n<think>I should also consider",
+			answer:    `{"score": 0.5}`,
+			reasoning: "I should also consider",
+		},
+		{
+			name:      "several blocks",
+			response:  "<think>one</think>{\"score\":<think>two</think> 0.5}",
+			answer:    `{"score": 0.5}`,
+			reasoning: "one\n\ntwo",
+		},
+		{
+			name:     "empty block",
+			response: "<think>\n\n</think>\n{\"score\": 0.5}",
+			answer:   `{"score": 0.5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, reasoning := parse.SplitReasoning(tt.response)
+			if answer != tt.answer {
+				t.Errorf("Expected answer %q, got %q", tt.answer, answer)
+			}
+			if reasoning != tt.reasoning {
+				t.Errorf("Expected reasoning %q, got %q", tt.reasoning, reasoning)
+			}
+		})
+	}
+}
+
+func TestSentiment_BracesInReasoning(t *testing.T) {
+	// Braces in the chain of thought used to be swallowed into the JSON candidate
+	response := `<think>
+The format is {"score": x, "label": y}. The entry mentions {a promotion} so it is positive.
+</think>
+{"score": 0.7, "label": "positive", "confidence": 0.85}`
+
+	result, err := parse.Sentiment(response)
+	if err != nil {
+		t.Fatalf("Sentiment failed: %v", err)
+	}
+	if result.Score != 0.7 || result.Label != "positive" || result.Confidence != 0.85 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if !strings.Contains(result.Reasoning, "{a promotion}") {
+		t.Errorf("Expected reasoning to be captured, got %q", result.Reasoning)
+	}
+}
+
+func TestSentiment_ReportsOffendingSegment(t *testing.T) {
+	t.Run("decode error", func(t *testing.T) {
+		_, err := parse.Sentiment(`<think>easy</think>{"score": [0.5], "label": "positive", "confidence": 0.9}`)
+		if !errors.Is(err, models.ErrInvalidAIResponse) {
+			t.Fatalf("Expected ErrInvalidAIResponse, got %v", err)
+		}
+
+		var parseErr *parse.Error
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected *parse.Error, got %T", err)
+		}
+		if parseErr.Offset < 0 || !strings.Contains(parseErr.Segment, `[0.5]`) {
+			t.Errorf("Expected segment around the error, got offset %d segment %q", parseErr.Offset, parseErr.Segment)
+		}
+		if parseErr.Reasoning != "easy" || parseErr.ReasoningTrace() != "easy" {
+			t.Errorf("Expected reasoning on the error, got %q", parseErr.Reasoning)
+		}
+		if !strings.Contains(err.Error(), "at offset") {
+			t.Errorf("Expected segment in message, got %q", err.Error())
+		}
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		_, err := parse.Sentiment(`{"score": 4, "label": "positive", "confidence": 0.9}`)
+
+		var parseErr *parse.Error
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected *parse.Error, got %T", err)
+		}
+		if !strings.Contains(err.Error(), "invalid sentiment score") || !strings.Contains(parseErr.Segment, `"score": 4`) {
+			t.Errorf("Expected reason and segment, got %q", err.Error())
+		}
+	})
+
+	t.Run("reasoning only", func(t *testing.T) {
+		_, err := parse.Sentiment(`<think>I think the answer is {"score": 0.5}`)
+		if !errors.Is(err, models.ErrInvalidAIResponse) {
+			t.Errorf("Expected JSON inside unfinished reasoning to be rejected, got %v", err)
+		}
+	})
+}
+
+func TestGeneratedJournal_StripsReasoning(t *testing.T) {
+	result, err := parse.GeneratedJournal(`<think>Write about {the park}.</think>
+{"content": "I walked in the park.", "metadata": {"themes": ["nature"]}}`)
+	if err != nil {
+		t.Fatalf("GeneratedJournal failed: %v", err)
+	}
+	if result.Content != "I walked in the park." {
+		t.Errorf("Unexpected content %q", result.Content)
+	}
+}
+
+func TestEmotions(t *testing.T) {
+	result, err := parse.Emotions(`<think>Mostly relief.</think>
+{"emotions": {"joy": 0.6, "sadness": 0.1, "anger": 0, "fear": 0.3, "surprise": 0, "disgust": 0, "trust": 0.2, "anticipation": 0.4},
+ "valence": 0.4, "arousal": 0.5,
+ "sentences": [{"text": "The results came back clear.", "emotions": {"joy": 0.6, "fear": 0.3}, "valence": 0.4, "arousal": 0.5}]}`)
+	if err != nil {
+		t.Fatalf("Emotions failed: %v", err)
+	}
+	if result.DominantEmotion != models.EmotionJoy || len(result.Sentences) != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"no sentences", `{"emotions": {"joy": 0.5}, "valence": 0.3, "arousal": 0.5, "sentences": []}`, "at least one sentence"},
+		{"intensity out of range", `{"emotions": {"fear": 1.5}, "valence": 0, "arousal": 0.5, "sentences": [{"text": "Hi.", "emotions": {}, "valence": 0, "arousal": 0}]}`, "invalid fear intensity"},
+		{"valence out of range", `{"emotions": {}, "valence": -2, "arousal": 0.5, "sentences": [{"text": "Hi.", "emotions": {}, "valence": 0, "arousal": 0}]}`, "invalid valence"},
+		{"sentence arousal out of range", `{"emotions": {}, "valence": 0, "arousal": 0.5, "sentences": [{"text": "Hi.", "emotions": {}, "valence": 0, "arousal": 3}]}`, "sentence 1: invalid arousal"},
+		{"empty sentence", `{"emotions": {}, "valence": 0, "arousal": 0.5, "sentences": [{"text": " ", "emotions": {}, "valence": 0, "arousal": 0}]}`, "sentence 1: text cannot be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse.Emotions(tt.response)
+			if !errors.Is(err, models.ErrInvalidAIResponse) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an invalid response error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExtraction(t *testing.T) {
+	result, err := parse.Extraction(`{"people": [{"name": " Alice ", "confidence": 0.9}, {"name": "alice", "confidence": 0.95}],
+ "places": [{"name": "Lisbon", "confidence": 0.8}], "organizations": [], "activities": [{"name": "surfing", "confidence": 0.7}],
+ "themes": [{"name": "travel", "confidence": 0.9}], "tags": [{"name": "Travel", "confidence": 0.8}, {"name": "friends", "confidence": 0.6}]}`)
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if len(result.People) != 1 || result.People[0].Name != "Alice" || result.People[0].Confidence != 0.95 {
+		t.Errorf("Expected repeated people to merge, got %+v", result.People)
+	}
+	if len(result.Tags) != 2 || result.Tags[0].Name != "travel" {
+		t.Errorf("Expected lower-case tags, got %+v", result.Tags)
+	}
+	if entities := result.Entities(); len(entities) != 2 || entities[1] != (models.Entity{Kind: models.EntityPlace, Name: "Lisbon"}) {
+		t.Errorf("Unexpected entities %+v", entities)
+	}
+
+	result, err = parse.Extraction(`{"people": [{"name": "Alice", "confidence": 0.9}], "themes": []}`)
+	if err != nil || len(result.People) != 1 || len(result.Themes) != 0 {
+		t.Errorf("Expected an extraction without themes to be accepted, got %+v, %v", result, err)
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"no themes array", `{"people": [{"name": "Alice", "confidence": 0.9}]}`, "themes array"},
+		{"item alone", `{"name": "Alice", "confidence": 0.9}`, "themes array"},
+		{"empty name", `{"places": [{"name": "", "confidence": 0.5}], "themes": [{"name": "work", "confidence": 0.5}]}`, "places 1: name cannot be empty"},
+		{"confidence out of range", `{"themes": [{"name": "work", "confidence": 1.5}]}`, "themes 1: invalid confidence"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse.Extraction(tt.response)
+			if !errors.Is(err, models.ErrInvalidAIResponse) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an invalid response error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	result, err := parse.Summary(`<think>Two entries.</think>{"summary": " A busy week. ", "moods": ["joy", "Joy", " "],
+ "themes": ["work"], "notable_events": ["Launch slipped"], "change": ""}`)
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if result.Summary != "A busy week." || len(result.Moods) != 1 || result.Moods[0] != "joy" {
+		t.Errorf("Expected trimmed text and merged moods, got %+v", result)
+	}
+
+	if _, err := parse.Summary(`{"summary": "  ", "moods": ["joy"]}`); !errors.Is(err, models.ErrInvalidAIResponse) || !strings.Contains(err.Error(), "summary cannot be empty") {
+		t.Errorf("Expected an empty summary to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"strings"
+)
+
+// reasoningTags are the tags reasoning models wrap their chain of thought in
+// deepseek-r1 and qwq use <think>; others use the longer variants.
+var reasoningTags = []string{"think", "thinking", "reasoning", "reflection"}
+
+// SplitReasoning separates reasoning blocks from the answer in a model response
+//
+// Every <think>...</think> block (and the other reasoningTags, matched
+// case-insensitively) is removed from the answer and returned as reasoning,
+// blocks joined by blank lines. Two malformed shapes are handled as well: a
+// closing tag without an opening one, which some chat templates produce by
+// putting the opening tag in the prompt, makes everything before it
+// reasoning; an opening tag that is never closed, as in truncated output,
+// makes everything after it reasoning.
+func SplitReasoning(response string) (answer, reasoning string) {
+	var blocks []string
+	answer = response
+
+	for _, tag := range reasoningTags {
+		open, close := "<"+tag+">", "</"+tag+">"
+
+		for {
+			lower := asciiLower(answer)
+			start := strings.Index(lower, open)
+			end := strings.Index(lower, close)
+
+			switch {
+			case start < 0 && end < 0:
+			case start < 0 || (end >= 0 && end < start):
+				// Closing tag only: the opening tag was part of the prompt
+				blocks = append(blocks, answer[:end])
+				answer = answer[end+len(close):]
+				continue
+			case end < 0:
+				// Opening tag only: the response was cut off mid-thought
+				blocks = append(blocks, answer[start+len(open):])
+				answer = answer[:start]
+				continue
+			default:
+				blocks = append(blocks, answer[start+len(open):end])
+				answer = answer[:start] + answer[end+len(close):]
+				continue
+			}
+			break
+		}
+	}
+
+	for i := range blocks {
+		blocks[i] = strings.TrimSpace(blocks[i])
+	}
+	blocks = removeEmpty(blocks)
+
+	return strings.TrimSpace(answer), strings.Join(blocks, "\n\n")
+}
+
+// asciiLower lowercases ASCII letters only, so byte offsets into the result
+// are valid in s; strings.ToLower rewrites invalid UTF-8 and shifts them
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func removeEmpty(values []string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,369 @@
+// Package prompt holds the versioned prompt templates providers render.
+//
+// A template file is a JSON header between two "---" lines followed by Go
+// text/template definitions:
+//
+//	---
+//	{
+//	  "name": "sentiment",
+//	  "version": "v2",
+//	  "task": "sentiment",
+//	  "description": "System prompt with few-shot examples",
+//	  "variables": ["Content"],
+//	  "schema": "SentimentResult",
+//	  "examples": [{"variables": {"Content": "..."}, "output": {...}}]
+//	}
+//	---
+//	{{define "system"}}...{{end}}
+//	{{define "prompt"}}...{{.Content}}...{{end}}
+//
+// The "prompt" block is required and "system" is optional. Both see the
+// declared variables plus .Schema, the JSON schema of the target type.
+// Examples are rendered through the same "prompt" block with their own
+// variables, and their output becomes the expected answer.
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/schema"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// Task is the kind of call a template is written for
+type Task string
+
+const (
+	TaskSentiment  Task = "sentiment"
+	TaskGeneration Task = "generation"
+	TaskEmotion    Task = "emotion"
+	TaskExtraction Task = "extraction"
+	TaskSummary    Task = "summary"
+)
+
+// target is the result type a task's answer decodes into
+type target struct {
+	name   string
+	schema json.RawMessage
+}
+
+var targets = map[Task]target{
+	TaskSentiment:  {name: "SentimentResult", schema: schema.MustFor[models.SentimentResult]()},
+	TaskGeneration: {name: "GeneratedJournal", schema: schema.MustFor[models.GeneratedJournal]()},
+	TaskEmotion:    {name: "EmotionResult", schema: schema.MustFor[models.EmotionResult]()},
+	TaskExtraction: {name: "ExtractionResult", schema: schema.MustFor[models.ExtractionResult]()},
+	TaskSummary:    {name: "SummaryResult", schema: schema.MustFor[models.SummaryResult]()},
+}
+
+// headerDelimiter separates the JSON header from the template body
+const headerDelimiter = "---"
+
+// Template is one version of a prompt
+type Template struct {
+	Name        string
+	Version     string
+	Task        Task
+	Description string
+
+	// Variables are the inputs the template expects, e.g. Content
+	Variables []string
+
+	// Examples are few-shot input and answer pairs
+	Examples []Example
+
+	// SchemaName is the Go type the answer must decode into
+	SchemaName string
+
+	// Schema is the JSON schema of SchemaName
+	Schema json.RawMessage
+
+	// Source is where the template was loaded from
+	Source string
+
+	tmpl *template.Template
+}
+
+// Example is a few-shot example in a template header
+type Example struct {
+	Variables map[string]any  `json:"variables"`
+	Output    json.RawMessage `json:"output"`
+}
+
+// header is the JSON at the top of a template file
+type header struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Task        Task      `json:"task"`
+	Description string    `json:"description"`
+	Variables   []string  `json:"variables"`
+	Schema      string    `json:"schema"`
+	Examples    []Example `json:"examples"`
+}
+
+// Parse reads a template file; source names it in errors and listings
+func Parse(source string, data []byte) (*Template, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	rest, ok := strings.CutPrefix(text, headerDelimiter+"\n")
+	if !ok {
+		return nil, fmt.Errorf("prompt template %s: missing %q header", source, headerDelimiter)
+	}
+	rawHeader, body, ok := strings.Cut(rest, "\n"+headerDelimiter+"\n")
+	if !ok {
+		return nil, fmt.Errorf("prompt template %s: unterminated header", source)
+	}
+
+	var h header
+	decoder := json.NewDecoder(strings.NewReader(rawHeader))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&h); err != nil {
+		return nil, fmt.Errorf("prompt template %s: invalid header: %w", source, err)
+	}
+
+	t, err := newTemplate(source, h, body)
+	if err != nil {
+		return nil, fmt.Errorf("prompt template %s: %w", source, err)
+	}
+	return t, nil
+}
+
+func newTemplate(source string, h header, body string) (*Template, error) {
+	if h.Name == "" || h.Version == "" {
+		return nil, fmt.Errorf("name and version are required")
+	}
+	if strings.Contains(h.Name, "@") || strings.Contains(h.Version, "@") {
+		return nil, fmt.Errorf("name and version cannot contain '@'")
+	}
+
+	tg, ok := targets[h.Task]
+	if !ok {
+		return nil, fmt.Errorf("unknown task %q", h.Task)
+	}
+	if h.Schema == "" {
+		h.Schema = tg.name
+	}
+	if h.Schema != tg.name {
+		return nil, fmt.Errorf("schema %s does not match task %s, which answers with %s", h.Schema, h.Task, tg.name)
+	}
+
+	tmpl, err := template.New(h.Name).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": toJSON,
+	}).Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.Lookup("prompt") == nil {
+		return nil, fmt.Errorf(`missing {{define "prompt"}} block`)
+	}
+
+	t := &Template{
+		Name:        h.Name,
+		Version:     h.Version,
+		Task:        h.Task,
+		Description: h.Description,
+		Variables:   h.Variables,
+		Examples:    h.Examples,
+		SchemaName:  h.Schema,
+		Schema:      tg.schema,
+		Source:      source,
+		tmpl:        tmpl,
+	}
+
+	// Render once with every declared variable so references to undeclared
+	// ones and broken examples fail at load time rather than per request
+	placeholders := make(map[string]any, len(t.Variables))
+	for _, name := range t.Variables {
+		placeholders[name] = ""
+	}
+	if _, err := t.Render(placeholders); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Ref identifies this template version
+func (t *Template) Ref() models.PromptRef {
+	return models.PromptRef{Name: t.Name, Version: t.Version}
+}
+
+// Message is one chat turn
+type Message struct {
+	Role    string // "system", "user" or "assistant"
+	Content string
+}
+
+// RenderedExample is a few-shot example rendered through the prompt block
+type RenderedExample struct {
+	Prompt   string
+	Response string
+}
+
+// Rendered is a template filled in for one call
+type Rendered struct {
+	Ref      models.PromptRef
+	System   string
+	Examples []RenderedExample
+	Prompt   string
+}
+
+// Render fills the template with vars
+// Every declared variable must be present; .Schema is added automatically.
+func (t *Template) Render(vars map[string]any) (*Rendered, error) {
+	for _, name := range t.Variables {
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("render %s: missing variable %s", t.Ref(), name)
+		}
+	}
+
+	rendered := &Rendered{Ref: t.Ref()}
+
+	var err error
+	if t.tmpl.Lookup("system") != nil {
+		if rendered.System, err = t.execute("system", vars); err != nil {
+			return nil, err
+		}
+	}
+	if rendered.Prompt, err = t.execute("prompt", vars); err != nil {
+		return nil, err
+	}
+
+	for i, example := range t.Examples {
+		examplePrompt, err := t.execute("prompt", example.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i+1, err)
+		}
+
+		var response bytes.Buffer
+		if err := json.Compact(&response, example.Output); err != nil {
+			return nil, fmt.Errorf("render %s: example %d output: %w", t.Ref(), i+1, err)
+		}
+
+		rendered.Examples = append(rendered.Examples, RenderedExample{Prompt: examplePrompt, Response: response.String()})
+	}
+
+	return rendered, nil
+}
+
+func (t *Template) execute(block string, vars map[string]any) (string, error) {
+	data := make(map[string]any, len(vars)+1)
+	for k, v := range vars {
+		data[k] = v
+	}
+	data["Schema"] = string(t.Schema)
+
+	var buf bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&buf, block, data); err != nil {
+		return "", fmt.Errorf("render %s: %w", t.Ref(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Messages returns the rendered prompt as chat turns: the system prompt, a
+// user and assistant turn per example, then the prompt itself
+func (r *Rendered) Messages() []Message {
+	var messages []Message
+	if r.System != "" {
+		messages = append(messages, Message{Role: "system", Content: r.System})
+	}
+	for _, example := range r.Examples {
+		messages = append(messages,
+			Message{Role: "user", Content: example.Prompt},
+			Message{Role: "assistant", Content: example.Response},
+		)
+	}
+	return append(messages, Message{Role: "user", Content: r.Prompt})
+}
+
+// Text returns the examples and prompt as a single completion prompt, for
+// APIs without chat turns; the system prompt is left to the caller
+func (r *Rendered) Text() string {
+	if len(r.Examples) == 0 {
+		return r.Prompt
+	}
+
+	var b strings.Builder
+	for i, example := range r.Examples {
+		fmt.Fprintf(&b, "Example %d input:\n%s\n\nExample %d output:\n%s\n\n", i+1, example.Prompt, i+1, example.Response)
+	}
+	b.WriteString(r.Prompt)
+	return b.String()
+}
+
+// SummaryVars returns the variables of a summary template for req
+// Entries and summaries are numbered and separated by blank lines; dates are
+// written as YYYY-MM-DD with To as the last day of the period.
+func SummaryVars(req *models.SummaryRequest) map[string]any {
+	return map[string]any{
+		"Period":    string(req.Period),
+		"From":      req.From.Format(time.DateOnly),
+		"To":        req.To.Add(-time.Nanosecond).Format(time.DateOnly),
+		"Entries":   numbered("Entry", req.Entries),
+		"Summaries": numbered("Part", req.Summaries),
+		"Partial":   req.Partial,
+		"Previous":  req.Previous,
+	}
+}
+
+// numbered joins items as "<label> 1:\n<item>" blocks
+func numbered(label string, items []string) string {
+	blocks := make([]string, len(items))
+	for i, item := range items {
+		blocks[i] = fmt.Sprintf("%s %d:\n%s", label, i+1, strings.TrimSpace(item))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// contextKey is a type for context keys to avoid collisions
+type contextKey string
+
+const (
+	templateKey contextKey = "prompt_template"
+	refKey      contextKey = "prompt_ref"
+)
+
+// WithTemplate returns a context carrying the template providers should render
+func WithTemplate(ctx context.Context, t *Template) context.Context {
+	return context.WithValue(ctx, templateKey, t)
+}
+
+// FromContext returns the template set by WithTemplate, or nil
+func FromContext(ctx context.Context) *Template {
+	t, _ := ctx.Value(templateKey).(*Template)
+	return t
+}
+
+// Select returns the template providers should render for task: the one in
+// ctx, or the built-in default when the caller chose none
+func Select(ctx context.Context, task Task) (*Template, error) {
+	if t := FromContext(ctx); t != nil {
+		if t.Task != task {
+			return nil, fmt.Errorf("%w: %s is a %s template, not %s", ErrUnknownTemplate, t.Ref(), t.Task, task)
+		}
+		return t, nil
+	}
+	return Default().Resolve(task, "")
+}
+
+// WithRef returns a context carrying a template requested by reference, such
+// as "sentiment@v1"; the AI service resolves it before calling providers
+func WithRef(ctx context.Context, ref string) context.Context {
+	return context.WithValue(ctx, refKey, ref)
+}
+
+// RefFromContext returns the reference set by WithRef, or ""
+func RefFromContext(ctx context.Context) string {
+	ref, _ := ctx.Value(refKey).(string)
+	return ref
+}
@@ -0,0 +1,301 @@
+package prompt_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+const customTemplate = `---
+{
+  "name": "sentiment-terse",
+  "version": "v10",
+  "task": "sentiment",
+  "variables": ["Content"],
+  "examples": [{"variables": {"Content": "Great day"}, "output": {"score": 0.8, "label": "positive", "confidence": 0.9}}]
+}
+---
+{{define "system"}}Answer with JSON matching {{.Schema}}{{end}}
+{{define "prompt"}}Entry: {{.Content}}{{end}}
+`
+
+func TestBuiltin(t *testing.T) {
+	r, err := prompt.Builtin()
+	if err != nil {
+		t.Fatalf("Builtin failed: %v", err)
+	}
+
+	defaults := map[string]bool{"emotion@v1": true, "extraction@v1": true, "generation@v2": true, "sentiment@v2": true, "summary@v1": true}
+	var refs []string
+	for _, info := range r.List() {
+		refs = append(refs, info.Ref)
+		if info.Default != defaults[info.Ref] {
+			t.Errorf("Expected only the latest version of each task to be a default, got %+v", info)
+		}
+	}
+	if strings.Join(refs, ",") != "emotion@v1,extraction@v1,generation@v1,generation@v2,sentiment@v1,sentiment@v2,summary@v1" {
+		t.Errorf("Unexpected built-in templates %v", refs)
+	}
+}
+
+func TestBuiltin_V1MatchesOriginalPrompts(t *testing.T) {
+	r, _ := prompt.Builtin()
+
+	sentiment, err := r.Resolve(prompt.TaskSentiment, "sentiment@v1")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	rendered, err := sentiment.Render(map[string]any{"Content": "A quiet day."})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if rendered.System != "" || len(rendered.Examples) != 0 {
+		t.Errorf("Expected a bare prompt, got %+v", rendered)
+	}
+	if !strings.HasPrefix(rendered.Prompt, "Analyze the sentiment of the following journal entry") ||
+		!strings.Contains(rendered.Prompt, "Journal entry to analyze:\nA quiet day.\n\nRemember:") {
+		t.Errorf("Unexpected prompt %q", rendered.Prompt)
+	}
+
+	generation, _ := r.Resolve(prompt.TaskGeneration, "generation@v1")
+	rendered, err = generation.Render(map[string]any{"Prompt": "A walk", "Context": ""})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(rendered.Prompt, "User prompt: A walk\n\nRespond with ONLY valid JSON") {
+		t.Errorf("Expected no context line, got %q", rendered.Prompt)
+	}
+	rendered, _ = generation.Render(map[string]any{"Prompt": "A walk", "Context": "Sunny"})
+	if !strings.Contains(rendered.Prompt, "User prompt: A walk\nContext: Sunny\n") {
+		t.Errorf("Expected context line, got %q", rendered.Prompt)
+	}
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	r, _ := prompt.Builtin()
+	custom, err := prompt.Parse("custom.tmpl", []byte(customTemplate))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	r.Add(custom)
+
+	tests := []struct {
+		name string
+		task prompt.Task
+		ref  string
+		want string
+	}{
+		{"default is latest of task name", prompt.TaskSentiment, "", "sentiment@v2"},
+		{"name selects latest", prompt.TaskGeneration, "generation", "generation@v2"},
+		{"pinned version", prompt.TaskSentiment, "sentiment@v1", "sentiment@v1"},
+		{"custom template", prompt.TaskSentiment, "sentiment-terse", "sentiment-terse@v10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := r.Resolve(tt.task, tt.ref)
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if tmpl.Ref().String() != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, tmpl.Ref())
+			}
+		})
+	}
+
+	for _, ref := range []string{"missing", "sentiment@v9", "generation@v1"} {
+		if _, err := r.Resolve(prompt.TaskSentiment, ref); !errors.Is(err, prompt.ErrUnknownTemplate) {
+			t.Errorf("Expected ErrUnknownTemplate for %s, got %v", ref, err)
+		}
+	}
+
+	if err := r.SetDefault(prompt.TaskSentiment, "sentiment-terse@v10"); err != nil {
+		t.Fatalf("SetDefault failed: %v", err)
+	}
+	if tmpl, _ := r.Resolve(prompt.TaskSentiment, ""); tmpl.Name != "sentiment-terse" {
+		t.Errorf("Expected the configured default, got %s", tmpl.Ref())
+	}
+	if err := r.SetDefault(prompt.TaskGeneration, "sentiment@v1"); !errors.Is(err, prompt.ErrUnknownTemplate) {
+		t.Errorf("Expected a default for the wrong task to be rejected, got %v", err)
+	}
+}
+
+func TestRegistry_VersionOrder(t *testing.T) {
+	r := prompt.NewRegistry()
+	for _, version := range []string{"v10", "v2", "v1.5", "v1"} {
+		tmpl, err := prompt.Parse(version, []byte(strings.Replace(customTemplate, `"v10"`, `"`+version+`"`, 1)))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		r.Add(tmpl)
+	}
+
+	var versions []string
+	for _, info := range r.List() {
+		versions = append(versions, info.Version)
+	}
+	if strings.Join(versions, ",") != "v1,v1.5,v2,v10" {
+		t.Errorf("Unexpected version order %v", versions)
+	}
+	if latest, _ := r.Resolve(prompt.TaskSentiment, "sentiment-terse"); latest.Version != "v10" {
+		t.Errorf("Expected v10 as latest, got %s", latest.Version)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := prompt.Parse("custom.tmpl", []byte(customTemplate))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	rendered, err := tmpl.Render(map[string]any{"Content": "Long week"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(rendered.System, `"enum":["positive","negative","neutral"]`) {
+		t.Errorf("Expected the schema in the system prompt, got %q", rendered.System)
+	}
+
+	messages := rendered.Messages()
+	roles := make([]string, len(messages))
+	for i, m := range messages {
+		roles[i] = m.Role
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,user" {
+		t.Errorf("Unexpected roles %v", roles)
+	}
+	if messages[1].Content != "Entry: Great day" || messages[2].Content != `{"score":0.8,"label":"positive","confidence":0.9}` {
+		t.Errorf("Unexpected example turns %+v", messages[1:3])
+	}
+
+	text := rendered.Text()
+	if !strings.HasPrefix(text, "Example 1 input:\nEntry: Great day") || !strings.HasSuffix(text, "Entry: Long week") {
+		t.Errorf("Unexpected completion prompt %q", text)
+	}
+
+	if _, err := tmpl.Render(map[string]any{}); err == nil || !strings.Contains(err.Error(), "missing variable Content") {
+		t.Errorf("Expected missing variable error, got %v", err)
+	}
+}
+
+func TestSummaryVars(t *testing.T) {
+	tmpl, err := prompt.Default().Resolve(prompt.TaskSummary, "")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	from := time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)
+	rendered, err := tmpl.Render(prompt.SummaryVars(&models.SummaryRequest{
+		Period:   models.DigestWeekly,
+		From:     from,
+		To:       from.AddDate(0, 0, 7),
+		Entries:  []string{"Morning run", " Launch slipped "},
+		Previous: "A quiet week",
+	}))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	expected := "weekly period from 2025-08-04 to 2025-08-10\n\nJournal entries:\nEntry 1:\nMorning run\n\nEntry 2:\nLaunch slipped\n\nSummary of the previous period:\nA quiet week"
+	if rendered.Prompt != expected {
+		t.Errorf("Unexpected prompt %q", rendered.Prompt)
+	}
+
+	rendered, _ = tmpl.Render(prompt.SummaryVars(&models.SummaryRequest{
+		Period:    models.DigestMonthly,
+		From:      from,
+		To:        from.AddDate(0, 1, 0),
+		Summaries: []string{"First half", "Second half"},
+		Partial:   true,
+	}))
+	expected = "monthly period from 2025-08-04 to 2025-09-03 (this is only part of the period)\n\nSummaries of the parts of the period, in order:\nPart 1:\nFirst half\n\nPart 2:\nSecond half"
+	if rendered.Prompt != expected {
+		t.Errorf("Unexpected prompt %q", rendered.Prompt)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := func(header, body string) string {
+		return "---\n" + header + "\n---\n" + body
+	}
+	const header = `{"name": "x", "version": "v1", "task": "sentiment", "variables": ["Content"]}`
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no header", `{{define "prompt"}}hi{{end}}`, "missing"},
+		{"unterminated header", "---\n{}", "unterminated header"},
+		{"unknown field", valid(`{"name": "x", "version": "v1", "task": "sentiment", "model": "y"}`, ""), "unknown field"},
+		{"unknown task", valid(`{"name": "x", "version": "v1", "task": "summary"}`, ""), "unknown task"},
+		{"schema mismatch", valid(`{"name": "x", "version": "v1", "task": "sentiment", "schema": "GeneratedJournal"}`, ""), "does not match task"},
+		{"missing version", valid(`{"name": "x", "task": "sentiment"}`, ""), "name and version are required"},
+		{"missing prompt block", valid(header, `{{define "system"}}hi{{end}}`), "missing {{define \"prompt\"}}"},
+		{"undeclared variable", valid(header, `{{define "prompt"}}{{.Content}} {{.Mood}}{{end}}`), "Mood"},
+		{"bad syntax", valid(header, `{{define "prompt"}}{{.Content{{end}}`), "prompt template bad syntax"},
+		{"bad example", valid(`{"name": "x", "version": "v1", "task": "sentiment", "variables": ["Content"], "examples": [{"variables": {}, "output": {}}]}`, `{{define "prompt"}}{{.Content}}{{end}}`), "example 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := prompt.Parse(tt.name, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "terse.tmpl"), []byte(customTemplate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AI_PROMPTS_DIR", dir)
+	t.Setenv("AI_PROMPT_SENTIMENT", "sentiment-terse")
+	t.Setenv("AI_PROMPT_GENERATION", "generation@v1")
+
+	r, err := prompt.LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+
+	sentiment, _ := r.Resolve(prompt.TaskSentiment, "")
+	generation, _ := r.Resolve(prompt.TaskGeneration, "")
+	if sentiment.Ref().String() != "sentiment-terse@v10" || generation.Ref().String() != "generation@v1" {
+		t.Errorf("Unexpected defaults %s and %s", sentiment.Ref(), generation.Ref())
+	}
+	if sentiment.Source != filepath.Join(dir, "terse.tmpl") {
+		t.Errorf("Unexpected source %s", sentiment.Source)
+	}
+
+	t.Setenv("AI_PROMPT_SENTIMENT", "nope")
+	if _, err := prompt.LoadFromEnv(); !errors.Is(err, prompt.ErrUnknownTemplate) {
+		t.Errorf("Expected ErrUnknownTemplate for an unknown default, got %v", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tmpl, _ := prompt.Parse("custom.tmpl", []byte(customTemplate))
+
+	selected, err := prompt.Select(prompt.WithTemplate(context.Background(), tmpl), prompt.TaskSentiment)
+	if err != nil || selected != tmpl {
+		t.Errorf("Expected the template from the context, got %v, %v", selected, err)
+	}
+
+	if _, err := prompt.Select(prompt.WithTemplate(context.Background(), tmpl), prompt.TaskGeneration); !errors.Is(err, prompt.ErrUnknownTemplate) {
+		t.Errorf("Expected a task mismatch error, got %v", err)
+	}
+
+	selected, err = prompt.Select(context.Background(), prompt.TaskGeneration)
+	if err != nil || selected.Ref().String() != "generation@v2" {
+		t.Errorf("Expected the built-in default, got %v, %v", selected, err)
+	}
+}
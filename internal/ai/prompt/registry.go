@@ -0,0 +1,262 @@
+package prompt
+
+import (
+	"cmp"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownTemplate is returned when a reference matches no template for the task
+var ErrUnknownTemplate = errors.New("unknown prompt template")
+
+//go:embed templates/*.tmpl
+var builtinFS embed.FS
+
+// Registry holds prompt templates by name and version
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string][]*Template // by name, ordered by version
+	defaults  map[Task]string
+}
+
+// NewRegistry creates an empty template registry
+func NewRegistry() *Registry {
+	return &Registry{
+		templates: make(map[string][]*Template),
+		defaults:  make(map[Task]string),
+	}
+}
+
+// Builtin returns a registry with the templates shipped with englog
+func Builtin() (*Registry, error) {
+	r := NewRegistry()
+	if err := r.LoadFS(builtinFS, "templates", "builtin:"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Default returns the shared registry of built-in templates, used when no
+// registry has been configured
+var Default = sync.OnceValue(func() *Registry {
+	r, err := Builtin()
+	if err != nil {
+		panic(err)
+	}
+	return r
+})
+
+// LoadFromEnv returns the built-in templates plus those in AI_PROMPTS_DIR,
+// with per-task defaults from AI_PROMPT_SENTIMENT, AI_PROMPT_GENERATION,
+// AI_PROMPT_EMOTION, AI_PROMPT_EXTRACTION and AI_PROMPT_SUMMARY
+// A file in the directory replaces a built-in template of the same name and
+// version.
+func LoadFromEnv() (*Registry, error) {
+	r, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+
+	if dir := os.Getenv("AI_PROMPTS_DIR"); dir != "" {
+		if err := r.LoadFS(os.DirFS(dir), ".", dir+"/"); err != nil {
+			return nil, err
+		}
+	}
+
+	for env, task := range map[string]Task{
+		"AI_PROMPT_SENTIMENT":  TaskSentiment,
+		"AI_PROMPT_GENERATION": TaskGeneration,
+		"AI_PROMPT_EMOTION":    TaskEmotion,
+		"AI_PROMPT_EXTRACTION": TaskExtraction,
+		"AI_PROMPT_SUMMARY":    TaskSummary,
+	} {
+		if ref := os.Getenv(env); ref != "" {
+			if err := r.SetDefault(task, ref); err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", env, ref, err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// LoadFS adds every *.tmpl file in dir of fsys
+// Sources are recorded as prefix followed by the file name.
+func (r *Registry) LoadFS(fsys fs.FS, dir, prefix string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt template: %w", err)
+		}
+
+		t, err := Parse(prefix+path.Base(file), data)
+		if err != nil {
+			return err
+		}
+		r.Add(t)
+	}
+
+	return nil
+}
+
+// Add registers t, replacing a template with the same name and version
+func (r *Registry) Add(t *Template) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := slices.DeleteFunc(r.templates[t.Name], func(existing *Template) bool {
+		return existing.Version == t.Version
+	})
+	versions = append(versions, t)
+	slices.SortFunc(versions, func(a, b *Template) int {
+		return compareVersions(a.Version, b.Version)
+	})
+	r.templates[t.Name] = versions
+}
+
+// SetDefault makes ref the template used for task when a request names none
+func (r *Registry) SetDefault(task Task, ref string) error {
+	if _, err := r.Resolve(task, ref); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaults[task] = ref
+	return nil
+}
+
+// Resolve finds the template for task named by ref
+//
+// ref is "name@version", or just "name" for its latest version. An empty ref
+// selects the task's default: the one set with SetDefault, otherwise the
+// latest version of the template named after the task.
+func (r *Registry) Resolve(task Task, ref string) (*Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if ref == "" {
+		ref = r.defaults[task]
+	}
+	if ref == "" {
+		ref = string(task)
+	}
+
+	name, version, pinned := strings.Cut(ref, "@")
+	versions := r.templates[name]
+
+	var found *Template
+	switch {
+	case len(versions) == 0:
+	case !pinned:
+		found = versions[len(versions)-1]
+	default:
+		for _, t := range versions {
+			if t.Version == version {
+				found = t
+			}
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, ref)
+	}
+	if found.Task != task {
+		return nil, fmt.Errorf("%w: %s is a %s template, not %s", ErrUnknownTemplate, ref, found.Task, task)
+	}
+
+	return found, nil
+}
+
+// Info describes a template for listings
+type Info struct {
+	Name        string   `json:"name" example:"sentiment"`
+	Version     string   `json:"version" example:"v2"`
+	Ref         string   `json:"ref" example:"sentiment@v2"`
+	Task        Task     `json:"task" example:"sentiment"`
+	Description string   `json:"description,omitempty"`
+	Variables   []string `json:"variables"`
+	Schema      string   `json:"schema" example:"SentimentResult"`
+	System      bool     `json:"has_system_prompt"`
+	Examples    int      `json:"examples"`
+	Default     bool     `json:"default"`
+	Source      string   `json:"source" example:"builtin:sentiment-v2.tmpl"`
+}
+
+// List describes every template, ordered by task, name and version
+func (r *Registry) List() []Info {
+	defaults := make(map[*Template]bool)
+	for task := range targets {
+		if t, err := r.Resolve(task, ""); err == nil {
+			defaults[t] = true
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var infos []Info
+	for _, versions := range r.templates {
+		for _, t := range versions {
+			infos = append(infos, Info{
+				Name:        t.Name,
+				Version:     t.Version,
+				Ref:         t.Ref().String(),
+				Task:        t.Task,
+				Description: t.Description,
+				Variables:   t.Variables,
+				Schema:      t.SchemaName,
+				System:      t.tmpl.Lookup("system") != nil,
+				Examples:    len(t.Examples),
+				Default:     defaults[t],
+				Source:      t.Source,
+			})
+		}
+	}
+
+	slices.SortFunc(infos, func(a, b Info) int {
+		return cmp.Or(
+			cmp.Compare(a.Task, b.Task),
+			cmp.Compare(a.Name, b.Name),
+			compareVersions(a.Version, b.Version),
+		)
+	})
+	return infos
+}
+
+// compareVersions orders versions like v1 < v2 < v10 and 1.2 < 1.10,
+// comparing dot-separated parts numerically where both are numbers
+func compareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := range min(len(aParts), len(bParts)) {
+		an, aErr := strconv.Atoi(aParts[i])
+		bn, bErr := strconv.Atoi(bParts[i])
+		if aErr == nil && bErr == nil {
+			if c := cmp.Compare(an, bn); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := cmp.Compare(aParts[i], bParts[i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Or(cmp.Compare(len(aParts), len(bParts)), cmp.Compare(a, b))
+}
@@ -0,0 +1,308 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/garnizeh/englog/internal/ai/lexicon"
+	"github.com/garnizeh/englog/internal/ai/ollama"
+	"github.com/garnizeh/englog/internal/ai/openai"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// DefaultProvider is used when AI_PROVIDER is not set
+const DefaultProvider = ollama.ProviderName
+
+// ErrUnknownProvider is returned when no factory is registered under a name
+var ErrUnknownProvider = errors.New("unknown AI provider")
+
+// Provider is implemented by every AI backend the service can run on
+type Provider interface {
+	Name() string
+	Capabilities() models.AICapabilities
+	AnalyzeSentiment(ctx context.Context, content string) (*models.SentimentResult, error)
+	GenerateJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error)
+	HealthCheck(ctx context.Context) error
+}
+
+// StreamingProvider is implemented by providers that can stream generated
+// text as the model produces it
+type StreamingProvider interface {
+	Provider
+	GenerateJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error)
+}
+
+// ErrEmotionsUnsupported is returned when no provider can analyze emotions
+var ErrEmotionsUnsupported = fmt.Errorf("emotion analysis: %w", errors.ErrUnsupported)
+
+// EmotionProvider is implemented by providers that can analyze emotions
+// beyond a single sentiment score
+type EmotionProvider interface {
+	Provider
+	AnalyzeEmotions(ctx context.Context, content string) (*models.EmotionResult, error)
+}
+
+// ErrExtractionUnsupported is returned when no provider can extract entities and topics
+var ErrExtractionUnsupported = fmt.Errorf("extraction: %w", errors.ErrUnsupported)
+
+// ExtractionProvider is implemented by providers that can extract entities
+// and topics from journals
+type ExtractionProvider interface {
+	Provider
+	ExtractEntities(ctx context.Context, content string) (*models.ExtractionResult, error)
+}
+
+// ErrSummariesUnsupported is returned when no provider can summarize periods
+var ErrSummariesUnsupported = fmt.Errorf("summaries: %w", errors.ErrUnsupported)
+
+// SummaryProvider is implemented by providers that can summarize a period of
+// journals, or part of one
+type SummaryProvider interface {
+	Provider
+	Summarize(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error)
+}
+
+// ErrEmbeddingsUnsupported is returned when no provider can embed text
+var ErrEmbeddingsUnsupported = fmt.Errorf("embeddings: %w", errors.ErrUnsupported)
+
+// EmbeddingProvider is implemented by providers that can embed text as
+// vectors for semantic search
+type EmbeddingProvider interface {
+	Provider
+	Embed(ctx context.Context, texts []string) (*models.Embeddings, error)
+}
+
+// Ensure the built-in clients implement Provider
+var (
+	_ Provider           = (*ollama.Client)(nil)
+	_ Provider           = (*openai.Client)(nil)
+	_ Provider           = (*lexicon.Analyzer)(nil)
+	_ StreamingProvider  = (*ollama.Client)(nil)
+	_ EmotionProvider    = (*ollama.Client)(nil)
+	_ EmotionProvider    = (*openai.Client)(nil)
+	_ EmotionProvider    = (*lexicon.Analyzer)(nil)
+	_ ExtractionProvider = (*ollama.Client)(nil)
+	_ ExtractionProvider = (*openai.Client)(nil)
+	_ SummaryProvider    = (*ollama.Client)(nil)
+	_ SummaryProvider    = (*openai.Client)(nil)
+	_ EmbeddingProvider  = (*ollama.Client)(nil)
+)
+
+// ProviderConfig holds the settings passed to a provider factory
+type ProviderConfig struct {
+	Model          string // Model to run, e.g. "gemma3:1b"
+	BaseURL        string // Server URL of the backend
+	APIKey         string // Credential for hosted backends; unused by local ones
+	EmbeddingModel string // Model that embeds text, for providers that can
+}
+
+// ProviderFactory creates a provider from its configuration
+type ProviderFactory func(ctx context.Context, config ProviderConfig, logger *logging.Logger) (Provider, error)
+
+// registration is a provider factory with the configuration it falls back to
+type registration struct {
+	factory  ProviderFactory
+	defaults ProviderConfig
+}
+
+// Registry maps provider names to factories
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]registration
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]registration)}
+}
+
+// Register adds a provider factory under a case-insensitive name
+// defaults fill in settings missing from the environment
+func (r *Registry) Register(name string, factory ProviderFactory, defaults ProviderConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[strings.ToLower(name)] = registration{factory: factory, defaults: defaults}
+}
+
+// Names returns the registered provider names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.namesLocked()
+}
+
+// New creates the named provider
+func (r *Registry) New(ctx context.Context, name string, config ProviderConfig, logger *logging.Logger) (Provider, error) {
+	reg, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := reg.factory(ctx, config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", name, err)
+	}
+
+	return provider, nil
+}
+
+// ConfigFromEnv reads <NAME>_MODEL_NAME, <NAME>_SERVER_URL, <NAME>_API_KEY and
+// <NAME>_EMBEDDING_MODEL for the named provider, falling back to its
+// registered defaults
+func (r *Registry) ConfigFromEnv(name string) (ProviderConfig, error) {
+	reg, err := r.lookup(name)
+	if err != nil {
+		return ProviderConfig{}, err
+	}
+
+	prefix := strings.ToUpper(name) + "_"
+	config := reg.defaults
+	if model := os.Getenv(prefix + "MODEL_NAME"); model != "" {
+		config.Model = model
+	}
+	if baseURL := os.Getenv(prefix + "SERVER_URL"); baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	if apiKey := os.Getenv(prefix + "API_KEY"); apiKey != "" {
+		config.APIKey = apiKey
+	}
+	if embeddingModel := os.Getenv(prefix + "EMBEDDING_MODEL"); embeddingModel != "" {
+		config.EmbeddingModel = embeddingModel
+	}
+
+	return config, nil
+}
+
+// lookup finds the registration for a provider name
+func (r *Registry) lookup(name string) (registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reg, exists := r.providers[strings.ToLower(name)]
+	if !exists {
+		return registration{}, fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, name, strings.Join(r.namesLocked(), ", "))
+	}
+
+	return reg, nil
+}
+
+// namesLocked lists provider names; the caller holds r.mu
+func (r *Registry) namesLocked() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// DefaultRegistry holds the built-in providers
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.Register(ollama.ProviderName, newOllamaProvider, ProviderConfig{
+		Model:          "deepseek-r1:1.5b",
+		BaseURL:        "http://localhost:11434",
+		EmbeddingModel: "nomic-embed-text",
+	})
+	DefaultRegistry.Register(openai.ProviderName, newOpenAIProvider, ProviderConfig{
+		Model:   "gpt-4o-mini",
+		BaseURL: "https://api.openai.com/v1",
+	})
+	DefaultRegistry.Register(lexicon.ProviderName, newLexiconProvider, ProviderConfig{
+		Model: lexicon.Model,
+	})
+}
+
+// newOllamaProvider creates an Ollama-backed provider
+func newOllamaProvider(ctx context.Context, config ProviderConfig, logger *logging.Logger) (Provider, error) {
+	client, err := ollama.New(ctx, config.Model, config.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	client.SetEmbeddingModel(config.EmbeddingModel)
+	return client, nil
+}
+
+// newOpenAIProvider creates a provider for any OpenAI-compatible server
+func newOpenAIProvider(ctx context.Context, config ProviderConfig, logger *logging.Logger) (Provider, error) {
+	client, err := openai.New(config.Model, config.BaseURL, config.APIKey, logger)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// newLexiconProvider creates the offline lexicon analyzer; it takes no settings
+func newLexiconProvider(ctx context.Context, config ProviderConfig, logger *logging.Logger) (Provider, error) {
+	analyzer, err := lexicon.New()
+	if err != nil {
+		return nil, err
+	}
+	return analyzer, nil
+}
+
+// NewProviderFromEnv creates the providers listed in AI_PROVIDERS (or the
+// single AI_PROVIDER) from the default registry and chains them in order,
+// each behind a circuit breaker configured by BreakerConfigFromEnv and with
+// attempts capped by AttemptTimeoutFromEnv
+func NewProviderFromEnv(ctx context.Context, logger *logging.Logger) (Provider, error) {
+	names := ProviderNamesFromEnv()
+
+	breakerConfig, err := BreakerConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	attemptTimeout, err := AttemptTimeoutFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]Provider, 0, len(names))
+	for _, name := range names {
+		config, err := DefaultRegistry.ConfigFromEnv(name)
+		if err != nil {
+			return nil, err
+		}
+
+		provider, err := DefaultRegistry.New(ctx, name, config, logger)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	chain, err := NewChain(providers, breakerConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	chain.SetAttemptTimeout(attemptTimeout)
+	return chain, nil
+}
+
+// ProviderNamesFromEnv returns the provider names in fallback order
+// AI_PROVIDERS is a comma-separated list; it takes precedence over AI_PROVIDER.
+func ProviderNamesFromEnv() []string {
+	var names []string
+	for name := range strings.SplitSeq(os.Getenv("AI_PROVIDERS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		return names
+	}
+
+	if name := os.Getenv("AI_PROVIDER"); name != "" {
+		return []string{name}
+	}
+	return []string{DefaultProvider}
+}
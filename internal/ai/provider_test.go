@@ -0,0 +1,242 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/vector"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := ai.NewRegistry()
+	registry.Register("Mock", func(ctx context.Context, config ai.ProviderConfig, logger *logging.Logger) (ai.Provider, error) {
+		if config.Model == "" {
+			return nil, errors.New("model is required")
+		}
+		return ai.NewMockAIProvider(), nil
+	}, ai.ProviderConfig{Model: "mock-small", BaseURL: "http://mock.local"})
+
+	t.Run("CreatesRegisteredProvider", func(t *testing.T) {
+		provider, err := registry.New(context.Background(), "mock", ai.ProviderConfig{Model: "m"}, logger())
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if provider.Name() != ai.MockProviderName {
+			t.Errorf("Expected mock provider, got %s", provider.Name())
+		}
+	})
+
+	t.Run("WrapsFactoryErrors", func(t *testing.T) {
+		if _, err := registry.New(context.Background(), "mock", ai.ProviderConfig{}, logger()); err == nil {
+			t.Error("Expected factory error")
+		}
+	})
+
+	t.Run("RejectsUnknownProvider", func(t *testing.T) {
+		_, err := registry.New(context.Background(), "vendor-x", ai.ProviderConfig{}, logger())
+		if !errors.Is(err, ai.ErrUnknownProvider) {
+			t.Errorf("Expected ErrUnknownProvider, got %v", err)
+		}
+	})
+
+	t.Run("ConfigFromEnv", func(t *testing.T) {
+		t.Setenv("MOCK_MODEL_NAME", "mock-large")
+		t.Setenv("MOCK_API_KEY", "secret")
+
+		config, err := registry.ConfigFromEnv("mock")
+		if err != nil {
+			t.Fatalf("ConfigFromEnv failed: %v", err)
+		}
+		want := ai.ProviderConfig{Model: "mock-large", BaseURL: "http://mock.local", APIKey: "secret"}
+		if config != want {
+			t.Errorf("Expected %+v, got %+v", want, config)
+		}
+	})
+
+	if names := registry.Names(); len(names) != 1 || names[0] != "mock" {
+		t.Errorf("Unexpected provider names: %v", names)
+	}
+}
+
+func TestDefaultRegistryIncludesOllama(t *testing.T) {
+	config, err := ai.DefaultRegistry.ConfigFromEnv("ollama")
+	if err != nil {
+		t.Fatalf("ConfigFromEnv failed: %v", err)
+	}
+	if config.Model == "" || config.BaseURL == "" {
+		t.Errorf("Expected Ollama defaults, got %+v", config)
+	}
+
+	t.Setenv("AI_PROVIDERS", "lexicon")
+	provider, err := ai.NewProviderFromEnv(context.Background(), logger())
+	if err != nil {
+		t.Fatalf("NewProviderFromEnv failed: %v", err)
+	}
+	if provider.Name() != "lexicon" || !provider.Capabilities().Sentiment || provider.Capabilities().Generation {
+		t.Errorf("Expected the lexicon analyzer, got %s %+v", provider.Name(), provider.Capabilities())
+	}
+
+	t.Setenv("AI_PROVIDERS", "")
+	t.Setenv("AI_PROVIDER", "unknown")
+	if _, err := ai.NewProviderFromEnv(context.Background(), logger()); !errors.Is(err, ai.ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestService_UsesProvider(t *testing.T) {
+	provider := ai.NewMockAIProvider()
+	provider.ProcessJournalSentimentFunc = func(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+		return &models.SentimentResult{Score: -0.5, Label: "negative", Confidence: 0.7}, nil
+	}
+	service := ai.NewService(provider, logger())
+
+	result, err := service.ProcessJournalSentiment(context.Background(), &models.Journal{ID: "j", Content: "A rough day"})
+	if err != nil {
+		t.Fatalf("ProcessJournalSentiment failed: %v", err)
+	}
+	if result.Label != "negative" {
+		t.Errorf("Expected provider result, got %+v", result)
+	}
+
+	generated, err := service.GenerateStructuredJournal(context.Background(), &models.PromptRequest{Prompt: "Write about today"})
+	if err != nil || generated.Content == "" {
+		t.Errorf("Expected generated journal, got %+v (%v)", generated, err)
+	}
+
+	if service.Provider().Name() != ai.MockProviderName {
+		t.Errorf("Expected mock provider, got %s", service.Provider().Name())
+	}
+}
+
+func TestService_ProcessJournalEmotions(t *testing.T) {
+	provider := ai.NewMockAIProvider()
+	provider.ProcessJournalEmotionsFunc = func(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+		tmpl, err := prompt.Select(ctx, prompt.TaskEmotion)
+		if err != nil || tmpl.Ref().String() != "emotion@v1" {
+			t.Errorf("Expected the default emotion template in the context, got %v (%v)", tmpl, err)
+		}
+		if opts := options.Select(ctx, prompt.TaskEmotion); opts.Temperature == nil || *opts.Temperature != 0 {
+			t.Errorf("Expected deterministic options, got %+v", opts)
+		}
+		return &models.EmotionResult{Emotions: models.Emotions{Fear: 0.6}, DominantEmotion: models.EmotionFear}, nil
+	}
+	service := ai.NewService(provider, logger())
+
+	// A sentiment template requested for the journal does not apply to emotions
+	ctx := prompt.WithRef(context.Background(), "sentiment@v1")
+	result, err := service.ProcessJournalEmotions(ctx, &models.Journal{ID: "j", Content: "Waiting for the results"})
+	if err != nil {
+		t.Fatalf("ProcessJournalEmotions failed: %v", err)
+	}
+	if result.DominantEmotion != models.EmotionFear || result.Provider != ai.MockProviderName {
+		t.Errorf("Expected provider result, got %+v", result)
+	}
+
+	if _, err := service.ProcessJournalEmotions(context.Background(), &models.Journal{ID: "j", Content: " "}); err == nil {
+		t.Error("Expected error for empty content")
+	}
+
+	// Providers without AnalyzeEmotions are reported as unsupported
+	service = ai.NewService(struct{ ai.Provider }{ai.NewMockAIProvider()}, logger())
+	_, err = service.ProcessJournalEmotions(context.Background(), &models.Journal{ID: "j", Content: "Waiting for the results"})
+	if !errors.Is(err, ai.ErrEmotionsUnsupported) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrEmotionsUnsupported, got %v", err)
+	}
+}
+
+func TestService_ProcessJournalExtraction(t *testing.T) {
+	provider := ai.NewMockAIProvider()
+	provider.ProcessJournalExtractionFunc = func(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error) {
+		tmpl, err := prompt.Select(ctx, prompt.TaskExtraction)
+		if err != nil || tmpl.Ref().String() != "extraction@v1" {
+			t.Errorf("Expected the default extraction template in the context, got %v (%v)", tmpl, err)
+		}
+		return &models.ExtractionResult{Themes: []models.ExtractedItem{{Name: "family", Confidence: 0.8}}}, nil
+	}
+	service := ai.NewService(provider, logger())
+
+	result, err := service.ProcessJournalExtraction(context.Background(), &models.Journal{ID: "j", Content: "Dinner at grandma's"})
+	if err != nil {
+		t.Fatalf("ProcessJournalExtraction failed: %v", err)
+	}
+	if len(result.Themes) != 1 || result.Provider != ai.MockProviderName {
+		t.Errorf("Expected provider result, got %+v", result)
+	}
+
+	service = ai.NewService(struct{ ai.Provider }{ai.NewMockAIProvider()}, logger())
+	_, err = service.ProcessJournalExtraction(context.Background(), &models.Journal{ID: "j", Content: "Dinner at grandma's"})
+	if !errors.Is(err, ai.ErrExtractionUnsupported) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrExtractionUnsupported, got %v", err)
+	}
+}
+
+func TestService_SummarizePeriod(t *testing.T) {
+	provider := ai.NewMockAIProvider()
+	provider.SummarizeFunc = func(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+		tmpl, err := prompt.Select(ctx, prompt.TaskSummary)
+		if err != nil || tmpl.Ref().String() != "summary@v1" {
+			t.Errorf("Expected the default summary template in the context, got %v (%v)", tmpl, err)
+		}
+		if opts := options.Select(ctx, prompt.TaskSummary); opts.Seed == nil {
+			t.Errorf("Expected deterministic summary options, got %+v", opts)
+		}
+		return &models.SummaryResult{Summary: "A quiet day."}, nil
+	}
+	service := ai.NewService(provider, logger())
+
+	req := &models.SummaryRequest{Period: models.DigestDaily, Entries: []string{"Read a book"}}
+	result, err := service.SummarizePeriod(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SummarizePeriod failed: %v", err)
+	}
+	if result.Summary != "A quiet day." || result.Provider != ai.MockProviderName {
+		t.Errorf("Expected provider result, got %+v", result)
+	}
+
+	if _, err := service.SummarizePeriod(context.Background(), &models.SummaryRequest{Period: models.DigestDaily}); err == nil {
+		t.Error("Expected an error with nothing to summarize")
+	}
+
+	service = ai.NewService(struct{ ai.Provider }{ai.NewMockAIProvider()}, logger())
+	if _, err := service.SummarizePeriod(context.Background(), req); !errors.Is(err, ai.ErrSummariesUnsupported) {
+		t.Errorf("Expected ErrSummariesUnsupported, got %v", err)
+	}
+}
+
+func TestService_Embed(t *testing.T) {
+	service := ai.NewService(ai.NewMockAIProvider(), logger())
+
+	result, err := service.Embed(context.Background(), []string{"Long run by the river", "Running by the river", "Baking bread"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if result.Model != ai.MockEmbeddingModel || len(result.Vectors) != 3 || len(result.Vectors[0]) != ai.MockEmbeddingDimensions {
+		t.Fatalf("Unexpected embeddings %+v", result)
+	}
+	if vector.Cosine(result.Vectors[0], result.Vectors[1]) <= vector.Cosine(result.Vectors[0], result.Vectors[2]) {
+		t.Error("Expected texts sharing words to embed closer together")
+	}
+
+	if _, err := service.Embed(context.Background(), nil); err == nil {
+		t.Error("Expected an error for no texts")
+	}
+
+	provider := ai.NewMockAIProvider()
+	provider.EmbedFunc = func(ctx context.Context, texts []string) (*models.Embeddings, error) {
+		return &models.Embeddings{Model: "short", Vectors: [][]float32{{1}}}, nil
+	}
+	if _, err := ai.NewService(provider, logger()).Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("Expected an error when vectors are missing")
+	}
+
+	service = ai.NewService(struct{ ai.Provider }{ai.NewMockAIProvider()}, logger())
+	if _, err := service.Embed(context.Background(), []string{"Baking bread"}); !errors.Is(err, ai.ErrEmbeddingsUnsupported) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrEmbeddingsUnsupported, got %v", err)
+	}
+}
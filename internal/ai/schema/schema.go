@@ -0,0 +1,213 @@
+// Package schema derives JSON schemas from Go types so providers can ask
+// models for output that decodes straight into them.
+//
+// Fields are named after their json tags and are required unless tagged
+// omitempty. Constraints come from the same struct tags the API docs use:
+// enum (comma separated), minimum, maximum, minLength and minItems. Fields
+// tagged schema:"-" are left out, which is how timestamps and other fields
+// filled in by the server rather than the model are excluded.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema needed to describe model output
+type Schema struct {
+	Type                 string     `json:"type,omitempty"`
+	Format               string     `json:"format,omitempty"`
+	Enum                 []string   `json:"enum,omitempty"`
+	Minimum              *float64   `json:"minimum,omitempty"`
+	Maximum              *float64   `json:"maximum,omitempty"`
+	MinLength            *int       `json:"minLength,omitempty"`
+	MinItems             *int       `json:"minItems,omitempty"`
+	Items                *Schema    `json:"items,omitempty"`
+	Properties           Properties `json:"properties,omitempty"`
+	Required             []string   `json:"required,omitempty"`
+	AdditionalProperties any        `json:"additionalProperties,omitempty"`
+}
+
+// Property is a named object property
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties keeps struct field order when marshaled, since models generate
+// properties in the order the schema lists them
+type Properties []Property
+
+// MarshalJSON encodes the properties as an object in field order
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// For returns the JSON schema of T
+func For[T any]() (json.RawMessage, error) {
+	s, err := Of(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+// MustFor is like For but panics on error; it is meant for package-level
+// variables holding the schemas of known types
+func MustFor[T any]() json.RawMessage {
+	s, err := For[T]()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Of builds the schema of t
+func Of(t reflect.Type) (*Schema, error) {
+	return build(t, make(map[reflect.Type]bool))
+}
+
+func build(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := build(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		s := &Schema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			values, err := build(t.Elem(), visiting)
+			if err != nil {
+				return nil, err
+			}
+			s.AdditionalProperties = values
+		}
+		return s, nil
+	case reflect.Struct:
+		return buildStruct(t, visiting)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+func buildStruct(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("recursive type %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	s := &Schema{Type: "object", AdditionalProperties: false}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("schema") == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := build(field.Type, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		if err := applyTags(prop, field.Tag); err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		s.Properties = append(s.Properties, Property{Name: name, Schema: prop})
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s, nil
+}
+
+// applyTags copies constraint tags onto s
+func applyTags(s *Schema, tag reflect.StructTag) error {
+	if enum := tag.Get("enum"); enum != "" {
+		s.Enum = strings.Split(enum, ",")
+	}
+
+	for key, dst := range map[string]**float64{"minimum": &s.Minimum, "maximum": &s.Maximum} {
+		value := tag.Get(key)
+		if value == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+		*dst = &f
+	}
+
+	for key, dst := range map[string]**int{"minLength": &s.MinLength, "minItems": &s.MinItems} {
+		value := tag.Get(key)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+		*dst = &n
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/schema"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func TestFor_SentimentResult(t *testing.T) {
+	got, err := schema.For[models.SentimentResult]()
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+
+	want := `{"type":"object","properties":{` +
+		`"score":{"type":"number","minimum":-1,"maximum":1},` +
+		`"label":{"type":"string","enum":["positive","negative","neutral"]},` +
+		`"confidence":{"type":"number","minimum":0,"maximum":1}},` +
+		`"required":["score","label","confidence"],"additionalProperties":false}`
+	if string(got) != want {
+		t.Errorf("Unexpected schema:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFor_GeneratedJournal(t *testing.T) {
+	got, err := schema.For[models.GeneratedJournal]()
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(got, &s); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	if _, ok := s.Properties["generated_at"]; ok {
+		t.Error("Expected server-filled generated_at to be excluded")
+	}
+	if !strings.Contains(string(s.Properties["metadata"]), `"themes":{"type":"array","minItems":1,"items":{"type":"string"}}`) {
+		t.Errorf("Expected constrained themes, got %s", s.Properties["metadata"])
+	}
+	if string(s.Properties["processing_hints"]) != `{"type":"object"}` {
+		t.Errorf("Expected free-form processing hints, got %s", s.Properties["processing_hints"])
+	}
+
+	// Properties keep field order so content is generated first
+	if !strings.HasPrefix(string(got), `{"type":"object","properties":{"content":`) {
+		t.Errorf("Expected content first, got %s", got)
+	}
+}
+
+func TestOf(t *testing.T) {
+	type inner struct {
+		At time.Time `json:"at"`
+	}
+	type sample struct {
+		Name     string          `json:"name,omitempty" minLength:"2"`
+		Count    *int            `json:"count"`
+		Inner    []inner         `json:"inner"`
+		Scores   map[string]uint `json:"scores"`
+		Ignored  string          `json:"-"`
+		Internal string          `schema:"-"`
+		Plain    bool
+		private  string
+	}
+
+	s, err := schema.Of(reflect.TypeFor[sample]())
+	if err != nil {
+		t.Fatalf("Of failed: %v", err)
+	}
+
+	var names []string
+	for _, p := range s.Properties {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "name,count,inner,scores,Plain" {
+		t.Errorf("Unexpected properties %v", names)
+	}
+	if strings.Join(s.Required, ",") != "count,inner,scores,Plain" {
+		t.Errorf("Unexpected required %v", s.Required)
+	}
+
+	data, _ := json.Marshal(s)
+	for _, fragment := range []string{
+		`"name":{"type":"string","minLength":2}`,
+		`"count":{"type":"integer"}`,
+		`"at":{"type":"string","format":"date-time"}`,
+		`"scores":{"type":"object","additionalProperties":{"type":"integer"}}`,
+	} {
+		if !strings.Contains(string(data), fragment) {
+			t.Errorf("Expected %s in %s", fragment, data)
+		}
+	}
+}
+
+type node struct {
+	Next *node `json:"next"`
+}
+
+func TestOf_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want string
+	}{
+		{"recursive", reflect.TypeFor[node](), "recursive type"},
+		{"unsupported kind", reflect.TypeFor[struct {
+			C chan int `json:"c"`
+		}](), "unsupported type"},
+		{"non-string map key", reflect.TypeFor[map[int]string](), "unsupported map key"},
+		{"bad tag", reflect.TypeFor[struct {
+			N float64 `json:"n" minimum:"low"`
+		}](), "invalid minimum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := schema.Of(tt.typ); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -6,7 +6,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/garnizeh/englog/internal/ai/ollama"
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/prompt"
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/models"
 )
@@ -20,26 +21,85 @@ type AIService interface {
 	HealthCheck(ctx context.Context) error
 }
 
-// Service provides AI processing capabilities
+// Service provides AI processing capabilities on top of a Provider
 type Service struct {
-	ollamaClient *ollama.Client
-	logger       *logging.Logger
+	provider Provider
+	prompts  *prompt.Registry
+	options  options.Config
+	logger   *logging.Logger
 }
 
 // Ensure Service implements AIService interface
 var _ AIService = (*Service)(nil)
 
-// NewService creates a new AI service
-func NewService(ctx context.Context, modelName, baseURL string, logger *logging.Logger) (*Service, error) {
-	ollamaClient, err := ollama.New(ctx, modelName, baseURL)
+// NewService creates a new AI service backed by the given provider
+func NewService(provider Provider, logger *logging.Logger) *Service {
+	return &Service{
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+// Provider returns the provider the service runs on
+func (s *Service) Provider() Provider {
+	return s.provider
+}
+
+// SetPrompts sets the prompt templates requests are resolved against; the
+// built-in templates are used until it is called
+func (s *Service) SetPrompts(prompts *prompt.Registry) {
+	s.prompts = prompts
+}
+
+// Prompts returns the prompt template registry in use
+func (s *Service) Prompts() *prompt.Registry {
+	if s.prompts == nil {
+		return prompt.Default()
+	}
+	return s.prompts
+}
+
+// SetGenerationOptions sets the generation options of each task; the built-in
+// defaults are used until it is called
+func (s *Service) SetGenerationOptions(config options.Config) {
+	s.options = config
+}
+
+// GenerationOptions returns the generation options of each task
+func (s *Service) GenerationOptions() options.Config {
+	if s.options == nil {
+		return options.Defaults()
+	}
+	return s.options
+}
+
+// PromptTemplates lists the available prompt templates
+func (s *Service) PromptTemplates() []prompt.Info {
+	return s.Prompts().List()
+}
+
+// withPrompt resolves ref (empty for the deployment default) and returns a
+// context carrying the template for providers to render
+func (s *Service) withPrompt(ctx context.Context, task prompt.Task, ref string) (context.Context, error) {
+	tmpl, err := s.Prompts().Resolve(task, ref)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Ollama client: %w", err)
+		return nil, err
 	}
+	return prompt.WithTemplate(ctx, tmpl), nil
+}
 
-	return &Service{
-		ollamaClient: ollamaClient,
-		logger:       logger,
-	}, nil
+// ProviderStatus lists the providers behind the service with their circuit
+// breaker states; a plain provider is reported as a single closed entry
+func (s *Service) ProviderStatus() []ProviderStatus {
+	if chain, ok := s.provider.(*Chain); ok {
+		return chain.Status()
+	}
+
+	return []ProviderStatus{{
+		Name:         s.provider.Name(),
+		Capabilities: s.provider.Capabilities(),
+		Breaker:      BreakerSnapshot{State: BreakerClosed},
+	}}
 }
 
 // ProcessJournalSentiment analyzes the sentiment of a journal entry
@@ -52,13 +112,21 @@ func (s *Service) ProcessJournalSentiment(ctx context.Context, journal *models.J
 		return nil, fmt.Errorf("journal content cannot be empty")
 	}
 
+	// A template requested by reference in ctx overrides the default
+	ctx, err := s.withPrompt(ctx, prompt.TaskSentiment, prompt.RefFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	ctx = options.WithOptions(ctx, s.GenerationOptions().For(prompt.TaskSentiment))
+
 	s.logger.Info("processing journal sentiment",
 		"journal_id", journal.ID,
 		"content_length", len(journal.Content),
+		"provider", s.provider.Name(),
 	)
 
 	start := time.Now()
-	result, err := s.ollamaClient.AnalyzeSentiment(ctx, journal.Content)
+	result, err := s.provider.AnalyzeSentiment(ctx, journal.Content)
 	if err != nil {
 		s.logger.Error("sentiment analysis failed",
 			"journal_id", journal.ID,
@@ -67,12 +135,219 @@ func (s *Service) ProcessJournalSentiment(ctx context.Context, journal *models.J
 		)
 		return nil, fmt.Errorf("sentiment analysis failed for journal %s: %w", journal.ID, err)
 	}
+	if result.Provider == "" {
+		result.Provider = s.provider.Name()
+	}
 
 	s.logger.Info("sentiment analysis completed",
 		"journal_id", journal.ID,
 		"sentiment_score", result.Score,
 		"sentiment_label", result.Label,
 		"confidence", result.Confidence,
+		"provider", result.Provider,
+		"duration", time.Since(start),
+	)
+
+	return result, nil
+}
+
+// ProcessJournalEmotions analyzes the emotions of a journal entry
+// It returns ErrEmotionsUnsupported when the provider cannot analyze emotions.
+func (s *Service) ProcessJournalEmotions(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+	if journal == nil {
+		return nil, fmt.Errorf("journal cannot be nil")
+	}
+
+	if strings.TrimSpace(journal.Content) == "" {
+		return nil, fmt.Errorf("journal content cannot be empty")
+	}
+
+	analyzer, ok := s.provider.(EmotionProvider)
+	if !ok || !s.provider.Capabilities().Emotions {
+		return nil, ErrEmotionsUnsupported
+	}
+
+	ctx, err := s.withPrompt(ctx, prompt.TaskEmotion, "")
+	if err != nil {
+		return nil, err
+	}
+	ctx = options.WithOptions(ctx, s.GenerationOptions().For(prompt.TaskEmotion))
+
+	s.logger.Info("processing journal emotions",
+		"journal_id", journal.ID,
+		"content_length", len(journal.Content),
+		"provider", s.provider.Name(),
+	)
+
+	start := time.Now()
+	result, err := analyzer.AnalyzeEmotions(ctx, journal.Content)
+	if err != nil {
+		s.logger.Error("emotion analysis failed",
+			"journal_id", journal.ID,
+			"error", err,
+			"duration", time.Since(start),
+		)
+		return nil, fmt.Errorf("emotion analysis failed for journal %s: %w", journal.ID, err)
+	}
+	if result.Provider == "" {
+		result.Provider = s.provider.Name()
+	}
+
+	s.logger.Info("emotion analysis completed",
+		"journal_id", journal.ID,
+		"dominant_emotion", result.DominantEmotion,
+		"valence", result.Valence,
+		"arousal", result.Arousal,
+		"sentences", len(result.Sentences),
+		"provider", result.Provider,
+		"duration", time.Since(start),
+	)
+
+	return result, nil
+}
+
+// ProcessJournalExtraction extracts entities and topics from a journal entry
+// It returns ErrExtractionUnsupported when the provider cannot extract them.
+func (s *Service) ProcessJournalExtraction(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error) {
+	if journal == nil {
+		return nil, fmt.Errorf("journal cannot be nil")
+	}
+
+	if strings.TrimSpace(journal.Content) == "" {
+		return nil, fmt.Errorf("journal content cannot be empty")
+	}
+
+	extractor, ok := s.provider.(ExtractionProvider)
+	if !ok || !s.provider.Capabilities().Extraction {
+		return nil, ErrExtractionUnsupported
+	}
+
+	ctx, err := s.withPrompt(ctx, prompt.TaskExtraction, "")
+	if err != nil {
+		return nil, err
+	}
+	ctx = options.WithOptions(ctx, s.GenerationOptions().For(prompt.TaskExtraction))
+
+	s.logger.Info("processing journal extraction",
+		"journal_id", journal.ID,
+		"content_length", len(journal.Content),
+		"provider", s.provider.Name(),
+	)
+
+	start := time.Now()
+	result, err := extractor.ExtractEntities(ctx, journal.Content)
+	if err != nil {
+		s.logger.Error("extraction failed",
+			"journal_id", journal.ID,
+			"error", err,
+			"duration", time.Since(start),
+		)
+		return nil, fmt.Errorf("extraction failed for journal %s: %w", journal.ID, err)
+	}
+	if result.Provider == "" {
+		result.Provider = s.provider.Name()
+	}
+
+	s.logger.Info("extraction completed",
+		"journal_id", journal.ID,
+		"entities", len(result.Entities()),
+		"themes", len(result.Themes),
+		"tags", len(result.Tags),
+		"provider", result.Provider,
+		"duration", time.Since(start),
+	)
+
+	return result, nil
+}
+
+// Embed turns texts into vectors with the provider's embedding model
+// It returns ErrEmbeddingsUnsupported when the provider cannot embed text.
+func (s *Service) Embed(ctx context.Context, texts []string) (*models.Embeddings, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts to embed")
+	}
+
+	embedder, ok := s.provider.(EmbeddingProvider)
+	if !ok || !s.provider.Capabilities().Embeddings {
+		return nil, ErrEmbeddingsUnsupported
+	}
+
+	start := time.Now()
+	result, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		s.logger.Error("embedding failed",
+			"texts", len(texts),
+			"error", err,
+			"duration", time.Since(start),
+		)
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(result.Vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding failed: got %d vectors for %d texts", len(result.Vectors), len(texts))
+	}
+
+	s.logger.Debug("embedding completed",
+		"texts", len(texts),
+		"model", result.Model,
+		"duration", time.Since(start),
+	)
+
+	return result, nil
+}
+
+// SummarizePeriod summarizes the journal entries of a period, or the
+// summaries of its parts
+// It returns ErrSummariesUnsupported when the provider cannot summarize.
+func (s *Service) SummarizePeriod(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("summary request cannot be nil")
+	}
+
+	if len(req.Entries) == 0 && len(req.Summaries) == 0 {
+		return nil, fmt.Errorf("nothing to summarize")
+	}
+
+	summarizer, ok := s.provider.(SummaryProvider)
+	if !ok || !s.provider.Capabilities().Summaries {
+		return nil, ErrSummariesUnsupported
+	}
+
+	ctx, err := s.withPrompt(ctx, prompt.TaskSummary, "")
+	if err != nil {
+		return nil, err
+	}
+	ctx = options.WithOptions(ctx, s.GenerationOptions().For(prompt.TaskSummary))
+
+	s.logger.Info("summarizing period",
+		"period", req.Period,
+		"from", req.From,
+		"entries", len(req.Entries),
+		"summaries", len(req.Summaries),
+		"partial", req.Partial,
+		"provider", s.provider.Name(),
+	)
+
+	start := time.Now()
+	result, err := summarizer.Summarize(ctx, req)
+	if err != nil {
+		s.logger.Error("summary failed",
+			"period", req.Period,
+			"from", req.From,
+			"error", err,
+			"duration", time.Since(start),
+		)
+		return nil, fmt.Errorf("summary failed: %w", err)
+	}
+	if result.Provider == "" {
+		result.Provider = s.provider.Name()
+	}
+
+	s.logger.Info("summary completed",
+		"period", req.Period,
+		"from", req.From,
+		"themes", len(result.Themes),
+		"notable_events", len(result.NotableEvents),
+		"provider", result.Provider,
 		"duration", time.Since(start),
 	)
 
@@ -81,6 +356,26 @@ func (s *Service) ProcessJournalSentiment(ctx context.Context, journal *models.J
 
 // GenerateStructuredJournal creates a structured journal entry from a prompt
 func (s *Service) GenerateStructuredJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+	return s.generate(ctx, req, func(ctx context.Context) (*models.GeneratedJournal, error) {
+		return s.provider.GenerateJournal(ctx, req)
+	})
+}
+
+// GenerateStructuredJournalStream creates a structured journal entry like
+// GenerateStructuredJournal, passing generated text to onToken as it arrives
+// Providers that cannot stream return the whole journal without tokens.
+func (s *Service) GenerateStructuredJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error) {
+	return s.generate(ctx, req, func(ctx context.Context) (*models.GeneratedJournal, error) {
+		if streamer, ok := s.provider.(StreamingProvider); ok {
+			return streamer.GenerateJournalStream(ctx, req, onToken)
+		}
+		return s.provider.GenerateJournal(ctx, req)
+	})
+}
+
+// generate validates req, resolves its prompt template and generation
+// options into ctx and runs call with it
+func (s *Service) generate(ctx context.Context, req *models.PromptRequest, call func(context.Context) (*models.GeneratedJournal, error)) (*models.GeneratedJournal, error) {
 	if req == nil {
 		return nil, fmt.Errorf("prompt request cannot be nil")
 	}
@@ -89,13 +384,26 @@ func (s *Service) GenerateStructuredJournal(ctx context.Context, req *models.Pro
 		return nil, fmt.Errorf("prompt cannot be empty")
 	}
 
+	ctx, err := s.withPrompt(ctx, prompt.TaskGeneration, req.PromptTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options in the request metadata override the task's options
+	overrides, errs := models.GenerationOptionsFromMetadata(req.Metadata)
+	if errs.HasErrors() {
+		return nil, errs
+	}
+	ctx = options.WithOptions(ctx, s.GenerationOptions().For(prompt.TaskGeneration).Merge(overrides))
+
 	s.logger.Info("generating structured journal",
 		"prompt_length", len(req.Prompt),
 		"has_context", req.Context != "",
+		"provider", s.provider.Name(),
 	)
 
 	start := time.Now()
-	result, err := s.ollamaClient.GenerateJournal(ctx, req)
+	result, err := call(ctx)
 	if err != nil {
 		s.logger.Error("journal generation failed",
 			"error", err,
@@ -0,0 +1,72 @@
+// Package analytics computes statistics over stored journals for charts and
+// insights. It only reads the results of earlier AI processing; nothing here
+// calls a model.
+package analytics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// MoodMetadataKey is the journal metadata key holding a numeric mood rating
+const MoodMetadataKey = "mood"
+
+// moodRating returns the numeric mood rating in a journal's metadata
+// Ratings may be JSON numbers or numeric strings; anything else is ignored.
+func moodRating(journal *models.Journal) (float64, bool) {
+	return numberOf(journal.Metadata[MoodMetadataKey])
+}
+
+// numberOf returns a metadata value as a number; numeric strings count
+func numberOf(value any) (float64, bool) {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		n = parsed
+	default:
+		return 0, false
+	}
+	return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+}
+
+// mean accumulates a weighted average
+type mean struct {
+	sum, weight float64
+}
+
+func (m *mean) add(value, weight float64) {
+	m.sum += value * weight
+	m.weight += weight
+}
+
+func (m *mean) merge(other mean) {
+	m.sum += other.sum
+	m.weight += other.weight
+}
+
+// value returns the average, or nil when nothing was added
+func (m mean) value() *float64 {
+	if m.weight == 0 {
+		return nil
+	}
+	v := round(m.sum / m.weight)
+	return &v
+}
+
+// round keeps three decimals of a statistic
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
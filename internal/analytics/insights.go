@@ -0,0 +1,399 @@
+package analytics
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+// Factors journals are grouped by
+const (
+	FactorTag       = "tag"
+	FactorLocation  = "location"
+	FactorWeekday   = "weekday"
+	FactorTimeOfDay = "time_of_day"
+	FactorMetadata  = "metadata"
+)
+
+// Effect size measures
+const (
+	MeasureCohensD = "cohens_d"  // Standardized difference between two group means
+	MeasurePearson = "pearson_r" // Correlation between a number and sentiment
+)
+
+const (
+	// DefaultMinSamples is the smallest group an insight is drawn from
+	DefaultMinSamples = 5
+
+	// maxCategoryLength keeps free-text metadata, which rarely repeats, out
+	// of the categories compared
+	maxCategoryLength = 64
+)
+
+const (
+	tagsMetadataKey     = "tags"
+	locationMetadataKey = "location"
+)
+
+// InsightQuery selects the journals insights are drawn from
+type InsightQuery struct {
+	Location   *time.Location // Time zone of weekdays and times of day; nil means UTC
+	From       *time.Time     // Inclusive lower bound on created_at
+	To         *time.Time     // Exclusive upper bound on created_at
+	MinSamples int            // Smallest group, and smallest rest, compared; 0 uses DefaultMinSamples
+}
+
+// Insight is an association between a factor and sentiment
+type Insight struct {
+	Factor string `json:"factor"`          // tag, location, weekday, time_of_day or metadata
+	Key    string `json:"key,omitempty"`   // Metadata key, for metadata factors
+	Value  string `json:"value,omitempty"` // Group value; empty for numeric metadata
+
+	Measure   string  `json:"measure"`   // cohens_d or pearson_r
+	Effect    float64 `json:"effect"`    // Positive when the factor goes with higher sentiment
+	Magnitude string  `json:"magnitude"` // small, medium or large
+
+	// Journals is the size of the group, or of the sample for numeric
+	// metadata; Others is the number of analyzed journals outside the group
+	Journals         int      `json:"journals"`
+	Others           int      `json:"others,omitempty"`
+	AverageSentiment *float64 `json:"average_sentiment,omitempty"`
+	OthersSentiment  *float64 `json:"others_sentiment,omitempty"`
+
+	Explanation string `json:"explanation"`
+
+	// strength compares d and r on one scale for ranking
+	strength float64
+}
+
+// InsightReport lists the associations found in a set of journals, strongest
+// first
+type InsightReport struct {
+	Timezone   string    `json:"timezone"`
+	Analyzed   int       `json:"analyzed"` // Journals with a stored sentiment
+	MinSamples int       `json:"min_samples"`
+	Insights   []Insight `json:"insights"`
+}
+
+// Insights finds the factors associated with higher or lower sentiment in
+// the journals in store matching query
+func Insights(store storage.JournalStore, query InsightQuery) (*InsightReport, error) {
+	journals, err := storage.JournalsBetween(store, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	return insights(journals, query), nil
+}
+
+// group collects the sentiment scores of the journals sharing a value
+type group struct {
+	factor, key, value string
+	scores             []float64
+}
+
+// pairs collects a numeric metadata value and sentiment per journal
+type pairs struct {
+	key  string
+	x, y []float64
+}
+
+// insights compares the sentiment of every group of journals with the rest
+// Groups and rests smaller than MinSamples are skipped, as are effects too
+// small to matter (|d| < 0.2, |r| < 0.1).
+func insights(journals []*models.Journal, query InsightQuery) *InsightReport {
+	loc := query.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	minSamples := query.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultMinSamples
+	}
+
+	report := &InsightReport{Timezone: loc.String(), MinSamples: minSamples, Insights: []Insight{}}
+
+	groups := map[[3]string]*group{}
+	numeric := map[string]*pairs{}
+	var all []float64
+
+	addTo := func(factor, key, value string, score float64) {
+		id := [3]string{factor, key, value}
+		if groups[id] == nil {
+			groups[id] = &group{factor: factor, key: key, value: value}
+		}
+		groups[id].scores = append(groups[id].scores, score)
+	}
+
+	for _, journal := range journals {
+		sentiment := storage.SentimentOf(journal)
+		if sentiment == nil {
+			continue
+		}
+		score := sentiment.Score
+		all = append(all, score)
+
+		local := journal.CreatedAt.In(loc)
+		addTo(FactorWeekday, "", local.Weekday().String(), score)
+		addTo(FactorTimeOfDay, "", timeOfDay(local), score)
+
+		for key, value := range journal.Metadata {
+			switch key {
+			case tagsMetadataKey:
+				for _, tag := range tagsOf(value) {
+					addTo(FactorTag, "", tag, score)
+				}
+				continue
+			case locationMetadataKey:
+				if category, ok := categoryOf(value); ok {
+					addTo(FactorLocation, "", category, score)
+				}
+				continue
+			}
+
+			if number, ok := numberOf(value); ok {
+				if numeric[key] == nil {
+					numeric[key] = &pairs{key: key}
+				}
+				numeric[key].x = append(numeric[key].x, number)
+				numeric[key].y = append(numeric[key].y, score)
+			} else if category, ok := categoryOf(value); ok {
+				addTo(FactorMetadata, key, category, score)
+			}
+		}
+	}
+	report.Analyzed = len(all)
+
+	everyone := summarize(all)
+	for _, g := range groups {
+		if len(g.scores) < minSamples || everyone.n-len(g.scores) < minSamples {
+			continue
+		}
+		if insight, ok := compareGroup(g, everyone); ok {
+			report.Insights = append(report.Insights, insight)
+		}
+	}
+	for _, p := range numeric {
+		if len(p.x) < minSamples {
+			continue
+		}
+		if insight, ok := correlate(p); ok {
+			report.Insights = append(report.Insights, insight)
+		}
+	}
+
+	slices.SortFunc(report.Insights, func(a, b Insight) int {
+		return cmp.Or(
+			cmp.Compare(b.strength, a.strength),
+			cmp.Compare(a.Factor, b.Factor),
+			cmp.Compare(a.Key, b.Key),
+			cmp.Compare(a.Value, b.Value),
+		)
+	})
+
+	return report
+}
+
+// sums holds the count, sum and sum of squares of a set of scores
+type sums struct {
+	n            int
+	sum, squares float64
+}
+
+func summarize(scores []float64) sums {
+	s := sums{n: len(scores)}
+	for _, score := range scores {
+		s.sum += score
+		s.squares += score * score
+	}
+	return s
+}
+
+// compareGroup measures how the sentiment of a group differs from the rest
+// of the analyzed journals with Cohen's d
+// The rest's statistics are derived from those of every analyzed journal.
+func compareGroup(g *group, everyone sums) (Insight, bool) {
+	in := summarize(g.scores)
+	rest := sums{n: everyone.n - in.n, sum: everyone.sum - in.sum, squares: everyone.squares - in.squares}
+
+	n1, n2 := in.n, rest.n
+	mean1, mean2 := in.sum/float64(n1), rest.sum/float64(n2)
+	ss1 := in.squares - float64(n1)*mean1*mean1
+	ss2 := rest.squares - float64(n2)*mean2*mean2
+	pooled := math.Sqrt(math.Max(ss1+ss2, 0) / float64(n1+n2-2))
+	if pooled < 1e-9 {
+		return Insight{}, false
+	}
+
+	d := (mean1 - mean2) / pooled
+	magnitude := magnitudeOf(math.Abs(d), 0.2, 0.5, 0.8)
+	if magnitude == "" {
+		return Insight{}, false
+	}
+
+	insight := Insight{
+		Factor:           g.factor,
+		Key:              g.key,
+		Value:            g.value,
+		Measure:          MeasureCohensD,
+		Effect:           round(d),
+		Magnitude:        magnitude,
+		Journals:         n1,
+		Others:           n2,
+		AverageSentiment: ptr(round(mean1)),
+		OthersSentiment:  ptr(round(mean2)),
+		strength:         math.Abs(d),
+	}
+
+	direction := "higher"
+	if d < 0 {
+		direction = "lower"
+	}
+	insight.Explanation = fmt.Sprintf("%s have an average sentiment of %.2f, %s than the %.2f of other entries (%s effect, %d vs %d entries).",
+		describeGroup(g), mean1, direction, mean2, magnitude, n1, n2)
+
+	return insight, true
+}
+
+// correlate measures how a numeric metadata value moves with sentiment
+// with Pearson's r
+func correlate(p *pairs) (Insight, bool) {
+	n := float64(len(p.x))
+	meanX, meanY := summarize(p.x).sum/n, summarize(p.y).sum/n
+
+	var sxy, sxx, syy float64
+	for i := range p.x {
+		dx, dy := p.x[i]-meanX, p.y[i]-meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx < 1e-9 || syy < 1e-9 {
+		return Insight{}, false
+	}
+
+	r := sxy / math.Sqrt(sxx*syy)
+	magnitude := magnitudeOf(math.Abs(r), 0.1, 0.3, 0.5)
+	if magnitude == "" {
+		return Insight{}, false
+	}
+
+	direction := "more"
+	if r < 0 {
+		direction = "less"
+	}
+
+	return Insight{
+		Factor:    FactorMetadata,
+		Key:       p.key,
+		Measure:   MeasurePearson,
+		Effect:    round(r),
+		Magnitude: magnitude,
+		Journals:  len(p.x),
+		Explanation: fmt.Sprintf("Higher %s values go with %s positive sentiment (r = %.2f, %s effect, %d entries).",
+			p.key, direction, r, magnitude, len(p.x)),
+		// Cohen's d equivalent of r, so both measures rank on one scale
+		strength: math.Abs(2 * r / math.Sqrt(math.Max(1-r*r, 1e-9))),
+	}, true
+}
+
+// magnitudeOf labels an effect size against the conventional thresholds,
+// returning "" below the smallest
+func magnitudeOf(effect, small, medium, large float64) string {
+	switch {
+	case effect >= large:
+		return "large"
+	case effect >= medium:
+		return "medium"
+	case effect >= small:
+		return "small"
+	default:
+		return ""
+	}
+}
+
+// describeGroup names a group of journals in an explanation
+func describeGroup(g *group) string {
+	switch g.factor {
+	case FactorTag:
+		return fmt.Sprintf("Entries tagged %q", g.value)
+	case FactorLocation:
+		return fmt.Sprintf("Entries written at %q", g.value)
+	case FactorWeekday:
+		return "Entries written on " + g.value + "s"
+	case FactorTimeOfDay:
+		if g.value == "night" {
+			return "Entries written at night"
+		}
+		return "Entries written in the " + g.value
+	default:
+		return fmt.Sprintf("Entries with %s %q", g.key, g.value)
+	}
+}
+
+// timeOfDay names the part of the day of a local time
+func timeOfDay(t time.Time) string {
+	switch hour := t.Hour(); {
+	case hour >= 5 && hour < 12:
+		return "morning"
+	case hour >= 12 && hour < 17:
+		return "afternoon"
+	case hour >= 17 && hour < 22:
+		return "evening"
+	default:
+		return "night"
+	}
+}
+
+// tagsOf returns the distinct tags of a tags metadata value, which may be a
+// list or a single string
+func tagsOf(value any) []string {
+	var tags []string
+	add := func(v any) {
+		if tag, ok := categoryOf(v); ok && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			add(item)
+		}
+	case []string:
+		for _, item := range v {
+			add(item)
+		}
+	default:
+		add(v)
+	}
+	return tags
+}
+
+// categoryOf returns a metadata value as a category: a short string,
+// compared case-insensitively, or a boolean
+func categoryOf(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		category := strings.ToLower(strings.TrimSpace(v))
+		if category == "" || utf8.RuneCountInString(category) > maxCategoryLength {
+			return "", false
+		}
+		return category, true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
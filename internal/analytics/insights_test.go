@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// insightJournals returns twenty journals, all written at noon UTC on the
+// same Wednesday so only metadata varies:
+// exercise raises sentiment, the office lowers it, a numeric energy rating
+// rises with it, and "rare" is too uncommon to judge
+func insightJournals() []*models.Journal {
+	noon := time.Date(2025, 8, 6, 12, 0, 0, 0, time.UTC)
+
+	var journals []*models.Journal
+	for i := range 20 {
+		score := -0.2 + 0.1*float64(i%5)
+		metadata := map[string]any{"energy": float64(i % 5)}
+		if i < 6 {
+			score += 0.7
+			metadata["tags"] = []any{"exercise", "Exercise"}
+		}
+		if i >= 12 {
+			score -= 0.2
+			metadata["location"] = "Office"
+		} else {
+			metadata["location"] = "home"
+		}
+		if i == 0 || i == 19 {
+			metadata["tags"] = []any{"rare"}
+		}
+		journal := newJournal(noon.Add(time.Duration(i)*time.Second), "neutral", score, 0.9, nil)
+		journal.Metadata = metadata
+		journals = append(journals, journal)
+	}
+
+	// Unanalyzed journals are ignored
+	journals = append(journals, newJournal(noon, "", 0, 0, nil))
+	return journals
+}
+
+func findInsight(report *InsightReport, factor, key, value string) *Insight {
+	for i, insight := range report.Insights {
+		if insight.Factor == factor && insight.Key == key && insight.Value == value {
+			return &report.Insights[i]
+		}
+	}
+	return nil
+}
+
+func TestInsights(t *testing.T) {
+	report := insights(insightJournals(), InsightQuery{})
+
+	if report.Analyzed != 20 || report.MinSamples != DefaultMinSamples || report.Timezone != "UTC" {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	// Journal 0 is tagged "rare" instead, leaving five exercise journals
+	exercise := findInsight(report, FactorTag, "", "exercise")
+	if exercise == nil {
+		t.Fatalf("Expected an exercise insight, got %+v", report.Insights)
+	}
+	if exercise.Measure != MeasureCohensD || exercise.Effect <= 0.8 || exercise.Magnitude != "large" || exercise.Journals != 5 || exercise.Others != 15 {
+		t.Errorf("Expected a large positive effect over 5 journals, got %+v", exercise)
+	}
+	if !strings.HasPrefix(exercise.Explanation, `Entries tagged "exercise" have an average sentiment of`) || !strings.Contains(exercise.Explanation, "higher than") {
+		t.Errorf("Unexpected explanation %q", exercise.Explanation)
+	}
+
+	office := findInsight(report, FactorLocation, "", "office")
+	if office == nil || office.Effect >= 0 || !strings.Contains(office.Explanation, "lower than") {
+		t.Errorf("Expected lower sentiment at the office, got %+v", office)
+	}
+	// Home is the complement of the office, so it shows the opposite effect
+	if home := findInsight(report, FactorLocation, "", "home"); home == nil || math.Abs(home.Effect+office.Effect) > 1e-3 {
+		t.Errorf("Expected home to mirror the office, got %+v", home)
+	}
+
+	energy := findInsight(report, FactorMetadata, "energy", "")
+	if energy == nil || energy.Measure != MeasurePearson || energy.Effect <= 0 || energy.Journals != 20 {
+		t.Errorf("Expected a positive energy correlation, got %+v", energy)
+	}
+	if energy != nil && !strings.HasPrefix(energy.Explanation, "Higher energy values go with more positive sentiment") {
+		t.Errorf("Unexpected explanation %q", energy.Explanation)
+	}
+
+	if rare := findInsight(report, FactorTag, "", "rare"); rare != nil {
+		t.Errorf("Expected groups below the minimum sample to be skipped, got %+v", rare)
+	}
+	// Every journal was written on a Wednesday afternoon, leaving no rest to compare with
+	if weekday := findInsight(report, FactorWeekday, "", "Wednesday"); weekday != nil {
+		t.Errorf("Expected no weekday insight, got %+v", weekday)
+	}
+
+	for i := 1; i < len(report.Insights); i++ {
+		if report.Insights[i-1].strength < report.Insights[i].strength {
+			t.Errorf("Expected insights strongest first, got %+v", report.Insights)
+			break
+		}
+	}
+}
+
+func TestInsights_MinSamplesAndTimezone(t *testing.T) {
+	journals := insightJournals()
+
+	report := insights(journals, InsightQuery{MinSamples: 2})
+	if rare := findInsight(report, FactorTag, "", "rare"); rare == nil || rare.Journals != 2 {
+		t.Errorf("Expected the rare tag with a lower minimum, got %+v", rare)
+	}
+
+	report = insights(journals, InsightQuery{MinSamples: 11})
+	if exercise := findInsight(report, FactorTag, "", "exercise"); exercise != nil {
+		t.Errorf("Expected the exercise tag to be skipped, got %+v", exercise)
+	}
+
+	// Noon UTC is evening in Tokyo and 06:00 UTC afternoon, so times of day
+	// follow the time zone
+	tokyo := time.FixedZone("JST", 9*60*60)
+	for _, journal := range journals[:10] {
+		journal.CreatedAt = journal.CreatedAt.Add(-6 * time.Hour)
+	}
+	report = insights(journals, InsightQuery{Location: tokyo})
+	if report.Timezone != "JST" {
+		t.Errorf("Expected the JST time zone, got %s", report.Timezone)
+	}
+	if evening := findInsight(report, FactorTimeOfDay, "", "evening"); evening == nil || !strings.HasPrefix(evening.Explanation, "Entries written in the evening") {
+		t.Errorf("Expected an evening insight, got %+v", report.Insights)
+	}
+}
+
+func TestInsights_NoData(t *testing.T) {
+	report := insights(nil, InsightQuery{})
+	if report.Analyzed != 0 || len(report.Insights) != 0 || report.Insights == nil {
+		t.Errorf("Expected an empty report, got %+v", report)
+	}
+}
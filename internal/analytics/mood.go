@@ -0,0 +1,239 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+// Bucket is the length of the intervals a series is grouped into
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week" // Monday to Sunday
+	BucketMonth Bucket = "month"
+)
+
+// Buckets lists the supported bucket lengths
+var Buckets = []Bucket{BucketDay, BucketWeek, BucketMonth}
+
+const (
+	// MaxBuckets bounds the length of a series, so an open-ended day series
+	// over years of journals cannot grow without limit
+	MaxBuckets = 1000
+
+	// MaxWindow is the longest rolling average, in buckets
+	MaxWindow = 90
+)
+
+// ErrTooManyBuckets is returned when a series would exceed MaxBuckets
+var ErrTooManyBuckets = errors.New("too many buckets")
+
+// DefaultWindow returns the default rolling average length of a bucket:
+// a week of days, four weeks or three months
+func (b Bucket) DefaultWindow() int {
+	switch b {
+	case BucketWeek:
+		return 4
+	case BucketMonth:
+		return 3
+	default:
+		return 7
+	}
+}
+
+// start returns the start of the bucket containing t in loc
+func (b Bucket) start(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	switch b {
+	case BucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return day
+	}
+}
+
+// next returns the start of the bucket after the one starting at start
+// Calendar arithmetic keeps buckets aligned to local midnight across
+// daylight saving changes.
+func (b Bucket) next(start time.Time) time.Time {
+	switch b {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// MoodQuery selects the journals and bucketing of a mood series
+type MoodQuery struct {
+	Bucket   Bucket
+	Location *time.Location // Time zone the buckets are aligned to; nil means UTC
+	From     *time.Time     // Inclusive lower bound on created_at
+	To       *time.Time     // Exclusive upper bound on created_at
+	Window   int            // Buckets per rolling average; 0 uses the bucket's default
+}
+
+// MoodStats summarizes the mood of a set of journals
+type MoodStats struct {
+	Journals int `json:"journals"`
+
+	// Analyzed is the number of journals with a stored sentiment
+	Analyzed          int            `json:"analyzed"`
+	AverageSentiment  *float64       `json:"average_sentiment"`
+	WeightedSentiment *float64       `json:"weighted_sentiment"` // Weighted by the model's confidence
+	Labels            map[string]int `json:"labels"`
+
+	// Rated is the number of journals with a numeric mood in their metadata
+	Rated       int      `json:"rated"`
+	AverageMood *float64 `json:"average_mood"`
+
+	sentiment, weighted, mood mean
+}
+
+// add counts a journal in the stats
+func (s *MoodStats) add(journal *models.Journal) {
+	s.Journals++
+
+	if sentiment := storage.SentimentOf(journal); sentiment != nil {
+		s.Analyzed++
+		s.Labels[sentiment.Label]++
+		s.sentiment.add(sentiment.Score, 1)
+		if sentiment.Confidence > 0 {
+			s.weighted.add(sentiment.Score, sentiment.Confidence)
+		}
+	}
+	if rating, ok := moodRating(journal); ok {
+		s.Rated++
+		s.mood.add(rating, 1)
+	}
+}
+
+// finish computes the averages of the stats
+func (s *MoodStats) finish() {
+	s.AverageSentiment = s.sentiment.value()
+	s.WeightedSentiment = s.weighted.value()
+	s.AverageMood = s.mood.value()
+}
+
+// MoodBucket is one interval of a mood series
+type MoodBucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	MoodStats
+
+	// Rolling averages over this bucket and the Window-1 before it, weighted
+	// by journal so sparse buckets do not count as much as busy ones
+	RollingSentiment *float64 `json:"rolling_sentiment"`
+	RollingMood      *float64 `json:"rolling_mood"`
+}
+
+// MoodSeries is a time-bucketed mood series
+type MoodSeries struct {
+	Bucket   Bucket       `json:"bucket"`
+	Timezone string       `json:"timezone"`
+	Window   int          `json:"window"`
+	Buckets  []MoodBucket `json:"buckets"`
+	Totals   MoodStats    `json:"totals"`
+}
+
+// Mood computes the mood series of the journals in store matching query
+func Mood(store storage.JournalStore, query MoodQuery) (*MoodSeries, error) {
+	journals, err := storage.JournalsBetween(store, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	return moodSeries(journals, query)
+}
+
+// moodSeries buckets journals, sorted by created_at, into a mood series
+// Buckets run from From, or the first journal, to To, or the last journal,
+// including empty ones so the series can be charted as is.
+func moodSeries(journals []*models.Journal, query MoodQuery) (*MoodSeries, error) {
+	loc := query.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	window := query.Window
+	if window <= 0 {
+		window = query.Bucket.DefaultWindow()
+	}
+
+	series := &MoodSeries{
+		Bucket:   query.Bucket,
+		Timezone: loc.String(),
+		Window:   window,
+		Buckets:  []MoodBucket{},
+		Totals:   MoodStats{Labels: map[string]int{}},
+	}
+
+	var first, last time.Time
+	switch {
+	case query.From != nil:
+		first = *query.From
+	case len(journals) > 0:
+		first = journals[0].CreatedAt
+	}
+	switch {
+	case query.To != nil:
+		last = query.To.Add(-time.Nanosecond)
+	case len(journals) > 0:
+		last = journals[len(journals)-1].CreatedAt
+	}
+	if first.IsZero() || last.IsZero() || last.Before(first) {
+		series.Totals.finish()
+		return series, nil
+	}
+
+	for start := query.Bucket.start(first, loc); !start.After(last); start = query.Bucket.next(start) {
+		if len(series.Buckets) == MaxBuckets {
+			return nil, fmt.Errorf("%w: more than %d %s buckets", ErrTooManyBuckets, MaxBuckets, query.Bucket)
+		}
+		series.Buckets = append(series.Buckets, MoodBucket{
+			Start:     start,
+			End:       query.Bucket.next(start),
+			MoodStats: MoodStats{Labels: map[string]int{}},
+		})
+	}
+
+	i := 0
+	for _, journal := range journals {
+		for i < len(series.Buckets) && !journal.CreatedAt.Before(series.Buckets[i].End) {
+			i++
+		}
+		if i == len(series.Buckets) {
+			break
+		}
+		if journal.CreatedAt.Before(series.Buckets[i].Start) {
+			continue
+		}
+		series.Buckets[i].add(journal)
+		series.Totals.add(journal)
+	}
+
+	for i := range series.Buckets {
+		bucket := &series.Buckets[i]
+		bucket.finish()
+
+		var sentiment, mood mean
+		for j := max(0, i-window+1); j <= i; j++ {
+			sentiment.merge(series.Buckets[j].sentiment)
+			mood.merge(series.Buckets[j].mood)
+		}
+		bucket.RollingSentiment = sentiment.value()
+		bucket.RollingMood = mood.value()
+	}
+	series.Totals.finish()
+
+	return series, nil
+}
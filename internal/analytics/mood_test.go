@@ -0,0 +1,157 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+func newJournal(createdAt time.Time, label string, score, confidence float64, mood any) *models.Journal {
+	journal := &models.Journal{
+		ID:        fmt.Sprintf("journal-%d", createdAt.UnixNano()),
+		Content:   "Journal entry",
+		CreatedAt: createdAt,
+	}
+	if label != "" {
+		journal.ProcessingResult = &models.ProcessingResult{
+			Status:          models.ProcessingStatusCompleted,
+			SentimentResult: &models.SentimentResult{Label: label, Score: score, Confidence: confidence},
+		}
+	}
+	if mood != nil {
+		journal.Metadata = map[string]any{MoodMetadataKey: mood}
+	}
+	return journal
+}
+
+func value(v *float64) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*v)
+}
+
+func TestMood_DailySeries(t *testing.T) {
+	store := storage.NewMemoryStore()
+	day := time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)
+	for _, journal := range []*models.Journal{
+		newJournal(day.Add(9*time.Hour), "positive", 0.8, 0.9, 8.0),
+		newJournal(day.Add(20*time.Hour), "negative", -0.4, 0.3, "4"),
+		// Nothing on August 5
+		newJournal(day.AddDate(0, 0, 2).Add(time.Hour), "neutral", 0, 1, "great"),
+		newJournal(day.AddDate(0, 0, 2).Add(2*time.Hour), "", 0, 0, 6),
+	} {
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	series, err := Mood(store, MoodQuery{Bucket: BucketDay, Window: 2})
+	if err != nil {
+		t.Fatalf("Mood failed: %v", err)
+	}
+
+	if len(series.Buckets) != 3 || series.Timezone != "UTC" || series.Window != 2 {
+		t.Fatalf("Expected three daily buckets, got %+v", series)
+	}
+
+	monday := series.Buckets[0]
+	if monday.Journals != 2 || monday.Analyzed != 2 || monday.Labels["positive"] != 1 || monday.Labels["negative"] != 1 {
+		t.Errorf("Unexpected counts %+v", monday.MoodStats)
+	}
+	// (0.8*0.9 - 0.4*0.3) / 1.2 = 0.5
+	if value(monday.AverageSentiment) != "0.2" || value(monday.WeightedSentiment) != "0.5" || value(monday.AverageMood) != "6" {
+		t.Errorf("Unexpected averages %s %s %s", value(monday.AverageSentiment), value(monday.WeightedSentiment), value(monday.AverageMood))
+	}
+
+	tuesday := series.Buckets[1]
+	if tuesday.Journals != 0 || tuesday.AverageSentiment != nil || value(tuesday.RollingSentiment) != "0.2" {
+		t.Errorf("Expected an empty bucket carrying the rolling average, got %+v", tuesday)
+	}
+
+	// The rolling window covers Tuesday and Wednesday only
+	wednesday := series.Buckets[2]
+	if wednesday.Rated != 1 || value(wednesday.AverageMood) != "6" || value(wednesday.RollingSentiment) != "0" {
+		t.Errorf("Unexpected Wednesday %+v rolling %s", wednesday.MoodStats, value(wednesday.RollingSentiment))
+	}
+
+	if series.Totals.Journals != 4 || series.Totals.Analyzed != 3 || series.Totals.Rated != 3 || value(series.Totals.AverageMood) != "6" {
+		t.Errorf("Unexpected totals %+v", series.Totals)
+	}
+}
+
+func TestMood_TimezoneBuckets(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	// 20:00 UTC on Sunday is already Monday morning in Tokyo
+	journals := []*models.Journal{
+		newJournal(time.Date(2025, 8, 3, 10, 0, 0, 0, time.UTC), "positive", 0.5, 1, nil),
+		newJournal(time.Date(2025, 8, 3, 20, 0, 0, 0, time.UTC), "negative", -0.5, 1, nil),
+	}
+
+	utc, err := moodSeries(journals, MoodQuery{Bucket: BucketWeek})
+	if err != nil {
+		t.Fatalf("moodSeries failed: %v", err)
+	}
+	if len(utc.Buckets) != 1 || utc.Buckets[0].Start.Format(time.DateOnly) != "2025-07-28" {
+		t.Errorf("Expected one UTC week, got %+v", utc.Buckets)
+	}
+
+	local, err := moodSeries(journals, MoodQuery{Bucket: BucketWeek, Location: tokyo})
+	if err != nil {
+		t.Fatalf("moodSeries failed: %v", err)
+	}
+	if len(local.Buckets) != 2 || local.Timezone != "Asia/Tokyo" {
+		t.Fatalf("Expected two Tokyo weeks, got %+v", local.Buckets)
+	}
+	if start := local.Buckets[1].Start; start.Format(time.RFC3339) != "2025-08-04T00:00:00+09:00" {
+		t.Errorf("Expected the week to start at local midnight, got %s", start)
+	}
+	if local.Buckets[1].Labels["negative"] != 1 {
+		t.Errorf("Expected the evening entry in the second week, got %+v", local.Buckets[1])
+	}
+}
+
+func TestMood_Bounds(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	series, err := moodSeries(nil, MoodQuery{Bucket: BucketMonth, From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("moodSeries failed: %v", err)
+	}
+	if len(series.Buckets) != 3 || series.Window != 3 || !series.Buckets[2].End.Equal(to) {
+		t.Errorf("Expected three empty months, got %+v", series)
+	}
+
+	empty, err := moodSeries(nil, MoodQuery{Bucket: BucketDay})
+	if err != nil || len(empty.Buckets) != 0 || empty.Totals.AverageSentiment != nil {
+		t.Errorf("Expected an empty series, got %+v (%v)", empty, err)
+	}
+
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := moodSeries(nil, MoodQuery{Bucket: BucketDay, From: &since, To: &to}); !errors.Is(err, ErrTooManyBuckets) {
+		t.Errorf("Expected ErrTooManyBuckets, got %v", err)
+	}
+}
+
+func TestBucket_DaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	// Clocks go forward on March 30, 2025, making it a 23-hour day
+	start := BucketDay.start(time.Date(2025, 3, 30, 12, 0, 0, 0, berlin), berlin)
+	next := BucketDay.next(start)
+	if next.Sub(start) != 23*time.Hour || next.Hour() != 0 {
+		t.Errorf("Expected a 23-hour day ending at midnight, got %s to %s", start, next)
+	}
+}
@@ -0,0 +1,210 @@
+// Package diff produces line-based unified diffs between two texts
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// opKind identifies how a line differs between the two texts
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is a single line of an edit script
+type edit struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff turning a into b, or "" when they are equal
+// fromName and toName label the two sides in the --- and +++ headers
+func Unified(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	if context < 0 {
+		context = DefaultContext
+	}
+
+	edits := lineEdits(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(edits, context) {
+		writeHunk(&out, edits, h)
+	}
+
+	return out.String()
+}
+
+// splitLines splits text into lines without their trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// lineEdits computes a shortest edit script with Myers' O(ND) algorithm
+func lineEdits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // move down: insertion
+			} else {
+				x = v[offset+k-1] + 1 // move right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the path
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{kind: opEqual, line: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				edits = append(edits, edit{kind: opInsert, line: b[y]})
+			} else {
+				x--
+				edits = append(edits, edit{kind: opDelete, line: a[x]})
+			}
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// hunk is a range of the edit script printed together
+type hunk struct {
+	start, end int // edit indexes, end exclusive
+}
+
+// hunks groups changes with up to context unchanged lines around them,
+// merging groups whose context would overlap
+func hunks(edits []edit, context int) []hunk {
+	var result []hunk
+
+	for i := 0; i < len(edits); i++ {
+		if edits[i].kind == opEqual {
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := min(i+1+context, len(edits))
+
+		if len(result) > 0 && start <= result[len(result)-1].end {
+			result[len(result)-1].end = end
+		} else {
+			result = append(result, hunk{start: start, end: end})
+		}
+	}
+
+	return result
+}
+
+// writeHunk prints a hunk header and its lines
+func writeHunk(out *strings.Builder, edits []edit, h hunk) {
+	// Line numbers are 1-based positions in each text where the hunk starts
+	fromLine, toLine := 1, 1
+	for _, e := range edits[:h.start] {
+		if e.kind != opInsert {
+			fromLine++
+		}
+		if e.kind != opDelete {
+			toLine++
+		}
+	}
+
+	fromCount, toCount := 0, 0
+	for _, e := range edits[h.start:h.end] {
+		if e.kind != opInsert {
+			fromCount++
+		}
+		if e.kind != opDelete {
+			toCount++
+		}
+	}
+
+	// An empty range is reported at the line before it, per the unified format
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+
+	for _, e := range edits[h.start:h.end] {
+		switch e.kind {
+		case opEqual:
+			out.WriteString(" ")
+		case opDelete:
+			out.WriteString("-")
+		case opInsert:
+			out.WriteString("+")
+		}
+		out.WriteString(e.line)
+		out.WriteString("\n")
+	}
+}
+
+// hunkRange formats a line range, omitting a count of one
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,56 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{
+			name: "equal",
+			a:    "same\ntext\n",
+			b:    "same\ntext\n",
+			want: "",
+		},
+		{
+			name: "changed line with context",
+			a:    "one\ntwo\nthree\nfour\nfive\n",
+			b:    "one\ntwo\nTHREE\nfour\nfive\n",
+			want: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n one\n two\n-three\n+THREE\n four\n five\n",
+		},
+		{
+			name: "insert into empty",
+			a:    "",
+			b:    "first\nsecond",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+first\n+second\n",
+		},
+		{
+			name: "delete everything",
+			a:    "gone",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1 +0,0 @@\n-gone\n",
+		},
+		{
+			name:    "separate hunks",
+			a:       "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n",
+			b:       "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n",
+			context: 1,
+			want:    "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -9,2 +9,2 @@\n i\n-j\n+J\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := tt.context
+			if context == 0 {
+				context = DefaultContext
+			}
+			if got := Unified("a", "b", tt.a, tt.b, context); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+// Package digest writes AI summaries of the journals of a day, week or month.
+// Journals are summarized in batches that fit the model's context window and
+// the batch summaries combined into one (map-reduce). Mood and sentiment
+// statistics come from the journals' stored analysis rather than the model.
+package digest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMaxBatchChars is the default amount of entry text per model
+	// call, about 3,000 tokens, which leaves room for the prompt and answer
+	// in a 4,096-token context window
+	DefaultMaxBatchChars = 12000
+
+	// dominantMoodLimit is the number of moods kept in a digest
+	dominantMoodLimit = 3
+)
+
+// ErrNoJournals is returned when a period has no journals to summarize
+var ErrNoJournals = errors.New("no journals in the period")
+
+// summarizer is the AI service a digest is written with
+type summarizer interface {
+	SummarizePeriod(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error)
+}
+
+// Config holds digest configuration
+type Config struct {
+	MaxBatchChars int                   // Entry or summary text per model call
+	StorePath     string                // File that persists digests; empty keeps them in memory
+	Schedule      []models.DigestPeriod // Periods written automatically once complete
+	CheckInterval time.Duration         // How often the scheduler looks for complete periods
+}
+
+// ConfigFromEnv reads the DIGEST_* environment variables
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		MaxBatchChars: DefaultMaxBatchChars,
+		StorePath:     os.Getenv("DIGEST_PATH"),
+		CheckInterval: defaultCheckInterval,
+	}
+
+	if raw := os.Getenv("DIGEST_MAX_BATCH_CHARS"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return config, fmt.Errorf("invalid DIGEST_MAX_BATCH_CHARS %q: must be a positive integer", raw)
+		}
+		config.MaxBatchChars = value
+	}
+
+	for name := range strings.SplitSeq(os.Getenv("DIGEST_SCHEDULE"), ",") {
+		period := models.DigestPeriod(strings.ToLower(strings.TrimSpace(name)))
+		if period == "" {
+			continue
+		}
+		if !slices.Contains(models.DigestPeriods, period) {
+			return config, fmt.Errorf("invalid DIGEST_SCHEDULE period %q: must be daily, weekly or monthly", name)
+		}
+		if !slices.Contains(config.Schedule, period) {
+			config.Schedule = append(config.Schedule, period)
+		}
+	}
+
+	if raw := os.Getenv("DIGEST_CHECK_INTERVAL"); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil || value <= 0 {
+			return config, fmt.Errorf("invalid DIGEST_CHECK_INTERVAL %q: must be a positive duration", raw)
+		}
+		config.CheckInterval = value
+	}
+
+	return config, nil
+}
+
+// Builder writes digests from stored journals and keeps them in a Store
+type Builder struct {
+	journals   storage.JournalStore
+	digests    *Store
+	summarizer summarizer
+	config     Config
+	logger     *logging.Logger
+
+	// mu serializes builds, so a period requested while the scheduler is
+	// writing it is not summarized twice
+	mu sync.Mutex
+}
+
+// NewBuilder creates a digest builder
+func NewBuilder(journals storage.JournalStore, digests *Store, summarizer summarizer, config Config, logger *logging.Logger) *Builder {
+	if config.MaxBatchChars <= 0 {
+		config.MaxBatchChars = DefaultMaxBatchChars
+	}
+
+	return &Builder{
+		journals:   journals,
+		digests:    digests,
+		summarizer: summarizer,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// Build writes the digest of the period containing date and stores it
+// A digest written earlier for the same period is replaced, keeping its ID.
+// It returns ErrNoJournals when the period has no journals.
+func (b *Builder) Build(ctx context.Context, period models.DigestPeriod, date time.Time) (*models.Digest, error) {
+	from, to, err := period.Window(date)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	journals, err := storage.JournalsBetween(b.journals, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+	if len(journals) == 0 {
+		return nil, fmt.Errorf("%w: %s from %s", ErrNoJournals, period, from.Format(time.DateOnly))
+	}
+
+	previousFrom, previousTo, _ := period.Window(from.AddDate(0, 0, -1))
+	previousJournals, err := storage.JournalsBetween(b.journals, &previousFrom, &previousTo)
+	if err != nil {
+		return nil, err
+	}
+	previous, _ := b.digests.Find(period, previousFrom)
+
+	req := models.SummaryRequest{Period: period, From: from, To: to}
+	if previous != nil {
+		req.Previous = previous.Summary
+	}
+
+	start := time.Now()
+	entries := make([]string, len(journals))
+	for i, journal := range journals {
+		entries[i] = formatEntry(journal, b.config.MaxBatchChars)
+	}
+	result, batches, err := b.summarize(ctx, req, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &models.Digest{
+		ID:              uuid.New().String(),
+		Period:          period,
+		From:            from,
+		To:              to,
+		Summary:         result.Summary,
+		DominantMoods:   dominantMoods(journals),
+		RecurringThemes: result.Themes,
+		NotableEvents:   result.NotableEvents,
+		Stats:           statsOf(journals),
+		JournalIDs:      make([]string, len(journals)),
+		Batches:         batches,
+		CreatedAt:       time.Now().UTC(),
+		Provider:        result.Provider,
+		Prompt:          result.Prompt,
+		Options:         result.Options,
+	}
+	for i, journal := range journals {
+		digest.JournalIDs[i] = journal.ID
+	}
+	digest.Change = changeFrom(digest.Stats, statsOf(previousJournals), previous, result.Change)
+
+	if existing, ok := b.digests.Find(period, from); ok {
+		digest.ID = existing.ID
+	}
+	if err := b.digests.Put(digest); err != nil {
+		return nil, err
+	}
+
+	b.logger.Info("Digest written",
+		"digest_id", digest.ID,
+		"period", period,
+		"from", from,
+		"journals", len(journals),
+		"batches", batches,
+		"provider", digest.Provider,
+		"duration", time.Since(start))
+
+	return digest, nil
+}
+
+// summarize runs the map-reduce over entries and returns the final summary
+// with the number of batches the entries were split into
+func (b *Builder) summarize(ctx context.Context, req models.SummaryRequest, entries []string) (*models.SummaryResult, int, error) {
+	batches := pack(entries, b.config.MaxBatchChars)
+	if len(batches) == 1 {
+		final := req
+		final.Entries = entries
+		result, err := b.summarizer.SummarizePeriod(ctx, &final)
+		return result, 1, err
+	}
+
+	// Map: summarize each batch of entries on its own
+	summaries := make([]string, 0, len(batches))
+	for _, batch := range batches {
+		part := models.SummaryRequest{Period: req.Period, From: req.From, To: req.To, Entries: batch, Partial: true}
+		result, err := b.summarizer.SummarizePeriod(ctx, &part)
+		if err != nil {
+			return nil, len(batches), err
+		}
+		summaries = append(summaries, b.partialSummary(result))
+	}
+
+	// Reduce: combine summaries until they fit in one call; partial summaries
+	// are at most half the budget, so every pass at least halves them
+	for {
+		groups := pack(summaries, b.config.MaxBatchChars)
+		if len(groups) == 1 {
+			break
+		}
+
+		combined := make([]string, 0, len(groups))
+		for _, group := range groups {
+			part := models.SummaryRequest{Period: req.Period, From: req.From, To: req.To, Summaries: group, Partial: true}
+			result, err := b.summarizer.SummarizePeriod(ctx, &part)
+			if err != nil {
+				return nil, len(batches), err
+			}
+			combined = append(combined, b.partialSummary(result))
+		}
+		summaries = combined
+	}
+
+	final := req
+	final.Summaries = summaries
+	result, err := b.summarizer.SummarizePeriod(ctx, &final)
+	return result, len(batches), err
+}
+
+// partialSummary formats the summary of part of a period, shortened to half
+// the batch budget so that any two fit in one call
+func (b *Builder) partialSummary(result *models.SummaryResult) string {
+	return shorten(formatPartial(result), b.config.MaxBatchChars/2)
+}
+
+// pack groups items in order into batches of at most maxChars characters
+// An item longer than maxChars gets a batch of its own.
+func pack(items []string, maxChars int) [][]string {
+	var (
+		batches [][]string
+		current []string
+		size    int
+	)
+
+	for _, item := range items {
+		length := utf8.RuneCountInString(item)
+		if len(current) > 0 && size+length > maxChars {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+		current = append(current, item)
+		size += length
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// formatEntry writes a journal as a summary entry: a header line with its
+// date and stored analysis, then its content shortened to maxChars
+func formatEntry(journal *models.Journal, maxChars int) string {
+	header := []string{journal.CreatedAt.UTC().Format("2006-01-02 15:04")}
+	if sentiment := storage.SentimentOf(journal); sentiment != nil {
+		header = append(header, fmt.Sprintf("sentiment: %s (%.2f)", sentiment.Label, sentiment.Score))
+	}
+	if result := journal.ProcessingResult; result != nil {
+		if result.EmotionResult != nil && result.EmotionResult.DominantEmotion != "" {
+			header = append(header, "emotion: "+result.EmotionResult.DominantEmotion)
+		}
+		if result.Extraction != nil && len(result.Extraction.Themes) > 0 {
+			themes := make([]string, len(result.Extraction.Themes))
+			for i, theme := range result.Extraction.Themes {
+				themes[i] = theme.Name
+			}
+			header = append(header, "themes: "+strings.Join(themes, ", "))
+		}
+	}
+
+	content := strings.TrimSpace(journal.Content)
+	if runes := []rune(content); len(runes) > maxChars {
+		content = string(runes[:maxChars]) + "..."
+	}
+
+	return strings.Join(header, " | ") + "\n" + content
+}
+
+// shorten cuts text to at most maxChars characters, marking the cut with "..."
+func shorten(text string, maxChars int) string {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	if maxChars <= len("...") {
+		return string(runes[:maxChars])
+	}
+	return string(runes[:maxChars-len("...")]) + "..."
+}
+
+// formatPartial writes the summary of part of a period for the next pass
+func formatPartial(result *models.SummaryResult) string {
+	lines := []string{result.Summary}
+	for label, items := range map[string][]string{
+		"Moods":          result.Moods,
+		"Themes":         result.Themes,
+		"Notable events": result.NotableEvents,
+	} {
+		if len(items) > 0 {
+			lines = append(lines, label+": "+strings.Join(items, "; "))
+		}
+	}
+	slices.Sort(lines[1:])
+	return strings.Join(lines, "\n")
+}
+
+// statsOf computes the sentiment statistics of journals
+func statsOf(journals []*models.Journal) models.DigestStats {
+	stats := models.DigestStats{Journals: len(journals), Sentiments: map[string]int{}}
+
+	var total float64
+	for _, journal := range journals {
+		sentiment := storage.SentimentOf(journal)
+		if sentiment == nil {
+			continue
+		}
+		stats.Analyzed++
+		stats.Sentiments[sentiment.Label]++
+		total += sentiment.Score
+	}
+	if stats.Analyzed > 0 {
+		average := round(total / float64(stats.Analyzed))
+		stats.AverageSentiment = &average
+	}
+
+	return stats
+}
+
+// dominantMoods counts journals per mood: the dominant emotion when emotions
+// were analyzed, the sentiment label otherwise
+func dominantMoods(journals []*models.Journal) []models.MoodCount {
+	counts := map[string]int{}
+	for _, journal := range journals {
+		mood := ""
+		if result := journal.ProcessingResult; result != nil && result.EmotionResult != nil {
+			mood = result.EmotionResult.DominantEmotion
+		}
+		if sentiment := storage.SentimentOf(journal); mood == "" && sentiment != nil {
+			mood = sentiment.Label
+		}
+		if mood != "" {
+			counts[mood]++
+		}
+	}
+
+	moods := make([]models.MoodCount, 0, len(counts))
+	for mood, count := range counts {
+		moods = append(moods, models.MoodCount{Mood: mood, Journals: count})
+	}
+	slices.SortFunc(moods, func(a, b models.MoodCount) int {
+		return cmp.Or(cmp.Compare(b.Journals, a.Journals), cmp.Compare(a.Mood, b.Mood))
+	})
+
+	if len(moods) > dominantMoodLimit {
+		moods = moods[:dominantMoodLimit]
+	}
+	return moods
+}
+
+// changeFrom compares a period's stats with the previous period's
+// It returns nil when the previous period had no journals.
+func changeFrom(current, previous models.DigestStats, previousDigest *models.Digest, summary string) *models.DigestChange {
+	if previous.Journals == 0 {
+		return nil
+	}
+
+	change := &models.DigestChange{Journals: current.Journals - previous.Journals}
+	if previousDigest != nil {
+		change.PreviousID = previousDigest.ID
+		change.Summary = summary
+	}
+	if current.AverageSentiment != nil && previous.AverageSentiment != nil {
+		delta := round(*current.AverageSentiment - *previous.AverageSentiment)
+		change.Sentiment = &delta
+	}
+
+	return change
+}
+
+// round keeps two decimals of a score
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
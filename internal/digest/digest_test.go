@@ -0,0 +1,359 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+func logger() *logging.Logger {
+	logConfig := logging.Config{
+		Level:  logging.DebugLevel,
+		Format: "json",
+	}
+
+	return logging.NewLogger(logConfig)
+}
+
+// fakeSummarizer records requests and summarizes them by counting items
+type fakeSummarizer struct {
+	mu       sync.Mutex
+	requests []models.SummaryRequest
+	err      error
+	padding  int // characters appended to every summary
+}
+
+func (f *fakeSummarizer) SummarizePeriod(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, *req)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	result := &models.SummaryResult{
+		Summary:       fmt.Sprintf("%d entries, %d summaries", len(req.Entries), len(req.Summaries)) + strings.Repeat("y", f.padding),
+		Themes:        []string{"work"},
+		NotableEvents: []string{fmt.Sprintf("call %d", len(f.requests))},
+		Provider:      "fake",
+	}
+	if req.Previous != "" {
+		result.Change = "Compared with: " + req.Previous
+	}
+	return result, nil
+}
+
+func storeJournal(t *testing.T, store storage.JournalStore, createdAt time.Time, content, label string, score float64) *models.Journal {
+	t.Helper()
+
+	journal := &models.Journal{ID: fmt.Sprintf("journal-%d", createdAt.Unix()), Content: content, CreatedAt: createdAt}
+	if label != "" {
+		journal.ProcessingResult = &models.ProcessingResult{
+			Status:          models.ProcessingStatusCompleted,
+			SentimentResult: &models.SentimentResult{Label: label, Score: score},
+		}
+	}
+	if err := store.Store(journal); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	return journal
+}
+
+func newTestBuilder(t *testing.T, maxBatchChars int) (*Builder, storage.JournalStore, *fakeSummarizer) {
+	t.Helper()
+
+	journals := storage.NewMemoryStore()
+	digests, err := NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	summarizer := &fakeSummarizer{}
+	return NewBuilder(journals, digests, summarizer, Config{MaxBatchChars: maxBatchChars}, logger()), journals, summarizer
+}
+
+var day = time.Date(2025, 8, 5, 0, 0, 0, 0, time.UTC)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("DIGEST_MAX_BATCH_CHARS", "500")
+	t.Setenv("DIGEST_SCHEDULE", " Weekly, daily,weekly,")
+	t.Setenv("DIGEST_CHECK_INTERVAL", "10m")
+	t.Setenv("DIGEST_PATH", "/tmp/digests.json")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv failed: %v", err)
+	}
+	if config.MaxBatchChars != 500 || config.CheckInterval != 10*time.Minute || config.StorePath != "/tmp/digests.json" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if !slices.Equal(config.Schedule, []models.DigestPeriod{models.DigestWeekly, models.DigestDaily}) {
+		t.Errorf("Expected weekly and daily schedules, got %v", config.Schedule)
+	}
+
+	for name, value := range map[string]string{
+		"DIGEST_MAX_BATCH_CHARS": "0",
+		"DIGEST_SCHEDULE":        "hourly",
+		"DIGEST_CHECK_INTERVAL":  "soon",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("Expected an error naming %s, got %v", name, err)
+			}
+		})
+	}
+}
+
+func TestPack(t *testing.T) {
+	batches := pack([]string{"aaaa", "bb", "cc", "dddddddd", "e"}, 6)
+	expected := [][]string{{"aaaa", "bb"}, {"cc"}, {"dddddddd"}, {"e"}}
+	if fmt.Sprint(batches) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, batches)
+	}
+	if batches := pack(nil, 6); len(batches) != 0 {
+		t.Errorf("Expected no batches, got %v", batches)
+	}
+}
+
+func TestBuilder_Build(t *testing.T) {
+	builder, journals, summarizer := newTestBuilder(t, DefaultMaxBatchChars)
+	first := storeJournal(t, journals, day.Add(8*time.Hour), "Morning run.", "positive", 0.6)
+	second := storeJournal(t, journals, day.Add(21*time.Hour), "Launch slipped.", "negative", -0.4)
+	storeJournal(t, journals, day.Add(22*time.Hour), "Not analyzed yet.", "", 0)
+	storeJournal(t, journals, day.AddDate(0, 0, 1), "Next day.", "positive", 0.9)
+
+	digest, err := builder.Build(context.Background(), models.DigestDaily, day.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if len(summarizer.requests) != 1 || summarizer.requests[0].Partial || len(summarizer.requests[0].Entries) != 3 {
+		t.Fatalf("Expected one call with every entry, got %+v", summarizer.requests)
+	}
+	if entry := summarizer.requests[0].Entries[0]; entry != "2025-08-05 08:00 | sentiment: positive (0.60)\nMorning run." {
+		t.Errorf("Unexpected entry %q", entry)
+	}
+
+	if digest.Summary != "3 entries, 0 summaries" || digest.Batches != 1 || digest.Provider != "fake" {
+		t.Errorf("Unexpected digest %+v", digest)
+	}
+	if !digest.From.Equal(day) || !digest.To.Equal(day.AddDate(0, 0, 1)) {
+		t.Errorf("Unexpected window %s to %s", digest.From, digest.To)
+	}
+	if digest.JournalIDs[0] != first.ID || digest.JournalIDs[1] != second.ID || len(digest.JournalIDs) != 3 {
+		t.Errorf("Expected the period's journals oldest first, got %v", digest.JournalIDs)
+	}
+	if digest.Stats.Journals != 3 || digest.Stats.Analyzed != 2 || *digest.Stats.AverageSentiment != 0.1 {
+		t.Errorf("Unexpected stats %+v", digest.Stats)
+	}
+	if fmt.Sprint(digest.DominantMoods) != "[{negative 1} {positive 1}]" {
+		t.Errorf("Unexpected moods %v", digest.DominantMoods)
+	}
+	if digest.Change != nil {
+		t.Errorf("Expected no change without a previous period, got %+v", digest.Change)
+	}
+
+	if stored, err := builder.digests.Get(digest.ID); err != nil || stored != digest {
+		t.Errorf("Expected the digest to be stored, got %v (%v)", stored, err)
+	}
+}
+
+func TestBuilder_BuildMapReduce(t *testing.T) {
+	// Each entry is 65 characters and each partial summary 59, so two fit
+	// in a batch
+	builder, journals, summarizer := newTestBuilder(t, 140)
+	for i := range 5 {
+		storeJournal(t, journals, day.AddDate(0, 0, i), strings.Repeat("x", 20), "neutral", 0)
+	}
+
+	digest, err := builder.Build(context.Background(), models.DigestWeekly, day)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Three batches of entries, a reduce pass combining the partials in two
+	// calls, then one final call
+	if len(summarizer.requests) != 6 {
+		t.Fatalf("Expected six calls, got %d", len(summarizer.requests))
+	}
+	var entries, partial int
+	for _, req := range summarizer.requests[:3] {
+		entries += len(req.Entries)
+		if req.Partial {
+			partial++
+		}
+	}
+	if entries != 5 || partial != 3 {
+		t.Errorf("Expected three partial calls over five entries, got %+v", summarizer.requests)
+	}
+	final := summarizer.requests[len(summarizer.requests)-1]
+	if final.Partial || len(final.Entries) != 0 || len(final.Summaries) != 2 {
+		t.Errorf("Expected a final call over combined summaries, got %+v", final)
+	}
+	if digest.Batches != 3 || len(digest.JournalIDs) != 5 {
+		t.Errorf("Unexpected digest %+v", digest)
+	}
+	if !digest.From.Equal(time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the week to start on Monday, got %s", digest.From)
+	}
+}
+
+func TestBuilder_BuildMapReduceOversizedSummaries(t *testing.T) {
+	// Every summary alone is larger than the whole budget
+	builder, journals, summarizer := newTestBuilder(t, 140)
+	summarizer.padding = 500
+	for i := range 5 {
+		storeJournal(t, journals, day.AddDate(0, 0, i), strings.Repeat("x", 20), "neutral", 0)
+	}
+
+	if _, err := builder.Build(context.Background(), models.DigestWeekly, day); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	for i, req := range summarizer.requests {
+		size := 0
+		for _, summary := range req.Summaries {
+			size += utf8.RuneCountInString(summary)
+		}
+		if size > 140 {
+			t.Errorf("Expected call %d to fit the budget, got %d characters of summaries", i, size)
+		}
+	}
+	if final := summarizer.requests[len(summarizer.requests)-1]; final.Partial || len(final.Summaries) != 2 {
+		t.Errorf("Expected a final call over two shortened summaries, got %+v", final)
+	}
+}
+
+func TestBuilder_BuildComparesWithPreviousPeriod(t *testing.T) {
+	builder, journals, summarizer := newTestBuilder(t, DefaultMaxBatchChars)
+	storeJournal(t, journals, day.AddDate(0, 0, -1).Add(time.Hour), "Calm day.", "neutral", 0)
+	storeJournal(t, journals, day.Add(time.Hour), "Great day.", "positive", 0.8)
+	storeJournal(t, journals, day.Add(2*time.Hour), "Good evening.", "positive", 0.4)
+
+	// Without a digest of the previous day, only the statistics are compared
+	digest, err := builder.Build(context.Background(), models.DigestDaily, day)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if digest.Change == nil || digest.Change.Journals != 1 || *digest.Change.Sentiment != 0.6 || digest.Change.PreviousID != "" {
+		t.Errorf("Unexpected change %+v", digest.Change)
+	}
+
+	previous, err := builder.Build(context.Background(), models.DigestDaily, day.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	rebuilt, err := builder.Build(context.Background(), models.DigestDaily, day)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if rebuilt.ID != digest.ID || len(builder.digests.List("")) != 2 {
+		t.Errorf("Expected the digest to be replaced, got %s and %d digests", rebuilt.ID, len(builder.digests.List("")))
+	}
+	if last := summarizer.requests[len(summarizer.requests)-1]; last.Previous != previous.Summary {
+		t.Errorf("Expected the previous summary in the request, got %q", last.Previous)
+	}
+	if rebuilt.Change.PreviousID != previous.ID || rebuilt.Change.Summary != "Compared with: "+previous.Summary {
+		t.Errorf("Unexpected change %+v", rebuilt.Change)
+	}
+}
+
+func TestBuilder_BuildErrors(t *testing.T) {
+	builder, journals, summarizer := newTestBuilder(t, DefaultMaxBatchChars)
+
+	if _, err := builder.Build(context.Background(), models.DigestDaily, day); !errors.Is(err, ErrNoJournals) {
+		t.Errorf("Expected ErrNoJournals, got %v", err)
+	}
+	if _, err := builder.Build(context.Background(), "hourly", day); err == nil {
+		t.Error("Expected an error for an unknown period")
+	}
+
+	storeJournal(t, journals, day, "Entry.", "", 0)
+	summarizer.err = errors.ErrUnsupported
+	if _, err := builder.Build(context.Background(), models.DigestDaily, day); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected the summarizer error, got %v", err)
+	}
+	if digests := builder.digests.List(""); len(digests) != 0 {
+		t.Errorf("Expected no digest to be stored, got %v", digests)
+	}
+}
+
+func TestStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "digests.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	for i, period := range []models.DigestPeriod{models.DigestDaily, models.DigestDaily, models.DigestWeekly} {
+		digest := &models.Digest{ID: fmt.Sprintf("digest-%d", i), Period: period, From: day.AddDate(0, 0, i)}
+		if err := store.Put(digest); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	daily := reopened.List(models.DigestDaily)
+	if len(daily) != 2 || daily[0].ID != "digest-1" {
+		t.Errorf("Expected daily digests newest first, got %v", daily)
+	}
+	if _, ok := reopened.Find(models.DigestWeekly, day.AddDate(0, 0, 2)); !ok {
+		t.Error("Expected to find the weekly digest")
+	}
+	if _, err := reopened.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	builder, journals, summarizer := newTestBuilder(t, DefaultMaxBatchChars)
+	storeJournal(t, journals, day.Add(time.Hour), "Entry.", "positive", 0.5)
+
+	scheduler := NewScheduler(builder, []models.DigestPeriod{models.DigestDaily, models.DigestMonthly}, time.Hour, logger())
+	scheduler.now = func() time.Time { return day.AddDate(0, 0, 1).Add(3 * time.Hour) }
+
+	// August is not over, so only the daily digest is written
+	written := scheduler.RunOnce(context.Background())
+	if len(written) != 1 || written[0].Period != models.DigestDaily || !written[0].From.Equal(day) {
+		t.Fatalf("Expected the previous day's digest, got %v", written)
+	}
+
+	if written := scheduler.RunOnce(context.Background()); len(written) != 0 || len(summarizer.requests) != 1 {
+		t.Errorf("Expected written periods to be skipped, got %v", written)
+	}
+
+	scheduler.now = func() time.Time { return time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC) }
+	written = scheduler.RunOnce(context.Background())
+	if len(written) != 1 || written[0].Period != models.DigestMonthly {
+		t.Errorf("Expected August's digest, got %v", written)
+	}
+}
+
+func TestLastComplete(t *testing.T) {
+	now := time.Date(2025, 8, 6, 10, 0, 0, 0, time.UTC) // A Wednesday
+	for period, expected := range map[models.DigestPeriod]time.Time{
+		models.DigestDaily:   day,
+		models.DigestWeekly:  time.Date(2025, 7, 28, 0, 0, 0, 0, time.UTC),
+		models.DigestMonthly: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got, err := LastComplete(period, now); err != nil || !got.Equal(expected) {
+			t.Errorf("LastComplete(%s) = %s (%v), expected %s", period, got, err, expected)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// defaultCheckInterval is how often the scheduler looks for complete periods
+const defaultCheckInterval = time.Hour
+
+// LastComplete returns the start of the most recent period of the given
+// kind that ended at or before now
+func LastComplete(period models.DigestPeriod, now time.Time) (time.Time, error) {
+	from, _, err := period.Window(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	previous, _, err := period.Window(from.AddDate(0, 0, -1))
+	return previous, err
+}
+
+// Scheduler writes the digest of each scheduled period once it is complete
+type Scheduler struct {
+	builder  *Builder
+	periods  []models.DigestPeriod
+	interval time.Duration
+	logger   *logging.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler for the given periods
+func NewScheduler(builder *Builder, periods []models.DigestPeriod, interval time.Duration, logger *logging.Logger) *Scheduler {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+
+	return &Scheduler{
+		builder:  builder,
+		periods:  periods,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Start checks for complete periods now and then every interval until
+// Shutdown is called
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	s.logger.Info("Digest scheduler started", "periods", s.periods, "interval", s.interval)
+}
+
+// Shutdown stops the scheduler, cancelling a digest being written, and
+// waits for it to return or ctx to expire
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce writes the digest of the last complete period of each scheduled
+// kind that has not been written yet and returns the digests written
+// Periods without journals are skipped; failures are logged and retried on
+// the next run.
+func (s *Scheduler) RunOnce(ctx context.Context) []*models.Digest {
+	var written []*models.Digest
+	for _, period := range s.periods {
+		from, err := LastComplete(period, s.now())
+		if err != nil {
+			s.logger.Error("Invalid digest schedule", "period", period, "error", err)
+			continue
+		}
+		if _, ok := s.builder.digests.Find(period, from); ok {
+			continue
+		}
+
+		digest, err := s.builder.Build(ctx, period, from)
+		switch {
+		case errors.Is(err, ErrNoJournals):
+			s.logger.Debug("No journals for scheduled digest", "period", period, "from", from)
+		case err != nil:
+			if ctx.Err() == nil {
+				s.logger.Warn("Scheduled digest failed", "period", period, "from", from, "error", err)
+			}
+		default:
+			written = append(written, digest)
+		}
+	}
+	return written
+}
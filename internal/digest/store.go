@@ -0,0 +1,161 @@
+package digest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// ErrNotFound is returned when a digest does not exist
+var ErrNotFound = errors.New("digest not found")
+
+// Store keeps digests in memory and, when given a path, in a JSON file that
+// is replaced atomically on every change
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	digests map[string]*models.Digest
+}
+
+// NewStore opens the digest store at path, creating its directory
+// An empty path keeps digests in memory only.
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, digests: make(map[string]*models.Digest)}
+	if path == "" {
+		return store, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create digest directory: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read digests: %w", err)
+	default:
+		var digests []*models.Digest
+		if err := json.Unmarshal(data, &digests); err != nil {
+			return nil, fmt.Errorf("failed to decode digests %s: %w", path, err)
+		}
+		for _, digest := range digests {
+			store.digests[digest.ID] = digest
+		}
+	}
+
+	return store, nil
+}
+
+// Put stores a digest, replacing one with the same ID
+func (s *Store) Put(digest *models.Digest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.digests[digest.ID]
+	s.digests[digest.ID] = digest
+	if err := s.save(); err != nil {
+		if existed {
+			s.digests[digest.ID] = previous
+		} else {
+			delete(s.digests, digest.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Get returns the digest with the given ID
+func (s *Store) Get(id string) (*models.Digest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	digest, ok := s.digests[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return digest, nil
+}
+
+// Find returns the digest of the period starting at from
+func (s *Store) Find(period models.DigestPeriod, from time.Time) (*models.Digest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, digest := range s.digests {
+		if digest.Period == period && digest.From.Equal(from) {
+			return digest, true
+		}
+	}
+	return nil, false
+}
+
+// List returns the digests of a period, or of every period when period is
+// empty, most recent period first
+func (s *Store) List(period models.DigestPeriod) []*models.Digest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	digests := make([]*models.Digest, 0, len(s.digests))
+	for _, digest := range s.digests {
+		if period == "" || digest.Period == period {
+			digests = append(digests, digest)
+		}
+	}
+	slices.SortFunc(digests, func(a, b *models.Digest) int {
+		if c := b.From.Compare(a.From); c != 0 {
+			return c
+		}
+		return b.To.Compare(a.To)
+	})
+
+	return digests
+}
+
+// save writes the digests to disk, syncing before replacing the previous file
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	digests := make([]*models.Digest, 0, len(s.digests))
+	for _, digest := range s.digests {
+		digests = append(digests, digest)
+	}
+	slices.SortFunc(digests, func(a, b *models.Digest) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	data, err := json.Marshal(digests)
+	if err != nil {
+		return fmt.Errorf("failed to encode digests: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write digests: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write digests: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync digests: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write digests: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace digests: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/worker"
+)
+
+// AdminHandler handles operational endpoints under /admin
+type AdminHandler struct {
+	pool   *worker.Pool
+	logger *logging.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(pool *worker.Pool, logger *logging.Logger) *AdminHandler {
+	return &AdminHandler{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface for admin endpoints
+//
+//	GET    /admin/dlq                    list dead-lettered jobs
+//	POST   /admin/dlq/retry              retry every dead-lettered job
+//	POST   /admin/dlq/{journal_id}/retry retry one job
+//	DELETE /admin/dlq                    purge every dead-lettered job
+//	DELETE /admin/dlq/{journal_id}       purge one job
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin"), "/")
+	resource, rest, _ := strings.Cut(path, "/")
+	if resource != "dlq" {
+		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	id, action, _ := strings.Cut(rest, "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		h.listDeadLetters(w, r)
+	case id == "" && r.Method == http.MethodDelete:
+		h.purgeDeadLetters(w, r)
+	case id == "retry" && action == "" && r.Method == http.MethodPost:
+		h.retryDeadLetters(w, r)
+	case id != "" && action == "retry" && r.Method == http.MethodPost:
+		h.retryDeadLetter(w, r, id)
+	case id != "" && action == "" && r.Method == http.MethodDelete:
+		h.purgeDeadLetter(w, r, id)
+	case action == "" || action == "retry":
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listDeadLetters handles GET /admin/dlq
+func (h *AdminHandler) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	jobs := h.pool.DeadLetters()
+
+	response := map[string]any{
+		"dead_letters": jobs,
+		"count":        len(jobs),
+		"queue":        h.pool.Stats(),
+		"retrieved_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// retryDeadLetter handles POST /admin/dlq/{journal_id}/retry
+func (h *AdminHandler) retryDeadLetter(w http.ResponseWriter, r *http.Request, journalID string) {
+	if err := h.pool.RetryDeadLetter(journalID); err != nil {
+		h.sendPoolError(w, r, "retry_dead_letter", journalID, err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Dead-lettered job requeued", "journal_id", journalID)
+
+	job, _ := h.pool.Status(journalID)
+	h.sendJSONResponse(w, job, http.StatusAccepted)
+}
+
+// retryDeadLetters handles POST /admin/dlq/retry
+func (h *AdminHandler) retryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	retried, err := h.pool.RetryDeadLetters()
+	if err != nil {
+		h.sendPoolError(w, r, "retry_dead_letters", "", err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Dead-lettered jobs requeued", "count", retried)
+
+	h.sendJSONResponse(w, map[string]any{"retried": retried}, http.StatusAccepted)
+}
+
+// purgeDeadLetter handles DELETE /admin/dlq/{journal_id}
+func (h *AdminHandler) purgeDeadLetter(w http.ResponseWriter, r *http.Request, journalID string) {
+	if err := h.pool.PurgeDeadLetter(journalID); err != nil {
+		h.sendPoolError(w, r, "purge_dead_letter", journalID, err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Dead-lettered job purged", "journal_id", journalID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purgeDeadLetters handles DELETE /admin/dlq
+func (h *AdminHandler) purgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	purged, err := h.pool.PurgeDeadLetters()
+	if err != nil {
+		h.sendPoolError(w, r, "purge_dead_letters", "", err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Dead-lettered jobs purged", "count", purged)
+
+	h.sendJSONResponse(w, map[string]any{"purged": purged}, http.StatusOK)
+}
+
+// sendPoolError maps worker pool errors to responses
+func (h *AdminHandler) sendPoolError(w http.ResponseWriter, r *http.Request, operation, journalID string, err error) {
+	switch {
+	case errors.Is(err, worker.ErrJobNotFound):
+		h.sendErrorResponse(w, "Dead-lettered job not found", http.StatusNotFound)
+	case errors.Is(err, worker.ErrPoolClosed):
+		h.sendErrorResponse(w, "AI processing is shutting down", http.StatusServiceUnavailable)
+	default:
+		h.logger.WithContext(r.Context()).Error("Dead-letter operation failed",
+			"operation", operation,
+			"journal_id", journalID,
+			"error", err)
+		h.sendErrorResponse(w, "Failed to update the job queue", http.StatusInternalServerError)
+	}
+}
+
+// sendJSONResponse sends a JSON response with the given data and status code
+func (h *AdminHandler) sendJSONResponse(w http.ResponseWriter, data any, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// sendErrorResponse sends an error response with the given message and status code
+func (h *AdminHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	response := map[string]any{
+		"status":    statusCode,
+		"error":     message,
+		"timestamp": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, statusCode)
+}
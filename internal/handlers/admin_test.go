@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/handlers"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/worker"
+)
+
+// switchableAIProcessor fails with an invalid response until it is fixed
+type switchableAIProcessor struct {
+	fixed chan struct{}
+}
+
+func (s *switchableAIProcessor) ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+	select {
+	case <-s.fixed:
+		return &models.SentimentResult{Score: 0.2, Label: "neutral", Confidence: 0.6}, nil
+	default:
+		return nil, errors.Join(models.ErrInvalidAIResponse, errors.New("model answered in prose"))
+	}
+}
+
+func TestAdminHandler_DeadLetters(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := &switchableAIProcessor{fixed: make(chan struct{})}
+	pool, err := worker.NewPool(worker.NewInMemoryWorker(processor, Logger()), store, worker.PoolConfig{
+		Workers:          1,
+		QueueSize:        5,
+		MaxParseAttempts: 1,
+		BaseBackoff:      time.Millisecond,
+	}, Logger())
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	defer pool.Shutdown(context.Background())
+	handler := handlers.NewAdminHandler(pool, Logger())
+
+	do := func(t *testing.T, method, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, id := range []string{"first", "second", "third"} {
+		if err := store.Store(&models.Journal{ID: id, Content: "Journal the model cannot analyze"}); err != nil {
+			t.Fatalf("Failed to seed journal: %v", err)
+		}
+		if err := pool.Submit(id); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(pool.DeadLetters()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for jobs to be dead-lettered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Run("List", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/admin/dlq")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response struct {
+			DeadLetters []worker.JobStatus `json:"dead_letters"`
+			Count       int                `json:"count"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Count != 3 || response.DeadLetters[0].LastFailure != worker.FailureInvalidResponse {
+			t.Errorf("Unexpected dead letters: %+v", response)
+		}
+	})
+
+	t.Run("PurgeOne", func(t *testing.T) {
+		if w := do(t, http.MethodDelete, "/admin/dlq/first"); w.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", w.Code)
+		}
+		if w := do(t, http.MethodDelete, "/admin/dlq/first"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("RetryOne", func(t *testing.T) {
+		close(processor.fixed)
+
+		if w := do(t, http.MethodPost, "/admin/dlq/second/retry"); w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			journal, _ := store.Get("second")
+			if journal.ProcessingResult != nil && journal.ProcessingResult.Status == models.ProcessingStatusCompleted {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out waiting for retried job")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("PurgeAll", func(t *testing.T) {
+		w := do(t, http.MethodDelete, "/admin/dlq")
+		var response map[string]int
+		json.NewDecoder(w.Body).Decode(&response)
+		if w.Code != http.StatusOK || response["purged"] != 1 {
+			t.Errorf("Expected one purged job, got %d %v", w.Code, response)
+		}
+		if w := do(t, http.MethodPost, "/admin/dlq/retry"); w.Code != http.StatusAccepted {
+			t.Errorf("Expected status 202, got %d", w.Code)
+		}
+	})
+
+	t.Run("Routing", func(t *testing.T) {
+		if w := do(t, http.MethodPut, "/admin/dlq"); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+		if w := do(t, http.MethodGet, "/admin/unknown"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/ai/prompt"
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/models"
 	"github.com/garnizeh/englog/internal/storage"
@@ -14,13 +17,13 @@ import (
 
 // AIHandler handles AI-related requests
 type AIHandler struct {
-	store     *storage.MemoryStore
+	store     storage.JournalStore
 	aiService ai.AIService
 	logger    *logging.Logger
 }
 
 // NewAIHandler creates a new AI handler
-func NewAIHandler(store *storage.MemoryStore, aiService ai.AIService, logger *logging.Logger) *AIHandler {
+func NewAIHandler(store storage.JournalStore, aiService ai.AIService, logger *logging.Logger) *AIHandler {
 	return &AIHandler{
 		aiService: aiService,
 		store:     store,
@@ -37,6 +40,8 @@ func (h *AIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleGenerateJournal(w, r)
 	case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/health"):
 		h.handleAIHealth(w, r)
+	case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/prompts"):
+		h.handleListPrompts(w, r)
 	default:
 		h.writeErrorJSON(w, "Method not allowed or endpoint not found", http.StatusMethodNotAllowed)
 	}
@@ -82,6 +87,7 @@ func (h *AIHandler) handleAnalyzeSentiment(w http.ResponseWriter, r *http.Reques
 
 	var journalID string
 	var content string
+	promptTemplate := r.URL.Query().Get("prompt_template")
 
 	// Try to get journal_id from query parameters first
 	if id := r.URL.Query().Get("journal_id"); id != "" {
@@ -89,8 +95,9 @@ func (h *AIHandler) handleAnalyzeSentiment(w http.ResponseWriter, r *http.Reques
 	} else {
 		// Parse request body if no query parameter
 		var req struct {
-			JournalID string `json:"journal_id,omitempty"`
-			Content   string `json:"content,omitempty"`
+			JournalID      string `json:"journal_id,omitempty"`
+			Content        string `json:"content,omitempty"`
+			PromptTemplate string `json:"prompt_template,omitempty"`
 		}
 
 		if r.ContentLength > 0 {
@@ -101,6 +108,9 @@ func (h *AIHandler) handleAnalyzeSentiment(w http.ResponseWriter, r *http.Reques
 			}
 			journalID = req.JournalID
 			content = req.Content
+			if req.PromptTemplate != "" {
+				promptTemplate = req.PromptTemplate
+			}
 		}
 	}
 
@@ -139,8 +149,17 @@ func (h *AIHandler) handleAnalyzeSentiment(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Analyze sentiment
-	result, err := h.aiService.ProcessJournalSentiment(r.Context(), journal)
+	// Analyze sentiment, with the requested prompt template if any
+	ctx := r.Context()
+	if promptTemplate != "" {
+		ctx = prompt.WithRef(ctx, promptTemplate)
+	}
+
+	result, err := h.aiService.ProcessJournalSentiment(ctx, journal)
+	if errors.Is(err, prompt.ErrUnknownTemplate) {
+		h.writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		fmt.Printf("Sentiment analysis failed: %v\n", err)
 		h.writeErrorJSON(w, fmt.Sprintf("Sentiment analysis failed: %v", err), http.StatusInternalServerError)
@@ -185,10 +204,25 @@ func (h *AIHandler) handleGenerateJournal(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if wantsEventStream(r) {
+		h.streamGenerateJournal(w, r, &req)
+		return
+	}
+
 	ctx := r.Context()
 
 	// Generate journal
 	result, err := h.aiService.GenerateStructuredJournal(ctx, &req)
+	if errors.Is(err, prompt.ErrUnknownTemplate) {
+		h.writeValidationErrorJSON(w, []models.ValidationError{
+			{
+				Field:   "prompt_template",
+				Message: err.Error(),
+				Code:    "UNKNOWN_PROMPT_TEMPLATE",
+			},
+		})
+		return
+	}
 	if err != nil {
 		fmt.Printf("Journal generation failed: %v\n", err)
 		h.writeErrorJSON(w, fmt.Sprintf("Journal generation failed: %v", err), http.StatusInternalServerError)
@@ -249,3 +283,33 @@ func (h *AIHandler) handleAIHealth(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("AI health check completed: %s\n", status)
 }
+
+// promptLister is implemented by AI services that expose their prompt templates
+type promptLister interface {
+	PromptTemplates() []prompt.Info
+}
+
+// handleListPrompts lists the prompt templates and which one each task uses
+// by default
+func (h *AIHandler) handleListPrompts(w http.ResponseWriter, r *http.Request) {
+	lister, ok := h.aiService.(promptLister)
+	if !ok {
+		h.writeErrorJSON(w, "Prompt templates are not available", http.StatusNotImplemented)
+		return
+	}
+
+	templates := lister.PromptTemplates()
+	defaults := make(map[prompt.Task]string)
+	for _, t := range templates {
+		if t.Default {
+			defaults[t.Task] = t.Ref
+		}
+	}
+
+	h.writeSuccessJSON(w, map[string]any{
+		"templates": templates,
+		"defaults":  defaults,
+		"count":     len(templates),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
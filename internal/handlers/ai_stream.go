@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/parse"
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// ErrServerShutdown is the cancellation cause of requests still running when
+// the server shuts down; event streams end with an error event carrying it
+var ErrServerShutdown = errors.New("server is shutting down")
+
+// journalStreamer is implemented by AI services that can stream journal
+// generation as the model produces it
+type journalStreamer interface {
+	GenerateStructuredJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error)
+}
+
+// streamError is the data of the "error" event that ends a failed stream
+type streamError struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	Reason    string `json:"reason,omitempty"`
+	Segment   string `json:"segment,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// wantsEventStream reports whether the client asked for Server-Sent Events
+func wantsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// streamGenerateJournal generates a journal and sends it as Server-Sent Events
+//
+// "token" events carry text as the model produces it. The stream ends with a
+// "result" event holding the same body as the JSON response, or an "error"
+// event. Services that cannot stream send the result alone. The model call
+// runs on the request context, so it is cancelled when the client goes away
+// or the server shuts down.
+func (h *AIHandler) streamGenerateJournal(w http.ResponseWriter, r *http.Request, req *models.PromptRequest) {
+	rc := http.NewResponseController(w)
+
+	// Generation can outlast the server's WriteTimeout; only the client ends a stream
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("failed to clear write deadline for event stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	send := func(event string, data any) {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			h.logger.Error("failed to encode event", "event", event, "error", err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		rc.Flush()
+	}
+
+	tokens := 0
+	onToken := func(token string) {
+		tokens++
+		send("token", map[string]string{"text": token})
+	}
+
+	var result *models.GeneratedJournal
+	var err error
+	if streamer, ok := h.aiService.(journalStreamer); ok {
+		result, err = streamer.GenerateStructuredJournalStream(r.Context(), req, onToken)
+	} else {
+		result, err = h.aiService.GenerateStructuredJournal(r.Context(), req)
+	}
+
+	if errors.Is(context.Cause(r.Context()), ErrServerShutdown) {
+		h.logger.Info("journal stream ended by server shutdown", "tokens", tokens)
+		send("error", newStreamError(ErrServerShutdown))
+		return
+	}
+	if r.Context().Err() != nil {
+		h.logger.Info("journal stream closed by client", "tokens", tokens)
+		return
+	}
+	if err != nil {
+		h.logger.Error("streamed journal generation failed", "tokens", tokens, "error", err)
+		send("error", newStreamError(err))
+		return
+	}
+
+	h.logger.Info("journal stream completed", "tokens", tokens)
+	send("result", map[string]any{
+		"generated_journal": result,
+		"original_prompt":   req.Prompt,
+		"timestamp":         time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// newStreamError describes err for an "error" event; unusable model output
+// carries the parser's reason and the offending segment
+func newStreamError(err error) streamError {
+	event := streamError{
+		Error:     err.Error(),
+		Code:      "GENERATION_FAILED",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var parseErr *parse.Error
+	switch {
+	case errors.Is(err, ErrServerShutdown):
+		event.Code = "SERVER_SHUTTING_DOWN"
+	case errors.Is(err, prompt.ErrUnknownTemplate):
+		event.Code = "UNKNOWN_PROMPT_TEMPLATE"
+	case errors.As(err, &parseErr):
+		event.Code = "INVALID_AI_RESPONSE"
+		event.Reason = parseErr.Reason
+		event.Segment = parseErr.Segment
+	case errors.Is(err, models.ErrInvalidAIResponse):
+		event.Code = "INVALID_AI_RESPONSE"
+	}
+
+	return event
+}
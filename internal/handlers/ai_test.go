@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +12,9 @@ import (
 	"time"
 
 	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/ai/options"
+	"github.com/garnizeh/englog/internal/ai/parse"
+	"github.com/garnizeh/englog/internal/ai/prompt"
 	"github.com/garnizeh/englog/internal/handlers"
 	"github.com/garnizeh/englog/internal/models"
 	"github.com/garnizeh/englog/internal/storage"
@@ -26,7 +30,7 @@ func TestAIHandler_ServeHTTP_AnalyzeSentiment(t *testing.T) {
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		t.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -149,7 +153,7 @@ func TestAIHandler_ServeHTTP_GenerateJournal(t *testing.T) {
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		t.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -264,7 +268,7 @@ func TestAIHandler_ServeHTTP_Health(t *testing.T) {
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		t.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -353,7 +357,7 @@ func TestAIHandler_ServeHTTP_UnknownRoutes(t *testing.T) {
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		t.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -419,7 +423,7 @@ func TestAIHandler_ServeHTTP_MalformedJSON(t *testing.T) {
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		t.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -481,13 +485,306 @@ func TestAIHandler_ServeHTTP_MalformedJSON(t *testing.T) {
 	}
 }
 
+// TestAIHandler_ServeHTTP_PromptTemplates tests listing and selecting prompt templates
+func TestAIHandler_ServeHTTP_PromptTemplates(t *testing.T) {
+	var used string
+	provider := ai.NewMockAIProvider()
+	provider.ProcessJournalSentimentFunc = func(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+		used = prompt.FromContext(ctx).Ref().String()
+		return &models.SentimentResult{Score: 0.5, Label: "positive", Confidence: 0.8}, nil
+	}
+	handler := handlers.NewAIHandler(storage.NewMemoryStore(), ai.NewService(provider, Logger()), Logger())
+
+	t.Run("list templates", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/ai/prompts", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Templates []prompt.Info     `json:"templates"`
+			Defaults  map[string]string `json:"defaults"`
+			Count     int               `json:"count"`
+			Timestamp time.Time         `json:"timestamp"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if time.Since(response.Timestamp) > time.Minute {
+			t.Errorf("Expected the current time, got %s", response.Timestamp)
+		}
+		if response.Count != len(response.Templates) || response.Count < 4 {
+			t.Errorf("Expected the built-in templates, got %d", response.Count)
+		}
+		if response.Defaults["sentiment"] != "sentiment@v2" || response.Defaults["generation"] != "generation@v2" {
+			t.Errorf("Unexpected defaults %v", response.Defaults)
+		}
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedPrompt string
+	}{
+		{"default template", "/ai/analyze-sentiment", `{"content": "A fine day"}`, http.StatusOK, "sentiment@v2"},
+		{"template via query param", "/ai/analyze-sentiment?prompt_template=sentiment@v1", `{"content": "A fine day"}`, http.StatusOK, "sentiment@v1"},
+		{"template via body", "/ai/analyze-sentiment", `{"content": "A fine day", "prompt_template": "sentiment@v1"}`, http.StatusOK, "sentiment@v1"},
+		{"unknown template", "/ai/analyze-sentiment?prompt_template=sentiment@v9", `{"content": "A fine day"}`, http.StatusBadRequest, ""},
+		{"template for another task", "/ai/analyze-sentiment?prompt_template=generation", `{"content": "A fine day"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used = ""
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if used != tt.expectedPrompt {
+				t.Errorf("Expected template %q to be rendered, got %q", tt.expectedPrompt, used)
+			}
+		})
+	}
+
+	t.Run("unknown generation template", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := `{"prompt": "Write about a quiet morning walk", "prompt_template": "missing"}`
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/ai/generate-journal", strings.NewReader(body)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "UNKNOWN_PROMPT_TEMPLATE") {
+			t.Errorf("Expected a prompt_template validation error, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+// TestAIHandler_ServeHTTP_GenerationOptions tests per-request generation options
+func TestAIHandler_ServeHTTP_GenerationOptions(t *testing.T) {
+	var used models.GenerationOptions
+	provider := ai.NewMockAIProvider()
+	provider.GenerateStructuredJournalFunc = func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+		used = options.Select(ctx, prompt.TaskGeneration)
+		return &models.GeneratedJournal{Content: "A quiet walk.", Options: &used}, nil
+	}
+
+	temperature := 0.4
+	service := ai.NewService(provider, Logger())
+	service.SetGenerationOptions(options.Config{
+		prompt.TaskGeneration: {Temperature: &temperature},
+	})
+	handler := handlers.NewAIHandler(storage.NewMemoryStore(), service, Logger())
+
+	t.Run("metadata overrides task options", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := `{"prompt": "Write about a quiet morning walk", "metadata": {"length": "short", "seed": 11, "num_ctx": 2048}}`
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/ai/generate-journal", strings.NewReader(body)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if used.Temperature == nil || *used.Temperature != 0.4 || *used.Seed != 11 || *used.NumCtx != 2048 {
+			t.Errorf("Expected task options merged with the request, got %+v", used)
+		}
+		if !strings.Contains(w.Body.String(), `"options":{"temperature":0.4,"seed":11,"num_ctx":2048}`) {
+			t.Errorf("Expected the options in the response, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := `{"prompt": "Write about a quiet morning walk", "metadata": {"top_p": 1.5}}`
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/ai/generate-journal", strings.NewReader(body)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "metadata.top_p") {
+			t.Errorf("Expected a metadata.top_p validation error, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+// streamingProvider is a mock provider that streams tokens before answering
+type streamingProvider struct {
+	*ai.MockAIProvider
+	tokens []string
+	err    error
+	cancel func() // simulates the request ending after the first token
+}
+
+func (p *streamingProvider) GenerateJournalStream(ctx context.Context, req *models.PromptRequest, onToken func(string)) (*models.GeneratedJournal, error) {
+	for _, token := range p.tokens {
+		onToken(token)
+		if p.cancel != nil {
+			p.cancel()
+			return nil, ctx.Err()
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.GenerateJournal(ctx, req)
+}
+
+// sseEvent is one Server-Sent Event read back from a response
+type sseEvent struct {
+	name string
+	data map[string]any
+}
+
+func readEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		if block == "" {
+			continue
+		}
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				event.name = name
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				if err := json.Unmarshal([]byte(data), &event.data); err != nil {
+					t.Fatalf("Invalid event data %q: %v", data, err)
+				}
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// TestAIHandler_ServeHTTP_GenerateJournalStream tests journal generation over Server-Sent Events
+func TestAIHandler_ServeHTTP_GenerateJournalStream(t *testing.T) {
+	const body = `{"prompt": "Write about a quiet morning walk"}`
+
+	stream := func(t *testing.T, service ai.AIService, ctx context.Context) *httptest.ResponseRecorder {
+		t.Helper()
+		handler := handlers.NewAIHandler(storage.NewMemoryStore(), service, Logger())
+		req := httptest.NewRequestWithContext(ctx, "POST", "/ai/generate-journal", strings.NewReader(body))
+		req.Header.Set("Accept", "text/event-stream")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("streams tokens then the result", func(t *testing.T) {
+		provider := &streamingProvider{MockAIProvider: ai.NewMockAIProvider(), tokens: []string{`{"content": "This is`, ` a mock"`}}
+		w := stream(t, ai.NewService(provider, Logger()), context.Background())
+
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("Expected an event stream, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if !w.Flushed {
+			t.Error("Expected events to be flushed")
+		}
+
+		events := readEvents(t, w.Body.String())
+		if len(events) != 3 || events[0].name != "token" || events[1].name != "token" || events[2].name != "result" {
+			t.Fatalf("Expected two tokens and a result, got %+v", events)
+		}
+		if events[1].data["text"] != ` a mock"` {
+			t.Errorf("Unexpected token %v", events[1].data)
+		}
+		journal, _ := events[2].data["generated_journal"].(map[string]any)
+		if !strings.HasPrefix(fmt.Sprint(journal["content"]), "This is a mock") || events[2].data["original_prompt"] == nil {
+			t.Errorf("Expected the generated journal in the result event, got %v", events[2].data)
+		}
+		if sent, err := time.Parse(time.RFC3339, fmt.Sprint(events[2].data["timestamp"])); err != nil || time.Since(sent) > time.Minute {
+			t.Errorf("Expected the current time in the result event, got %v", events[2].data["timestamp"])
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		provider := &streamingProvider{
+			MockAIProvider: ai.NewMockAIProvider(),
+			tokens:         []string{`{"content": ""}`},
+			err:            &parse.Error{Reason: "invalid generated journal: content is empty", Segment: `{"content": ""}`, Offset: -1},
+		}
+		w := stream(t, ai.NewService(provider, Logger()), context.Background())
+
+		events := readEvents(t, w.Body.String())
+		last := events[len(events)-1]
+		if last.name != "error" || last.data["code"] != "INVALID_AI_RESPONSE" {
+			t.Fatalf("Expected an INVALID_AI_RESPONSE error event, got %+v", last)
+		}
+		if last.data["reason"] != "invalid generated journal: content is empty" || last.data["segment"] != `{"content": ""}` {
+			t.Errorf("Expected the parse error details, got %v", last.data)
+		}
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		handler := handlers.NewAIHandler(storage.NewMemoryStore(), ai.NewService(ai.NewMockAIProvider(), Logger()), Logger())
+		req := httptest.NewRequest("POST", "/ai/generate-journal", strings.NewReader(`{"prompt": "Write about a walk", "prompt_template": "missing"}`))
+		req.Header.Set("Accept", "text/event-stream")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		events := readEvents(t, w.Body.String())
+		if len(events) != 1 || events[0].data["code"] != "UNKNOWN_PROMPT_TEMPLATE" {
+			t.Errorf("Expected an UNKNOWN_PROMPT_TEMPLATE error event, got %+v", events)
+		}
+	})
+
+	t.Run("provider without streaming", func(t *testing.T) {
+		w := stream(t, ai.NewService(ai.NewMockAIProvider(), Logger()), context.Background())
+
+		events := readEvents(t, w.Body.String())
+		if len(events) != 1 || events[0].name != "result" {
+			t.Errorf("Expected the result alone, got %+v", events)
+		}
+	})
+
+	t.Run("client disconnects", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		provider := &streamingProvider{MockAIProvider: ai.NewMockAIProvider(), tokens: []string{"{"}, cancel: cancel}
+		w := stream(t, ai.NewService(provider, Logger()), ctx)
+
+		events := readEvents(t, w.Body.String())
+		if len(events) != 1 || events[0].name != "token" {
+			t.Errorf("Expected the stream to end without a result, got %+v", events)
+		}
+	})
+
+	t.Run("server shuts down", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+
+		shutdown := func() { cancel(handlers.ErrServerShutdown) }
+		provider := &streamingProvider{MockAIProvider: ai.NewMockAIProvider(), tokens: []string{"{"}, cancel: shutdown}
+		w := stream(t, ai.NewService(provider, Logger()), ctx)
+
+		events := readEvents(t, w.Body.String())
+		if len(events) != 2 || events[1].name != "error" || events[1].data["code"] != "SERVER_SHUTTING_DOWN" {
+			t.Errorf("Expected the stream to end with a shutdown error event, got %+v", events)
+		}
+	})
+
+	t.Run("validation errors stay JSON", func(t *testing.T) {
+		handler := handlers.NewAIHandler(storage.NewMemoryStore(), ai.NewService(ai.NewMockAIProvider(), Logger()), Logger())
+		req := httptest.NewRequest("POST", "/ai/generate-journal", strings.NewReader(`{"prompt": ""}`))
+		req.Header.Set("Accept", "text/event-stream")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+			t.Errorf("Expected a JSON validation error, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+	})
+}
+
 // BenchmarkAIHandler_ServeHTTP_AnalyzeSentiment benchmarks the analyze sentiment endpoint
 func BenchmarkAIHandler_ServeHTTP_AnalyzeSentiment(b *testing.B) {
 	ctx := context.Background()
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		b.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -523,7 +820,7 @@ func BenchmarkAIHandler_ServeHTTP_Health(b *testing.B) {
 
 	// Setup test dependencies
 	store := storage.NewMemoryStore()
-	aiService, err := ai.NewService(ctx, modelName, "http://localhost:11434", Logger())
+	aiService, err := newOllamaService(ctx, modelName, "http://localhost:11434")
 	if err != nil || aiService == nil {
 		b.Fatalf("Failed to create AI service: %v", err)
 	}
@@ -544,3 +841,12 @@ func BenchmarkAIHandler_ServeHTTP_Health(b *testing.B) {
 		}
 	}
 }
+
+// newOllamaService creates an AI service backed by the registered Ollama provider
+func newOllamaService(ctx context.Context, model, baseURL string) (*ai.Service, error) {
+	provider, err := ai.DefaultRegistry.New(ctx, "ollama", ai.ProviderConfig{Model: model, BaseURL: baseURL}, Logger())
+	if err != nil {
+		return nil, err
+	}
+	return ai.NewService(provider, Logger()), nil
+}
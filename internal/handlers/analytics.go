@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/analytics"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+const (
+	defaultInsightLimit  = 20
+	maxInsightLimit      = 100
+	maxInsightMinSamples = 1000
+)
+
+// AnalyticsHandler handles journal statistics endpoints under /analytics
+type AnalyticsHandler struct {
+	store  storage.JournalStore
+	logger *logging.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(store storage.JournalStore, logger *logging.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface for analytics endpoints
+//
+//	GET /analytics/mood       time-bucketed sentiment and mood series
+//	GET /analytics/insights   factors associated with higher or lower sentiment
+func (h *AnalyticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/analytics"), "/")
+
+	switch {
+	case path == "mood" && r.Method == http.MethodGet:
+		h.moodTrends(w, r)
+	case path == "insights" && r.Method == http.MethodGet:
+		h.insights(w, r)
+	case path == "mood" || path == "insights":
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// moodTrends handles GET /analytics/mood
+func (h *AnalyticsHandler) moodTrends(w http.ResponseWriter, r *http.Request) {
+	query, validationErrors := parseMoodQuery(r.URL.Query())
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("mood_trends", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	series, err := analytics.Mood(h.store, query)
+	if errors.Is(err, analytics.ErrTooManyBuckets) {
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "bucket",
+				Message: fmt.Sprintf("The range spans more than %d buckets; narrow from and to or use a longer bucket", analytics.MaxBuckets),
+				Code:    "INVALID_QUERY_PARAM",
+			},
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(r.Context()).Error("Mood analytics failed", "error", err)
+		h.sendErrorResponse(w, "Failed to compute mood analytics", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Computed mood analytics",
+		"bucket", series.Bucket,
+		"timezone", series.Timezone,
+		"buckets", len(series.Buckets),
+		"journals", series.Totals.Journals)
+
+	response := map[string]any{
+		"bucket":      series.Bucket,
+		"timezone":    series.Timezone,
+		"window":      series.Window,
+		"series":      series.Buckets,
+		"count":       len(series.Buckets),
+		"totals":      series.Totals,
+		"computed_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// insights handles GET /analytics/insights
+func (h *AnalyticsHandler) insights(w http.ResponseWriter, r *http.Request) {
+	query, limit, validationErrors := parseInsightQuery(r.URL.Query())
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("insights", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	report, err := analytics.Insights(h.store, query)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Error("Insight analytics failed", "error", err)
+		h.sendErrorResponse(w, "Failed to compute insights", http.StatusInternalServerError)
+		return
+	}
+
+	found := len(report.Insights)
+	insights := report.Insights[:min(found, limit)]
+
+	h.logger.WithContext(r.Context()).Info("Computed insights",
+		"timezone", report.Timezone,
+		"analyzed", report.Analyzed,
+		"found", found)
+
+	response := map[string]any{
+		"timezone":    report.Timezone,
+		"analyzed":    report.Analyzed,
+		"min_samples": report.MinSamples,
+		"insights":    insights,
+		"count":       len(insights),
+		"total":       found,
+		"computed_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// parseAnalyticsRange parses the tz, from and to parameters shared by the
+// analytics endpoints
+// Dates without a time are midnight in the requested time zone.
+func parseAnalyticsRange(values url.Values, invalid func(field, message string)) (loc *time.Location, from, to *time.Time) {
+	loc = time.UTC
+	if raw := values.Get("tz"); raw != "" {
+		parsed, err := time.LoadLocation(raw)
+		if err != nil {
+			invalid("tz", "tz must be an IANA time zone such as America/Sao_Paulo")
+		} else {
+			loc = parsed
+		}
+	}
+
+	parseTime := func(name string) *time.Time {
+		raw := values.Get(name)
+		if raw == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			t, err = time.ParseInLocation(time.DateOnly, raw, loc)
+		}
+		if err != nil {
+			invalid(name, name+" must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return nil
+		}
+		return &t
+	}
+	from = parseTime("from")
+	to = parseTime("to")
+	if from != nil && to != nil && !from.Before(*to) {
+		invalid("from", "from must be before to")
+	}
+
+	return loc, from, to
+}
+
+// parseMoodQuery builds a mood query from GET /analytics/mood parameters
+func parseMoodQuery(values url.Values) (analytics.MoodQuery, models.ValidationErrors) {
+	query := analytics.MoodQuery{Bucket: analytics.BucketDay}
+	var errors models.ValidationErrors
+
+	invalid := func(field, message string) {
+		errors = append(errors, models.ValidationError{
+			Field:   field,
+			Message: message,
+			Code:    "INVALID_QUERY_PARAM",
+		})
+	}
+
+	if raw := strings.ToLower(values.Get("bucket")); raw != "" {
+		if slices.Contains(analytics.Buckets, analytics.Bucket(raw)) {
+			query.Bucket = analytics.Bucket(raw)
+		} else {
+			invalid("bucket", "bucket must be one of day, week, month")
+		}
+	}
+
+	query.Location, query.From, query.To = parseAnalyticsRange(values, invalid)
+
+	if raw := values.Get("window"); raw != "" {
+		window, err := strconv.Atoi(raw)
+		if err != nil || window < 1 || window > analytics.MaxWindow {
+			invalid("window", fmt.Sprintf("window must be an integer between 1 and %d", analytics.MaxWindow))
+		}
+		query.Window = window
+	}
+
+	return query, errors
+}
+
+// parseInsightQuery builds an insight query and result limit from
+// GET /analytics/insights parameters
+func parseInsightQuery(values url.Values) (analytics.InsightQuery, int, models.ValidationErrors) {
+	query := analytics.InsightQuery{MinSamples: analytics.DefaultMinSamples}
+	limit := defaultInsightLimit
+	var errors models.ValidationErrors
+
+	invalid := func(field, message string) {
+		errors = append(errors, models.ValidationError{
+			Field:   field,
+			Message: message,
+			Code:    "INVALID_QUERY_PARAM",
+		})
+	}
+
+	query.Location, query.From, query.To = parseAnalyticsRange(values, invalid)
+
+	if raw := values.Get("min_samples"); raw != "" {
+		minSamples, err := strconv.Atoi(raw)
+		if err != nil || minSamples < 2 || minSamples > maxInsightMinSamples {
+			invalid("min_samples", fmt.Sprintf("min_samples must be an integer between 2 and %d", maxInsightMinSamples))
+		}
+		query.MinSamples = minSamples
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxInsightLimit {
+			invalid("limit", fmt.Sprintf("limit must be an integer between 1 and %d", maxInsightLimit))
+		}
+		limit = parsed
+	}
+
+	return query, limit, errors
+}
+
+// sendJSONResponse sends a JSON response with the given data and status code
+func (h *AnalyticsHandler) sendJSONResponse(w http.ResponseWriter, data any, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// sendErrorResponse sends an error response with the given message and status code
+func (h *AnalyticsHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	response := map[string]any{
+		"status":    statusCode,
+		"error":     message,
+		"timestamp": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, statusCode)
+}
+
+// sendValidationErrorResponse sends a structured validation error response
+func (h *AnalyticsHandler) sendValidationErrorResponse(w http.ResponseWriter, validationErrors models.ValidationErrors) {
+	response := map[string]any{
+		"error":             "Validation failed",
+		"status":            http.StatusBadRequest,
+		"timestamp":         time.Now().UTC(),
+		"validation_errors": validationErrors,
+	}
+
+	h.sendJSONResponse(w, response, http.StatusBadRequest)
+}
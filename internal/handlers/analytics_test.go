@@ -0,0 +1,181 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/handlers"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+func TestAnalyticsHandler_Mood(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for i, createdAt := range []time.Time{
+		time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC),
+		// Still August 4 in São Paulo (UTC-3)
+		time.Date(2025, 8, 5, 1, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 6, 12, 0, 0, 0, time.UTC),
+	} {
+		journal := &models.Journal{
+			ID:        []string{"first", "second", "third"}[i],
+			Content:   "Journal entry",
+			CreatedAt: createdAt,
+			Metadata:  map[string]any{"mood": float64(6 + i)},
+			ProcessingResult: &models.ProcessingResult{
+				Status:          models.ProcessingStatusCompleted,
+				SentimentResult: &models.SentimentResult{Label: "positive", Score: 0.5, Confidence: 0.8},
+			},
+		}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Failed to seed journal: %v", err)
+		}
+	}
+	handler := handlers.NewAnalyticsHandler(store, Logger())
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	type bucket struct {
+		Start       time.Time `json:"start"`
+		Journals    int       `json:"journals"`
+		AverageMood *float64  `json:"average_mood"`
+	}
+	var response struct {
+		Timezone string   `json:"timezone"`
+		Series   []bucket `json:"series"`
+		Count    int      `json:"count"`
+		Totals   struct {
+			Journals          int      `json:"journals"`
+			WeightedSentiment *float64 `json:"weighted_sentiment"`
+		} `json:"totals"`
+	}
+
+	w := get(t, "/analytics/mood?tz=America/Sao_Paulo&from=2025-08-04&to=2025-08-07")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Timezone != "America/Sao_Paulo" || response.Count != 3 || len(response.Series) != 3 {
+		t.Fatalf("Expected three São Paulo days, got %+v", response)
+	}
+	if first := response.Series[0]; first.Journals != 2 || *first.AverageMood != 6.5 || first.Start.Format(time.RFC3339) != "2025-08-04T00:00:00-03:00" {
+		t.Errorf("Expected both August 4 entries in the first local day, got %+v", first)
+	}
+	if response.Series[1].Journals != 0 || response.Totals.Journals != 3 || *response.Totals.WeightedSentiment != 0.5 {
+		t.Errorf("Unexpected series %+v", response)
+	}
+
+	for _, path := range []string{
+		"/analytics/mood?bucket=year",
+		"/analytics/mood?tz=Mars/Olympus",
+		"/analytics/mood?from=yesterday",
+		"/analytics/mood?from=2025-08-07&to=2025-08-04",
+		"/analytics/mood?window=0",
+		"/analytics/mood?from=2000-01-01&to=2025-01-01",
+	} {
+		if w := get(t, path); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, w.Code)
+		}
+	}
+
+	if w := get(t, "/analytics/unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestAnalyticsHandler_Insights(t *testing.T) {
+	store := storage.NewMemoryStore()
+	start := time.Date(2025, 8, 4, 9, 0, 0, 0, time.UTC)
+	for i := range 12 {
+		score, tags := -0.2+0.05*float64(i%3), []any{"work"}
+		if i%2 == 0 {
+			score, tags = score+0.6, []any{"exercise"}
+		}
+		journal := &models.Journal{
+			ID:        fmt.Sprintf("journal-%d", i),
+			Content:   "Journal entry",
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+			Metadata:  map[string]any{"tags": tags},
+			ProcessingResult: &models.ProcessingResult{
+				Status:          models.ProcessingStatusCompleted,
+				SentimentResult: &models.SentimentResult{Label: "neutral", Score: score, Confidence: 0.8},
+			},
+		}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Failed to seed journal: %v", err)
+		}
+	}
+	handler := handlers.NewAnalyticsHandler(store, Logger())
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var response struct {
+		Analyzed   int `json:"analyzed"`
+		MinSamples int `json:"min_samples"`
+		Insights   []struct {
+			Factor      string  `json:"factor"`
+			Value       string  `json:"value"`
+			Effect      float64 `json:"effect"`
+			Explanation string  `json:"explanation"`
+		} `json:"insights"`
+		Count int `json:"count"`
+		Total int `json:"total"`
+	}
+
+	w := get(t, "/analytics/insights?limit=1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Analyzed != 12 || response.MinSamples != 5 || response.Count != 1 || response.Total < 2 {
+		t.Fatalf("Unexpected response %+v", response)
+	}
+	// Exercise and work split the journals evenly, so they tie on strength and
+	// are ordered by value
+	if top := response.Insights[0]; top.Factor != "tag" || top.Value != "exercise" || top.Effect <= 0 || top.Explanation == "" {
+		t.Errorf("Expected exercise as the strongest insight, got %+v", top)
+	}
+
+	if w := get(t, "/analytics/insights?min_samples=7"); !strings.Contains(w.Body.String(), `"count":0`) {
+		t.Errorf("Expected no insights with seven samples per group, got %s", w.Body.String())
+	}
+
+	for _, path := range []string{
+		"/analytics/insights?min_samples=1",
+		"/analytics/insights?limit=1000",
+		"/analytics/insights?tz=Nowhere",
+		"/analytics/insights?to=soon",
+	} {
+		if w := get(t, path); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, w.Code)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "/analytics/insights", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
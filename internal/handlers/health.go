@@ -17,13 +17,13 @@ var startTime = time.Now() // Application start time
 
 // HealthHandler handles health check and status endpoints
 type HealthHandler struct {
-	store     *storage.MemoryStore
+	store     storage.JournalStore
 	aiService ai.AIService
 	logger    *logging.Logger
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(store *storage.MemoryStore, aiService ai.AIService, logger *logging.Logger) *HealthHandler {
+func NewHealthHandler(store storage.JournalStore, aiService ai.AIService, logger *logging.Logger) *HealthHandler {
 	return &HealthHandler{
 		store:     store,
 		aiService: aiService,
@@ -47,6 +47,8 @@ func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleStatus(w, r)
 	case "status/ollama":
 		h.handleOllamaStatus(w, r)
+	case "status/ai":
+		h.handleAIStatus(w, r)
 	default:
 		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
 	}
@@ -57,14 +59,16 @@ func (h *HealthHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	requestLogger := h.logger.WithContext(r.Context())
 	start := time.Now()
 
+	stats := h.store.GetStats()
+
 	response := map[string]any{
 		"status":    "healthy",
 		"timestamp": time.Now().UTC(),
 		"service":   "englog-api",
 		"version":   "prototype-009",
 		"storage": map[string]any{
-			"type":          "memory",
-			"journal_count": h.store.Count(),
+			"type":          stats.Backend,
+			"journal_count": stats.TotalJournals,
 		},
 		"response_time_ms": time.Since(start).Milliseconds(),
 	}
@@ -106,7 +110,7 @@ func (h *HealthHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
 			"gc_cycles":             memStats.NumGC,
 		},
 		"storage": map[string]any{
-			"type":                   "memory",
+			"type":                   journalStats.Backend,
 			"journal_count":          journalStats.TotalJournals,
 			"processed_count":        journalStats.ProcessedJournals,
 			"avg_processing_time_ms": journalStats.AvgProcessingTimeMS,
@@ -163,6 +167,54 @@ func (h *HealthHandler) handleOllamaStatus(w http.ResponseWriter, r *http.Reques
 	h.sendJSONResponse(w, response, statusCode)
 }
 
+// providerStatusReporter is implemented by AI services that expose their providers
+type providerStatusReporter interface {
+	ProviderStatus() []ai.ProviderStatus
+}
+
+// handleAIStatus reports every AI provider with its circuit breaker state
+// It reads breaker state only and never calls the backends.
+func (h *HealthHandler) handleAIStatus(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.aiService.(providerStatusReporter)
+	if !ok {
+		h.sendErrorResponse(w, "AI provider status is not available", http.StatusNotImplemented)
+		return
+	}
+
+	providers := reporter.ProviderStatus()
+
+	open := 0
+	for _, provider := range providers {
+		if provider.Breaker.State == ai.BreakerOpen {
+			open++
+		}
+	}
+
+	status := "healthy"
+	statusCode := http.StatusOK
+	switch {
+	case open == len(providers):
+		status = "unhealthy"
+		statusCode = http.StatusServiceUnavailable
+	case open > 0:
+		status = "degraded"
+	}
+
+	response := map[string]any{
+		"status":    status,
+		"timestamp": time.Now().UTC(),
+		"service":   "ai-providers",
+		"providers": providers,
+	}
+
+	h.logger.WithContext(r.Context()).Debug("AI provider status checked",
+		"status", status,
+		"open_breakers", open,
+	)
+
+	h.sendJSONResponse(w, response, statusCode)
+}
+
 // sendJSONResponse sends a JSON response with the given data and status code
 func (h *HealthHandler) sendJSONResponse(w http.ResponseWriter, data any, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -505,6 +505,62 @@ func TestHealthHandler_OllamaStatusEndpoint(t *testing.T) {
 	}
 }
 
+func TestHealthHandler_AIStatusEndpoint(t *testing.T) {
+	failing := ai.NewMockAIProvider()
+	failing.ProcessJournalSentimentFunc = func(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	chain, err := ai.NewChain([]ai.Provider{failing}, ai.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}, Logger())
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+	service := ai.NewService(chain, Logger())
+	handler := handlers.NewHealthHandler(storage.NewMemoryStore(), service, Logger())
+
+	getStatus := func() (int, map[string]any) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/status/ai", nil))
+
+		var response map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		return rr.Code, response
+	}
+
+	code, response := getStatus()
+	if code != http.StatusOK || response["status"] != "healthy" {
+		t.Errorf("Expected healthy status, got %d %v", code, response["status"])
+	}
+
+	// One failure trips the breaker of the only provider
+	service.ProcessJournalSentiment(context.Background(), &models.Journal{ID: "j1", Content: "Some journal content"})
+
+	code, response = getStatus()
+	if code != http.StatusServiceUnavailable || response["status"] != "unhealthy" {
+		t.Errorf("Expected unhealthy status, got %d %v", code, response["status"])
+	}
+
+	providers, ok := response["providers"].([]any)
+	if !ok || len(providers) != 1 {
+		t.Fatalf("Expected one provider, got %v", response["providers"])
+	}
+	breaker := providers[0].(map[string]any)["breaker"].(map[string]any)
+	if breaker["state"] != string(ai.BreakerOpen) || breaker["last_error"] != "connection refused" {
+		t.Errorf("Expected open breaker with last error, got %v", breaker)
+	}
+
+	t.Run("NotAvailableForPlainServices", func(t *testing.T) {
+		handler := handlers.NewHealthHandler(storage.NewMemoryStore(), ai.NewMockAIProvider(), Logger())
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/status/ai", nil))
+		if rr.Code != http.StatusNotImplemented {
+			t.Errorf("Expected 501, got %d", rr.Code)
+		}
+	})
+}
+
 func TestHealthHandler_UnsupportedPaths(t *testing.T) {
 	store := storage.NewMemoryStore()
 	mockAI := ai.NewMockAIProvider()
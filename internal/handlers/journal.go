@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -9,19 +10,25 @@ import (
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/models"
 	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/vector"
 	"github.com/garnizeh/englog/internal/worker"
 	"github.com/google/uuid"
 )
 
 // JournalHandler handles journal-related HTTP requests
 type JournalHandler struct {
-	store  *storage.MemoryStore
-	worker *worker.InMemoryWorker
-	logger *logging.Logger
+	store     storage.JournalStore
+	worker    *worker.InMemoryWorker
+	pool      *worker.Pool
+	generator journalGenerator
+	index     *vector.Index
+	embedder  queryEmbedder
+	logger    *logging.Logger
 }
 
-// NewJournalHandler creates a new journal handler
-func NewJournalHandler(store *storage.MemoryStore, worker *worker.InMemoryWorker, logger *logging.Logger) *JournalHandler {
+// NewJournalHandler creates a new journal handler that processes journals
+// synchronously within the request
+func NewJournalHandler(store storage.JournalStore, worker *worker.InMemoryWorker, logger *logging.Logger) *JournalHandler {
 	return &JournalHandler{
 		store:  store,
 		worker: worker,
@@ -29,6 +36,16 @@ func NewJournalHandler(store *storage.MemoryStore, worker *worker.InMemoryWorker
 	}
 }
 
+// NewAsyncJournalHandler creates a journal handler that queues AI processing
+// on a worker pool and responds without waiting for it
+func NewAsyncJournalHandler(store storage.JournalStore, pool *worker.Pool, logger *logging.Logger) *JournalHandler {
+	return &JournalHandler{
+		store:  store,
+		pool:   pool,
+		logger: logger,
+	}
+}
+
 // ServeHTTP implements the http.Handler interface for journal operations
 func (h *JournalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Create logger with request context
@@ -43,19 +60,39 @@ func (h *JournalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.ContentLength,
 	)
 
-	switch r.Method {
-	case http.MethodPost:
+	// Extract ID and any sub-resource from path (format: /journals/{id}/...)
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/journals"), "/")
+	id, subPath, _ := strings.Cut(path, "/")
+
+	switch {
+	case id != "" && subPath == "processing" && r.Method == http.MethodGet:
+		h.getProcessingStatus(w, r, id)
+	case id != "" && (subPath == "revisions" || strings.HasPrefix(subPath, "revisions/")):
+		h.serveRevisions(w, r, id, strings.Trim(strings.TrimPrefix(subPath, "revisions"), "/"))
+	case id == "search" && subPath == "semantic" && r.Method == http.MethodGet:
+		h.semanticSearch(w, r)
+	case id != "" && subPath == "similar" && r.Method == http.MethodGet:
+		h.similarJournals(w, r, id)
+	case id == "search" && subPath == "semantic", id != "" && (subPath == "processing" || subPath == "similar"):
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	case subPath != "":
+		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
+	case r.Method == http.MethodPost && id == "":
 		h.createJournal(w, r)
-	case http.MethodGet:
-		// Check if this is a request for a specific journal (has ID in path)
-		path := strings.TrimPrefix(r.URL.Path, "/journals")
-		if path != "" && path != "/" {
-			// Extract ID from path (format: /journals/{id})
-			id := strings.Trim(path, "/")
-			h.getJournalByID(w, r, id)
-		} else {
-			h.getAllJournals(w, r)
-		}
+	case r.Method == http.MethodPost && id == "generate":
+		h.generateJournal(w, r)
+	case r.Method == http.MethodGet && id == "search":
+		h.searchJournals(w, r)
+	case r.Method == http.MethodGet && id != "":
+		h.getJournalByID(w, r, id)
+	case r.Method == http.MethodGet:
+		h.getAllJournals(w, r)
+	case r.Method == http.MethodPut && id != "":
+		h.updateJournal(w, r, id)
+	case r.Method == http.MethodPatch && id != "":
+		h.patchJournal(w, r, id)
+	case r.Method == http.MethodDelete && id != "":
+		h.deleteJournal(w, r, id)
 	default:
 		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -95,30 +132,22 @@ func (h *JournalHandler) createJournal(w http.ResponseWriter, r *http.Request) {
 		Timestamp: now,
 		CreatedAt: now,
 		UpdatedAt: now,
+		UpdatedBy: requestAuthor(r),
 		Metadata:  req.Metadata,
 	}
 
-	// Process journal with AI synchronously (with graceful failure handling)
-	if h.worker != nil {
-		h.logger.LogAIProcessingStart(journal.ID, journal.Content, len(journal.Content))
-
-		h.worker.ProcessJournalWithGracefulFailure(r.Context(), journal)
+	h.saveNewJournal(w, r, journal)
+}
 
-		if journal.ProcessingResult != nil {
-			var durationMs int64
-			if journal.ProcessingResult.ProcessingTime != nil {
-				durationMs = journal.ProcessingResult.ProcessingTime.Nanoseconds() / int64(time.Millisecond)
-			}
-			h.logger.LogAIProcessingComplete(journal.ID,
-				durationMs,
-				journal.ProcessingResult.Status == "completed",
-				"")
-		}
-	} else {
-		h.logger.WithContext(r.Context()).Warn("No AI worker available, skipping processing",
-			"journal_id", journal.ID)
+// saveNewJournal stores a new journal and runs or queues its AI processing
+func (h *JournalHandler) saveNewJournal(w http.ResponseWriter, r *http.Request, journal *models.Journal) {
+	if h.pool != nil {
+		h.createJournalAsync(w, r, journal)
+		return
 	}
 
+	h.processJournal(r, journal)
+
 	// Store the journal (with processing results if available)
 	if err := h.store.Store(journal); err != nil {
 		h.logger.LogStorageOperation("store", "journal", journal.ID, false, err.Error())
@@ -138,30 +167,126 @@ func (h *JournalHandler) createJournal(w http.ResponseWriter, r *http.Request) {
 		}())
 
 	// Return the created journal with processing results
+	setETag(w, journal)
 	h.sendJSONResponse(w, journal, http.StatusCreated)
 }
 
-// getAllJournals handles GET /journals
+// getAllJournals handles GET /journals with pagination, sorting and filtering
 func (h *JournalHandler) getAllJournals(w http.ResponseWriter, r *http.Request) {
-	journals, err := h.store.GetAll()
+	query, validationErrors := parseJournalQuery(r.URL.Query())
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("list_journals", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	page, err := h.store.List(query)
+	if errors.Is(err, storage.ErrInvalidQuery) {
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "cursor",
+				Message: err.Error(),
+				Code:    "INVALID_QUERY_PARAM",
+			},
+		})
+		return
+	}
 	if err != nil {
-		h.logger.LogStorageOperation("get_all", "journal", "all", false, err.Error())
+		h.logger.LogStorageOperation("list", "journal", "all", false, err.Error())
 		h.sendErrorResponse(w, "Failed to retrieve journals", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.WithContext(r.Context()).Info("Retrieved all journals", "count", len(journals))
+	h.logger.WithContext(r.Context()).Info("Retrieved journals",
+		"count", len(page.Journals),
+		"total", page.Total,
+		"has_more", page.NextCursor != "")
 
-	// Create response with journals and metadata
+	// Create response with journals and pagination metadata
 	response := map[string]any{
-		"journals":     journals,
-		"count":        len(journals),
+		"journals":     page.Journals,
+		"count":        len(page.Journals),
+		"total":        page.Total,
+		"next_cursor":  page.NextCursor,
 		"retrieved_at": time.Now().UTC(),
 	}
 
 	h.sendJSONResponse(w, response, http.StatusOK)
 }
 
+// processJournal runs AI processing on a journal synchronously (with graceful failure handling)
+func (h *JournalHandler) processJournal(r *http.Request, journal *models.Journal) {
+	if h.worker == nil {
+		h.logger.WithContext(r.Context()).Warn("No AI worker available, skipping processing",
+			"journal_id", journal.ID)
+		return
+	}
+
+	h.logger.LogAIProcessingStart(journal.ID, journal.Content, len(journal.Content))
+
+	h.worker.ProcessJournalWithGracefulFailure(r.Context(), journal)
+
+	if journal.ProcessingResult != nil {
+		var durationMs int64
+		if journal.ProcessingResult.ProcessingTime != nil {
+			durationMs = journal.ProcessingResult.ProcessingTime.Nanoseconds() / int64(time.Millisecond)
+		}
+		h.logger.LogAIProcessingComplete(journal.ID,
+			durationMs,
+			journal.ProcessingResult.Status == "completed",
+			"")
+	}
+}
+
+// searchJournals handles GET /journals/search?q=
+func (h *JournalHandler) searchJournals(w http.ResponseWriter, r *http.Request) {
+	searcher, ok := h.store.(storage.Searcher)
+	if !ok {
+		h.sendErrorResponse(w, "Search is not supported by the configured storage", http.StatusNotImplemented)
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	limit, validationErrors := parseSearchParams(q, r.URL.Query().Get("limit"))
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("search_journals", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	hits, total, err := searcher.Search(q, limit)
+	if errors.Is(err, storage.ErrInvalidQuery) {
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "q",
+				Message: err.Error(),
+				Code:    "INVALID_QUERY_PARAM",
+			},
+		})
+		return
+	}
+	if err != nil {
+		h.logger.LogStorageOperation("search", "journal", "all", false, err.Error())
+		h.sendErrorResponse(w, "Failed to search journals", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Searched journals",
+		"query", q,
+		"count", len(hits),
+		"total", total)
+
+	response := map[string]any{
+		"query":       q,
+		"results":     hits,
+		"count":       len(hits),
+		"total":       total,
+		"searched_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
 // getJournalByID handles GET /journals/{id}
 func (h *JournalHandler) getJournalByID(w http.ResponseWriter, r *http.Request, id string) {
 	// Validate ID format (basic UUID validation)
@@ -179,6 +304,7 @@ func (h *JournalHandler) getJournalByID(w http.ResponseWriter, r *http.Request,
 
 	h.logger.WithContext(r.Context()).Info("Retrieved journal by ID", "journal_id", id)
 
+	setETag(w, journal)
 	h.sendJSONResponse(w, journal, http.StatusOK)
 }
 
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai/prompt"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/google/uuid"
+)
+
+// ProvenanceAIGenerated marks journals whose content was written by the AI
+const ProvenanceAIGenerated = "ai_generated"
+
+// Limits journal metadata is validated against
+const (
+	maxMetadataItems  = 50
+	maxMetadataString = 1000
+)
+
+// journalGenerator writes journal entries from prompts
+type journalGenerator interface {
+	GenerateStructuredJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error)
+}
+
+// SetGenerator enables POST /journals/generate with the given AI service
+func (h *JournalHandler) SetGenerator(generator journalGenerator) {
+	h.generator = generator
+}
+
+// generateJournal handles POST /journals/generate
+// It generates an entry from a prompt and stores it like POST /journals, so
+// it goes through the same sentiment processing.
+func (h *JournalHandler) generateJournal(w http.ResponseWriter, r *http.Request) {
+	if h.generator == nil {
+		h.sendErrorResponse(w, "Journal generation is not available", http.StatusNotImplemented)
+		return
+	}
+
+	var req models.PromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithContext(r.Context()).Error("Failed to decode generate journal request", "error", err)
+		h.sendValidationErrorResponse(w, []models.ValidationError{
+			{
+				Field:   "body",
+				Message: "Invalid JSON format: " + err.Error(),
+				Code:    "INVALID_JSON",
+			},
+		})
+		return
+	}
+
+	if validationErrors := req.Validate(); validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("generate_journal", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	generated, err := h.generator.GenerateStructuredJournal(r.Context(), &req)
+	if errors.Is(err, prompt.ErrUnknownTemplate) {
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "prompt_template",
+				Message: err.Error(),
+				Code:    "UNKNOWN_PROMPT_TEMPLATE",
+			},
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(r.Context()).Error("Journal generation failed", "error", err)
+		h.sendErrorResponse(w, "Journal generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	entry := models.CreateJournalRequest{
+		Content:  strings.TrimSpace(generated.Content),
+		Metadata: generatedMetadata(&req, generated),
+	}
+	if validationErrors := entry.Validate(); validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).Error("Generated journal is not a valid entry",
+			"errors", validationErrors.Error())
+		h.sendErrorResponse(w, "Generated journal is not a valid entry: "+validationErrors.Error(), http.StatusBadGateway)
+		return
+	}
+
+	now := time.Now()
+	journal := &models.Journal{
+		ID:        uuid.New().String(),
+		Content:   entry.Content,
+		Timestamp: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+		UpdatedBy: requestAuthor(r),
+		Metadata:  entry.Metadata,
+	}
+
+	h.saveNewJournal(w, r, journal)
+}
+
+// generatedMetadata turns the metadata of a generated journal into journal
+// metadata, with a "provenance" object recording that the AI wrote it and
+// from which prompt
+func generatedMetadata(req *models.PromptRequest, generated *models.GeneratedJournal) map[string]any {
+	limit := func(items []string) []string {
+		return items[:min(len(items), maxMetadataItems)]
+	}
+
+	provenance := map[string]any{
+		"source":       ProvenanceAIGenerated,
+		"prompt":       truncateRunes(req.Prompt, maxMetadataString),
+		"generated_at": generated.GeneratedAt,
+	}
+	if generated.Prompt != nil {
+		provenance["prompt_template"] = generated.Prompt.String()
+	}
+	if generated.Options != nil {
+		provenance["options"] = generated.Options
+	}
+
+	metadata := map[string]any{
+		"mood":              generated.Metadata.Mood,
+		"emotional_context": generated.Metadata.EmotionalContext,
+		"themes":            limit(generated.Metadata.Themes),
+		"entities":          limit(generated.Metadata.Entities),
+		"key_phrases":       limit(generated.Metadata.KeyPhrases),
+		"tags":              limit(generated.Metadata.Tags),
+		"semantic_markers":  limit(generated.SemanticMarkers),
+		"provenance":        provenance,
+	}
+
+	// Round-trip through JSON so the metadata has the same shape as metadata
+	// sent by clients, which later PUT and PATCH requests are validated against
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return metadata
+	}
+	var normalized map[string]any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return metadata
+	}
+	for key, value := range normalized {
+		if value == nil || value == "" {
+			delete(normalized, key)
+		}
+	}
+	return normalized
+}
+
+// truncateRunes shortens s to at most n runes
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/worker"
+)
+
+// queueFullRetryAfter is the Retry-After hint, in seconds, sent when the processing queue is full
+const queueFullRetryAfter = "5"
+
+// createJournalAsync stores a new journal as pending and queues it for processing
+// The journal is removed again if the queue cannot take it, so a 429 leaves no trace
+func (h *JournalHandler) createJournalAsync(w http.ResponseWriter, r *http.Request, journal *models.Journal) {
+	journal.ProcessingResult = &models.ProcessingResult{Status: models.ProcessingStatusPending}
+
+	if err := h.store.Store(journal); err != nil {
+		h.logger.LogStorageOperation("store", "journal", journal.ID, false, err.Error())
+		h.sendErrorResponse(w, "Failed to create journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.pool.Submit(journal.ID); err != nil {
+		if deleteErr := h.store.Delete(journal.ID); deleteErr != nil {
+			h.logger.LogStorageOperation("delete", "journal", journal.ID, false, deleteErr.Error())
+		}
+		h.sendSubmitError(w, r, journal.ID, err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Journal created and queued for processing",
+		"journal_id", journal.ID,
+		"content_length", len(journal.Content))
+
+	w.Header().Set("Location", "/journals/"+journal.ID)
+	setETag(w, journal)
+	h.sendJSONResponse(w, journal, http.StatusAccepted)
+}
+
+// enqueueReprocessing queues a journal whose content changed
+// If the queue is full the journal keeps its stale status
+func (h *JournalHandler) enqueueReprocessing(r *http.Request, journalID string) {
+	if err := h.pool.Submit(journalID); err != nil {
+		h.logger.WithContext(r.Context()).Warn("Failed to queue journal for reprocessing",
+			"journal_id", journalID,
+			"error", err)
+	}
+}
+
+// getProcessingStatus handles GET /journals/{id}/processing
+func (h *JournalHandler) getProcessingStatus(w http.ResponseWriter, r *http.Request, id string) {
+	journal, err := h.store.Get(id)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Info("Journal not found", "journal_id", id, "error", err)
+		h.sendErrorResponse(w, "Journal not found", http.StatusNotFound)
+		return
+	}
+
+	status := models.ProcessingStatus("not_processed")
+	if journal.ProcessingResult != nil {
+		status = journal.ProcessingResult.Status
+	}
+
+	response := map[string]any{
+		"journal_id":        id,
+		"version":           journal.Version,
+		"processing_status": status,
+		"processing_result": journal.ProcessingResult,
+		"checked_at":        time.Now().UTC(),
+	}
+
+	if h.pool != nil {
+		if job, queued := h.pool.Status(id); queued {
+			response["job"] = job
+			switch job.State {
+			case worker.JobStateProcessing:
+				response["processing_status"] = models.ProcessingStatusProcessing
+			case worker.JobStateQueued:
+				response["processing_status"] = models.ProcessingStatusPending
+			}
+		}
+		response["queue"] = h.pool.Stats()
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// sendSubmitError maps worker pool submission errors to responses
+func (h *JournalHandler) sendSubmitError(w http.ResponseWriter, r *http.Request, journalID string, err error) {
+	h.logger.WithContext(r.Context()).Warn("Failed to queue journal for processing",
+		"journal_id", journalID,
+		"error", err)
+
+	if errors.Is(err, worker.ErrQueueFull) {
+		w.Header().Set("Retry-After", queueFullRetryAfter)
+		h.sendErrorResponse(w, "AI processing queue is full, please retry later", http.StatusTooManyRequests)
+		return
+	}
+
+	h.sendErrorResponse(w, "AI processing is unavailable", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+// metadataFilterPrefix introduces generic metadata filters, e.g. metadata.location=home
+const metadataFilterPrefix = "metadata."
+
+// metadataShortcuts are metadata keys that can be filtered without the prefix
+var metadataShortcuts = []string{"tags", "mood"}
+
+// parseJournalQuery builds a storage query from GET /journals query parameters
+func parseJournalQuery(values url.Values) (storage.JournalQuery, models.ValidationErrors) {
+	var query storage.JournalQuery
+	var errors models.ValidationErrors
+
+	invalid := func(field, message string) {
+		errors = append(errors, models.ValidationError{
+			Field:   field,
+			Message: message,
+			Code:    "INVALID_QUERY_PARAM",
+		})
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > storage.MaxPageLimit {
+			invalid("limit", fmt.Sprintf("limit must be an integer between 1 and %d", storage.MaxPageLimit))
+		}
+		query.Limit = limit
+	}
+
+	query.Cursor = values.Get("cursor")
+
+	switch sortBy := values.Get("sort"); sortBy {
+	case "", storage.SortByCreatedAt, storage.SortByTimestamp, storage.SortBySentimentScore:
+		query.SortBy = sortBy
+	default:
+		invalid("sort", "sort must be one of created_at, timestamp, sentiment_score")
+	}
+
+	switch order := strings.ToLower(values.Get("order")); order {
+	case "", storage.SortAsc, storage.SortDesc:
+		query.Order = order
+	default:
+		invalid("order", "order must be asc or desc")
+	}
+
+	if raw := values.Get("from"); raw != "" {
+		from, err := parseDateParam(raw)
+		if err != nil {
+			invalid("from", "from must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		} else {
+			query.From = &from
+		}
+	}
+
+	if raw := values.Get("to"); raw != "" {
+		to, err := parseDateParam(raw)
+		if err != nil {
+			invalid("to", "to must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		} else {
+			query.To = &to
+		}
+	}
+
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		invalid("from", "from must be before to")
+	}
+
+	switch status := models.ProcessingStatus(values.Get("processing_status")); status {
+	case "", models.ProcessingStatusPending, models.ProcessingStatusProcessing,
+		models.ProcessingStatusCompleted, models.ProcessingStatusFailed, models.ProcessingStatusStale:
+		query.Status = status
+	default:
+		invalid("processing_status", "processing_status must be one of pending, processing, completed, failed, stale")
+	}
+
+	switch label := strings.ToLower(values.Get("sentiment")); label {
+	case "", "positive", "negative", "neutral":
+		query.SentimentLabel = label
+	default:
+		invalid("sentiment", "sentiment must be one of positive, negative, neutral")
+	}
+
+	query.MinScore = parseRangeParam(values, "min_score", -1, 1, invalid)
+	query.MaxScore = parseRangeParam(values, "max_score", -1, 1, invalid)
+	if query.MinScore != nil && query.MaxScore != nil && *query.MinScore > *query.MaxScore {
+		invalid("min_score", "min_score cannot be greater than max_score")
+	}
+
+	if emotion := strings.ToLower(values.Get("emotion")); emotion != "" {
+		if _, ok := (models.Emotions{}).Intensity(emotion); ok {
+			query.DominantEmotion = emotion
+		} else {
+			invalid("emotion", "emotion must be one of "+strings.Join(models.EmotionNames, ", "))
+		}
+	}
+
+	for _, emotion := range models.EmotionNames {
+		if minimum := parseRangeParam(values, "min_"+emotion, 0, 1, invalid); minimum != nil {
+			if query.MinEmotions == nil {
+				query.MinEmotions = make(map[string]float64)
+			}
+			query.MinEmotions[emotion] = *minimum
+		}
+	}
+
+	query.MinValence = parseRangeParam(values, "min_valence", -1, 1, invalid)
+	query.MaxValence = parseRangeParam(values, "max_valence", -1, 1, invalid)
+	if query.MinValence != nil && query.MaxValence != nil && *query.MinValence > *query.MaxValence {
+		invalid("min_valence", "min_valence cannot be greater than max_valence")
+	}
+
+	query.MinArousal = parseRangeParam(values, "min_arousal", 0, 1, invalid)
+	query.MaxArousal = parseRangeParam(values, "max_arousal", 0, 1, invalid)
+	if query.MinArousal != nil && query.MaxArousal != nil && *query.MinArousal > *query.MaxArousal {
+		invalid("min_arousal", "min_arousal cannot be greater than max_arousal")
+	}
+
+	query.Entity = strings.TrimSpace(values.Get("entity"))
+
+	for key, vals := range values {
+		metadataKey := ""
+		if strings.HasPrefix(key, metadataFilterPrefix) {
+			metadataKey = strings.TrimPrefix(key, metadataFilterPrefix)
+		} else {
+			for _, shortcut := range metadataShortcuts {
+				if key == shortcut {
+					metadataKey = shortcut
+				}
+			}
+		}
+
+		if metadataKey == "" {
+			continue
+		}
+
+		if query.Metadata == nil {
+			query.Metadata = make(map[string][]string)
+		}
+		for _, value := range vals {
+			// Allow comma-separated lists such as tags=work,health
+			for _, part := range strings.Split(value, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					query.Metadata[metadataKey] = append(query.Metadata[metadataKey], part)
+				}
+			}
+		}
+	}
+
+	return query, errors
+}
+
+// parseDateParam accepts either an RFC 3339 timestamp or a plain date
+func parseDateParam(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+// parseRangeParam parses an optional bound, such as a sentiment score or an
+// emotion intensity, that must lie in [lo, hi]
+func parseRangeParam(values url.Values, name string, lo, hi float64, invalid func(field, message string)) *float64 {
+	raw := values.Get(name)
+	if raw == "" {
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < lo || value > hi {
+		invalid(name, fmt.Sprintf("%s must be a number between %.1f and %.1f", name, lo, hi))
+		return nil
+	}
+
+	return &value
+}
+
+// parseSearchParams validates the q and limit parameters of GET /journals/search
+func parseSearchParams(q, rawLimit string) (int, models.ValidationErrors) {
+	var errors models.ValidationErrors
+	if q == "" {
+		errors = append(errors, models.ValidationError{
+			Field:   "q",
+			Message: "q is required",
+			Code:    "INVALID_QUERY_PARAM",
+		})
+	}
+
+	limit, limitErrors := parseResultLimit(rawLimit)
+	return limit, append(errors, limitErrors...)
+}
+
+// parseResultLimit validates the limit of a ranked result list
+func parseResultLimit(rawLimit string) (int, models.ValidationErrors) {
+	if rawLimit == "" {
+		return storage.DefaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 1 || limit > storage.MaxPageLimit {
+		return limit, models.ValidationErrors{
+			{
+				Field:   "limit",
+				Message: fmt.Sprintf("limit must be an integer between 1 and %d", storage.MaxPageLimit),
+				Code:    "INVALID_QUERY_PARAM",
+			},
+		}
+	}
+
+	return limit, nil
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/diff"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+// serveRevisions routes requests under /journals/{id}/revisions
+func (h *JournalHandler) serveRevisions(w http.ResponseWriter, r *http.Request, id, path string) {
+	number, action, _ := strings.Cut(path, "/")
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		h.listRevisions(w, r, id)
+	case path == "diff" && r.Method == http.MethodGet:
+		h.diffRevisions(w, r, id)
+	case number != "" && action == "" && r.Method == http.MethodGet:
+		h.getRevision(w, r, id, number)
+	case number != "" && action == "restore" && r.Method == http.MethodPost:
+		h.restoreRevision(w, r, id, number)
+	case path == "" || path == "diff" || action == "" || action == "restore":
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listRevisions handles GET /journals/{id}/revisions
+func (h *JournalHandler) listRevisions(w http.ResponseWriter, r *http.Request, id string) {
+	revisions, err := h.store.ListRevisions(id)
+	if err != nil {
+		h.sendRevisionError(w, r, id, err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Retrieved journal revisions",
+		"journal_id", id,
+		"count", len(revisions))
+
+	response := map[string]any{
+		"journal_id":   id,
+		"revisions":    revisions,
+		"count":        len(revisions),
+		"retrieved_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// getRevision handles GET /journals/{id}/revisions/{n}
+func (h *JournalHandler) getRevision(w http.ResponseWriter, r *http.Request, id, rawNumber string) {
+	number, ok := h.parseRevisionNumber(w, "number", rawNumber)
+	if !ok {
+		return
+	}
+
+	revision, err := h.store.GetRevision(id, number)
+	if err != nil {
+		h.sendRevisionError(w, r, id, err)
+		return
+	}
+
+	h.sendJSONResponse(w, revision, http.StatusOK)
+}
+
+// diffRevisions handles GET /journals/{id}/revisions/diff?from=n&to=m
+// Without parameters it compares the latest revision with the one before it
+func (h *JournalHandler) diffRevisions(w http.ResponseWriter, r *http.Request, id string) {
+	revisions, err := h.store.ListRevisions(id)
+	if err != nil {
+		h.sendRevisionError(w, r, id, err)
+		return
+	}
+	if len(revisions) == 0 {
+		h.sendErrorResponse(w, "Revision not found", http.StatusNotFound)
+		return
+	}
+
+	to := revisions[len(revisions)-1].Number
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		var ok bool
+		if to, ok = h.parseRevisionNumber(w, "to", raw); !ok {
+			return
+		}
+	}
+
+	from := to - 1
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		var ok bool
+		if from, ok = h.parseRevisionNumber(w, "from", raw); !ok {
+			return
+		}
+	}
+
+	fromRevision, toRevision := findRevision(revisions, from), findRevision(revisions, to)
+	if fromRevision == nil || toRevision == nil {
+		h.sendErrorResponse(w, fmt.Sprintf("Revisions %d and %d must both exist", from, to), http.StatusNotFound)
+		return
+	}
+
+	fromLabel := fmt.Sprintf("journals/%s@%d", id, from)
+	toLabel := fmt.Sprintf("journals/%s@%d", id, to)
+
+	response := map[string]any{
+		"journal_id":    id,
+		"from":          from,
+		"to":            to,
+		"content_diff":  diff.Unified(fromLabel, toLabel, fromRevision.Content, toRevision.Content, diff.DefaultContext),
+		"metadata_diff": diff.Unified(fromLabel, toLabel, formatMetadata(fromRevision.Metadata), formatMetadata(toRevision.Metadata), diff.DefaultContext),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// restoreRevision handles POST /journals/{id}/revisions/{n}/restore
+// The journal's content, metadata and AI result are reset to those of
+// revision n, recorded as a new revision
+func (h *JournalHandler) restoreRevision(w http.ResponseWriter, r *http.Request, id, rawNumber string) {
+	number, ok := h.parseRevisionNumber(w, "number", rawNumber)
+	if !ok {
+		return
+	}
+
+	existing, ok := h.loadForWrite(w, r, id)
+	if !ok {
+		return
+	}
+
+	revision, err := h.store.GetRevision(id, number)
+	if err != nil {
+		h.sendRevisionError(w, r, id, err)
+		return
+	}
+
+	restored := *existing
+	restored.Content = revision.Content
+	restored.Metadata = revision.Metadata
+	if revision.ProcessingResult != nil && revision.ProcessingResult.Status == models.ProcessingStatusCompleted {
+		result := *revision.ProcessingResult
+		restored.ProcessingResult = &result
+	} else {
+		// The revision was recorded before its analysis finished; analyze it again
+		restored.ProcessingResult = &models.ProcessingResult{Status: models.ProcessingStatusStale}
+		if h.pool == nil {
+			h.processJournal(r, &restored)
+		}
+	}
+	restored.UpdatedBy = requestAuthor(r)
+
+	// Only a conditional request pins the version; otherwise last write wins
+	restored.Version = 0
+	if r.Header.Get("If-Match") != "" {
+		restored.Version = existing.Version
+	}
+
+	h.logger.WithContext(r.Context()).Info("Restoring journal revision",
+		"journal_id", id,
+		"revision", number,
+		"current_version", existing.Version)
+
+	h.saveJournalUpdate(w, r, existing, &restored)
+}
+
+// parseRevisionNumber parses a revision number, sending a validation error if invalid
+func (h *JournalHandler) parseRevisionNumber(w http.ResponseWriter, field, raw string) (int64, bool) {
+	number, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || number < 1 {
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   field,
+				Message: "Revision number must be a positive integer",
+				Code:    "INVALID_REVISION",
+			},
+		})
+		return 0, false
+	}
+
+	return number, true
+}
+
+// sendRevisionError maps revision lookup errors to responses
+func (h *JournalHandler) sendRevisionError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		h.logger.WithContext(r.Context()).Info("Revision not found", "journal_id", id, "error", err)
+		h.sendErrorResponse(w, "Revision not found", http.StatusNotFound)
+		return
+	}
+
+	h.logger.LogStorageOperation("get_revisions", "journal", id, false, err.Error())
+	h.sendErrorResponse(w, "Failed to retrieve revisions", http.StatusInternalServerError)
+}
+
+// findRevision returns the revision with the given number, or nil
+func findRevision(revisions []*models.Revision, number int64) *models.Revision {
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return revision
+		}
+	}
+	return nil
+}
+
+// formatMetadata renders metadata as indented JSON with sorted keys for diffing
+func formatMetadata(metadata map[string]any) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", metadata)
+	}
+
+	return string(data) + "\n"
+}
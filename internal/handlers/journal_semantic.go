@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/vector"
+)
+
+// semanticSnippetLength is the length in runes of semantic search snippets
+const semanticSnippetLength = 200
+
+// queryEmbedder embeds search queries
+type queryEmbedder interface {
+	Embed(ctx context.Context, texts []string) (*models.Embeddings, error)
+}
+
+// SetSemanticSearch enables GET /journals/search/semantic and
+// GET /journals/{id}/similar over the given vector index
+// The embedder must embed queries with the model the index was built with.
+func (h *JournalHandler) SetSemanticSearch(index *vector.Index, embedder queryEmbedder) {
+	h.index = index
+	h.embedder = embedder
+}
+
+// semanticSearch handles GET /journals/search/semantic?q=
+func (h *JournalHandler) semanticSearch(w http.ResponseWriter, r *http.Request) {
+	if h.index == nil || h.embedder == nil {
+		h.sendErrorResponse(w, "Semantic search is not available", http.StatusNotImplemented)
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	limit, validationErrors := parseSearchParams(q, r.URL.Query().Get("limit"))
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("semantic_search", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	embeddings, err := h.embedder.Embed(r.Context(), []string{q})
+	if errors.Is(err, errors.ErrUnsupported) {
+		h.sendErrorResponse(w, "Semantic search is not supported by the configured AI provider", http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(r.Context()).Error("Query embedding failed", "error", err)
+		h.sendErrorResponse(w, "Failed to embed search query", http.StatusServiceUnavailable)
+		return
+	}
+
+	hits := h.semanticHits(h.index.Search(embeddings.Model, embeddings.Vectors[0], limit))
+
+	h.logger.WithContext(r.Context()).Info("Semantically searched journals",
+		"query", q,
+		"model", embeddings.Model,
+		"count", len(hits))
+
+	response := map[string]any{
+		"query":       q,
+		"model":       embeddings.Model,
+		"results":     hits,
+		"count":       len(hits),
+		"searched_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// similarJournals handles GET /journals/{id}/similar
+func (h *JournalHandler) similarJournals(w http.ResponseWriter, r *http.Request, id string) {
+	if h.index == nil {
+		h.sendErrorResponse(w, "Semantic search is not available", http.StatusNotImplemented)
+		return
+	}
+
+	limit, validationErrors := parseResultLimit(r.URL.Query().Get("limit"))
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("similar_journals", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	if _, err := h.store.Get(id); err != nil {
+		h.logger.WithContext(r.Context()).Info("Journal not found", "journal_id", id, "error", err)
+		h.sendErrorResponse(w, "Journal not found", http.StatusNotFound)
+		return
+	}
+
+	results, err := h.index.Similar(id, limit)
+	if errors.Is(err, vector.ErrNotIndexed) {
+		h.sendErrorResponse(w, "Journal has not been embedded yet", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(r.Context()).Error("Similar journal lookup failed", "journal_id", id, "error", err)
+		h.sendErrorResponse(w, "Failed to find similar journals", http.StatusInternalServerError)
+		return
+	}
+
+	hits := h.semanticHits(results)
+
+	h.logger.WithContext(r.Context()).Info("Found similar journals",
+		"journal_id", id,
+		"count", len(hits))
+
+	response := map[string]any{
+		"journal_id":  id,
+		"results":     hits,
+		"count":       len(hits),
+		"searched_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// semanticHits loads the journals of ranked results, skipping any deleted
+// since they were embedded, with the best-matching chunk as the snippet
+func (h *JournalHandler) semanticHits(results []vector.Result) []storage.SearchHit {
+	hits := make([]storage.SearchHit, 0, len(results))
+	for _, result := range results {
+		journal, err := h.store.Get(result.ID)
+		if err != nil {
+			continue
+		}
+		hits = append(hits, storage.SearchHit{
+			Journal: journal,
+			Score:   result.Score,
+			Snippet: vector.Excerpt(journal.Content, result.Span, semanticSnippetLength),
+		})
+	}
+	return hits
+}
@@ -5,14 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/ai/prompt"
 	"github.com/garnizeh/englog/internal/handlers"
 	"github.com/garnizeh/englog/internal/models"
 	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/vector"
 	"github.com/garnizeh/englog/internal/worker"
 )
 
@@ -328,3 +335,970 @@ func TestJournalHandlers(t *testing.T) {
 		}
 	})
 }
+
+func TestJournalHandler_ListJournals(t *testing.T) {
+	store := storage.NewMemoryStore()
+	handler := handlers.NewJournalHandler(store, nil, Logger())
+
+	base := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	emotions := []*models.EmotionResult{
+		nil,
+		{Emotions: models.Emotions{Joy: 0.8, Anticipation: 0.5}, Valence: 0.6, Arousal: 0.7, DominantEmotion: "joy"},
+		{Emotions: models.Emotions{Joy: 0.2, Sadness: 0.5}, Valence: -0.3, Arousal: 0.3, DominantEmotion: "sadness"},
+	}
+	extractions := []*models.ExtractionResult{
+		{People: []models.ExtractedItem{{Name: "Alice", Confidence: 0.9}}},
+		nil,
+		{People: []models.ExtractedItem{{Name: "Alice", Confidence: 0.8}}, Places: []models.ExtractedItem{{Name: "Lisbon", Confidence: 0.7}}},
+	}
+	for i, tag := range []string{"work", "health", "work"} {
+		journal := &models.Journal{
+			ID:        fmt.Sprintf("journal-%d", i),
+			Content:   "Listing test journal entry",
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+			Metadata:  map[string]any{"tags": []any{tag}, "location": "home"},
+			ProcessingResult: &models.ProcessingResult{
+				Status:          models.ProcessingStatusCompleted,
+				SentimentResult: &models.SentimentResult{Score: 0.1 * float64(i), Label: "positive"},
+				EmotionResult:   emotions[i],
+				Extraction:      extractions[i],
+			},
+		}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Failed to seed journal: %v", err)
+		}
+	}
+
+	list := func(t *testing.T, query string) (int, map[string]any) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/journals"+query, nil)
+		handler.ServeHTTP(w, req)
+
+		var response map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return w.Code, response
+	}
+
+	t.Run("PaginatesWithCursor", func(t *testing.T) {
+		code, first := list(t, "?limit=2&sort=created_at&order=asc")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if first["count"].(float64) != 2 || first["total"].(float64) != 3 {
+			t.Errorf("Unexpected counts: count=%v total=%v", first["count"], first["total"])
+		}
+		cursor, _ := first["next_cursor"].(string)
+		if cursor == "" {
+			t.Fatal("Expected next_cursor on first page")
+		}
+
+		code, second := list(t, "?limit=2&sort=created_at&order=asc&cursor="+url.QueryEscape(cursor))
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		journals := second["journals"].([]any)
+		if len(journals) != 1 || journals[0].(map[string]any)["id"] != "journal-2" {
+			t.Errorf("Unexpected second page: %v", journals)
+		}
+		if second["next_cursor"] != "" {
+			t.Errorf("Expected empty next_cursor on last page, got %v", second["next_cursor"])
+		}
+	})
+
+	t.Run("FiltersByMetadata", func(t *testing.T) {
+		_, response := list(t, "?tags=work&metadata.location=home")
+		if response["total"].(float64) != 2 {
+			t.Errorf("Expected 2 journals tagged work, got %v", response["total"])
+		}
+	})
+
+	t.Run("FiltersByScoreRange", func(t *testing.T) {
+		_, response := list(t, "?min_score=0.05&sentiment=positive")
+		if response["total"].(float64) != 2 {
+			t.Errorf("Expected 2 journals, got %v", response["total"])
+		}
+	})
+
+	t.Run("FiltersByEmotion", func(t *testing.T) {
+		for query, expected := range map[string]float64{
+			"?emotion=joy":                      1,
+			"?emotion=Sadness&tags=work":        1,
+			"?min_joy=0.2":                      2,
+			"?min_joy=0.2&min_anticipation=0.5": 1,
+			"?max_valence=0":                    1,
+			"?min_arousal=0.5&max_arousal=1":    1,
+			"?min_valence=-1":                   2,
+		} {
+			code, response := list(t, query)
+			if code != http.StatusOK || response["total"].(float64) != expected {
+				t.Errorf("Expected %v journals for %s, got %d: %v", expected, query, code, response["total"])
+			}
+		}
+	})
+
+	t.Run("FiltersByEntity", func(t *testing.T) {
+		for query, expected := range map[string]float64{
+			"?entity=Alice":             2,
+			"?entity=lisbon":            1,
+			"?entity=Alice&tags=work":   2,
+			"?entity=Alice&emotion=joy": 0,
+			"?entity=Bob":               0,
+		} {
+			code, response := list(t, query)
+			if code != http.StatusOK || response["total"].(float64) != expected {
+				t.Errorf("Expected %v journals for %s, got %d: %v", expected, query, code, response["total"])
+			}
+		}
+	})
+
+	t.Run("RejectsInvalidParameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?emotion=boredom",
+			"?min_joy=1.5",
+			"?min_arousal=-0.1",
+			"?min_valence=0.5&max_valence=0",
+			"?limit=0",
+			"?sort=content",
+			"?order=up",
+			"?from=yesterday",
+			"?processing_status=done",
+			"?min_score=2",
+			"?min_score=0.5&max_score=0.1",
+			"?cursor=garbage",
+		} {
+			code, response := list(t, query)
+			if code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", query, code)
+			}
+			if _, ok := response["validation_errors"]; !ok {
+				t.Errorf("Expected validation_errors for %s", query)
+			}
+		}
+	})
+
+	t.Run("ReportsOnlyTheInvalidDate", func(t *testing.T) {
+		code, response := list(t, "?from=yesterday&to=2024-01-01")
+		errs, _ := response["validation_errors"].([]any)
+		if code != http.StatusBadRequest || len(errs) != 1 {
+			t.Errorf("Expected a single validation error for the invalid from, got %d: %v", code, response)
+		}
+	})
+}
+
+func TestJournalHandler_SearchJournals(t *testing.T) {
+	store, err := storage.NewIndexedStore(storage.NewMemoryStore(), "")
+	if err != nil {
+		t.Fatalf("Failed to create indexed store: %v", err)
+	}
+	handler := handlers.NewJournalHandler(store, nil, Logger())
+
+	for id, content := range map[string]string{
+		"run":  "Went for a morning run and it felt great",
+		"work": "Busy morning at work with back to back meetings",
+	} {
+		journal := &models.Journal{ID: id, Content: content, Metadata: map[string]any{"tags": []any{id}}}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Failed to seed journal: %v", err)
+		}
+	}
+
+	search := func(t *testing.T, query string) (int, map[string]any) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/journals/search"+query, nil)
+		handler.ServeHTTP(w, req)
+
+		var response map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return w.Code, response
+	}
+
+	t.Run("ReturnsRankedHitsWithSnippets", func(t *testing.T) {
+		code, response := search(t, "?q="+url.QueryEscape(`running "felt great"`))
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		results := response["results"].([]any)
+		if len(results) != 1 || response["total"].(float64) != 1 {
+			t.Fatalf("Expected 1 result, got %v", results)
+		}
+		hit := results[0].(map[string]any)
+		if hit["journal"].(map[string]any)["id"] != "run" {
+			t.Errorf("Expected journal 'run', got %v", hit["journal"])
+		}
+		if !strings.Contains(hit["snippet"].(string), "<mark>run</mark>") {
+			t.Errorf("Expected highlighted snippet, got %v", hit["snippet"])
+		}
+	})
+
+	t.Run("FiltersByTag", func(t *testing.T) {
+		_, response := search(t, "?q="+url.QueryEscape("morning tag:work"))
+		results := response["results"].([]any)
+		if len(results) != 1 || results[0].(map[string]any)["journal"].(map[string]any)["id"] != "work" {
+			t.Errorf("Expected only the work journal, got %v", results)
+		}
+	})
+
+	t.Run("RejectsMissingOrEmptyQuery", func(t *testing.T) {
+		for _, query := range []string{"", "?q=", "?q=the", "?q=run&limit=0"} {
+			code, response := search(t, query)
+			if code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", query, code)
+			}
+			if _, ok := response["validation_errors"]; !ok {
+				t.Errorf("Expected validation_errors for %q", query)
+			}
+		}
+	})
+
+	t.Run("NotSupportedWithoutIndex", func(t *testing.T) {
+		plain := handlers.NewJournalHandler(storage.NewMemoryStore(), nil, Logger())
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/journals/search?q=run", nil)
+		plain.ServeHTTP(w, req)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+}
+
+func TestJournalHandler_SemanticSearch(t *testing.T) {
+	store := storage.NewMemoryStore()
+	index := vector.NewIndex()
+	service := ai.NewService(ai.NewMockAIProvider(), Logger())
+	journalWorker := worker.NewInMemoryWorker(service, Logger())
+	journalWorker.SetVectorIndex(index)
+	handler := handlers.NewJournalHandler(store, journalWorker, Logger())
+	handler.SetSemanticSearch(index, service)
+
+	ids := map[string]string{}
+	for name, content := range map[string]string{
+		"run":    "Long run along the river trail before breakfast",
+		"trail":  "Hiked the river trail with friends in the afternoon",
+		"baking": "Baked sourdough bread and cinnamon rolls",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/journals", strings.NewReader(fmt.Sprintf(`{"content": %q}`, content)))
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create journal: %d %s", w.Code, w.Body.String())
+		}
+		var journal models.Journal
+		json.Unmarshal(w.Body.Bytes(), &journal)
+		ids[name] = journal.ID
+	}
+
+	get := func(t *testing.T, h *handlers.JournalHandler, path string) (int, map[string]any) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		h.ServeHTTP(w, req)
+
+		var response map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return w.Code, response
+	}
+	resultIDs := func(response map[string]any) []string {
+		var found []string
+		for _, result := range response["results"].([]any) {
+			found = append(found, result.(map[string]any)["journal"].(map[string]any)["id"].(string))
+		}
+		return found
+	}
+
+	t.Run("RanksByMeaning", func(t *testing.T) {
+		code, response := get(t, handler, "/journals/search/semantic?q="+url.QueryEscape("river trail")+"&limit=2")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %v", code, response)
+		}
+		found := resultIDs(response)
+		if len(found) != 2 || found[0] == ids["baking"] || found[1] == ids["baking"] {
+			t.Errorf("Expected the two river journals, got %v", found)
+		}
+		hit := response["results"].([]any)[0].(map[string]any)
+		if hit["snippet"] == "" || hit["score"].(float64) <= 0 || response["model"] != ai.MockEmbeddingModel {
+			t.Errorf("Expected a scored hit with a snippet, got %v", response)
+		}
+	})
+
+	t.Run("FindsSimilarJournals", func(t *testing.T) {
+		code, response := get(t, handler, "/journals/"+ids["run"]+"/similar")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %v", code, response)
+		}
+		found := resultIDs(response)
+		if len(found) != 2 || found[0] != ids["trail"] {
+			t.Errorf("Expected the trail journal first and the run journal excluded, got %v", found)
+		}
+	})
+
+	t.Run("RejectsInvalidParams", func(t *testing.T) {
+		for _, path := range []string{"/journals/search/semantic", "/journals/search/semantic?q=run&limit=0", "/journals/" + ids["run"] + "/similar?limit=x"} {
+			if code, _ := get(t, handler, path); code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", path, code)
+			}
+		}
+	})
+
+	t.Run("NotEmbeddedOrMissing", func(t *testing.T) {
+		store.Store(&models.Journal{ID: "unprocessed", Content: "Not embedded yet"})
+		if code, _ := get(t, handler, "/journals/unprocessed/similar"); code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", code)
+		}
+		if code, _ := get(t, handler, "/journals/missing/similar"); code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", code)
+		}
+	})
+
+	t.Run("DeleteRemovesVectors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/journals/"+ids["baking"], nil)
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", w.Code)
+		}
+
+		_, response := get(t, handler, "/journals/search/semantic?q=bread")
+		if slices.Contains(resultIDs(response), ids["baking"]) || index.Len() != 2 {
+			t.Errorf("Expected the deleted journal to be gone, got %v", resultIDs(response))
+		}
+	})
+
+	t.Run("RestoreKeepsJournalSearchable", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/journals/"+ids["run"], strings.NewReader(`{"content": "Swam laps at the pool after work"}`))
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		// Revision 1 carries its completed analysis, so it is not processed again
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/journals/"+ids["run"]+"/revisions/1/restore", nil)
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		code, response := get(t, handler, "/journals/"+ids["run"]+"/similar")
+		if found := resultIDs(response); code != http.StatusOK || len(found) == 0 || found[0] != ids["trail"] {
+			t.Errorf("Expected the restored journal to match the trail journal again, got %d: %v", code, response)
+		}
+	})
+
+	t.Run("EditDropsOldVectors", func(t *testing.T) {
+		// Without a worker the edited journal is not embedded again
+		edits := handlers.NewJournalHandler(store, nil, Logger())
+		edits.SetSemanticSearch(index, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/journals/"+ids["trail"], strings.NewReader(`{"content": "Painted the fence in the garden"}`))
+		edits.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		_, response := get(t, edits, "/journals/search/semantic?q="+url.QueryEscape("river trail"))
+		if slices.Contains(resultIDs(response), ids["trail"]) || index.Has(ids["trail"]) {
+			t.Errorf("Expected the edited journal to stop matching its old text, got %v", resultIDs(response))
+		}
+	})
+
+	t.Run("NotAvailable", func(t *testing.T) {
+		plain := handlers.NewJournalHandler(store, nil, Logger())
+		if code, _ := get(t, plain, "/journals/search/semantic?q=run"); code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", code)
+		}
+
+		unsupported := handlers.NewJournalHandler(store, nil, Logger())
+		unsupported.SetSemanticSearch(index, ai.NewService(struct{ ai.Provider }{ai.NewMockAIProvider()}, Logger()))
+		if code, _ := get(t, unsupported, "/journals/search/semantic?q=run"); code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501 without embedding support, got %d", code)
+		}
+	})
+}
+
+func TestJournalHandler_UpdatePatchDelete(t *testing.T) {
+	store := storage.NewMemoryStore()
+	mockAI := &mockAIProcessor{
+		sentimentResult: &models.SentimentResult{Score: -0.4, Label: "negative", Confidence: 0.8},
+	}
+	handler := handlers.NewJournalHandler(store, worker.NewInMemoryWorker(mockAI, Logger()), Logger())
+
+	journal := &models.Journal{
+		ID:       "editable",
+		Content:  "Original journal content for editing",
+		Metadata: map[string]any{"mood": float64(6), "location": "home"},
+		ProcessingResult: &models.ProcessingResult{
+			Status:          models.ProcessingStatusCompleted,
+			SentimentResult: &models.SentimentResult{Score: 0.6, Label: "positive"},
+		},
+	}
+	if err := store.Store(journal); err != nil {
+		t.Fatalf("Failed to seed journal: %v", err)
+	}
+
+	send := func(t *testing.T, method, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, "/journals/editable", strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.Journal {
+		t.Helper()
+		var journal models.Journal
+		if err := json.NewDecoder(w.Body).Decode(&journal); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return journal
+	}
+
+	t.Run("GetReturnsETag", func(t *testing.T) {
+		w := send(t, http.MethodGet, "", nil)
+		if etag := w.Header().Get("ETag"); etag != `"1"` {
+			t.Errorf(`Expected ETag "1", got %s`, etag)
+		}
+	})
+
+	t.Run("PatchMetadataKeepsAnalysis", func(t *testing.T) {
+		w := send(t, http.MethodPatch, `{"metadata": {"mood": 9, "location": null, "tags": ["edited"]}}`,
+			map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1"`})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if etag := w.Header().Get("ETag"); etag != `"2"` {
+			t.Errorf(`Expected ETag "2", got %s`, etag)
+		}
+
+		got := decode(t, w)
+		if got.Metadata["mood"] != float64(9) || got.Metadata["location"] != nil || got.Metadata["tags"] == nil {
+			t.Errorf("Unexpected merged metadata: %v", got.Metadata)
+		}
+		if got.ProcessingResult.SentimentResult.Label != "positive" {
+			t.Errorf("Expected metadata-only edit to keep analysis, got %+v", got.ProcessingResult)
+		}
+	})
+
+	t.Run("StaleIfMatchIsRejected", func(t *testing.T) {
+		w := send(t, http.MethodPut, `{"content": "Content written against an old version"}`,
+			map[string]string{"If-Match": `"1"`})
+		if w.Code != http.StatusPreconditionFailed {
+			t.Fatalf("Expected status 412, got %d", w.Code)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"2"` {
+			t.Errorf(`Expected current ETag "2", got %s`, etag)
+		}
+	})
+
+	t.Run("PutContentReprocesses", func(t *testing.T) {
+		w := send(t, http.MethodPut, `{"content": "Completely rewritten journal content", "metadata": {"mood": 3}}`,
+			map[string]string{"If-Match": `"2"`})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		got := decode(t, w)
+		if got.Version != 3 || got.Content != "Completely rewritten journal content" {
+			t.Errorf("Unexpected journal after PUT: version=%d content=%q", got.Version, got.Content)
+		}
+		if got.Metadata["location"] != nil || got.Metadata["mood"] != float64(3) {
+			t.Errorf("Expected PUT to replace metadata, got %v", got.Metadata)
+		}
+		if got.ProcessingResult.Status != models.ProcessingStatusCompleted || got.ProcessingResult.SentimentResult.Label != "negative" {
+			t.Errorf("Expected content edit to be reprocessed, got %+v", got.ProcessingResult)
+		}
+		if !got.CreatedAt.Equal(journal.CreatedAt) {
+			t.Errorf("Expected CreatedAt to be preserved")
+		}
+	})
+
+	t.Run("ContentEditWithoutWorkerIsStale", func(t *testing.T) {
+		plain := handlers.NewJournalHandler(store, nil, Logger())
+		req, _ := http.NewRequest(http.MethodPatch, "/journals/editable", strings.NewReader(`{"content": "Edited without any AI worker"}`))
+		w := httptest.NewRecorder()
+		plain.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if got := decode(t, w); got.ProcessingResult.Status != models.ProcessingStatusStale {
+			t.Errorf("Expected stale processing status, got %s", got.ProcessingResult.Status)
+		}
+	})
+
+	t.Run("RejectsInvalidPatches", func(t *testing.T) {
+		for _, body := range []string{`{"id": "other"}`, `{"content": 5}`, `{"content": null}`, `{"metadata": "x"}`, `[]`} {
+			w := send(t, http.MethodPatch, body, nil)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			}
+		}
+
+		w := send(t, http.MethodPatch, `{"content": "Valid content here"}`, map[string]string{"Content-Type": "text/plain"})
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected status 415, got %d", w.Code)
+		}
+	})
+
+	t.Run("DeleteHonoursIfMatch", func(t *testing.T) {
+		if w := send(t, http.MethodDelete, "", map[string]string{"If-Match": `"1"`}); w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status 412, got %d", w.Code)
+		}
+		if w := send(t, http.MethodDelete, "", map[string]string{"If-Match": "*"}); w.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", w.Code)
+		}
+		if w := send(t, http.MethodDelete, "", nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 after delete, got %d", w.Code)
+		}
+	})
+}
+
+func TestJournalHandler_Revisions(t *testing.T) {
+	store := storage.NewMemoryStore()
+	handler := handlers.NewJournalHandler(store, nil, Logger())
+
+	do := func(t *testing.T, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(t, http.MethodPost, "/journals", `{"content": "Day one: feeling unsure about the move", "metadata": {"mood": 4}}`,
+		map[string]string{"X-Author": "alice"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	var created models.Journal
+	json.NewDecoder(w.Body).Decode(&created)
+	base := "/journals/" + created.ID
+
+	if w := do(t, http.MethodPut, base, `{"content": "Day one: feeling excited about the move", "metadata": {"mood": 7}}`,
+		map[string]string{"X-Author": "alice"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	t.Run("ListsRevisions", func(t *testing.T) {
+		w := do(t, http.MethodGet, base+"/revisions", "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response struct {
+			Revisions []models.Revision `json:"revisions"`
+			Count     int               `json:"count"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Count != 2 || response.Revisions[0].Author != "alice" || response.Revisions[1].Number != 2 {
+			t.Errorf("Unexpected revisions: %+v", response)
+		}
+	})
+
+	t.Run("GetsSingleRevision", func(t *testing.T) {
+		w := do(t, http.MethodGet, base+"/revisions/1", "", nil)
+		var revision models.Revision
+		json.NewDecoder(w.Body).Decode(&revision)
+		if w.Code != http.StatusOK || revision.Content != "Day one: feeling unsure about the move" {
+			t.Errorf("Unexpected revision 1 (%d): %+v", w.Code, revision)
+		}
+
+		for path, want := range map[string]int{
+			base + "/revisions/9":         http.StatusNotFound,
+			base + "/revisions/zero":      http.StatusBadRequest,
+			"/journals/missing/revisions": http.StatusNotFound,
+		} {
+			if w := do(t, http.MethodGet, path, "", nil); w.Code != want {
+				t.Errorf("Expected status %d for %s, got %d", want, path, w.Code)
+			}
+		}
+	})
+
+	t.Run("DiffsRevisions", func(t *testing.T) {
+		w := do(t, http.MethodGet, base+"/revisions/diff?from=1&to=2", "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+
+		contentDiff := response["content_diff"].(string)
+		if !strings.Contains(contentDiff, "-Day one: feeling unsure about the move\n+Day one: feeling excited about the move") {
+			t.Errorf("Unexpected content diff:\n%s", contentDiff)
+		}
+		if metadataDiff := response["metadata_diff"].(string); !strings.Contains(metadataDiff, `-  "mood": 4`) {
+			t.Errorf("Unexpected metadata diff:\n%s", metadataDiff)
+		}
+	})
+
+	t.Run("RestoresRevision", func(t *testing.T) {
+		if w := do(t, http.MethodPost, base+"/revisions/1/restore", "", map[string]string{"If-Match": `"1"`}); w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status 412 for stale If-Match, got %d", w.Code)
+		}
+
+		w := do(t, http.MethodPost, base+"/revisions/1/restore", "", map[string]string{"If-Match": `"2"`, "X-Author": "bob"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var restored models.Journal
+		json.NewDecoder(w.Body).Decode(&restored)
+		if restored.Version != 3 || restored.Content != "Day one: feeling unsure about the move" || restored.Metadata["mood"] != float64(4) {
+			t.Errorf("Unexpected restored journal: %+v", restored)
+		}
+
+		revision, err := store.GetRevision(created.ID, 3)
+		if err != nil || revision.Author != "bob" {
+			t.Errorf("Expected restore to be recorded as revision 3 by bob, got %+v, %v", revision, err)
+		}
+	})
+}
+
+// gatedAIProcessor blocks every call until the gate is closed
+type gatedAIProcessor struct {
+	gate chan struct{}
+}
+
+func (g *gatedAIProcessor) ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+	select {
+	case <-g.gate:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &models.SentimentResult{Score: 0.3, Label: "positive", Confidence: 0.7}, nil
+}
+
+func TestJournalHandler_AsyncProcessing(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := &gatedAIProcessor{gate: make(chan struct{})}
+	pool, err := worker.NewPool(worker.NewInMemoryWorker(processor, Logger()), store, worker.PoolConfig{Workers: 1, QueueSize: 1}, Logger())
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	defer pool.Shutdown(context.Background())
+	handler := handlers.NewAsyncJournalHandler(store, pool, Logger())
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	processingStatus := func(t *testing.T, id string) map[string]any {
+		t.Helper()
+		w := do(t, http.MethodGet, "/journals/"+id+"/processing", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		return response
+	}
+
+	var first, second models.Journal
+
+	t.Run("CreateReturnsAccepted", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/journals", `{"content": "Queued for later analysis"}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		json.NewDecoder(w.Body).Decode(&first)
+		if first.ProcessingResult == nil || first.ProcessingResult.Status != models.ProcessingStatusPending {
+			t.Errorf("Expected pending processing result, got %+v", first.ProcessingResult)
+		}
+		if location := w.Header().Get("Location"); location != "/journals/"+first.ID {
+			t.Errorf("Unexpected Location header: %s", location)
+		}
+	})
+
+	t.Run("QueueFullReturns429", func(t *testing.T) {
+		// Wait for the first journal to occupy the only worker
+		deadline := time.Now().Add(2 * time.Second)
+		for pool.Stats().Processing == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		// This one fills the queue
+		w := do(t, http.MethodPost, "/journals", `{"content": "Waiting in the queue"}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		json.NewDecoder(w.Body).Decode(&second)
+
+		w = do(t, http.MethodPost, "/journals", `{"content": "No room for this one"}`)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429, got %d", w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Expected Retry-After header")
+		}
+		if count := store.Count(); count != 2 {
+			t.Errorf("Expected rejected journal not to be stored, got %d journals", count)
+		}
+	})
+
+	t.Run("StatusReportsQueuedJob", func(t *testing.T) {
+		response := processingStatus(t, second.ID)
+		if response["processing_status"] != string(models.ProcessingStatusPending) {
+			t.Errorf("Expected pending status, got %v", response["processing_status"])
+		}
+		if response["job"] == nil || response["queue"] == nil {
+			t.Errorf("Expected job and queue details, got %v", response)
+		}
+	})
+
+	t.Run("CompletesInBackground", func(t *testing.T) {
+		close(processor.gate)
+
+		deadline := time.Now().Add(2 * time.Second)
+		for _, id := range []string{first.ID, second.ID} {
+			for processingStatus(t, id)["processing_status"] != string(models.ProcessingStatusCompleted) {
+				if time.Now().After(deadline) {
+					t.Fatalf("Timed out waiting for journal %s to be processed", id)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+	})
+
+	t.Run("ContentEditIsReprocessed", func(t *testing.T) {
+		w := do(t, http.MethodPut, "/journals/"+first.ID, `{"content": "Edited after analysis"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			journal, _ := store.Get(first.ID)
+			if journal.ProcessingResult.Status == models.ProcessingStatusCompleted && journal.UpdatedBy == worker.ProcessorAuthor {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for reprocessing, status %s", journal.ProcessingResult.Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("RestoringUnanalyzedRevisionIsReprocessed", func(t *testing.T) {
+		// Revision 1 was recorded while the journal was still pending
+		w := do(t, http.MethodPost, "/journals/"+first.ID+"/revisions/1/restore", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var restored models.Journal
+		json.NewDecoder(w.Body).Decode(&restored)
+		if restored.ProcessingResult == nil || restored.ProcessingResult.Status != models.ProcessingStatusStale {
+			t.Errorf("Expected the restored journal to be stale, got %+v", restored.ProcessingResult)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			journal, _ := store.Get(first.ID)
+			if journal.ProcessingResult.Status == models.ProcessingStatusCompleted && journal.Content == "Queued for later analysis" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for the restored journal to be processed, status %s", journal.ProcessingResult.Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("UnknownJournal", func(t *testing.T) {
+		if w := do(t, http.MethodGet, "/journals/missing/processing", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("WrongMethodOnSubResource", func(t *testing.T) {
+		for path, want := range map[string]int{
+			"/journals/" + first.ID + "/processing": http.StatusMethodNotAllowed,
+			"/journals/" + first.ID + "/similar":    http.StatusMethodNotAllowed,
+			"/journals/search/semantic":             http.StatusMethodNotAllowed,
+			"/journals/" + first.ID + "/unknown":    http.StatusNotFound,
+		} {
+			if w := do(t, http.MethodPost, path, ""); w.Code != want {
+				t.Errorf("Expected status %d for POST %s, got %d", want, path, w.Code)
+			}
+		}
+	})
+}
+
+// generatorFunc adapts a function to the generator JournalHandler.SetGenerator takes
+type generatorFunc func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error)
+
+func (f generatorFunc) GenerateStructuredJournal(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+	return f(ctx, req)
+}
+
+func TestJournalHandler_GenerateJournal(t *testing.T) {
+	temperature := 0.7
+	generated := &models.GeneratedJournal{
+		Content: "  Walked along the river before work and felt calm for the first time this week.  ",
+		Metadata: models.GeneratedMetadata{
+			Mood:       "calm",
+			Themes:     []string{"nature", "rest"},
+			Entities:   []string{"river"},
+			KeyPhrases: []string{"felt calm"},
+			Tags:       []string{"walk"},
+		},
+		SemanticMarkers: []string{"morning_walk"},
+		GeneratedAt:     time.Date(2025, 8, 5, 10, 30, 0, 0, time.UTC),
+		Prompt:          &models.PromptRef{Name: "generation", Version: "v2"},
+		Options:         &models.GenerationOptions{Temperature: &temperature},
+	}
+
+	var received *models.PromptRequest
+	generator := generatorFunc(func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+		received = req
+		return generated, nil
+	})
+
+	post := func(handler *handlers.JournalHandler, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/journals/generate", strings.NewReader(body))
+		req.Header.Set("X-Author", "alice")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("stores and processes the generated journal", func(t *testing.T) {
+		store := storage.NewMemoryStore()
+		handler := handlers.NewJournalHandler(store, worker.NewInMemoryWorker(&mockAIProcessor{}, Logger()), Logger())
+		handler.SetGenerator(generator)
+
+		w := post(handler, `{"prompt": "Write about a morning walk", "prompt_template": "generation@v2"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if received.PromptTemplate != "generation@v2" {
+			t.Errorf("Expected the request to reach the generator, got %+v", received)
+		}
+
+		var journal models.Journal
+		if err := json.Unmarshal(w.Body.Bytes(), &journal); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if journal.Content != strings.TrimSpace(generated.Content) || journal.UpdatedBy != "alice" {
+			t.Errorf("Unexpected journal %+v", journal)
+		}
+		if journal.ProcessingResult == nil || journal.ProcessingResult.Status != models.ProcessingStatusCompleted {
+			t.Errorf("Expected sentiment processing to run, got %+v", journal.ProcessingResult)
+		}
+
+		stored, err := store.Get(journal.ID)
+		if err != nil {
+			t.Fatalf("Expected the journal to be stored: %v", err)
+		}
+		metadata := stored.Metadata
+		for key, want := range map[string]string{
+			"mood":             "calm",
+			"themes":           "[nature rest]",
+			"entities":         "[river]",
+			"key_phrases":      "[felt calm]",
+			"tags":             "[walk]",
+			"semantic_markers": "[morning_walk]",
+		} {
+			if got := fmt.Sprint(metadata[key]); got != want {
+				t.Errorf("Expected metadata %s %s, got %s", key, want, got)
+			}
+		}
+		if _, ok := metadata["emotional_context"]; ok {
+			t.Error("Expected empty fields to be left out")
+		}
+
+		provenance, _ := metadata["provenance"].(map[string]any)
+		if provenance["source"] != handlers.ProvenanceAIGenerated ||
+			provenance["prompt"] != "Write about a morning walk" ||
+			provenance["prompt_template"] != "generation@v2" ||
+			provenance["generated_at"] != "2025-08-05T10:30:00Z" {
+			t.Errorf("Unexpected provenance %v", provenance)
+		}
+		if options, _ := provenance["options"].(map[string]any); options["temperature"] != 0.7 {
+			t.Errorf("Expected the generation options in the provenance, got %v", provenance["options"])
+		}
+
+		// The stored metadata must survive a PATCH like client-sent metadata
+		patch := httptest.NewRequest(http.MethodPatch, "/journals/"+journal.ID, strings.NewReader(`{"metadata": {"reviewed": true}}`))
+		pw := httptest.NewRecorder()
+		handler.ServeHTTP(pw, patch)
+		if pw.Code != http.StatusOK {
+			t.Errorf("Expected the generated journal to be editable, got %d: %s", pw.Code, pw.Body.String())
+		}
+	})
+
+	t.Run("queues the generated journal", func(t *testing.T) {
+		store := storage.NewMemoryStore()
+		pool, err := worker.NewPool(worker.NewInMemoryWorker(&mockAIProcessor{}, Logger()), store, worker.PoolConfig{Workers: 1, QueueSize: 1}, Logger())
+		if err != nil {
+			t.Fatalf("NewPool failed: %v", err)
+		}
+		defer pool.Shutdown(context.Background())
+		handler := handlers.NewAsyncJournalHandler(store, pool, Logger())
+		handler.SetGenerator(generator)
+
+		w := post(handler, `{"prompt": "Write about a morning walk"}`)
+		if w.Code != http.StatusAccepted || !strings.HasPrefix(w.Header().Get("Location"), "/journals/") {
+			t.Errorf("Expected the journal to be queued, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			generator      generatorFunc
+			body           string
+			expectedStatus int
+			expectedBody   string
+		}{
+			{"invalid prompt", generator, `{"prompt": ""}`, http.StatusBadRequest, "Prompt is required"},
+			{"unknown template", func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+				return nil, fmt.Errorf("%w: missing", prompt.ErrUnknownTemplate)
+			}, `{"prompt": "Write about a walk"}`, http.StatusBadRequest, "UNKNOWN_PROMPT_TEMPLATE"},
+			{"generation failure", func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+				return nil, models.ErrInvalidAIResponse
+			}, `{"prompt": "Write about a walk"}`, http.StatusInternalServerError, "Journal generation failed"},
+			{"unusable content", func(ctx context.Context, req *models.PromptRequest) (*models.GeneratedJournal, error) {
+				return &models.GeneratedJournal{Content: "Short."}, nil
+			}, `{"prompt": "Write about a walk"}`, http.StatusBadGateway, "at least 10 characters"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				store := storage.NewMemoryStore()
+				handler := handlers.NewJournalHandler(store, nil, Logger())
+				handler.SetGenerator(tt.generator)
+
+				w := post(handler, tt.body)
+				if w.Code != tt.expectedStatus || !strings.Contains(w.Body.String(), tt.expectedBody) {
+					t.Errorf("Expected %d containing %q, got %d: %s", tt.expectedStatus, tt.expectedBody, w.Code, w.Body.String())
+				}
+				if page, _ := store.List(storage.JournalQuery{}); page != nil && page.Total != 0 {
+					t.Errorf("Expected nothing to be stored, got %d journals", page.Total)
+				}
+			})
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		w := post(handlers.NewJournalHandler(storage.NewMemoryStore(), nil, Logger()), `{"prompt": "Write about a walk"}`)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+}
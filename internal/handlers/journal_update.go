@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/vector"
+)
+
+// mergePatchContentType is the media type for JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// authorHeader names the author of a change, recorded in revision history
+const authorHeader = "X-Author"
+
+// defaultAuthor is recorded when a request does not name its author
+const defaultAuthor = "anonymous"
+
+// patchableFields are the journal fields a merge patch may change
+var patchableFields = map[string]bool{"content": true, "metadata": true}
+
+// updateJournal handles PUT /journals/{id}, replacing content and metadata
+func (h *JournalHandler) updateJournal(w http.ResponseWriter, r *http.Request, id string) {
+	existing, ok := h.loadForWrite(w, r, id)
+	if !ok {
+		return
+	}
+
+	var req models.CreateJournalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithContext(r.Context()).Error("Failed to decode update journal request", "error", err)
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "body",
+				Message: "Invalid JSON format: " + err.Error(),
+				Code:    "INVALID_JSON",
+			},
+		})
+		return
+	}
+
+	h.applyJournalUpdate(w, r, existing, req)
+}
+
+// patchJournal handles PATCH /journals/{id} with a JSON Merge Patch document
+func (h *JournalHandler) patchJournal(w http.ResponseWriter, r *http.Request, id string) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergePatchContentType && mediaType != "application/json") {
+			h.sendErrorResponse(w, "Content-Type must be "+mergePatchContentType, http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	existing, ok := h.loadForWrite(w, r, id)
+	if !ok {
+		return
+	}
+
+	var patch map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		message := "Merge patch must be a JSON object"
+		if err != nil {
+			message = "Invalid JSON format: " + err.Error()
+		}
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "body",
+				Message: message,
+				Code:    "INVALID_JSON",
+			},
+		})
+		return
+	}
+
+	req, validationErrors := applyMergePatch(existing, patch)
+	if validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("patch_journal", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	h.applyJournalUpdate(w, r, existing, req)
+}
+
+// deleteJournal handles DELETE /journals/{id}
+func (h *JournalHandler) deleteJournal(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := h.loadForWrite(w, r, id); !ok {
+		return
+	}
+
+	if err := h.store.Delete(id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			h.sendErrorResponse(w, "Journal not found", http.StatusNotFound)
+			return
+		}
+		h.logger.LogStorageOperation("delete", "journal", id, false, err.Error())
+		h.sendErrorResponse(w, "Failed to delete journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	if h.index != nil {
+		h.index.Remove(id)
+	}
+
+	h.logger.WithContext(r.Context()).Info("Journal deleted successfully", "journal_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadForWrite fetches a journal and checks the If-Match precondition
+// It writes the error response and returns false when the write must not proceed
+func (h *JournalHandler) loadForWrite(w http.ResponseWriter, r *http.Request, id string) (*models.Journal, bool) {
+	existing, err := h.store.Get(id)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Info("Journal not found", "journal_id", id, "error", err)
+		h.sendErrorResponse(w, "Journal not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if !ifMatch(r.Header.Get("If-Match"), existing.Version) {
+		h.sendPreconditionFailed(w, existing)
+		return nil, false
+	}
+
+	return existing, true
+}
+
+// applyJournalUpdate validates the new content and metadata, reprocesses the
+// journal if its content changed and saves it against the version that was read
+func (h *JournalHandler) applyJournalUpdate(w http.ResponseWriter, r *http.Request, existing *models.Journal, req models.CreateJournalRequest) {
+	if validationErrors := req.Validate(); validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("update_journal", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	updated := *existing
+	updated.Content = strings.TrimSpace(req.Content)
+	updated.Metadata = req.Metadata
+
+	// Only a conditional request pins the version; otherwise last write wins
+	updated.Version = 0
+	if r.Header.Get("If-Match") != "" {
+		updated.Version = existing.Version
+	}
+
+	updated.UpdatedBy = requestAuthor(r)
+
+	if updated.Content != existing.Content {
+		// The previous analysis no longer describes the content
+		updated.ProcessingResult = &models.ProcessingResult{Status: models.ProcessingStatusStale}
+		if h.pool == nil {
+			h.processJournal(r, &updated)
+		}
+	}
+
+	h.saveJournalUpdate(w, r, existing, &updated)
+}
+
+// saveJournalUpdate writes an updated journal and sends the response
+// Version conflicts are reported as 412 with the current ETag
+func (h *JournalHandler) saveJournalUpdate(w http.ResponseWriter, r *http.Request, existing, updated *models.Journal) {
+	if err := h.store.Update(existing.ID, updated); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrVersionConflict):
+			current, getErr := h.store.Get(existing.ID)
+			if getErr != nil {
+				h.sendErrorResponse(w, "Journal not found", http.StatusNotFound)
+				return
+			}
+			h.sendPreconditionFailed(w, current)
+		case errors.Is(err, storage.ErrNotFound):
+			h.sendErrorResponse(w, "Journal not found", http.StatusNotFound)
+		default:
+			h.logger.LogStorageOperation("update", "journal", existing.ID, false, err.Error())
+			h.sendErrorResponse(w, "Failed to update journal entry", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Journal updated successfully",
+		"journal_id", updated.ID,
+		"version", updated.Version,
+		"content_changed", updated.Content != existing.Content)
+
+	// Vectors of the old content would keep matching it until the journal is
+	// embedded again, which never happens if reprocessing fails
+	if h.index != nil && updated.Content != existing.Content {
+		h.index.RemoveStale(updated.ID, vector.ContentHash(updated.Content))
+
+		// A restored analysis is not queued again, so its content is embedded here
+		if updated.ProcessingResult != nil && updated.ProcessingResult.Status == models.ProcessingStatusCompleted {
+			h.embedMissing(r, updated)
+		}
+	}
+
+	if h.pool != nil && updated.ProcessingResult != nil && updated.ProcessingResult.Status == models.ProcessingStatusStale {
+		h.enqueueReprocessing(r, updated.ID)
+	}
+
+	setETag(w, updated)
+	h.sendJSONResponse(w, updated, http.StatusOK)
+}
+
+// embedMissing embeds a completed journal that has no vectors in the index
+func (h *JournalHandler) embedMissing(r *http.Request, journal *models.Journal) {
+	journals := []*models.Journal{journal}
+	switch {
+	case h.pool != nil:
+		h.pool.EmbedMissing(r.Context(), journals)
+	case h.worker != nil:
+		h.worker.EmbedMissing(r.Context(), journals)
+	}
+}
+
+// requestAuthor identifies who made a change, from the X-Author header
+func requestAuthor(r *http.Request) string {
+	if author := strings.TrimSpace(r.Header.Get(authorHeader)); author != "" {
+		return author
+	}
+	return defaultAuthor
+}
+
+// sendPreconditionFailed reports that If-Match did not match the current version
+func (h *JournalHandler) sendPreconditionFailed(w http.ResponseWriter, current *models.Journal) {
+	setETag(w, current)
+	h.sendErrorResponse(w, "Journal has been modified; fetch the latest version and retry", http.StatusPreconditionFailed)
+}
+
+// applyMergePatch applies a JSON Merge Patch (RFC 7396) to a journal's
+// content and metadata and returns the resulting update request
+func applyMergePatch(journal *models.Journal, patch map[string]any) (models.CreateJournalRequest, models.ValidationErrors) {
+	var errors models.ValidationErrors
+
+	for field := range patch {
+		if !patchableFields[field] {
+			errors = append(errors, models.ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("Field '%s' cannot be patched; only content and metadata are editable", field),
+				Code:    "IMMUTABLE_FIELD",
+			})
+		}
+	}
+
+	req := models.CreateJournalRequest{
+		Content:  journal.Content,
+		Metadata: journal.Metadata,
+	}
+
+	if value, exists := patch["content"]; exists {
+		content, ok := value.(string)
+		if !ok {
+			errors = append(errors, models.ValidationError{
+				Field:   "content",
+				Message: "Content must be a string",
+				Code:    "INVALID_TYPE",
+			})
+		}
+		req.Content = content
+	}
+
+	if value, exists := patch["metadata"]; exists {
+		switch merged := mergePatch(journal.Metadata, value).(type) {
+		case nil:
+			req.Metadata = nil
+		case map[string]any:
+			req.Metadata = merged
+			if len(merged) == 0 {
+				req.Metadata = nil
+			}
+		default:
+			errors = append(errors, models.ValidationError{
+				Field:   "metadata",
+				Message: "Metadata must be an object or null",
+				Code:    "INVALID_TYPE",
+			})
+		}
+	}
+
+	return req, errors
+}
+
+// mergePatch implements the RFC 7396 MergePatch algorithm
+// Objects are merged recursively, null removes a member and any other value
+// replaces the target. The target is never modified.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	result := make(map[string]any)
+	if targetObject, ok := target.(map[string]any); ok {
+		for key, value := range targetObject {
+			result[key] = value
+		}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergePatch(result[key], value)
+	}
+
+	return result
+}
+
+// etag formats a journal version as a strong entity tag
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag sets the ETag header for a journal response
+func setETag(w http.ResponseWriter, journal *models.Journal) {
+	w.Header().Set("ETag", etag(journal.Version))
+}
+
+// ifMatch evaluates an If-Match header against the current version
+// An absent header or "*" matches any existing journal
+func ifMatch(header string, version int64) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := etag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		// If-Match uses strong comparison, so weak tags never match
+		if strings.TrimSpace(candidate) == current {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/digest"
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// SummaryHandler handles digest endpoints under /summaries
+type SummaryHandler struct {
+	builder *digest.Builder
+	digests *digest.Store
+	logger  *logging.Logger
+}
+
+// NewSummaryHandler creates a new summary handler
+func NewSummaryHandler(builder *digest.Builder, digests *digest.Store, logger *logging.Logger) *SummaryHandler {
+	return &SummaryHandler{
+		builder: builder,
+		digests: digests,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface for summary endpoints
+//
+//	POST /summaries              write the digest of a period
+//	GET  /summaries?period=      list digests, most recent period first
+//	GET  /summaries/{id}         get one digest
+func (h *SummaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/summaries"), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		h.createDigest(w, r)
+	case id == "" && r.Method == http.MethodGet:
+		h.listDigests(w, r)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodGet:
+		h.getDigest(w, r, id)
+	case strings.Contains(id, "/"):
+		h.sendErrorResponse(w, "Not found", http.StatusNotFound)
+	default:
+		h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createDigest handles POST /summaries
+func (h *SummaryHandler) createDigest(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateDigestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WithContext(r.Context()).Error("Failed to decode create digest request", "error", err)
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "body",
+				Message: "Invalid JSON format: " + err.Error(),
+				Code:    "INVALID_JSON",
+			},
+		})
+		return
+	}
+
+	if validationErrors := req.Validate(); validationErrors.HasErrors() {
+		h.logger.WithContext(r.Context()).LogValidationError("create_digest", validationErrors)
+		h.sendValidationErrorResponse(w, validationErrors)
+		return
+	}
+
+	var date time.Time
+	if req.Date != nil {
+		date = *req.Date
+	} else {
+		date, _ = digest.LastComplete(req.Period, time.Now())
+	}
+
+	result, err := h.builder.Build(r.Context(), req.Period, date)
+	switch {
+	case errors.Is(err, digest.ErrNoJournals):
+		h.sendErrorResponse(w, "No journals in the period", http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, errors.ErrUnsupported):
+		h.sendErrorResponse(w, "Summaries are not supported by the configured AI provider", http.StatusNotImplemented)
+		return
+	case err != nil:
+		h.logger.WithContext(r.Context()).Error("Digest failed", "period", req.Period, "date", date, "error", err)
+		h.sendErrorResponse(w, "Failed to write the digest", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(w, result, http.StatusCreated)
+}
+
+// listDigests handles GET /summaries?period=
+func (h *SummaryHandler) listDigests(w http.ResponseWriter, r *http.Request) {
+	period := models.DigestPeriod(r.URL.Query().Get("period"))
+	if period != "" && !slices.Contains(models.DigestPeriods, period) {
+		h.sendValidationErrorResponse(w, models.ValidationErrors{
+			{
+				Field:   "period",
+				Message: "Period must be daily, weekly or monthly",
+				Code:    "INVALID_VALUE",
+			},
+		})
+		return
+	}
+
+	digests := h.digests.List(period)
+
+	response := map[string]any{
+		"digests":      digests,
+		"count":        len(digests),
+		"retrieved_at": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, http.StatusOK)
+}
+
+// getDigest handles GET /summaries/{id}
+func (h *SummaryHandler) getDigest(w http.ResponseWriter, r *http.Request, id string) {
+	result, err := h.digests.Get(id)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Info("Digest not found", "digest_id", id, "error", err)
+		h.sendErrorResponse(w, "Digest not found", http.StatusNotFound)
+		return
+	}
+
+	h.sendJSONResponse(w, result, http.StatusOK)
+}
+
+// sendJSONResponse sends a JSON response with the given data and status code
+func (h *SummaryHandler) sendJSONResponse(w http.ResponseWriter, data any, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// sendErrorResponse sends an error response with the given message and status code
+func (h *SummaryHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	response := map[string]any{
+		"status":    statusCode,
+		"error":     message,
+		"timestamp": time.Now().UTC(),
+	}
+
+	h.sendJSONResponse(w, response, statusCode)
+}
+
+// sendValidationErrorResponse sends a structured validation error response
+func (h *SummaryHandler) sendValidationErrorResponse(w http.ResponseWriter, validationErrors models.ValidationErrors) {
+	response := map[string]any{
+		"error":             "Validation failed",
+		"status":            http.StatusBadRequest,
+		"timestamp":         time.Now().UTC(),
+		"validation_errors": validationErrors,
+	}
+
+	h.sendJSONResponse(w, response, http.StatusBadRequest)
+}
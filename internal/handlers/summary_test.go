@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/digest"
+	"github.com/garnizeh/englog/internal/handlers"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+func TestSummaryHandler(t *testing.T) {
+	store := storage.NewMemoryStore()
+	day := time.Date(2025, 8, 5, 0, 0, 0, 0, time.UTC)
+	for i, content := range []string{"Morning run by the river.", "Launch slipped again."} {
+		journal := &models.Journal{
+			ID:        []string{"run", "launch"}[i],
+			Content:   content,
+			CreatedAt: day.Add(time.Duration(8+i*12) * time.Hour),
+			ProcessingResult: &models.ProcessingResult{
+				Status:          models.ProcessingStatusCompleted,
+				SentimentResult: &models.SentimentResult{Label: "positive", Score: 0.5},
+			},
+		}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Failed to seed journal: %v", err)
+		}
+	}
+
+	provider := ai.NewMockAIProvider()
+	digests, err := digest.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	builder := digest.NewBuilder(store, digests, ai.NewService(provider, Logger()), digest.Config{}, Logger())
+	handler := handlers.NewSummaryHandler(builder, digests, Logger())
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var created models.Digest
+	t.Run("Create", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/summaries", `{"period": "daily", "date": "2025-08-05T12:00:00Z"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+			t.Fatalf("Failed to decode digest: %v", err)
+		}
+		if created.Summary == "" || created.Provider != ai.MockProviderName {
+			t.Errorf("Expected a summary from the mock provider, got %+v", created)
+		}
+		if len(created.JournalIDs) != 2 || created.JournalIDs[0] != "run" {
+			t.Errorf("Expected links to the source journals, got %v", created.JournalIDs)
+		}
+		if created.Stats.Analyzed != 2 || len(created.DominantMoods) != 1 || created.DominantMoods[0].Mood != "positive" {
+			t.Errorf("Expected stats from the stored analysis, got %+v %+v", created.Stats, created.DominantMoods)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/summaries/"+created.ID, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if w := do(t, http.MethodGet, "/summaries/missing", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/summaries?period=daily", "")
+		var response struct {
+			Digests []models.Digest `json:"digests"`
+			Count   int             `json:"count"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Count != 1 || response.Digests[0].ID != created.ID {
+			t.Errorf("Expected the daily digest, got %+v", response)
+		}
+		if w := do(t, http.MethodGet, "/summaries?period=weekly", ""); !bytes.Contains(w.Body.Bytes(), []byte(`"count":0`)) {
+			t.Errorf("Expected no weekly digests, got %s", w.Body.String())
+		}
+		if w := do(t, http.MethodGet, "/summaries?period=hourly", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown period, got %d", w.Code)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name, method, path, body string
+			expected                 int
+		}{
+			{"invalid json", http.MethodPost, "/summaries", `{`, http.StatusBadRequest},
+			{"missing period", http.MethodPost, "/summaries", `{}`, http.StatusBadRequest},
+			{"unknown period", http.MethodPost, "/summaries", `{"period": "hourly"}`, http.StatusBadRequest},
+			{"no journals", http.MethodPost, "/summaries", `{"period": "daily", "date": "2025-09-01T00:00:00Z"}`, http.StatusUnprocessableEntity},
+			{"method", http.MethodDelete, "/summaries", "", http.StatusMethodNotAllowed},
+			{"nested path", http.MethodGet, "/summaries/a/b", "", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if w := do(t, tt.method, tt.path, tt.body); w.Code != tt.expected {
+				t.Errorf("%s: expected status %d, got %d", tt.name, tt.expected, w.Code)
+			}
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		provider.SummarizeFunc = func(ctx context.Context, req *models.SummaryRequest) (*models.SummaryResult, error) {
+			return nil, ai.ErrSummariesUnsupported
+		}
+		if _, err := builder.Build(context.Background(), models.DigestDaily, day); !errors.Is(err, errors.ErrUnsupported) {
+			t.Fatalf("Expected an unsupported error, got %v", err)
+		}
+		if w := do(t, http.MethodPost, "/summaries", `{"period": "daily", "date": "2025-08-05T00:00:00Z"}`); w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -16,8 +18,13 @@ const (
 	ProcessingStatusProcessing ProcessingStatus = "processing"
 	ProcessingStatusCompleted  ProcessingStatus = "completed"
 	ProcessingStatusFailed     ProcessingStatus = "failed"
+	ProcessingStatusStale      ProcessingStatus = "stale"
 )
 
+// ErrInvalidAIResponse marks failures where the AI service answered but its
+// output could not be parsed or validated, as opposed to transport errors
+var ErrInvalidAIResponse = errors.New("invalid AI response")
+
 // ProcessingResult contains the results of AI processing for a journal entry
 // Schema: Complete AI analysis results including sentiment, timing, and error information
 type ProcessingResult struct {
@@ -35,6 +42,216 @@ type ProcessingResult struct {
 
 	// Error contains error message if processing failed (only set if status is "failed")
 	Error string `json:"error,omitempty" example:"AI service temporarily unavailable"`
+
+	// Provider names the AI backend that produced the result (only set if completed)
+	Provider string `json:"provider,omitempty" example:"ollama"`
+
+	// Reasoning is the model's chain of thought, kept for debugging when
+	// AI_STORE_REASONING is enabled
+	Reasoning string `json:"reasoning,omitempty"`
+
+	// Prompt identifies the prompt template version used (only set if completed
+	// by a model-backed provider)
+	Prompt *PromptRef `json:"prompt,omitempty"`
+
+	// Options are the generation options the model was called with, so the
+	// analysis can be replayed (only set if completed by a model-backed provider)
+	Options *GenerationOptions `json:"options,omitempty"`
+
+	// EmotionResult contains the emotion analysis, when the provider supports it
+	// It records its own provider, prompt and options.
+	EmotionResult *EmotionResult `json:"emotion_result,omitempty"`
+
+	// Extraction contains the people, places, organizations, activities,
+	// themes and suggested tags found in the entry, when the provider supports it
+	Extraction *ExtractionResult `json:"extraction,omitempty"`
+}
+
+// PromptRef identifies a version of a prompt template
+type PromptRef struct {
+	Name    string `json:"name" example:"sentiment"`
+	Version string `json:"version" example:"v2"`
+}
+
+// String returns the reference in name@version form
+func (r PromptRef) String() string {
+	return r.Name + "@" + r.Version
+}
+
+// GenerationOptions are the sampling parameters a model is called with
+// Unset fields leave the model's own default in place. Field names follow the
+// Ollama API, so the options can be sent as they are.
+type GenerationOptions struct {
+	// Temperature controls randomness; 0 always picks the most likely token
+	Temperature *float64 `json:"temperature,omitempty" example:"0"`
+
+	// TopP limits sampling to the smallest set of tokens with this total probability
+	TopP *float64 `json:"top_p,omitempty" example:"0.9"`
+
+	// Seed makes sampling reproducible for the same model, prompt and options
+	Seed *int `json:"seed,omitempty" example:"42"`
+
+	// NumCtx is the context window in tokens
+	NumCtx *int `json:"num_ctx,omitempty" example:"4096"`
+
+	// NumPredict caps the number of generated tokens; -1 means no limit
+	NumPredict *int `json:"num_predict,omitempty" example:"512"`
+
+	// Stop sequences end generation when the model emits one of them
+	Stop []string `json:"stop,omitempty"`
+}
+
+// generationOptionKeys are the PromptRequest.Metadata keys read as options
+var generationOptionKeys = []string{"temperature", "top_p", "seed", "num_ctx", "num_predict", "stop"}
+
+// IsZero reports whether no option is set
+func (o GenerationOptions) IsZero() bool {
+	return o.Temperature == nil && o.TopP == nil && o.Seed == nil &&
+		o.NumCtx == nil && o.NumPredict == nil && len(o.Stop) == 0
+}
+
+// Merge returns o with every option set in override replacing its own
+func (o GenerationOptions) Merge(override GenerationOptions) GenerationOptions {
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		o.TopP = override.TopP
+	}
+	if override.Seed != nil {
+		o.Seed = override.Seed
+	}
+	if override.NumCtx != nil {
+		o.NumCtx = override.NumCtx
+	}
+	if override.NumPredict != nil {
+		o.NumPredict = override.NumPredict
+	}
+	if override.Stop != nil {
+		o.Stop = override.Stop
+	}
+	return o
+}
+
+// Validate checks that every set option is in range
+// Fields are reported with prefix, e.g. "metadata." for request metadata.
+func (o GenerationOptions) Validate(prefix string) ValidationErrors {
+	var errors ValidationErrors
+	outOfRange := func(field, message string) {
+		errors = append(errors, ValidationError{
+			Field:   prefix + field,
+			Message: message,
+			Code:    "OUT_OF_RANGE",
+		})
+	}
+
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		outOfRange("temperature", "Temperature must be between 0 and 2")
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > 1) {
+		outOfRange("top_p", "Top P must be greater than 0 and at most 1")
+	}
+	if o.NumCtx != nil && *o.NumCtx < 1 {
+		outOfRange("num_ctx", "Context window must be a positive number of tokens")
+	}
+	if o.NumPredict != nil && *o.NumPredict < 1 && *o.NumPredict != -1 {
+		outOfRange("num_predict", "Token limit must be positive, or -1 for no limit")
+	}
+	if len(o.Stop) > 4 {
+		outOfRange("stop", "At most 4 stop sequences are allowed")
+	}
+	for _, stop := range o.Stop {
+		if stop == "" {
+			outOfRange("stop", "Stop sequences cannot be empty")
+			break
+		}
+	}
+
+	return errors
+}
+
+// GenerationOptionsFromMetadata reads the options set in request metadata
+// under the keys temperature, top_p, seed, num_ctx, num_predict and stop;
+// other keys are generation hints and are ignored
+func GenerationOptionsFromMetadata(metadata map[string]any) (GenerationOptions, ValidationErrors) {
+	var options GenerationOptions
+	var errors ValidationErrors
+
+	for _, key := range generationOptionKeys {
+		value, ok := metadata[key]
+		if !ok || value == nil {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "temperature":
+			options.Temperature, err = metadataFloat(value)
+		case "top_p":
+			options.TopP, err = metadataFloat(value)
+		case "seed":
+			options.Seed, err = metadataInt(value)
+		case "num_ctx":
+			options.NumCtx, err = metadataInt(value)
+		case "num_predict":
+			options.NumPredict, err = metadataInt(value)
+		case "stop":
+			options.Stop, err = metadataStrings(value)
+		}
+		if err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "metadata." + key,
+				Message: err.Error(),
+				Code:    "INVALID_TYPE",
+			})
+		}
+	}
+
+	return options, append(errors, options.Validate("metadata.")...)
+}
+
+func metadataFloat(value any) (*float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return &v, nil
+	case int:
+		f := float64(v)
+		return &f, nil
+	}
+	return nil, fmt.Errorf("must be a number")
+}
+
+func metadataInt(value any) (*int, error) {
+	switch v := value.(type) {
+	case int:
+		return &v, nil
+	case float64:
+		if v == float64(int(v)) {
+			i := int(v)
+			return &i, nil
+		}
+	}
+	return nil, fmt.Errorf("must be an integer")
+}
+
+func metadataStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		stops := make([]string, 0, len(v))
+		for _, item := range v {
+			stop, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a string or an array of strings")
+			}
+			stops = append(stops, stop)
+		}
+		return stops, nil
+	}
+	return nil, fmt.Errorf("must be a string or an array of strings")
 }
 
 // Journal represents a journal entry in the system
@@ -61,6 +278,12 @@ type Journal struct {
 	// UpdatedAt represents when the journal entry was last updated
 	UpdatedAt time.Time `json:"updated_at" example:"2025-08-05T10:30:15Z"`
 
+	// Version is incremented on every update and backs the ETag used for optimistic concurrency
+	Version int64 `json:"version" example:"1"`
+
+	// UpdatedBy identifies who made the latest change; recorded as the revision author
+	UpdatedBy string `json:"updated_by,omitempty" example:"alice"`
+
 	// Metadata contains additional structured data associated with the journal entry
 	// Can include mood ratings, tags, location data, etc.
 	// Maximum 20 fields, each key max 100 chars, each string value max 1000 chars
@@ -70,6 +293,51 @@ type Journal struct {
 	ProcessingResult *ProcessingResult `json:"processing_result,omitempty"`
 }
 
+// Revision is an immutable snapshot of a journal entry taken after each mutation
+// Schema: Captures content, metadata and AI results as they were at a given version
+type Revision struct {
+	// JournalID identifies the journal this revision belongs to
+	JournalID string `json:"journal_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Number is the journal version captured by this revision, starting at 1
+	Number int64 `json:"number" example:"2"`
+
+	// Content is the journal content at this revision
+	Content string `json:"content" example:"Today was a wonderful day filled with new experiences..."`
+
+	// Metadata is the journal metadata at this revision
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// ProcessingResult is the AI analysis attached to the journal at this revision
+	ProcessingResult *ProcessingResult `json:"processing_result,omitempty"`
+
+	// Author identifies who made the change
+	Author string `json:"author,omitempty" example:"alice"`
+
+	// CreatedAt is when the change was made
+	CreatedAt time.Time `json:"created_at" example:"2025-08-05T10:30:15Z"`
+}
+
+// NewRevision captures the current state of a journal as a revision
+func NewRevision(journal *Journal) *Revision {
+	revision := &Revision{
+		JournalID: journal.ID,
+		Number:    journal.Version,
+		Content:   journal.Content,
+		Metadata:  journal.Metadata,
+		Author:    journal.UpdatedBy,
+		CreatedAt: journal.UpdatedAt,
+	}
+
+	// Copy the result so later in-place status changes don't rewrite history
+	if journal.ProcessingResult != nil {
+		result := *journal.ProcessingResult
+		revision.ProcessingResult = &result
+	}
+
+	return revision
+}
+
 // CreateJournalRequest represents the request body for creating a journal
 // Schema: Defines the required and optional fields for creating a new journal entry
 type CreateJournalRequest struct {
@@ -88,7 +356,7 @@ type CreateJournalRequest struct {
 type SentimentResult struct {
 	// Score represents sentiment polarity from -1.0 (very negative) to 1.0 (very positive)
 	// 0.0 represents neutral sentiment
-	Score float64 `json:"score" example:"0.75"`
+	Score float64 `json:"score" example:"0.75" minimum:"-1" maximum:"1"`
 
 	// Label provides a human-readable sentiment classification
 	// Valid values: "positive", "negative", "neutral"
@@ -96,29 +364,474 @@ type SentimentResult struct {
 
 	// Confidence represents the AI model's confidence in this sentiment analysis
 	// Range: 0.0 (no confidence) to 1.0 (maximum confidence)
-	Confidence float64 `json:"confidence" example:"0.92"`
+	Confidence float64 `json:"confidence" example:"0.92" minimum:"0" maximum:"1"`
 
 	// ProcessedAt timestamp when sentiment analysis was performed
-	ProcessedAt time.Time `json:"processed_at" example:"2025-08-05T10:30:18Z"`
+	ProcessedAt time.Time `json:"processed_at" example:"2025-08-05T10:30:18Z" schema:"-"`
+
+	// Provider names the AI backend that produced this result
+	Provider string `json:"provider,omitempty" example:"ollama" schema:"-"`
+
+	// Prompt identifies the prompt template version the model was given
+	Prompt *PromptRef `json:"prompt,omitempty" schema:"-"`
+
+	// Options are the generation options the model was called with
+	Options *GenerationOptions `json:"options,omitempty" schema:"-"`
+
+	// Reasoning is the chain of thought a reasoning model emitted before its
+	// answer; it is only persisted through ProcessingResult.Reasoning
+	Reasoning string `json:"-"`
+}
+
+// Emotion names, in the order of Plutchik's taxonomy
+const (
+	EmotionJoy          = "joy"
+	EmotionSadness      = "sadness"
+	EmotionAnger        = "anger"
+	EmotionFear         = "fear"
+	EmotionSurprise     = "surprise"
+	EmotionDisgust      = "disgust"
+	EmotionTrust        = "trust"
+	EmotionAnticipation = "anticipation"
+)
+
+// EmotionNames lists every emotion of the taxonomy
+var EmotionNames = []string{
+	EmotionJoy, EmotionSadness, EmotionAnger, EmotionFear,
+	EmotionSurprise, EmotionDisgust, EmotionTrust, EmotionAnticipation,
+}
+
+// Emotions holds the intensity of each emotion of the taxonomy
+// Range: 0.0 (absent) to 1.0 (overwhelming)
+type Emotions struct {
+	Joy          float64 `json:"joy" example:"0.7" minimum:"0" maximum:"1"`
+	Sadness      float64 `json:"sadness" example:"0.1" minimum:"0" maximum:"1"`
+	Anger        float64 `json:"anger" example:"0" minimum:"0" maximum:"1"`
+	Fear         float64 `json:"fear" example:"0.05" minimum:"0" maximum:"1"`
+	Surprise     float64 `json:"surprise" example:"0.2" minimum:"0" maximum:"1"`
+	Disgust      float64 `json:"disgust" example:"0" minimum:"0" maximum:"1"`
+	Trust        float64 `json:"trust" example:"0.4" minimum:"0" maximum:"1"`
+	Anticipation float64 `json:"anticipation" example:"0.5" minimum:"0" maximum:"1"`
+}
+
+// field returns the intensity of the named emotion, or nil for unknown names
+func (e *Emotions) field(name string) *float64 {
+	switch strings.ToLower(name) {
+	case EmotionJoy:
+		return &e.Joy
+	case EmotionSadness:
+		return &e.Sadness
+	case EmotionAnger:
+		return &e.Anger
+	case EmotionFear:
+		return &e.Fear
+	case EmotionSurprise:
+		return &e.Surprise
+	case EmotionDisgust:
+		return &e.Disgust
+	case EmotionTrust:
+		return &e.Trust
+	case EmotionAnticipation:
+		return &e.Anticipation
+	}
+	return nil
+}
+
+// Intensity returns the intensity of the named emotion; ok is false for
+// names outside the taxonomy
+func (e Emotions) Intensity(name string) (intensity float64, ok bool) {
+	if f := e.field(name); f != nil {
+		return *f, true
+	}
+	return 0, false
+}
+
+// Set changes the intensity of the named emotion; it reports false for names
+// outside the taxonomy
+func (e *Emotions) Set(name string, intensity float64) bool {
+	f := e.field(name)
+	if f == nil {
+		return false
+	}
+	*f = intensity
+	return true
+}
+
+// Dominant returns the most intense emotion, or "" when every intensity is 0
+// Ties go to the emotion listed first in EmotionNames.
+func (e Emotions) Dominant() string {
+	dominant, highest := "", 0.0
+	for _, name := range EmotionNames {
+		if intensity, _ := e.Intensity(name); intensity > highest {
+			dominant, highest = name, intensity
+		}
+	}
+	return dominant
+}
+
+// SentenceEmotions is the emotion analysis of a single sentence
+type SentenceEmotions struct {
+	// Text is the sentence as it appears in the entry
+	Text string `json:"text" example:"Finally finished the marathon." minLength:"1"`
+
+	// Emotions holds the intensity of each emotion in the sentence
+	Emotions Emotions `json:"emotions"`
+
+	// Valence is how pleasant the sentence is, from -1.0 to 1.0
+	Valence float64 `json:"valence" example:"0.8" minimum:"-1" maximum:"1"`
+
+	// Arousal is how activated or calm the sentence is, from 0.0 to 1.0
+	Arousal float64 `json:"arousal" example:"0.7" minimum:"0" maximum:"1"`
+}
+
+// EmotionResult represents the result of emotion analysis
+// Schema: Emotion intensities, valence and arousal for the whole entry and
+// for each sentence
+type EmotionResult struct {
+	// Emotions holds the intensity of each emotion across the entry
+	Emotions Emotions `json:"emotions"`
+
+	// Valence is how pleasant the entry is, from -1.0 (very unpleasant) to 1.0
+	Valence float64 `json:"valence" example:"0.6" minimum:"-1" maximum:"1"`
+
+	// Arousal is how activated the entry is, from 0.0 (calm) to 1.0 (agitated)
+	Arousal float64 `json:"arousal" example:"0.55" minimum:"0" maximum:"1"`
+
+	// Sentences breaks the analysis down sentence by sentence
+	Sentences []SentenceEmotions `json:"sentences" minItems:"1"`
+
+	// DominantEmotion is the most intense emotion, empty when none is present
+	DominantEmotion string `json:"dominant_emotion,omitempty" example:"joy" schema:"-"`
+
+	// ProcessedAt timestamp when emotion analysis was performed
+	ProcessedAt time.Time `json:"processed_at" example:"2025-08-05T10:30:19Z" schema:"-"`
+
+	// Provider names the AI backend that produced this result
+	Provider string `json:"provider,omitempty" example:"ollama" schema:"-"`
+
+	// Prompt identifies the prompt template version the model was given
+	Prompt *PromptRef `json:"prompt,omitempty" schema:"-"`
+
+	// Options are the generation options the model was called with
+	Options *GenerationOptions `json:"options,omitempty" schema:"-"`
+}
+
+// ExtractedItem is something found in a journal, with how sure the model is of it
+type ExtractedItem struct {
+	// Name is the item as it should be displayed, e.g. "Alice" or "running"
+	Name string `json:"name" example:"Alice" minLength:"1"`
+
+	// Confidence ranges from 0.0 (a guess) to 1.0 (stated outright)
+	Confidence float64 `json:"confidence" example:"0.9" minimum:"0" maximum:"1"`
+}
+
+// ExtractionResult represents the entities and topics extracted from a journal
+// Schema: Named entities, activities, themes and suggested tags, each with confidence
+type ExtractionResult struct {
+	// People mentioned in the entry
+	People []ExtractedItem `json:"people"`
+
+	// Places mentioned in the entry
+	Places []ExtractedItem `json:"places"`
+
+	// Organizations mentioned in the entry, such as employers or clubs
+	Organizations []ExtractedItem `json:"organizations"`
+
+	// Activities the writer did or planned
+	Activities []ExtractedItem `json:"activities"`
+
+	// Themes are the main topics of the entry
+	Themes []ExtractedItem `json:"themes" minItems:"1"`
+
+	// Tags are suggested categorization tags, in lower case
+	Tags []ExtractedItem `json:"tags"`
+
+	// ProcessedAt timestamp when extraction was performed
+	ProcessedAt time.Time `json:"processed_at" example:"2025-08-05T10:30:19Z" schema:"-"`
+
+	// Provider names the AI backend that produced this result
+	Provider string `json:"provider,omitempty" example:"ollama" schema:"-"`
+
+	// Prompt identifies the prompt template version the model was given
+	Prompt *PromptRef `json:"prompt,omitempty" schema:"-"`
+
+	// Options are the generation options the model was called with
+	Options *GenerationOptions `json:"options,omitempty" schema:"-"`
+}
+
+// Entity kinds of an extraction, as recorded in the entity index
+const (
+	EntityPerson       = "person"
+	EntityPlace        = "place"
+	EntityOrganization = "organization"
+)
+
+// Entity is a named entity of an extraction
+type Entity struct {
+	Kind string // person, place or organization
+	Name string
+}
+
+// Entities lists the people, places and organizations of the extraction
+func (r *ExtractionResult) Entities() []Entity {
+	var entities []Entity
+	for _, group := range []struct {
+		kind  string
+		items []ExtractedItem
+	}{
+		{EntityPerson, r.People},
+		{EntityPlace, r.Places},
+		{EntityOrganization, r.Organizations},
+	} {
+		for _, item := range group.items {
+			entities = append(entities, Entity{Kind: group.kind, Name: item.Name})
+		}
+	}
+	return entities
+}
+
+// Embeddings are the vectors an embedding model produced for a batch of texts
+type Embeddings struct {
+	// Model names the embedding model; vectors from different models are not comparable
+	Model string `json:"model" example:"nomic-embed-text"`
+
+	// Vectors holds one vector per input text, in input order
+	Vectors [][]float32 `json:"vectors"`
+}
+
+// DigestPeriod is the length of time a digest covers
+type DigestPeriod string
+
+const (
+	DigestDaily   DigestPeriod = "daily"
+	DigestWeekly  DigestPeriod = "weekly"
+	DigestMonthly DigestPeriod = "monthly"
+)
+
+// DigestPeriods lists every digest period, shortest first
+var DigestPeriods = []DigestPeriod{DigestDaily, DigestWeekly, DigestMonthly}
+
+// Window returns the period containing t as [from, to) in UTC
+// Days start at midnight, weeks on Monday and months on the first.
+func (p DigestPeriod) Window(t time.Time) (from, to time.Time, err error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch p {
+	case DigestDaily:
+		return day, day.AddDate(0, 0, 1), nil
+	case DigestWeekly:
+		monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return monday, monday.AddDate(0, 0, 7), nil
+	case DigestMonthly:
+		first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first, first.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown digest period %q (must be daily, weekly or monthly)", p)
+	}
+}
+
+// SummaryRequest asks a model to summarize a period, or part of one
+// Periods too long for one call are summarized in parts whose summaries are
+// then combined; Entries and Summaries are never both set.
+type SummaryRequest struct {
+	Period DigestPeriod
+	From   time.Time // Inclusive start of the period
+	To     time.Time // Exclusive end of the period
+
+	// Entries are journal entries, each with its date and stored analysis
+	Entries []string
+
+	// Summaries are summaries of consecutive parts of the period
+	Summaries []string
+
+	// Partial is set when the result will cover only part of the period
+	Partial bool
+
+	// Previous is the summary of the previous period, to describe the change
+	Previous string
+}
+
+// SummaryResult is a model's summary of a period, or of part of one
+type SummaryResult struct {
+	// Summary is a short narrative of the period
+	Summary string `json:"summary" minLength:"1"`
+
+	// Moods are the prevailing moods, most prevalent first
+	Moods []string `json:"moods"`
+
+	// Themes are the topics that came up repeatedly
+	Themes []string `json:"themes"`
+
+	// NotableEvents are the events worth remembering from the period
+	NotableEvents []string `json:"notable_events"`
+
+	// Change describes how the period differs from the previous one; empty
+	// when there was no previous summary to compare with
+	Change string `json:"change"`
+
+	// ProcessedAt timestamp when the summary was written
+	ProcessedAt time.Time `json:"processed_at" schema:"-"`
+
+	// Provider names the AI backend that produced this result
+	Provider string `json:"provider,omitempty" schema:"-"`
+
+	// Prompt identifies the prompt template version the model was given
+	Prompt *PromptRef `json:"prompt,omitempty" schema:"-"`
+
+	// Options are the generation options the model was called with
+	Options *GenerationOptions `json:"options,omitempty" schema:"-"`
+}
+
+// Digest is a stored summary of the journals of a day, week or month
+type Digest struct {
+	// ID is a unique identifier for the digest (UUID v4 format)
+	ID string `json:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+
+	// Period is the length of time the digest covers
+	Period DigestPeriod `json:"period" example:"weekly"`
+
+	// From and To bound the period as [From, To) on journal creation time
+	From time.Time `json:"from" example:"2025-08-04T00:00:00Z"`
+	To   time.Time `json:"to" example:"2025-08-11T00:00:00Z"`
+
+	// Summary is the narrative summary of the period
+	Summary string `json:"summary"`
+
+	// DominantMoods counts journals per mood, most frequent first; the mood of
+	// a journal is its dominant emotion, or its sentiment label without one
+	DominantMoods []MoodCount `json:"dominant_moods"`
+
+	// RecurringThemes are the topics that came up repeatedly
+	RecurringThemes []string `json:"recurring_themes"`
+
+	// NotableEvents are the events worth remembering from the period
+	NotableEvents []string `json:"notable_events"`
+
+	// Stats are computed from the stored analysis of the journals
+	Stats DigestStats `json:"stats"`
+
+	// Change compares the period with the previous one
+	Change *DigestChange `json:"change,omitempty"`
+
+	// JournalIDs link to the journals the digest was written from
+	JournalIDs []string `json:"journal_ids"`
+
+	// Batches is the number of parts the journals were summarized in
+	Batches int `json:"batches" example:"1"`
+
+	// CreatedAt is when the digest was written
+	CreatedAt time.Time `json:"created_at"`
+
+	// Provider names the AI backend that wrote the summary
+	Provider string `json:"provider,omitempty" example:"ollama"`
+
+	// Prompt identifies the prompt template version the model was given
+	Prompt *PromptRef `json:"prompt,omitempty"`
+
+	// Options are the generation options the model was called with
+	Options *GenerationOptions `json:"options,omitempty"`
+}
+
+// MoodCount is the number of journals of a period with a mood
+type MoodCount struct {
+	Mood     string `json:"mood" example:"joy"`
+	Journals int    `json:"journals" example:"3"`
+}
+
+// DigestStats summarize the stored analysis of a period's journals
+type DigestStats struct {
+	// Journals is the number of journals in the period
+	Journals int `json:"journals" example:"5"`
+
+	// Analyzed is the number of journals with a sentiment result
+	Analyzed int `json:"analyzed" example:"4"`
+
+	// AverageSentiment is the mean sentiment score of the analyzed journals
+	AverageSentiment *float64 `json:"average_sentiment,omitempty" example:"0.35"`
+
+	// Sentiments counts analyzed journals per sentiment label
+	Sentiments map[string]int `json:"sentiments"`
+}
+
+// DigestChange compares a period with the previous one
+type DigestChange struct {
+	// PreviousID is the stored digest of the previous period, if any
+	PreviousID string `json:"previous_id,omitempty"`
+
+	// Journals is the difference in the number of journals
+	Journals int `json:"journals" example:"-2"`
+
+	// Sentiment is the difference in average sentiment, when both periods
+	// have analyzed journals
+	Sentiment *float64 `json:"sentiment,omitempty" example:"0.2"`
+
+	// Summary describes the change; it needs a stored previous digest
+	Summary string `json:"summary,omitempty"`
+}
+
+// CreateDigestRequest represents the request body for writing a digest
+type CreateDigestRequest struct {
+	// Period is daily, weekly or monthly
+	Period DigestPeriod `json:"period" example:"weekly"`
+
+	// Date is any time within the period; it defaults to the last complete period
+	Date *time.Time `json:"date,omitempty" example:"2025-08-05T00:00:00Z"`
+}
+
+// Validate validates a CreateDigestRequest
+func (req *CreateDigestRequest) Validate() ValidationErrors {
+	var errors ValidationErrors
+
+	if req.Period == "" {
+		errors = append(errors, ValidationError{
+			Field:   "period",
+			Message: "Period is required",
+			Code:    "REQUIRED",
+		})
+	} else if !slices.Contains(DigestPeriods, req.Period) {
+		errors = append(errors, ValidationError{
+			Field:   "period",
+			Message: "Period must be daily, weekly or monthly",
+			Code:    "INVALID_VALUE",
+		})
+	}
+
+	return errors
 }
 
 // GeneratedJournal represents an AI-generated journal entry
 type GeneratedJournal struct {
-	Content         string            `json:"content"`          // Structured text optimized for semantic analysis
-	Metadata        GeneratedMetadata `json:"metadata"`         // Comprehensive metadata
-	SemanticMarkers []string          `json:"semantic_markers"` // Prepared for future embedding generation
-	ProcessingHints map[string]any    `json:"processing_hints"` // Optimization flags for Phase 2 vectorization
-	GeneratedAt     time.Time         `json:"generated_at"`
+	Content         string             `json:"content" minLength:"1"` // Structured text optimized for semantic analysis
+	Metadata        GeneratedMetadata  `json:"metadata"`              // Comprehensive metadata
+	SemanticMarkers []string           `json:"semantic_markers"`      // Prepared for future embedding generation
+	ProcessingHints map[string]any     `json:"processing_hints"`      // Optimization flags for Phase 2 vectorization
+	GeneratedAt     time.Time          `json:"generated_at" schema:"-"`
+	Prompt          *PromptRef         `json:"prompt,omitempty" schema:"-"`  // Prompt template version used
+	Options         *GenerationOptions `json:"options,omitempty" schema:"-"` // Generation options the model was called with
 }
 
 // GeneratedMetadata contains comprehensive metadata for generated journal entries
 type GeneratedMetadata struct {
-	Mood             string   `json:"mood"`              // Overall mood assessment
-	EmotionalContext string   `json:"emotional_context"` // Detailed emotional state
-	Themes           []string `json:"themes"`            // Main themes identified
-	Entities         []string `json:"entities"`          // People, places, objects mentioned
-	KeyPhrases       []string `json:"key_phrases"`       // Important phrases for semantic analysis
-	Tags             []string `json:"tags"`              // Categorization tags
+	Mood             string   `json:"mood"`                // Overall mood assessment
+	EmotionalContext string   `json:"emotional_context"`   // Detailed emotional state
+	Themes           []string `json:"themes" minItems:"1"` // Main themes identified
+	Entities         []string `json:"entities"`            // People, places, objects mentioned
+	KeyPhrases       []string `json:"key_phrases"`         // Important phrases for semantic analysis
+	Tags             []string `json:"tags"`                // Categorization tags
+}
+
+// AICapabilities describes what an AI provider supports
+type AICapabilities struct {
+	Sentiment        bool   `json:"sentiment"`         // Can analyze journal sentiment
+	Generation       bool   `json:"generation"`        // Can generate journal entries from prompts
+	Emotions         bool   `json:"emotions"`          // Can analyze emotions, valence and arousal
+	Extraction       bool   `json:"extraction"`        // Can extract entities and topics from journals
+	Embeddings       bool   `json:"embeddings"`        // Can embed text as vectors for semantic search
+	Summaries        bool   `json:"summaries"`         // Can summarize periods of journals
+	Streaming        bool   `json:"streaming"`         // Can stream generated text as it is produced
+	StructuredOutput bool   `json:"structured_output"` // Can constrain output to a JSON schema
+	Model            string `json:"model,omitempty"`   // Model the provider is configured to use
 }
 
 // PromptRequest represents a request to generate a journal entry from a prompt
@@ -133,8 +846,13 @@ type PromptRequest struct {
 	Context string `json:"context,omitempty" example:"I've been working on mindfulness practices lately"`
 
 	// Metadata contains hints and preferences for journal generation
-	// Optional field, maximum 10 fields allowed
+	// Optional field, maximum 10 fields allowed. The keys temperature, top_p,
+	// seed, num_ctx, num_predict and stop override the generation options.
 	Metadata map[string]any `json:"metadata,omitempty" example:"{\"mood_preference\": \"positive\", \"length\": \"medium\"}"`
+
+	// PromptTemplate selects the prompt template as "name@version", or "name"
+	// for its latest version; empty uses the deployment default
+	PromptTemplate string `json:"prompt_template,omitempty" example:"generation@v1"`
 }
 
 // ValidationError represents a validation error with details
@@ -379,6 +1097,9 @@ func (req *PromptRequest) Validate() ValidationErrors {
 				})
 			}
 		}
+
+		_, optionErrors := GenerationOptionsFromMetadata(req.Metadata)
+		errors = append(errors, optionErrors...)
 	}
 
 	return errors
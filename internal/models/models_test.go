@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/garnizeh/englog/internal/models"
@@ -472,6 +473,41 @@ func TestPromptRequest_Validate(t *testing.T) {
 			expectedHasErrors:     true,
 			expectedErrorsContain: []string{"Metadata keys cannot be empty"},
 		},
+		{
+			name: "valid request with generation options",
+			request: models.PromptRequest{
+				Prompt: "Write about gratitude",
+				Metadata: map[string]interface{}{
+					"length":      "short",
+					"temperature": 0.9,
+					"seed":        float64(7),
+					"stop":        []interface{}{"###"},
+				},
+			},
+			expectedHasErrors: false,
+		},
+		{
+			name: "invalid - generation option out of range",
+			request: models.PromptRequest{
+				Prompt: "Valid prompt here",
+				Metadata: map[string]interface{}{
+					"temperature": 2.5,
+				},
+			},
+			expectedHasErrors:     true,
+			expectedErrorsContain: []string{"metadata.temperature", "Temperature must be between 0 and 2"},
+		},
+		{
+			name: "invalid - generation option of the wrong type",
+			request: models.PromptRequest{
+				Prompt: "Valid prompt here",
+				Metadata: map[string]interface{}{
+					"num_ctx": "large",
+				},
+			},
+			expectedHasErrors:     true,
+			expectedErrorsContain: []string{"metadata.num_ctx", "must be an integer"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -495,6 +531,65 @@ func TestPromptRequest_Validate(t *testing.T) {
 	}
 }
 
+func TestGenerationOptionsFromMetadata(t *testing.T) {
+	options, errs := models.GenerationOptionsFromMetadata(map[string]any{
+		"mood_preference": "positive",
+		"temperature":     float64(1),
+		"top_p":           0.9,
+		"seed":            float64(42),
+		"num_ctx":         4096,
+		"num_predict":     float64(-1),
+		"stop":            "\n\n",
+	})
+	if errs.HasErrors() {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if *options.Temperature != 1 || *options.TopP != 0.9 || *options.Seed != 42 ||
+		*options.NumCtx != 4096 || *options.NumPredict != -1 || len(options.Stop) != 1 {
+		t.Errorf("Unexpected options %+v", options)
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]any
+		field    string
+		code     string
+	}{
+		{"fractional seed", map[string]any{"seed": 1.5}, "metadata.seed", "INVALID_TYPE"},
+		{"string temperature", map[string]any{"temperature": "hot"}, "metadata.temperature", "INVALID_TYPE"},
+		{"mixed stop list", map[string]any{"stop": []any{"a", 1.0}}, "metadata.stop", "INVALID_TYPE"},
+		{"zero top_p", map[string]any{"top_p": 0.0}, "metadata.top_p", "OUT_OF_RANGE"},
+		{"zero num_predict", map[string]any{"num_predict": 0.0}, "metadata.num_predict", "OUT_OF_RANGE"},
+		{"empty stop", map[string]any{"stop": []any{""}}, "metadata.stop", "OUT_OF_RANGE"},
+		{"too many stops", map[string]any{"stop": []any{"a", "b", "c", "d", "e"}}, "metadata.stop", "OUT_OF_RANGE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := models.GenerationOptionsFromMetadata(tt.metadata)
+			if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Code != tt.code {
+				t.Errorf("Expected one %s error for %s, got %+v", tt.code, tt.field, errs)
+			}
+		})
+	}
+}
+
+func TestGenerationOptions_Merge(t *testing.T) {
+	zero, seed, hot := 0.0, 42, 0.9
+	base := models.GenerationOptions{Temperature: &zero, Seed: &seed}
+
+	merged := base.Merge(models.GenerationOptions{Temperature: &hot, Stop: []string{"###"}})
+	if *merged.Temperature != 0.9 || *merged.Seed != 42 || len(merged.Stop) != 1 {
+		t.Errorf("Unexpected merged options %+v", merged)
+	}
+	if *base.Temperature != 0 {
+		t.Error("Expected Merge to leave the receiver unchanged")
+	}
+	if !(models.GenerationOptions{}).IsZero() || merged.IsZero() {
+		t.Error("Unexpected IsZero result")
+	}
+}
+
 // Test exported constants and types for proper JSON marshaling
 func TestJSONMarshaling(t *testing.T) {
 	t.Run("ProcessingStatus values", func(t *testing.T) {
@@ -595,3 +690,50 @@ func TestValidationEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestDigestPeriod_Window(t *testing.T) {
+	// A Wednesday evening in a UTC-3 zone is already Thursday in UTC
+	date := time.Date(2025, 8, 6, 22, 30, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+	tests := []struct {
+		period   models.DigestPeriod
+		from, to string
+	}{
+		{models.DigestDaily, "2025-08-07", "2025-08-08"},
+		{models.DigestWeekly, "2025-08-04", "2025-08-11"},
+		{models.DigestMonthly, "2025-08-01", "2025-09-01"},
+	}
+	for _, tt := range tests {
+		from, to, err := tt.period.Window(date)
+		if err != nil {
+			t.Fatalf("Window(%s) failed: %v", tt.period, err)
+		}
+		if from.Format(time.DateOnly) != tt.from || to.Format(time.DateOnly) != tt.to || from.Location() != time.UTC {
+			t.Errorf("Window(%s) = %s to %s, expected %s to %s in UTC", tt.period, from, to, tt.from, tt.to)
+		}
+	}
+
+	// Sunday belongs to the week that started on Monday
+	if from, _, _ := models.DigestWeekly.Window(time.Date(2025, 8, 10, 12, 0, 0, 0, time.UTC)); from.Day() != 4 {
+		t.Errorf("Expected Sunday in the week of August 4, got %s", from)
+	}
+	if _, _, err := models.DigestPeriod("hourly").Window(date); err == nil {
+		t.Error("Expected an error for an unknown period")
+	}
+}
+
+func TestCreateDigestRequest_Validate(t *testing.T) {
+	for period, code := range map[models.DigestPeriod]string{
+		"":                   "REQUIRED",
+		"hourly":             "INVALID_VALUE",
+		models.DigestMonthly: "",
+	} {
+		errors := (&models.CreateDigestRequest{Period: period}).Validate()
+		if code == "" && errors.HasErrors() {
+			t.Errorf("Expected %q to be valid, got %v", period, errors)
+		}
+		if code != "" && (len(errors) != 1 || errors[0].Code != code) {
+			t.Errorf("Expected %s for %q, got %v", code, period, errors)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Highlight markers wrapped around matched words in snippets
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// Snippet extracts the part of content with the most query matches and wraps
+// matched words in highlight markers. The text around markers is HTML-escaped.
+// maxRunes bounds the length of the excerpt, excluding markers and ellipses
+func Snippet(content string, query Query, maxRunes int) string {
+	terms := query.highlightTerms()
+
+	var matches []Token
+	for _, token := range Tokenize(content) {
+		if terms[token.Term] {
+			matches = append(matches, token)
+		}
+	}
+
+	if maxRunes <= 0 || utf8.RuneCountInString(content) <= maxRunes {
+		return highlightRange(content, 0, len(content), matches)
+	}
+
+	start, end := bestWindow(content, matches, maxRunes)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(highlightRange(content, start, end, matches))
+	if end < len(content) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+// bestWindow finds the byte range of at most maxRunes runes that contains the
+// most matches, starting at a match and snapped to word boundaries
+func bestWindow(content string, matches []Token, maxRunes int) (int, int) {
+	start := 0
+	bestCount := 0
+
+	for i, match := range matches {
+		limit := runeOffset(content, match.Start, maxRunes)
+		count := 0
+		for _, other := range matches[i:] {
+			if other.End > limit {
+				break
+			}
+			count++
+		}
+		if count > bestCount {
+			bestCount = count
+			start = match.Start
+		}
+	}
+
+	// Back up a little so the first match has some leading context
+	start = runeOffset(content, start, -maxRunes/4)
+	start = snapToWordStart(content, start)
+
+	end := runeOffset(content, start, maxRunes)
+	end = snapToWordEnd(content, end)
+
+	return start, end
+}
+
+// highlightRange escapes content[start:end] and wraps matches in markers
+func highlightRange(content string, start, end int, matches []Token) string {
+	var b strings.Builder
+	cursor := start
+
+	for _, match := range matches {
+		if match.Start < start || match.End > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(content[cursor:match.Start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(content[match.Start:match.End]))
+		b.WriteString(HighlightEnd)
+		cursor = match.End
+	}
+	b.WriteString(html.EscapeString(content[cursor:end]))
+
+	return b.String()
+}
+
+// runeOffset moves a byte offset by n runes (negative moves backwards)
+func runeOffset(content string, offset, n int) int {
+	for n > 0 && offset < len(content) {
+		_, size := utf8.DecodeRuneInString(content[offset:])
+		offset += size
+		n--
+	}
+	for n < 0 && offset > 0 {
+		_, size := utf8.DecodeLastRuneInString(content[:offset])
+		offset -= size
+		n++
+	}
+	return offset
+}
+
+// snapToWordStart moves offset back to the start of the word it falls in
+func snapToWordStart(content string, offset int) int {
+	for offset > 0 && content[offset-1] != ' ' && content[offset-1] != '\n' {
+		_, size := utf8.DecodeLastRuneInString(content[:offset])
+		offset -= size
+	}
+	return offset
+}
+
+// snapToWordEnd moves offset back to the end of the last complete word
+func snapToWordEnd(content string, offset int) int {
+	if offset >= len(content) {
+		return len(content)
+	}
+	for end := offset; end > 0; end-- {
+		if content[end] == ' ' || content[end] == '\n' {
+			return end
+		}
+	}
+	return offset
+}
@@ -0,0 +1,410 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// BM25 ranking parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// indexFormatVersion is bumped whenever the persisted layout or the
+// tokenizer changes, forcing a rebuild from the stored journals
+const indexFormatVersion = 1
+
+// Result is a single ranked search hit
+type Result struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// document is the forward index entry for a single journal
+type document struct {
+	Length    int                 `json:"length"`
+	Terms     map[string][]int    `json:"terms"`  // term -> positions
+	Fields    map[string][]string `json:"fields"` // metadata key -> normalized values
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// persistedIndex is the on-disk representation of an index
+type persistedIndex struct {
+	Version   int                  `json:"version"`
+	SavedAt   time.Time            `json:"saved_at"`
+	Documents map[string]*document `json:"documents"`
+}
+
+// Index is an in-memory inverted index over journal content and metadata
+// It can be saved to disk and reloaded, and is safe for concurrent use
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[string]*document
+	postings    map[string]map[string][]int // term -> journal ID -> positions
+	totalLength int
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string][]int),
+	}
+}
+
+// LoadIndex reads a previously saved index from path
+// A missing file or an outdated format yields an empty index
+func LoadIndex(path string) (*Index, error) {
+	index := NewIndex()
+	if path == "" {
+		return index, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var persisted persistedIndex
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, fmt.Errorf("failed to decode search index: %w", err)
+	}
+
+	if persisted.Version != indexFormatVersion {
+		return index, nil
+	}
+
+	for id, doc := range persisted.Documents {
+		index.addDocument(id, doc)
+	}
+
+	return index, nil
+}
+
+// Save writes the index to path atomically
+func (ix *Index) Save(path string) error {
+	ix.mu.RLock()
+	data, err := json.Marshal(persistedIndex{
+		Version:   indexFormatVersion,
+		SavedAt:   time.Now().UTC(),
+		Documents: ix.docs,
+	})
+	ix.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace search index: %w", err)
+	}
+
+	return nil
+}
+
+// Add indexes a journal, replacing any previous version of it
+func (ix *Index) Add(journal *models.Journal) {
+	doc := &document{
+		Terms:     make(map[string][]int),
+		Fields:    metadataFields(journal.Metadata),
+		UpdatedAt: journal.UpdatedAt,
+	}
+
+	for _, token := range Tokenize(journal.Content) {
+		doc.Terms[token.Term] = append(doc.Terms[token.Term], token.Position)
+		doc.Length++
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(journal.ID)
+	ix.addDocument(journal.ID, doc)
+}
+
+// Remove drops a journal from the index
+func (ix *Index) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(id)
+}
+
+// Len returns the number of indexed journals
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.docs)
+}
+
+// IndexedAt returns the UpdatedAt of the indexed version of a journal
+func (ix *Index) IndexedAt(id string) (time.Time, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	doc, exists := ix.docs[id]
+	if !exists {
+		return time.Time{}, false
+	}
+	return doc.UpdatedAt, true
+}
+
+// IDs returns the IDs of all indexed journals
+func (ix *Index) IDs() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	ids := make([]string, 0, len(ix.docs))
+	for id := range ix.docs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Search ranks journals against a query and returns up to limit results
+// along with the total number of matching journals
+func (ix *Index) Search(query Query, limit int) ([]Result, int) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	candidates := ix.candidatesLocked(query)
+
+	results := make([]Result, 0, len(candidates))
+	for id := range candidates {
+		results = append(results, Result{ID: id, Score: ix.scoreLocked(id, query)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	total := len(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, total
+}
+
+// candidatesLocked returns journals satisfying the query's required clauses
+// The caller must hold ix.mu
+func (ix *Index) candidatesLocked(query Query) map[string]bool {
+	candidates := make(map[string]bool)
+
+	switch {
+	case len(query.Phrases) > 0:
+		// Phrases are required, so their first term bounds the candidates
+		for id := range ix.postings[query.Phrases[0][0]] {
+			candidates[id] = true
+		}
+	case len(query.Terms) > 0:
+		// Free terms are OR-ed; ranking rewards journals matching more of them
+		for _, term := range query.Terms {
+			for id := range ix.postings[term] {
+				candidates[id] = true
+			}
+		}
+	default:
+		for id := range ix.docs {
+			candidates[id] = true
+		}
+	}
+
+	for id := range candidates {
+		doc := ix.docs[id]
+		if !matchesFilters(doc, query.Filters) || !ix.matchesPhrasesLocked(id, query.Phrases) {
+			delete(candidates, id)
+		}
+	}
+
+	return candidates
+}
+
+// matchesPhrasesLocked reports whether every phrase appears in order in a journal
+// The caller must hold ix.mu
+func (ix *Index) matchesPhrasesLocked(id string, phrases [][]string) bool {
+	for _, phrase := range phrases {
+		if !ix.containsPhraseLocked(id, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPhraseLocked checks for consecutive positions of the phrase terms
+// The caller must hold ix.mu
+func (ix *Index) containsPhraseLocked(id string, phrase []string) bool {
+	doc := ix.docs[id]
+
+	for _, start := range doc.Terms[phrase[0]] {
+		matched := true
+		for offset, term := range phrase[1:] {
+			if !containsPosition(doc.Terms[term], start+offset+1) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scoreLocked computes the BM25 score of a journal for the query terms
+// Phrase terms contribute to the score as well
+// The caller must hold ix.mu
+func (ix *Index) scoreLocked(id string, query Query) float64 {
+	doc := ix.docs[id]
+	if len(ix.docs) == 0 || doc.Length == 0 {
+		return 0
+	}
+
+	avgLength := float64(ix.totalLength) / float64(len(ix.docs))
+	n := float64(len(ix.docs))
+
+	terms := append([]string(nil), query.Terms...)
+	for _, phrase := range query.Phrases {
+		terms = append(terms, phrase...)
+	}
+
+	score := 0.0
+	for _, term := range terms {
+		tf := float64(len(doc.Terms[term]))
+		if tf == 0 {
+			continue
+		}
+
+		df := float64(len(ix.postings[term]))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		norm := tf + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLength)
+		score += idf * tf * (bm25K1 + 1) / norm
+	}
+
+	return score
+}
+
+// addDocument inserts a document into the forward and inverted indexes
+// The caller must hold ix.mu or have exclusive access to the index
+func (ix *Index) addDocument(id string, doc *document) {
+	ix.docs[id] = doc
+	ix.totalLength += doc.Length
+
+	for term, positions := range doc.Terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[string][]int)
+		}
+		ix.postings[term][id] = positions
+	}
+}
+
+// removeLocked deletes a document from the forward and inverted indexes
+// The caller must hold ix.mu
+func (ix *Index) removeLocked(id string) {
+	doc, exists := ix.docs[id]
+	if !exists {
+		return
+	}
+
+	for term := range doc.Terms {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+
+	ix.totalLength -= doc.Length
+	delete(ix.docs, id)
+}
+
+// matchesFilters reports whether a document satisfies every field filter
+func matchesFilters(doc *document, filters map[string][]string) bool {
+	for field, wanted := range filters {
+		values := doc.Fields[field]
+		for _, want := range wanted {
+			found := false
+			for _, value := range values {
+				if value == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// metadataFields flattens scalar and array metadata values for field filtering
+func metadataFields(metadata map[string]any) map[string][]string {
+	fields := make(map[string][]string)
+
+	for key, value := range metadata {
+		key = normalizeFieldValue(key)
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				if s, ok := scalarString(item); ok {
+					fields[key] = append(fields[key], s)
+				}
+			}
+		case []string:
+			for _, item := range v {
+				fields[key] = append(fields[key], normalizeFieldValue(item))
+			}
+		default:
+			if s, ok := scalarString(v); ok {
+				fields[key] = append(fields[key], s)
+			}
+		}
+	}
+
+	return fields
+}
+
+// scalarString renders a scalar metadata value as a normalized string
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return normalizeFieldValue(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// containsPosition reports whether a sorted position list contains p
+func containsPosition(positions []int, p int) bool {
+	i := sort.SearchInts(positions, p)
+	return i < len(positions) && positions[i] == p
+}
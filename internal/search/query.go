@@ -0,0 +1,152 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fieldAliases maps query field names to the metadata keys they filter on
+var fieldAliases = map[string]string{
+	"tag": "tags",
+}
+
+// Query is a parsed full-text search query
+// Free terms are ranked with BM25; phrases and field filters must all match
+type Query struct {
+	Terms   []string            // Stemmed free-text terms
+	Phrases [][]string          // Stemmed terms of each quoted phrase
+	Filters map[string][]string // Metadata field filters, e.g. tags -> [work]
+}
+
+// ParseQuery parses a query such as: morning run "felt great" tag:health mood:8
+func ParseQuery(raw string) Query {
+	query := Query{Filters: make(map[string][]string)}
+
+	for _, part := range splitQuery(raw) {
+		if part.quoted {
+			query.addPhrase(part.text)
+			continue
+		}
+
+		if field, value, ok := strings.Cut(part.text, ":"); ok && isFieldName(field) && value != "" {
+			field = strings.ToLower(field)
+			if alias, exists := fieldAliases[field]; exists {
+				field = alias
+			}
+			query.Filters[field] = append(query.Filters[field], normalizeFieldValue(strings.Trim(value, `"`)))
+			continue
+		}
+
+		for _, token := range Tokenize(part.text) {
+			query.Terms = append(query.Terms, token.Term)
+		}
+	}
+
+	return query
+}
+
+// IsEmpty reports whether the query has no terms, phrases or filters
+func (q Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0 && len(q.Filters) == 0
+}
+
+// highlightTerms returns every stemmed term that should be highlighted
+func (q Query) highlightTerms() map[string]bool {
+	terms := make(map[string]bool)
+	for _, term := range q.Terms {
+		terms[term] = true
+	}
+	for _, phrase := range q.Phrases {
+		for _, term := range phrase {
+			terms[term] = true
+		}
+	}
+	return terms
+}
+
+// addPhrase adds a quoted phrase; single-word phrases become plain terms
+func (q *Query) addPhrase(text string) {
+	tokens := Tokenize(text)
+	switch len(tokens) {
+	case 0:
+		return
+	case 1:
+		q.Terms = append(q.Terms, tokens[0].Term)
+	default:
+		phrase := make([]string, len(tokens))
+		for i, token := range tokens {
+			phrase[i] = token.Term
+		}
+		q.Phrases = append(q.Phrases, phrase)
+	}
+}
+
+// queryPart is a whitespace-separated word or a quoted phrase
+type queryPart struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery splits a raw query on whitespace, keeping quoted phrases together
+// A quote directly after a field prefix (location:"new york") stays with the field
+func splitQuery(raw string) []queryPart {
+	var parts []queryPart
+	var current strings.Builder
+	inQuotes := false
+	fieldQuote := false
+
+	flush := func(quoted bool) {
+		if current.Len() > 0 {
+			parts = append(parts, queryPart{text: current.String(), quoted: quoted})
+			current.Reset()
+		}
+	}
+
+	for _, r := range raw {
+		switch {
+		case r == '"' && !inQuotes:
+			if strings.HasSuffix(current.String(), ":") {
+				fieldQuote = true
+				current.WriteRune(r)
+			} else {
+				flush(false)
+			}
+			inQuotes = true
+		case r == '"' && inQuotes:
+			if fieldQuote {
+				current.WriteRune(r)
+				flush(false)
+			} else {
+				flush(true)
+			}
+			inQuotes = false
+			fieldQuote = false
+		case unicode.IsSpace(r) && !inQuotes:
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	flush(inQuotes && !fieldQuote)
+
+	return parts
+}
+
+// isFieldName reports whether s looks like a metadata field name
+func isFieldName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeFieldValue lowercases and trims a metadata value for exact matching
+func normalizeFieldValue(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
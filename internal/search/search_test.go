@@ -0,0 +1,244 @@
+package search
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"hopeful":        "hope",
+		"goodness":       "good",
+		"adjustment":     "adjust",
+		"running":        "run",
+		"runs":           "run",
+		"controll":       "control",
+		"go":             "go",
+		"café":           "café",
+	}
+
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("I went Running, and I didn't stop!")
+
+	var terms []string
+	for _, token := range tokens {
+		terms = append(terms, token.Term)
+	}
+	if want := []string{"went", "run", "didnt", "stop"}; !reflect.DeepEqual(terms, want) {
+		t.Fatalf("Expected terms %v, got %v", want, terms)
+	}
+
+	for i, token := range tokens {
+		if token.Position != i {
+			t.Errorf("Expected token %d to have position %d, got %d", i, i, token.Position)
+		}
+	}
+
+	if got := "I went Running, and I didn't stop!"[tokens[1].Start:tokens[1].End]; got != "Running" {
+		t.Errorf("Expected offsets to cover original word, got %q", got)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	query := ParseQuery(`morning runs "felt really great" tag:Health mood:8 location:"new york" "walking"`)
+
+	if want := []string{"morn", "run", "walk"}; !reflect.DeepEqual(query.Terms, want) {
+		t.Errorf("Expected terms %v, got %v", want, query.Terms)
+	}
+	if want := [][]string{{"felt", "realli", "great"}}; !reflect.DeepEqual(query.Phrases, want) {
+		t.Errorf("Expected phrases %v, got %v", want, query.Phrases)
+	}
+
+	wantFilters := map[string][]string{
+		"tags":     {"health"},
+		"mood":     {"8"},
+		"location": {"new york"},
+	}
+	if !reflect.DeepEqual(query.Filters, wantFilters) {
+		t.Errorf("Expected filters %v, got %v", wantFilters, query.Filters)
+	}
+
+	if !ParseQuery("the and of").IsEmpty() {
+		t.Error("Expected a query of only stop words to be empty")
+	}
+}
+
+func newTestIndex() *Index {
+	index := NewIndex()
+	journals := []*models.Journal{
+		{
+			ID:       "run",
+			Content:  "Went for a morning run today. The run felt great and I ran further than ever.",
+			Metadata: map[string]any{"tags": []any{"health", "Exercise"}, "mood": float64(8)},
+		},
+		{
+			ID:       "work",
+			Content:  "Long day at work. The morning meeting dragged on and I felt tired.",
+			Metadata: map[string]any{"tags": []any{"work"}, "mood": float64(4)},
+		},
+		{
+			ID:       "walk",
+			Content:  "A quiet walk in the park. It felt great to be outside after running errands.",
+			Metadata: map[string]any{"tags": []any{"health"}, "mood": float64(7)},
+		},
+	}
+	for _, journal := range journals {
+		index.Add(journal)
+	}
+	return index
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func TestIndex_SearchRanksWithBM25(t *testing.T) {
+	index := newTestIndex()
+
+	results, total := index.Search(ParseQuery("running"), 0)
+	if total != 2 {
+		t.Fatalf("Expected 2 matches for stemmed term, got %d", total)
+	}
+	// "run" appears twice in the first journal, so it ranks above the walk
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{"run", "walk"}) {
+		t.Errorf("Expected ranking [run walk], got %v", got)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("Expected descending scores, got %v", results)
+	}
+
+	results, total = index.Search(ParseQuery("morning"), 1)
+	if total != 2 || len(results) != 1 {
+		t.Errorf("Expected limit to cap results but not total, got %d results of %d", len(results), total)
+	}
+}
+
+func TestIndex_SearchPhrasesAndFilters(t *testing.T) {
+	index := newTestIndex()
+
+	results, _ := index.Search(ParseQuery(`"felt great"`), 0)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{"run", "walk"}) && !reflect.DeepEqual(got, []string{"walk", "run"}) {
+		t.Errorf("Expected phrase to match run and walk, got %v", got)
+	}
+
+	results, _ = index.Search(ParseQuery(`"great felt"`), 0)
+	if len(results) != 0 {
+		t.Errorf("Expected phrase order to matter, got %v", resultIDs(results))
+	}
+
+	results, _ = index.Search(ParseQuery(`felt tag:health mood:7`), 0)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{"walk"}) {
+		t.Errorf("Expected filters to narrow to walk, got %v", got)
+	}
+
+	results, _ = index.Search(ParseQuery(`tag:exercise`), 0)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{"run"}) {
+		t.Errorf("Expected filter-only query to match run, got %v", got)
+	}
+}
+
+func TestIndex_UpdateAndRemove(t *testing.T) {
+	index := newTestIndex()
+
+	index.Add(&models.Journal{ID: "work", Content: "Relaxing evening with a book"})
+	if results, _ := index.Search(ParseQuery("meeting"), 0); len(results) != 0 {
+		t.Errorf("Expected re-indexed journal to drop old terms, got %v", resultIDs(results))
+	}
+	if results, _ := index.Search(ParseQuery("book"), 0); len(results) != 1 {
+		t.Errorf("Expected re-indexed journal to match new terms, got %v", resultIDs(results))
+	}
+
+	index.Remove("run")
+	if index.Len() != 2 {
+		t.Errorf("Expected 2 indexed journals after remove, got %d", index.Len())
+	}
+	if results, _ := index.Search(ParseQuery("run"), 0); !reflect.DeepEqual(resultIDs(results), []string{"walk"}) {
+		t.Errorf("Expected removed journal to be gone, got %v", resultIDs(results))
+	}
+}
+
+func TestIndex_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	index := newTestIndex()
+	updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	index.Add(&models.Journal{ID: "dated", Content: "Dated entry", UpdatedAt: updatedAt})
+
+	if err := index.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex failed: %v", err)
+	}
+	if loaded.Len() != index.Len() {
+		t.Fatalf("Expected %d journals after load, got %d", index.Len(), loaded.Len())
+	}
+
+	want, _ := index.Search(ParseQuery(`running "felt great"`), 0)
+	got, _ := loaded.Search(ParseQuery(`running "felt great"`), 0)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected identical results after load, want %v got %v", want, got)
+	}
+
+	if at, ok := loaded.IndexedAt("dated"); !ok || !at.Equal(updatedAt) {
+		t.Errorf("Expected IndexedAt %v, got %v (%v)", updatedAt, at, ok)
+	}
+
+	missing, err := LoadIndex(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || missing.Len() != 0 {
+		t.Errorf("Expected empty index for missing file, got %d journals, err %v", missing.Len(), err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	query := ParseQuery("running")
+
+	got := Snippet("I went running & it was good", query, 0)
+	if want := "I went <mark>running</mark> &amp; it was good"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	long := strings.Repeat("filler words here ", 20) + "then a long run at dawn " + strings.Repeat("more filler text ", 20)
+	got = Snippet(long, query, 60)
+	if !strings.Contains(got, "<mark>run</mark>") {
+		t.Errorf("Expected snippet to highlight match, got %q", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("Expected ellipses around trimmed snippet, got %q", got)
+	}
+	plain := strings.NewReplacer(HighlightStart, "", HighlightEnd, "", "…", "").Replace(got)
+	if len([]rune(plain)) > 60 {
+		t.Errorf("Expected snippet of at most 60 runes, got %d: %q", len([]rune(plain)), plain)
+	}
+}
@@ -0,0 +1,254 @@
+package search
+
+// Stem reduces an English word to its stem using the Porter stemming algorithm
+// Words containing characters outside a-z are returned unchanged
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+
+	return string(s.b)
+}
+
+// stemmer holds the word being stemmed
+type stemmer struct {
+	b []byte
+}
+
+// isConsonant reports whether the letter at position i is a consonant
+func (s *stemmer) isConsonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.isConsonant(i-1)
+	default:
+		return true
+	}
+}
+
+// measure counts the VC sequences in the first n letters
+func (s *stemmer) measure(n int) int {
+	m := 0
+	i := 0
+
+	// Skip the optional leading consonants
+	for i < n && s.isConsonant(i) {
+		i++
+	}
+
+	for i < n {
+		for i < n && !s.isConsonant(i) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		for i < n && s.isConsonant(i) {
+			i++
+		}
+		m++
+	}
+
+	return m
+}
+
+// hasVowel reports whether the first n letters contain a vowel
+func (s *stemmer) hasVowel(n int) bool {
+	for i := 0; i < n; i++ {
+		if !s.isConsonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsDoubleConsonant reports whether the first n letters end with a double consonant
+func (s *stemmer) endsDoubleConsonant(n int) bool {
+	return n >= 2 && s.b[n-1] == s.b[n-2] && s.isConsonant(n-1)
+}
+
+// endsCVC reports whether the first n letters end consonant-vowel-consonant,
+// where the final consonant is not w, x or y
+func (s *stemmer) endsCVC(n int) bool {
+	if n < 3 || !s.isConsonant(n-3) || s.isConsonant(n-2) || !s.isConsonant(n-1) {
+		return false
+	}
+	switch s.b[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// hasSuffix reports whether the word ends with suffix
+func (s *stemmer) hasSuffix(suffix string) bool {
+	n := len(s.b)
+	return n >= len(suffix) && string(s.b[n-len(suffix):]) == suffix
+}
+
+// stemLen returns the length of the word without the given suffix
+func (s *stemmer) stemLen(suffix string) int {
+	return len(s.b) - len(suffix)
+}
+
+// replace swaps a suffix for a replacement
+func (s *stemmer) replace(suffix, replacement string) {
+	s.b = append(s.b[:s.stemLen(suffix)], replacement...)
+}
+
+// replaceIfMeasure replaces the first matching suffix when the remaining stem
+// has a measure greater than minMeasure; it stops at the first match either way
+func (s *stemmer) replaceIfMeasure(rules [][2]string, minMeasure int) {
+	for _, rule := range rules {
+		if s.hasSuffix(rule[0]) {
+			if s.measure(s.stemLen(rule[0])) > minMeasure {
+				s.replace(rule[0], rule[1])
+			}
+			return
+		}
+	}
+}
+
+func (s *stemmer) step1a() {
+	switch {
+	case s.hasSuffix("sses"):
+		s.replace("sses", "ss")
+	case s.hasSuffix("ies"):
+		s.replace("ies", "i")
+	case s.hasSuffix("ss"):
+	case s.hasSuffix("s"):
+		s.replace("s", "")
+	}
+}
+
+func (s *stemmer) step1b() {
+	if s.hasSuffix("eed") {
+		if s.measure(s.stemLen("eed")) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+
+	removed := false
+	for _, suffix := range []string{"ed", "ing"} {
+		if s.hasSuffix(suffix) && s.hasVowel(s.stemLen(suffix)) {
+			s.replace(suffix, "")
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return
+	}
+
+	n := len(s.b)
+	switch {
+	case s.hasSuffix("at"), s.hasSuffix("bl"), s.hasSuffix("iz"):
+		s.b = append(s.b, 'e')
+	case s.endsDoubleConsonant(n):
+		switch s.b[n-1] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:n-1]
+		}
+	case s.measure(n) == 1 && s.endsCVC(n):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.hasSuffix("y") && s.hasVowel(s.stemLen("y")) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+// step2Rules are ordered so longer suffixes are tried before their tails
+var step2Rules = sortedByLength([][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+})
+
+func (s *stemmer) step2() {
+	s.replaceIfMeasure(step2Rules, 0)
+}
+
+var step3Rules = sortedByLength([][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+})
+
+func (s *stemmer) step3() {
+	s.replaceIfMeasure(step3Rules, 0)
+}
+
+var step4Suffixes = []string{
+	"ement", "ance", "ence", "able", "ible", "ment",
+	"ant", "ent", "ion", "ism", "ate", "iti", "ous", "ive", "ize",
+	"al", "er", "ic", "ou",
+}
+
+func (s *stemmer) step4() {
+	for _, suffix := range step4Suffixes {
+		if !s.hasSuffix(suffix) {
+			continue
+		}
+
+		n := s.stemLen(suffix)
+		if s.measure(n) <= 1 {
+			return
+		}
+		if suffix == "ion" && (n == 0 || (s.b[n-1] != 's' && s.b[n-1] != 't')) {
+			return
+		}
+
+		s.b = s.b[:n]
+		return
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.hasSuffix("e") {
+		n := s.stemLen("e")
+		m := s.measure(n)
+		if m > 1 || (m == 1 && !s.endsCVC(n)) {
+			s.b = s.b[:n]
+		}
+	}
+
+	n := len(s.b)
+	if n > 0 && s.b[n-1] == 'l' && s.endsDoubleConsonant(n) && s.measure(n) > 1 {
+		s.b = s.b[:n-1]
+	}
+}
+
+// sortedByLength returns rules ordered by descending suffix length so the
+// longest matching suffix always wins; the sort is stable
+func sortedByLength(rules [][2]string) [][2]string {
+	sorted := make([][2]string, len(rules))
+	copy(sorted, rules)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && len(sorted[j][0]) > len(sorted[j-1][0]); j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a normalized term found in a piece of text
+type Token struct {
+	Term     string // Stemmed, lowercased term
+	Position int    // Ordinal position among indexed (non stop word) tokens
+	Start    int    // Byte offset of the original word in the text
+	End      int    // Byte offset just past the original word
+}
+
+// stopWords are common English words excluded from the index
+var stopWords = map[string]bool{
+	"a": true, "about": true, "above": true, "after": true, "again": true, "against": true,
+	"all": true, "am": true, "an": true, "and": true, "any": true, "are": true, "as": true,
+	"at": true, "be": true, "because": true, "been": true, "before": true, "being": true,
+	"below": true, "between": true, "both": true, "but": true, "by": true, "can": true,
+	"did": true, "do": true, "does": true, "doing": true, "down": true, "during": true,
+	"each": true, "few": true, "for": true, "from": true, "further": true, "had": true,
+	"has": true, "have": true, "having": true, "he": true, "her": true, "here": true,
+	"hers": true, "herself": true, "him": true, "himself": true, "his": true, "how": true,
+	"i": true, "if": true, "in": true, "into": true, "is": true, "it": true, "its": true,
+	"itself": true, "just": true, "me": true, "more": true, "most": true, "my": true,
+	"myself": true, "no": true, "nor": true, "not": true, "now": true, "of": true,
+	"off": true, "on": true, "once": true, "only": true, "or": true, "other": true,
+	"our": true, "ours": true, "ourselves": true, "out": true, "over": true, "own": true,
+	"same": true, "she": true, "should": true, "so": true, "some": true, "such": true,
+	"than": true, "that": true, "the": true, "their": true, "theirs": true, "them": true,
+	"themselves": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "those": true, "through": true, "to": true, "too": true, "under": true,
+	"until": true, "up": true, "very": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true, "who": true,
+	"whom": true, "why": true, "will": true, "with": true, "would": true, "you": true,
+	"your": true, "yours": true, "yourself": true, "yourselves": true,
+}
+
+// IsStopWord reports whether a lowercased word is excluded from the index
+func IsStopWord(word string) bool {
+	return stopWords[word]
+}
+
+// Tokenize splits text into stemmed terms, skipping stop words
+// Apostrophes inside words are dropped so "don't" becomes "dont"
+func Tokenize(text string) []Token {
+	var tokens []Token
+	position := 0
+
+	emit := func(word string, start, end int) {
+		if word == "" || IsStopWord(word) {
+			return
+		}
+		tokens = append(tokens, Token{
+			Term:     Stem(word),
+			Position: position,
+			Start:    start,
+			End:      end,
+		})
+		position++
+	}
+
+	var word strings.Builder
+	start := -1
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+			word.WriteRune(unicode.ToLower(r))
+		case (r == '\'' || r == '’') && start >= 0:
+			// Keep contractions together; the apostrophe itself is not indexed
+		default:
+			if start >= 0 {
+				emit(word.String(), start, i)
+				word.Reset()
+				start = -1
+			}
+		}
+
+		i += size
+	}
+
+	if start >= 0 {
+		emit(word.String(), start, len(text))
+	}
+
+	return tokens
+}
@@ -0,0 +1,454 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+const (
+	snapshotFileName = "journals.snapshot.json"
+	logFileName      = "journals.log"
+)
+
+// Log operation types
+const (
+	opStore  = "store"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// logEntry represents a single mutation in the append-only journal log
+type logEntry struct {
+	Op       string          `json:"op"`
+	ID       string          `json:"id"`
+	Journal  *models.Journal `json:"journal,omitempty"`
+	LoggedAt time.Time       `json:"logged_at"`
+}
+
+// snapshot represents the compacted state of all journals at a point in time
+type snapshot struct {
+	TakenAt   time.Time                     `json:"taken_at"`
+	Journals  []*models.Journal             `json:"journals"`
+	Revisions map[string][]*models.Revision `json:"revisions,omitempty"`
+}
+
+// FileStore provides durable journal storage backed by an append-only log
+// and a periodic snapshot. Reads are served from an in-memory copy.
+type FileStore struct {
+	dir     string
+	cache   *MemoryStore
+	logFile *os.File
+	logSize int64
+	mu      sync.Mutex
+	done    chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// NewFileStore opens (or creates) a file-backed store in the given directory,
+// replaying the latest snapshot and log, and starts periodic snapshotting
+func NewFileStore(dir string, snapshotInterval time.Duration) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	fs := &FileStore{
+		dir:   dir,
+		cache: NewMemoryStore(),
+		done:  make(chan struct{}),
+	}
+
+	if err := fs.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	logSize, err := fs.replayLog()
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop a torn tail before appending, or the next entry would be written
+	// onto the end of it and corrupt the log
+	if err := os.Truncate(fs.logPath(), logSize); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to truncate journal log: %w", err)
+	}
+
+	logFile, err := os.OpenFile(fs.logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal log: %w", err)
+	}
+	fs.logFile = logFile
+	fs.logSize = logSize
+
+	if snapshotInterval > 0 {
+		fs.wg.Add(1)
+		go fs.snapshotLoop(snapshotInterval)
+	}
+
+	return fs, nil
+}
+
+// Store saves a journal entry durably
+func (fs *FileStore) Store(journal *models.Journal) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.closed {
+		return fmt.Errorf("file store is closed")
+	}
+
+	previous, _ := fs.cache.Get(journal.ID)
+	previousRevisions := fs.cache.revisions[journal.ID]
+	if err := fs.cache.Store(journal); err != nil {
+		return err
+	}
+
+	if err := fs.appendLog(logEntry{Op: opStore, ID: journal.ID, Journal: journal}); err != nil {
+		fs.rollback(journal.ID, previous, previousRevisions)
+		return err
+	}
+
+	return nil
+}
+
+// Get retrieves a journal entry by ID
+func (fs *FileStore) Get(id string) (*models.Journal, error) {
+	return fs.cache.Get(id)
+}
+
+// GetAll returns all journal entries
+func (fs *FileStore) GetAll() ([]*models.Journal, error) {
+	return fs.cache.GetAll()
+}
+
+// List returns a filtered, sorted page of journal entries
+func (fs *FileStore) List(query JournalQuery) (*JournalPage, error) {
+	return fs.cache.List(query)
+}
+
+// Update modifies an existing journal entry durably
+func (fs *FileStore) Update(id string, journal *models.Journal) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.closed {
+		return fmt.Errorf("file store is closed")
+	}
+
+	previous, err := fs.cache.Get(id)
+	if err != nil {
+		return err
+	}
+	previousRevisions := fs.cache.revisions[id]
+	if err := fs.cache.Update(id, journal); err != nil {
+		return err
+	}
+
+	if err := fs.appendLog(logEntry{Op: opUpdate, ID: id, Journal: journal}); err != nil {
+		fs.rollback(id, previous, previousRevisions)
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes a journal entry durably
+func (fs *FileStore) Delete(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.closed {
+		return fmt.Errorf("file store is closed")
+	}
+
+	if _, err := fs.cache.Get(id); err != nil {
+		return err
+	}
+
+	// Log first so a failed write leaves the entry untouched
+	if err := fs.appendLog(logEntry{Op: opDelete, ID: id}); err != nil {
+		return err
+	}
+
+	return fs.cache.Delete(id)
+}
+
+// ListRevisions returns the revision history of a journal, oldest first
+func (fs *FileStore) ListRevisions(id string) ([]*models.Revision, error) {
+	return fs.cache.ListRevisions(id)
+}
+
+// GetRevision returns a single revision of a journal
+func (fs *FileStore) GetRevision(id string, number int64) (*models.Revision, error) {
+	return fs.cache.GetRevision(id, number)
+}
+
+// Count returns the total number of journal entries
+func (fs *FileStore) Count() int {
+	return fs.cache.Count()
+}
+
+// GetStats returns statistics about stored journals
+func (fs *FileStore) GetStats() StorageStats {
+	stats := fs.cache.GetStats()
+	stats.Backend = BackendFile
+	return stats
+}
+
+// Snapshot writes the current state to disk and truncates the log
+func (fs *FileStore) Snapshot() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.closed {
+		return fmt.Errorf("file store is closed")
+	}
+
+	return fs.snapshotLocked()
+}
+
+// Close stops periodic snapshotting, writes a final snapshot and closes the log
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	if fs.closed {
+		fs.mu.Unlock()
+		return nil
+	}
+	close(fs.done)
+	fs.mu.Unlock()
+
+	fs.wg.Wait()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	snapshotErr := fs.snapshotLocked()
+	fs.closed = true
+
+	if err := fs.logFile.Close(); err != nil {
+		return fmt.Errorf("failed to close journal log: %w", err)
+	}
+
+	return snapshotErr
+}
+
+// snapshotLoop periodically compacts the log into a snapshot
+func (fs *FileStore) snapshotLoop(interval time.Duration) {
+	defer fs.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fs.done:
+			return
+		case <-ticker.C:
+			// Errors are retried on the next tick; the log remains authoritative
+			_ = fs.Snapshot()
+		}
+	}
+}
+
+// snapshotLocked writes a snapshot atomically and truncates the log
+// The caller must hold fs.mu
+func (fs *FileStore) snapshotLocked() error {
+	journals, err := fs.cache.GetAll()
+	if err != nil {
+		return err
+	}
+
+	fs.cache.mu.RLock()
+	data, err := json.Marshal(snapshot{
+		TakenAt:   time.Now().UTC(),
+		Journals:  journals,
+		Revisions: fs.cache.revisions,
+	})
+	fs.cache.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmpPath := fs.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, fs.snapshotPath()); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	// Replaying the log over a newer snapshot is idempotent, so a crash
+	// between the rename and the truncate cannot lose or duplicate data
+	if err := fs.logFile.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate journal log: %w", err)
+	}
+	fs.logSize = 0
+
+	return nil
+}
+
+// appendLog writes a single entry to the log and syncs it to disk
+// The caller must hold fs.mu
+func (fs *FileStore) appendLog(entry logEntry) error {
+	entry.LoggedAt = time.Now().UTC()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode log entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	// A failed write may leave part of the entry behind; cut it off so the
+	// entries appended after it stay readable
+	if _, err := fs.logFile.Write(data); err != nil {
+		_ = fs.logFile.Truncate(fs.logSize)
+		return fmt.Errorf("failed to write journal log: %w", err)
+	}
+	if err := fs.logFile.Sync(); err != nil {
+		_ = fs.logFile.Truncate(fs.logSize)
+		return fmt.Errorf("failed to sync journal log: %w", err)
+	}
+	fs.logSize += int64(len(data))
+
+	return nil
+}
+
+// rollback restores the cached state of a journal after a failed log write
+func (fs *FileStore) rollback(id string, previous *models.Journal, previousRevisions []*models.Revision) {
+	if previous == nil {
+		_ = fs.cache.Delete(id)
+		return
+	}
+
+	fs.cache.mu.Lock()
+	fs.cache.journals[id] = previous
+	fs.cache.revisions[id] = previousRevisions
+	fs.cache.mu.Unlock()
+}
+
+// loadSnapshot loads the latest snapshot into the cache, if one exists
+func (fs *FileStore) loadSnapshot() error {
+	data, err := os.ReadFile(fs.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	for _, journal := range snap.Journals {
+		fs.cache.journals[journal.ID] = journal
+
+		// Snapshots written before revisions existed start history at the current state
+		if revisions, exists := snap.Revisions[journal.ID]; exists {
+			fs.cache.revisions[journal.ID] = revisions
+		} else {
+			fs.cache.revisions[journal.ID] = []*models.Revision{models.NewRevision(journal)}
+		}
+	}
+
+	return nil
+}
+
+// replayLog applies all log entries written since the last snapshot and
+// returns the offset just past the last complete entry
+func (fs *FileStore) replayLog() (int64, error) {
+	file, err := os.Open(fs.logPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open journal log: %w", err)
+	}
+	defer file.Close()
+
+	var offset int64
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A trailing line without a newline is a write interrupted by a crash
+			return offset, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read journal log: %w", err)
+		}
+		offset += int64(len(line))
+
+		var entry logEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return 0, fmt.Errorf("corrupt journal log at line %d: %w", lineNumber, err)
+		}
+
+		switch entry.Op {
+		case opStore, opUpdate:
+			if entry.Journal == nil {
+				return 0, fmt.Errorf("corrupt journal log at line %d: missing journal", lineNumber)
+			}
+			fs.cache.journals[entry.ID] = entry.Journal
+			fs.replayRevision(entry)
+		case opDelete:
+			delete(fs.cache.journals, entry.ID)
+			delete(fs.cache.revisions, entry.ID)
+		default:
+			return 0, fmt.Errorf("corrupt journal log at line %d: unknown operation %q", lineNumber, entry.Op)
+		}
+	}
+}
+
+// replayRevision records the revision produced by a replayed store or update
+// Entries already captured by the snapshot are skipped, keeping replay idempotent
+func (fs *FileStore) replayRevision(entry logEntry) {
+	revision := models.NewRevision(entry.Journal)
+
+	if entry.Op == opStore {
+		fs.cache.revisions[entry.ID] = []*models.Revision{revision}
+		return
+	}
+
+	history := fs.cache.revisions[entry.ID]
+	if len(history) > 0 && history[len(history)-1].Number >= revision.Number {
+		return
+	}
+	fs.cache.revisions[entry.ID] = append(history, revision)
+}
+
+func (fs *FileStore) snapshotPath() string {
+	return filepath.Join(fs.dir, snapshotFileName)
+}
+
+func (fs *FileStore) logPath() string {
+	return filepath.Join(fs.dir, logFileName)
+}
+
+// writeFileSync writes data to a file and syncs it before closing
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
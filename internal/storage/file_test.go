@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func newTestFileStore(t *testing.T, dir string) *FileStore {
+	t.Helper()
+
+	store, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	return store
+}
+
+func TestFileStore_PersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestFileStore(t, dir)
+
+	journal := &models.Journal{
+		ID:       "persisted-1",
+		Content:  "This entry must survive a restart",
+		Metadata: map[string]any{"mood": float64(7)},
+	}
+	if err := store.Store(journal); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	updated := &models.Journal{Content: "Updated content that must also survive"}
+	if err := store.Update("persisted-1", updated); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if err := store.Store(&models.Journal{ID: "deleted-1", Content: "Short lived entry"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if err := store.Delete("deleted-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// Simulate a crash: close the log without taking a snapshot
+	store.logFile.Close()
+
+	reopened := newTestFileStore(t, dir)
+	defer reopened.Close()
+
+	if reopened.Count() != 1 {
+		t.Fatalf("Expected 1 journal after restart, got %d", reopened.Count())
+	}
+
+	got, err := reopened.Get("persisted-1")
+	if err != nil {
+		t.Fatalf("Get failed after restart: %v", err)
+	}
+	if got.Content != "Updated content that must also survive" {
+		t.Errorf("Expected updated content, got %q", got.Content)
+	}
+	if !got.CreatedAt.Equal(journal.CreatedAt) {
+		t.Errorf("Expected CreatedAt %v to be preserved, got %v", journal.CreatedAt, got.CreatedAt)
+	}
+
+	if _, err := reopened.Get("deleted-1"); err == nil {
+		t.Error("Expected deleted journal to stay deleted after restart")
+	}
+}
+
+func TestFileStore_SnapshotCompactsLog(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestFileStore(t, dir)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := store.Store(&models.Journal{ID: id, Content: "Snapshot test entry " + id}); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	if err := store.Snapshot(); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, logFileName))
+	if err != nil {
+		t.Fatalf("Failed to stat log: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected log to be truncated after snapshot, got %d bytes", info.Size())
+	}
+
+	// Writes after the snapshot land in the log and are replayed on top of it
+	if err := store.Delete("b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened := newTestFileStore(t, dir)
+	defer reopened.Close()
+
+	if reopened.Count() != 2 {
+		t.Errorf("Expected 2 journals after reopen, got %d", reopened.Count())
+	}
+	if _, err := reopened.Get("b"); err == nil {
+		t.Error("Expected journal 'b' to be deleted")
+	}
+}
+
+func TestFileStore_IgnoresTruncatedLogTail(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestFileStore(t, dir)
+
+	if err := store.Store(&models.Journal{ID: "complete", Content: "Fully written entry"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	store.logFile.Close()
+
+	// Append a partial record as if the process died mid-write
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if _, err := f.WriteString(`{"op":"store","id":"partial","jour`); err != nil {
+		t.Fatalf("Failed to write partial record: %v", err)
+	}
+	f.Close()
+
+	reopened := newTestFileStore(t, dir)
+	defer reopened.Close()
+
+	if reopened.Count() != 1 {
+		t.Errorf("Expected 1 journal, got %d", reopened.Count())
+	}
+}
+
+func TestFileStore_AppendsAfterTruncatedLogTail(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestFileStore(t, dir)
+
+	if err := store.Store(&models.Journal{ID: "complete", Content: "Fully written entry"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	store.logFile.Close()
+
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if _, err := f.WriteString(`{"op":"store","id":"partial","jour`); err != nil {
+		t.Fatalf("Failed to write partial record: %v", err)
+	}
+	f.Close()
+
+	// Recover, store a new journal and crash again without a final snapshot
+	recovered := newTestFileStore(t, dir)
+	if err := recovered.Store(&models.Journal{ID: "after", Content: "Written after recovery"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	recovered.logFile.Close()
+
+	reopened := newTestFileStore(t, dir)
+	defer reopened.Close()
+
+	for _, id := range []string{"complete", "after"} {
+		if _, err := reopened.Get(id); err != nil {
+			t.Errorf("Expected journal %q after reopen, got %v", id, err)
+		}
+	}
+}
+
+func TestFileStore_RejectsCorruptLog(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, logFileName), []byte("not json\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	if _, err := NewFileStore(dir, 0); err == nil {
+		t.Error("Expected error for corrupt log")
+	}
+}
+
+func TestFileStore_UpdateAndDeleteMissing(t *testing.T) {
+	store := newTestFileStore(t, t.TempDir())
+	defer store.Close()
+
+	if err := store.Update("missing", &models.Journal{Content: "nothing"}); err == nil {
+		t.Error("Expected error updating missing journal")
+	}
+	if err := store.Delete("missing"); err == nil {
+		t.Error("Expected error deleting missing journal")
+	}
+}
+
+func TestFileStore_PeriodicSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Store(&models.Journal{ID: "periodic", Content: "Periodic snapshot entry"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Error("Expected snapshot file to be written periodically")
+}
+
+func TestNewStore(t *testing.T) {
+	memory, err := NewStore(Config{})
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if memory.GetStats().Backend != BackendMemory {
+		t.Errorf("Expected memory backend by default, got %s", memory.GetStats().Backend)
+	}
+
+	file, err := NewStore(Config{Backend: "file", Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer file.(io.Closer).Close()
+	if file.GetStats().Backend != BackendFile {
+		t.Errorf("Expected file backend, got %s", file.GetStats().Backend)
+	}
+
+	if _, err := NewStore(Config{Backend: "unknown"}); err == nil {
+		t.Error("Expected error for unsupported backend")
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/search"
+)
+
+// snippetLength bounds the length of highlighted search snippets in runes
+const snippetLength = 200
+
+// SearchHit is a journal matching a full-text query
+type SearchHit struct {
+	Journal *models.Journal `json:"journal"`
+	Score   float64         `json:"score"`
+	Snippet string          `json:"snippet"`
+}
+
+// Searcher is implemented by stores that support full-text search
+type Searcher interface {
+	Search(query string, limit int) ([]SearchHit, int, error)
+}
+
+// Ensure IndexedStore satisfies the store and search interfaces
+var (
+	_ JournalStore = (*IndexedStore)(nil)
+	_ Searcher     = (*IndexedStore)(nil)
+)
+
+// IndexedStore wraps a JournalStore and keeps a full-text index in sync with it
+// The index is persisted to indexPath on Close and rebuilt from the underlying
+// store at startup for journals that changed while it was not being maintained
+type IndexedStore struct {
+	JournalStore
+	index     *search.Index
+	indexPath string
+	mu        sync.Mutex // serializes writes so store and index stay in step
+}
+
+// NewIndexedStore wraps store with a full-text index persisted at indexPath
+// An empty indexPath keeps the index in memory only
+func NewIndexedStore(store JournalStore, indexPath string) (*IndexedStore, error) {
+	index, err := search.LoadIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &IndexedStore{
+		JournalStore: store,
+		index:        index,
+		indexPath:    indexPath,
+	}
+
+	if err := s.sync(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// sync brings the index up to date with the underlying store
+func (s *IndexedStore) sync() error {
+	journals, err := s.JournalStore.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load journals for search index: %w", err)
+	}
+
+	present := make(map[string]bool, len(journals))
+	changed := false
+
+	for _, journal := range journals {
+		present[journal.ID] = true
+		indexedAt, indexed := s.index.IndexedAt(journal.ID)
+		if !indexed || !indexedAt.Equal(journal.UpdatedAt) {
+			s.index.Add(journal)
+			changed = true
+		}
+	}
+
+	for _, id := range s.index.IDs() {
+		if !present[id] {
+			s.index.Remove(id)
+			changed = true
+		}
+	}
+
+	if changed && s.indexPath != "" {
+		return s.index.Save(s.indexPath)
+	}
+
+	return nil
+}
+
+// Store saves a journal and adds it to the index
+func (s *IndexedStore) Store(journal *models.Journal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.JournalStore.Store(journal); err != nil {
+		return err
+	}
+
+	s.index.Add(journal)
+	return nil
+}
+
+// Update replaces a journal and re-indexes it
+func (s *IndexedStore) Update(id string, journal *models.Journal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.JournalStore.Update(id, journal); err != nil {
+		return err
+	}
+
+	s.index.Add(journal)
+	return nil
+}
+
+// Delete removes a journal and drops it from the index
+func (s *IndexedStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.JournalStore.Delete(id); err != nil {
+		return err
+	}
+
+	s.index.Remove(id)
+	return nil
+}
+
+// Search runs a full-text query and returns up to limit ranked hits along
+// with the total number of matches
+func (s *IndexedStore) Search(raw string, limit int) ([]SearchHit, int, error) {
+	query := search.ParseQuery(raw)
+	if query.IsEmpty() {
+		return nil, 0, fmt.Errorf("%w: search query has no searchable terms", ErrInvalidQuery)
+	}
+
+	results, total := s.index.Search(query, limit)
+
+	hits := make([]SearchHit, 0, len(results))
+	for _, result := range results {
+		journal, err := s.JournalStore.Get(result.ID)
+		if err != nil {
+			// Deleted between the index lookup and the fetch
+			total--
+			continue
+		}
+		hits = append(hits, SearchHit{
+			Journal: journal,
+			Score:   result.Score,
+			Snippet: search.Snippet(journal.Content, query, snippetLength),
+		})
+	}
+
+	return hits, total, nil
+}
+
+// Close persists the index and closes the underlying store
+func (s *IndexedStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var saveErr error
+	if s.indexPath != "" {
+		saveErr = s.index.Save(s.indexPath)
+	}
+
+	if closer, ok := s.JournalStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+
+	return saveErr
+}
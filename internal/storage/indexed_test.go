@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func TestIndexedStore_MaintainsIndex(t *testing.T) {
+	store, err := NewIndexedStore(NewMemoryStore(), "")
+	if err != nil {
+		t.Fatalf("NewIndexedStore failed: %v", err)
+	}
+
+	if err := store.Store(&models.Journal{ID: "a", Content: "Morning run by the river"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if err := store.Store(&models.Journal{ID: "b", Content: "Evening reading session"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	hits, total, err := store.Search("running", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if total != 1 || hits[0].Journal.ID != "a" {
+		t.Fatalf("Expected journal 'a', got %d hits", total)
+	}
+
+	if err := store.Update("a", &models.Journal{Content: "Rainy day indoors"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, total, _ := store.Search("run", 10); total != 0 {
+		t.Errorf("Expected updated journal to no longer match, got %d", total)
+	}
+
+	if err := store.Delete("b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, total, _ := store.Search("reading", 10); total != 0 {
+		t.Errorf("Expected deleted journal to no longer match, got %d", total)
+	}
+
+	if _, _, err := store.Search("the and", 10); err == nil {
+		t.Error("Expected error for query without searchable terms")
+	}
+}
+
+func TestIndexedStore_RebuildsOnStartup(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "search_index.json")
+
+	base, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	store, err := NewIndexedStore(base, indexPath)
+	if err != nil {
+		t.Fatalf("NewIndexedStore failed: %v", err)
+	}
+	if err := store.Store(&models.Journal{ID: "kept", Content: "Garden planting day"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Change the data behind the index's back, as if it were written by an
+	// older version or the index save was lost
+	base, err = NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	if err := base.Update("kept", &models.Journal{Content: "Harvesting tomatoes", UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := base.Store(&models.Journal{ID: "added", Content: "Tomato soup for dinner"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	store, err = NewIndexedStore(base, indexPath)
+	if err != nil {
+		t.Fatalf("NewIndexedStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if _, total, _ := store.Search("garden", 10); total != 0 {
+		t.Errorf("Expected stale terms to be re-indexed, got %d matches", total)
+	}
+	if _, total, _ := store.Search("tomatoes", 10); total != 2 {
+		t.Errorf("Expected 2 matches after rebuild, got %d", total)
+	}
+}
@@ -10,14 +10,16 @@ import (
 
 // MemoryStore provides in-memory storage for journal entries
 type MemoryStore struct {
-	journals map[string]*models.Journal
-	mu       sync.RWMutex
+	journals  map[string]*models.Journal
+	revisions map[string][]*models.Revision
+	mu        sync.RWMutex
 }
 
 // NewMemoryStore creates a new in-memory storage instance
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		journals: make(map[string]*models.Journal),
+		journals:  make(map[string]*models.Journal),
+		revisions: make(map[string][]*models.Revision),
 	}
 }
 
@@ -31,8 +33,12 @@ func (ms *MemoryStore) Store(journal *models.Journal) error {
 		journal.CreatedAt = now
 	}
 	journal.UpdatedAt = now
+	if journal.Version == 0 {
+		journal.Version = 1
+	}
 
 	ms.journals[journal.ID] = journal
+	ms.revisions[journal.ID] = []*models.Revision{models.NewRevision(journal)}
 	return nil
 }
 
@@ -43,7 +49,7 @@ func (ms *MemoryStore) Get(id string) (*models.Journal, error) {
 
 	journal, exists := ms.journals[id]
 	if !exists {
-		return nil, fmt.Errorf("journal with ID %s not found", id)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
 	return journal, nil
@@ -62,6 +68,16 @@ func (ms *MemoryStore) GetAll() ([]*models.Journal, error) {
 	return journals, nil
 }
 
+// List returns a filtered, sorted page of journal entries
+func (ms *MemoryStore) List(query JournalQuery) (*JournalPage, error) {
+	journals, err := ms.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return ApplyQuery(journals, query)
+}
+
 // Update modifies an existing journal entry
 func (ms *MemoryStore) Update(id string, journal *models.Journal) error {
 	ms.mu.Lock()
@@ -69,15 +85,20 @@ func (ms *MemoryStore) Update(id string, journal *models.Journal) error {
 
 	existing, exists := ms.journals[id]
 	if !exists {
-		return fmt.Errorf("journal with ID %s not found", id)
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if journal.Version != 0 && journal.Version != existing.Version {
+		return fmt.Errorf("%w: journal %s is at version %d", ErrVersionConflict, id, existing.Version)
 	}
 
 	// Preserve original creation time
 	journal.CreatedAt = existing.CreatedAt
 	journal.UpdatedAt = time.Now()
 	journal.ID = id
+	journal.Version = existing.Version + 1
 
 	ms.journals[id] = journal
+	ms.revisions[id] = append(ms.revisions[id], models.NewRevision(journal))
 	return nil
 }
 
@@ -87,13 +108,47 @@ func (ms *MemoryStore) Delete(id string) error {
 	defer ms.mu.Unlock()
 
 	if _, exists := ms.journals[id]; !exists {
-		return fmt.Errorf("journal with ID %s not found", id)
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
 	delete(ms.journals, id)
+	delete(ms.revisions, id)
 	return nil
 }
 
+// ListRevisions returns the revision history of a journal, oldest first
+func (ms *MemoryStore) ListRevisions(id string) ([]*models.Revision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, exists := ms.journals[id]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	revisions := make([]*models.Revision, len(ms.revisions[id]))
+	copy(revisions, ms.revisions[id])
+
+	return revisions, nil
+}
+
+// GetRevision returns a single revision of a journal
+func (ms *MemoryStore) GetRevision(id string, number int64) (*models.Revision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, exists := ms.journals[id]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	for _, revision := range ms.revisions[id] {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: revision %d of journal %s", ErrNotFound, number, id)
+}
+
 // Count returns the total number of journal entries
 func (ms *MemoryStore) Count() int {
 	ms.mu.RLock()
@@ -104,6 +159,7 @@ func (ms *MemoryStore) Count() int {
 
 // StorageStats represents statistics about stored journals
 type StorageStats struct {
+	Backend             string  `json:"backend"`
 	TotalJournals       int     `json:"total_journals"`
 	ProcessedJournals   int     `json:"processed_journals"`
 	AvgProcessingTimeMS float64 `json:"avg_processing_time_ms"`
@@ -117,6 +173,7 @@ func (ms *MemoryStore) GetStats() StorageStats {
 	defer ms.mu.RUnlock()
 
 	stats := StorageStats{
+		Backend:       BackendMemory,
 		TotalJournals: len(ms.journals),
 	}
 
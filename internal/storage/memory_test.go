@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -593,3 +594,25 @@ func BenchmarkMemoryStore_Count(b *testing.B) {
 		store.Count()
 	}
 }
+
+func TestMemoryStore_VersionConflict(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Store(&models.Journal{ID: "v", Content: "Versioned entry"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	if err := store.Update("v", &models.Journal{Content: "First edit", Version: 1}); err != nil {
+		t.Fatalf("Conditional update failed: %v", err)
+	}
+	if err := store.Update("v", &models.Journal{Content: "Stale edit", Version: 1}); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, got %v", err)
+	}
+	if err := store.Update("missing", &models.Journal{Content: "Nothing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	got, _ := store.Get("v")
+	if got.Version != 2 || got.Content != "First edit" {
+		t.Errorf("Expected version 2 with first edit, got version %d %q", got.Version, got.Content)
+	}
+}
@@ -0,0 +1,444 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// Sortable journal fields
+const (
+	SortByCreatedAt      = "created_at"
+	SortByTimestamp      = "timestamp"
+	SortBySentimentScore = "sentiment_score"
+)
+
+// Sort orders
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Page size limits for journal listings
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ErrInvalidQuery is returned when a journal query cannot be executed as given
+var ErrInvalidQuery = errors.New("invalid journal query")
+
+// JournalQuery describes filtering, sorting and pagination for journal listings
+type JournalQuery struct {
+	Limit  int    // Maximum number of journals per page
+	Cursor string // Opaque cursor returned as NextCursor by a previous page
+	SortBy string // created_at, timestamp or sentiment_score
+	Order  string // asc or desc
+
+	From *time.Time // Inclusive lower bound on created_at
+	To   *time.Time // Exclusive upper bound on created_at
+
+	Status         models.ProcessingStatus // Processing status to match
+	SentimentLabel string                  // Sentiment label to match
+	MinScore       *float64                // Inclusive lower bound on sentiment score
+	MaxScore       *float64                // Inclusive upper bound on sentiment score
+
+	DominantEmotion string             // Most intense emotion to match
+	MinEmotions     map[string]float64 // Inclusive lower bounds on emotion intensities
+	MinValence      *float64           // Inclusive lower bound on emotional valence
+	MaxValence      *float64           // Inclusive upper bound on emotional valence
+	MinArousal      *float64           // Inclusive lower bound on emotional arousal
+	MaxArousal      *float64           // Inclusive upper bound on emotional arousal
+
+	Entity string // Person, place or organization the extraction must name
+
+	// Metadata maps metadata keys to values that must all be present
+	// Array values (e.g. tags) match when any element equals the wanted value
+	Metadata map[string][]string
+}
+
+// JournalPage is a single page of journals matching a query
+type JournalPage struct {
+	Journals   []*models.Journal
+	NextCursor string // Empty when there are no more results
+	Total      int    // Number of journals matching the filters across all pages
+}
+
+// JournalsBetween returns the journals created in [from, to), oldest first,
+// reading every page of the listing
+// Nil bounds leave that side open.
+func JournalsBetween(store JournalStore, from, to *time.Time) ([]*models.Journal, error) {
+	query := JournalQuery{
+		Limit:  MaxPageLimit,
+		SortBy: SortByCreatedAt,
+		Order:  SortAsc,
+		From:   from,
+		To:     to,
+	}
+
+	var journals []*models.Journal
+	for {
+		page, err := store.List(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list journals: %w", err)
+		}
+		journals = append(journals, page.Journals...)
+		if page.NextCursor == "" {
+			return journals, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// normalize fills in defaults and validates the query
+func (q JournalQuery) normalize() (JournalQuery, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+
+	switch q.SortBy {
+	case "":
+		q.SortBy = SortByCreatedAt
+	case SortByCreatedAt, SortByTimestamp, SortBySentimentScore:
+	default:
+		return q, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidQuery, q.SortBy)
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = SortDesc
+	case SortAsc, SortDesc:
+	default:
+		return q, fmt.Errorf("%w: unsupported sort order %q", ErrInvalidQuery, q.Order)
+	}
+
+	return q, nil
+}
+
+// journalCursor identifies the last journal of a page for keyset pagination
+type journalCursor struct {
+	SortBy string  `json:"s"`
+	Order  string  `json:"o"`
+	Nanos  int64   `json:"n,omitempty"`
+	Score  float64 `json:"f,omitempty"`
+	ID     string  `json:"id"`
+}
+
+// encodeCursor builds an opaque cursor pointing after the given journal
+func encodeCursor(q JournalQuery, journal *models.Journal) string {
+	return encodeCursorKey(q, sortKeyOf(journal, q.SortBy))
+}
+
+// encodeCursorKey builds an opaque cursor pointing after the given sort key
+func encodeCursorKey(q JournalQuery, key sortKey) string {
+	data, _ := json.Marshal(journalCursor{
+		SortBy: q.SortBy,
+		Order:  q.Order,
+		Nanos:  key.nanos,
+		Score:  key.score,
+		ID:     key.id,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an opaque cursor and checks it matches the query ordering
+func decodeCursor(q JournalQuery) (*journalCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	var cursor journalCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	if cursor.SortBy != q.SortBy || cursor.Order != q.Order {
+		return nil, fmt.Errorf("%w: cursor does not match the requested sort order", ErrInvalidQuery)
+	}
+
+	return &cursor, nil
+}
+
+// sortKey is the comparable value of a journal for a sort field
+type sortKey struct {
+	nanos int64
+	score float64
+	id    string
+}
+
+// missingScore sorts unprocessed journals below every valid sentiment score
+const missingScore = -2.0
+
+// sortKeyOf extracts the sort key of a journal for the given field
+func sortKeyOf(journal *models.Journal, sortBy string) sortKey {
+	key := sortKey{id: journal.ID}
+
+	switch sortBy {
+	case SortByTimestamp:
+		key.nanos = journal.Timestamp.UnixNano()
+	case SortBySentimentScore:
+		key.score = missingScore
+		if sentiment := SentimentOf(journal); sentiment != nil {
+			key.score = sentiment.Score
+		}
+	default:
+		key.nanos = journal.CreatedAt.UnixNano()
+	}
+
+	return key
+}
+
+// compare orders two sort keys ascending, breaking ties by ID
+func (k sortKey) compare(other sortKey) int {
+	switch {
+	case k.nanos < other.nanos:
+		return -1
+	case k.nanos > other.nanos:
+		return 1
+	case k.score < other.score:
+		return -1
+	case k.score > other.score:
+		return 1
+	}
+
+	return strings.Compare(k.id, other.id)
+}
+
+// ApplyQuery filters, sorts and paginates journals in memory
+// Backends without native query support use it on top of GetAll
+func ApplyQuery(journals []*models.Journal, query JournalQuery) (*JournalPage, error) {
+	q, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := decodeCursor(q)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*models.Journal, 0, len(journals))
+	for _, journal := range journals {
+		if matchesQuery(journal, q) {
+			matched = append(matched, journal)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		cmp := sortKeyOf(matched[i], q.SortBy).compare(sortKeyOf(matched[j], q.SortBy))
+		if q.Order == SortDesc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	start := 0
+	if cursor != nil {
+		after := sortKey{nanos: cursor.Nanos, score: cursor.Score, id: cursor.ID}
+		start = sort.Search(len(matched), func(i int) bool {
+			cmp := sortKeyOf(matched[i], q.SortBy).compare(after)
+			if q.Order == SortDesc {
+				return cmp < 0
+			}
+			return cmp > 0
+		})
+	}
+
+	end := min(start+q.Limit, len(matched))
+
+	page := &JournalPage{
+		Journals: matched[start:end],
+		Total:    len(matched),
+	}
+	if end < len(matched) {
+		page.NextCursor = encodeCursor(q, matched[end-1])
+	}
+
+	return page, nil
+}
+
+// matchesQuery reports whether a journal satisfies all query filters
+func matchesQuery(journal *models.Journal, q JournalQuery) bool {
+	if q.From != nil && journal.CreatedAt.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !journal.CreatedAt.Before(*q.To) {
+		return false
+	}
+
+	if q.Status != "" && statusOf(journal) != q.Status {
+		return false
+	}
+
+	if q.SentimentLabel != "" || q.MinScore != nil || q.MaxScore != nil {
+		sentiment := SentimentOf(journal)
+		if sentiment == nil {
+			return false
+		}
+		if q.SentimentLabel != "" && !strings.EqualFold(sentiment.Label, q.SentimentLabel) {
+			return false
+		}
+		if q.MinScore != nil && sentiment.Score < *q.MinScore {
+			return false
+		}
+		if q.MaxScore != nil && sentiment.Score > *q.MaxScore {
+			return false
+		}
+	}
+
+	if q.hasEmotionFilters() && !matchesEmotions(emotionsOf(journal), q) {
+		return false
+	}
+
+	if q.Entity != "" && !namesEntity(extractionOf(journal), q.Entity) {
+		return false
+	}
+
+	for key, wanted := range q.Metadata {
+		value, exists := journal.Metadata[key]
+		if !exists {
+			return false
+		}
+		for _, want := range wanted {
+			if !metadataValueMatches(value, want) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasEmotionFilters reports whether the query filters on emotion analysis
+func (q JournalQuery) hasEmotionFilters() bool {
+	return q.DominantEmotion != "" || len(q.MinEmotions) > 0 ||
+		q.MinValence != nil || q.MaxValence != nil || q.MinArousal != nil || q.MaxArousal != nil
+}
+
+// matchesEmotions reports whether an emotion analysis satisfies the emotion
+// filters of a query; journals without one never do
+func matchesEmotions(emotions *models.EmotionResult, q JournalQuery) bool {
+	if emotions == nil {
+		return false
+	}
+
+	if q.DominantEmotion != "" && !strings.EqualFold(emotions.DominantEmotion, q.DominantEmotion) {
+		return false
+	}
+	for name, minimum := range q.MinEmotions {
+		if intensity, ok := emotions.Emotions.Intensity(name); !ok || intensity < minimum {
+			return false
+		}
+	}
+
+	if q.MinValence != nil && emotions.Valence < *q.MinValence {
+		return false
+	}
+	if q.MaxValence != nil && emotions.Valence > *q.MaxValence {
+		return false
+	}
+	if q.MinArousal != nil && emotions.Arousal < *q.MinArousal {
+		return false
+	}
+	if q.MaxArousal != nil && emotions.Arousal > *q.MaxArousal {
+		return false
+	}
+
+	return true
+}
+
+// namesEntity reports whether an extraction names the entity, ignoring case;
+// journals without one never do
+func namesEntity(extraction *models.ExtractionResult, name string) bool {
+	if extraction == nil {
+		return false
+	}
+
+	for _, entity := range extraction.Entities() {
+		if strings.EqualFold(entity.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusOf returns the effective processing status of a journal
+func statusOf(journal *models.Journal) models.ProcessingStatus {
+	if journal.ProcessingResult != nil {
+		return journal.ProcessingResult.Status
+	}
+	return journal.ProcessingStatus
+}
+
+// SentimentOf returns the sentiment result of a journal, if any
+func SentimentOf(journal *models.Journal) *models.SentimentResult {
+	if journal.ProcessingResult == nil {
+		return nil
+	}
+	return journal.ProcessingResult.SentimentResult
+}
+
+// emotionsOf returns the emotion analysis of a journal, if any
+func emotionsOf(journal *models.Journal) *models.EmotionResult {
+	if journal.ProcessingResult == nil {
+		return nil
+	}
+	return journal.ProcessingResult.EmotionResult
+}
+
+// extractionOf returns the entity and topic extraction of a journal, if any
+func extractionOf(journal *models.Journal) *models.ExtractionResult {
+	if journal.ProcessingResult == nil {
+		return nil
+	}
+	return journal.ProcessingResult.Extraction
+}
+
+// metadataValueMatches compares a stored metadata value with a query string
+func metadataValueMatches(value any, want string) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.EqualFold(v, want)
+	case float64:
+		n, err := strconv.ParseFloat(want, 64)
+		return err == nil && n == v
+	case int:
+		n, err := strconv.ParseFloat(want, 64)
+		return err == nil && n == float64(v)
+	case int64:
+		n, err := strconv.ParseFloat(want, 64)
+		return err == nil && n == float64(v)
+	case bool:
+		b, err := strconv.ParseBool(want)
+		return err == nil && b == v
+	case []any:
+		for _, item := range v {
+			if metadataValueMatches(item, want) {
+				return true
+			}
+		}
+	case []string:
+		for _, item := range v {
+			if strings.EqualFold(item, want) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
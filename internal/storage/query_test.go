@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// seedQueryJournals creates journals with predictable timestamps, scores,
+// emotions, extractions and metadata
+func seedQueryJournals(t *testing.T, store JournalStore) time.Time {
+	t.Helper()
+
+	base := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	fixtures := []struct {
+		label      string
+		score      float64
+		tags       []any
+		mood       float64
+		emotions   *models.EmotionResult
+		extraction *models.ExtractionResult
+	}{
+		{"positive", 0.8, []any{"work", "focus"}, 8, emotionFixture(models.Emotions{Joy: 0.8, Anticipation: 0.4}, 0.7, 0.6), extractionFixture([]string{"Alice"}, "Acme")},
+		{"negative", -0.6, []any{"health"}, 3, emotionFixture(models.Emotions{Sadness: 0.7, Fear: 0.2}, -0.5, 0.3), nil},
+		{"neutral", 0.0, []any{"work"}, 5, nil, extractionFixture(nil, "Acme")},
+		{"positive", 0.4, []any{"family"}, 7, emotionFixture(models.Emotions{Joy: 0.4, Trust: 0.6}, 0.5, 0.3), extractionFixture([]string{"alice", "Bob"}, "")},
+		{"negative", -0.2, []any{"work", "stress"}, 4, emotionFixture(models.Emotions{Fear: 0.6, Anger: 0.3}, -0.3, 0.8), nil},
+	}
+
+	for i, f := range fixtures {
+		journal := &models.Journal{
+			ID:        fmt.Sprintf("journal-%d", i),
+			Content:   fmt.Sprintf("Journal entry number %d", i),
+			Timestamp: base.Add(time.Duration(len(fixtures)-i) * time.Hour),
+			CreatedAt: base.Add(time.Duration(i) * 24 * time.Hour),
+			Metadata:  map[string]any{"tags": f.tags, "mood": f.mood},
+			ProcessingResult: &models.ProcessingResult{
+				Status:          models.ProcessingStatusCompleted,
+				SentimentResult: &models.SentimentResult{Score: f.score, Label: f.label},
+				EmotionResult:   f.emotions,
+				Extraction:      f.extraction,
+			},
+		}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	if err := store.Store(&models.Journal{
+		ID:               "journal-pending",
+		Content:          "Not processed yet",
+		CreatedAt:        base.Add(10 * 24 * time.Hour),
+		ProcessingResult: &models.ProcessingResult{Status: models.ProcessingStatusPending},
+	}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	return base
+}
+
+func emotionFixture(emotions models.Emotions, valence, arousal float64) *models.EmotionResult {
+	return &models.EmotionResult{
+		Emotions:        emotions,
+		Valence:         valence,
+		Arousal:         arousal,
+		DominantEmotion: emotions.Dominant(),
+	}
+}
+
+func extractionFixture(people []string, organization string) *models.ExtractionResult {
+	extraction := &models.ExtractionResult{Themes: []models.ExtractedItem{{Name: "daily life", Confidence: 0.5}}}
+	for _, name := range people {
+		extraction.People = append(extraction.People, models.ExtractedItem{Name: name, Confidence: 0.9})
+	}
+	if organization != "" {
+		extraction.Organizations = []models.ExtractedItem{{Name: organization, Confidence: 0.8}}
+	}
+	return extraction
+}
+
+func journalIDs(page *JournalPage) []string {
+	ids := make([]string, 0, len(page.Journals))
+	for _, journal := range page.Journals {
+		ids = append(ids, journal.ID)
+	}
+	return ids
+}
+
+func TestList_BackendsAgree(t *testing.T) {
+	sqliteStore := newTestSQLiteStore(t, t.TempDir())
+	defer sqliteStore.Close()
+
+	backends := map[string]JournalStore{
+		"memory": NewMemoryStore(),
+		"sqlite": sqliteStore,
+	}
+
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			base := seedQueryJournals(t, store)
+
+			t.Run("default order is newest first", func(t *testing.T) {
+				page, err := store.List(JournalQuery{})
+				if err != nil {
+					t.Fatalf("List failed: %v", err)
+				}
+				if page.Total != 6 || len(page.Journals) != 6 {
+					t.Fatalf("Expected 6 journals, got total=%d len=%d", page.Total, len(page.Journals))
+				}
+				if page.Journals[0].ID != "journal-pending" || page.Journals[5].ID != "journal-0" {
+					t.Errorf("Unexpected order: %v", journalIDs(page))
+				}
+				if page.NextCursor != "" {
+					t.Error("Expected no next cursor on the last page")
+				}
+			})
+
+			t.Run("cursor pagination visits every journal once", func(t *testing.T) {
+				seen := make(map[string]bool)
+				query := JournalQuery{Limit: 2, SortBy: SortBySentimentScore, Order: SortDesc}
+				var order []string
+
+				for pages := 0; pages < 10; pages++ {
+					page, err := store.List(query)
+					if err != nil {
+						t.Fatalf("List failed: %v", err)
+					}
+					if page.Total != 6 {
+						t.Errorf("Expected total 6, got %d", page.Total)
+					}
+					for _, journal := range page.Journals {
+						if seen[journal.ID] {
+							t.Errorf("Journal %s returned twice", journal.ID)
+						}
+						seen[journal.ID] = true
+						order = append(order, journal.ID)
+					}
+					if page.NextCursor == "" {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+
+				if len(seen) != 6 {
+					t.Errorf("Expected to visit 6 journals, visited %d", len(seen))
+				}
+				expected := []string{"journal-0", "journal-3", "journal-2", "journal-4", "journal-1", "journal-pending"}
+				for i := range expected {
+					if order[i] != expected[i] {
+						t.Fatalf("Expected order %v, got %v", expected, order)
+					}
+				}
+			})
+
+			t.Run("pages follow the full ordering", func(t *testing.T) {
+				for _, query := range []JournalQuery{
+					{SortBy: SortByCreatedAt, Order: SortAsc},
+					// The pending journal has no timestamp and sorts last
+					{SortBy: SortByTimestamp, Order: SortDesc},
+					{SortBy: SortBySentimentScore, Order: SortAsc, Status: models.ProcessingStatusCompleted},
+				} {
+					all, err := store.List(query)
+					if err != nil {
+						t.Fatalf("List failed: %v", err)
+					}
+
+					var paged []string
+					query.Limit = 4
+					for {
+						page, err := store.List(query)
+						if err != nil {
+							t.Fatalf("List failed: %v", err)
+						}
+						if page.Total != all.Total {
+							t.Errorf("Expected total %d on every page, got %d", all.Total, page.Total)
+						}
+						paged = append(paged, journalIDs(page)...)
+						if page.NextCursor == "" {
+							break
+						}
+						query.Cursor = page.NextCursor
+					}
+
+					if fmt.Sprint(paged) != fmt.Sprint(journalIDs(all)) {
+						t.Errorf("%s %s: expected pages %v, got %v", query.SortBy, query.Order, journalIDs(all), paged)
+					}
+					if query.SortBy == SortByTimestamp && paged[len(paged)-1] != "journal-pending" {
+						t.Errorf("Expected the journal without a timestamp last, got %v", paged)
+					}
+				}
+			})
+
+			t.Run("sort by timestamp ascending", func(t *testing.T) {
+				page, err := store.List(JournalQuery{SortBy: SortByTimestamp, Order: SortAsc, Status: models.ProcessingStatusCompleted})
+				if err != nil {
+					t.Fatalf("List failed: %v", err)
+				}
+				if page.Journals[0].ID != "journal-4" || page.Journals[4].ID != "journal-0" {
+					t.Errorf("Unexpected order: %v", journalIDs(page))
+				}
+			})
+
+			t.Run("filters", func(t *testing.T) {
+				from := base.Add(24 * time.Hour)
+				to := base.Add(4 * 24 * time.Hour)
+				minScore := -0.5
+				maxScore := 0.5
+				zero, half := 0.0, 0.5
+
+				tests := []struct {
+					name     string
+					query    JournalQuery
+					expected int
+				}{
+					{"date range", JournalQuery{From: &from, To: &to}, 3},
+					{"processing status", JournalQuery{Status: models.ProcessingStatusPending}, 1},
+					{"sentiment label", JournalQuery{SentimentLabel: "negative"}, 2},
+					{"score range", JournalQuery{MinScore: &minScore, MaxScore: &maxScore}, 3},
+					{"tag", JournalQuery{Metadata: map[string][]string{"tags": {"work"}}}, 3},
+					{"multiple tags", JournalQuery{Metadata: map[string][]string{"tags": {"work", "stress"}}}, 1},
+					{"numeric mood", JournalQuery{Metadata: map[string][]string{"mood": {"8"}}}, 1},
+					{"combined", JournalQuery{SentimentLabel: "positive", Metadata: map[string][]string{"tags": {"family"}}}, 1},
+					{"no match", JournalQuery{Metadata: map[string][]string{"location": {"home"}}}, 0},
+					{"dominant emotion", JournalQuery{DominantEmotion: "joy"}, 1},
+					{"emotion intensity", JournalQuery{MinEmotions: map[string]float64{"joy": 0.4}}, 2},
+					{"several emotion intensities", JournalQuery{MinEmotions: map[string]float64{"joy": 0.4, "trust": 0.5}}, 1},
+					{"valence", JournalQuery{MinValence: &zero}, 2},
+					{"arousal", JournalQuery{MinArousal: &half}, 2},
+					{"emotions combined", JournalQuery{DominantEmotion: "fear", MaxValence: &zero, Metadata: map[string][]string{"tags": {"stress"}}}, 1},
+					{"unanalyzed journals never match", JournalQuery{MaxArousal: &half, SentimentLabel: "neutral"}, 0},
+					{"entity ignores case", JournalQuery{Entity: "ALICE"}, 2},
+					{"organization entity", JournalQuery{Entity: "acme"}, 2},
+					{"entity combined", JournalQuery{Entity: "Alice", SentimentLabel: "positive", MinScore: &half}, 1},
+					{"themes are not entities", JournalQuery{Entity: "daily life"}, 0},
+				}
+
+				for _, tt := range tests {
+					t.Run(tt.name, func(t *testing.T) {
+						page, err := store.List(tt.query)
+						if err != nil {
+							t.Fatalf("List failed: %v", err)
+						}
+						if page.Total != tt.expected {
+							t.Errorf("Expected %d journals, got %d (%v)", tt.expected, page.Total, journalIDs(page))
+						}
+					})
+				}
+			})
+		})
+	}
+}
+
+func TestApplyQuery_InvalidInput(t *testing.T) {
+	store := NewMemoryStore()
+	seedQueryJournals(t, store)
+
+	page, err := store.List(JournalQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query JournalQuery
+	}{
+		{"unknown sort field", JournalQuery{SortBy: "content"}},
+		{"unknown order", JournalQuery{Order: "sideways"}},
+		{"malformed cursor", JournalQuery{Cursor: "not-a-cursor"}},
+		{"cursor from a different sort", JournalQuery{Cursor: page.NextCursor, Order: SortAsc}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.List(tt.query)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyQuery_LimitIsCapped(t *testing.T) {
+	journals := make([]*models.Journal, 0, MaxPageLimit+10)
+	for i := 0; i < MaxPageLimit+10; i++ {
+		journals = append(journals, &models.Journal{ID: fmt.Sprintf("j-%03d", i), CreatedAt: time.Now()})
+	}
+
+	page, err := ApplyQuery(journals, JournalQuery{Limit: MaxPageLimit * 2})
+	if err != nil {
+		t.Fatalf("ApplyQuery failed: %v", err)
+	}
+	if len(page.Journals) != MaxPageLimit {
+		t.Errorf("Expected %d journals, got %d", MaxPageLimit, len(page.Journals))
+	}
+	if page.NextCursor == "" {
+		t.Error("Expected a next cursor when more journals remain")
+	}
+}
+
+func TestJournalsBetween(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxPageLimit+10; i++ {
+		journal := &models.Journal{ID: fmt.Sprintf("j-%03d", i), Content: "entry", CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	from, to := start.Add(5*time.Minute), start.Add(time.Duration(MaxPageLimit+5)*time.Minute)
+	journals, err := JournalsBetween(store, &from, &to)
+	if err != nil {
+		t.Fatalf("JournalsBetween failed: %v", err)
+	}
+	if len(journals) != MaxPageLimit || journals[0].ID != "j-005" || journals[len(journals)-1].ID != fmt.Sprintf("j-%03d", MaxPageLimit+4) {
+		t.Errorf("Expected every journal in [from, to) oldest first across pages, got %d", len(journals))
+	}
+
+	all, err := JournalsBetween(store, nil, nil)
+	if err != nil || len(all) != MaxPageLimit+10 {
+		t.Errorf("Expected open bounds to return every journal, got %d, %v", len(all), err)
+	}
+}
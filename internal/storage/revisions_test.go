@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// revisionBackends returns a fresh store per backend for revision tests
+func revisionBackends(t *testing.T) map[string]JournalStore {
+	t.Helper()
+
+	sqlite := newTestSQLiteStore(t, t.TempDir())
+	t.Cleanup(func() { sqlite.Close() })
+
+	file := newTestFileStore(t, t.TempDir())
+	t.Cleanup(func() { file.Close() })
+
+	return map[string]JournalStore{
+		BackendMemory: NewMemoryStore(),
+		BackendFile:   file,
+		BackendSQLite: sqlite,
+	}
+}
+
+func TestRevisions_RecordEveryMutation(t *testing.T) {
+	for name, store := range revisionBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			journal := &models.Journal{ID: "r", Content: "First draft", UpdatedBy: "alice",
+				Metadata: map[string]any{"mood": float64(5)}}
+			if err := store.Store(journal); err != nil {
+				t.Fatalf("Store failed: %v", err)
+			}
+
+			result := &models.ProcessingResult{Status: models.ProcessingStatusCompleted}
+			if err := store.Update("r", &models.Journal{Content: "Second draft", UpdatedBy: "bob", ProcessingResult: result}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+
+			// Mutating the live result must not rewrite history
+			result.Status = models.ProcessingStatusFailed
+
+			revisions, err := store.ListRevisions("r")
+			if err != nil {
+				t.Fatalf("ListRevisions failed: %v", err)
+			}
+			if len(revisions) != 2 {
+				t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+			}
+
+			first, second := revisions[0], revisions[1]
+			if first.Number != 1 || first.Content != "First draft" || first.Author != "alice" || first.Metadata["mood"] != float64(5) {
+				t.Errorf("Unexpected first revision: %+v", first)
+			}
+			if second.Number != 2 || second.Content != "Second draft" || second.Author != "bob" {
+				t.Errorf("Unexpected second revision: %+v", second)
+			}
+			if second.ProcessingResult == nil || second.ProcessingResult.Status != models.ProcessingStatusCompleted {
+				t.Errorf("Expected revision to keep its processing result, got %+v", second.ProcessingResult)
+			}
+
+			got, err := store.GetRevision("r", 1)
+			if err != nil || got.Content != "First draft" {
+				t.Errorf("GetRevision(1) = %+v, %v", got, err)
+			}
+			if _, err := store.GetRevision("r", 3); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for missing revision, got %v", err)
+			}
+
+			if err := store.Delete("r"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := store.ListRevisions("r"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStore_RevisionsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestFileStore(t, dir)
+
+	if err := store.Store(&models.Journal{ID: "r", Content: "Version one"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if err := store.Update("r", &models.Journal{Content: "Version two"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := store.Snapshot(); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if err := store.Update("r", &models.Journal{Content: "Version three"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Crash without a final snapshot: the last update is only in the log
+	store.logFile.Close()
+
+	reopened := newTestFileStore(t, dir)
+	defer reopened.Close()
+
+	revisions, err := reopened.ListRevisions("r")
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("Expected 3 revisions after restart, got %d", len(revisions))
+	}
+	for i, want := range []string{"Version one", "Version two", "Version three"} {
+		if revisions[i].Content != want || revisions[i].Number != int64(i+1) {
+			t.Errorf("Revision %d: expected %q, got #%d %q", i, want, revisions[i].Number, revisions[i].Content)
+		}
+	}
+}
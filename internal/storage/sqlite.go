@@ -0,0 +1,785 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver
+)
+
+const sqliteFileName = "englog.db"
+
+// migrations contains the ordered schema changes for the SQLite backend
+// Each entry is one schema version; new migrations must be appended and
+// existing entries must never be edited
+var migrations = []string{
+	// Journals table with JSON columns for metadata and processing results
+	`CREATE TABLE journals (
+		id                TEXT PRIMARY KEY,
+		content           TEXT NOT NULL,
+		processing_status TEXT NOT NULL DEFAULT '',
+		timestamp         INTEGER NOT NULL,
+		created_at        INTEGER NOT NULL,
+		updated_at        INTEGER NOT NULL,
+		metadata          TEXT CHECK (metadata IS NULL OR json_valid(metadata)),
+		processing_result TEXT CHECK (processing_result IS NULL OR json_valid(processing_result))
+	)`,
+	// Derived sentiment columns used for filtering and aggregates
+	`ALTER TABLE journals ADD COLUMN sentiment_label TEXT
+		GENERATED ALWAYS AS (json_extract(processing_result, '$.sentiment_result.label')) VIRTUAL`,
+	`ALTER TABLE journals ADD COLUMN sentiment_score REAL
+		GENERATED ALWAYS AS (json_extract(processing_result, '$.sentiment_result.score')) VIRTUAL`,
+	// Indexes for common access paths
+	`CREATE INDEX idx_journals_created_at ON journals (created_at)`,
+	`CREATE INDEX idx_journals_processing_status ON journals (processing_status)`,
+	`CREATE INDEX idx_journals_sentiment_label ON journals (sentiment_label)`,
+	// Version counter for optimistic concurrency
+	`ALTER TABLE journals ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+	// Revision history, seeded with the current state of existing journals
+	`ALTER TABLE journals ADD COLUMN updated_by TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE journal_revisions (
+		journal_id        TEXT NOT NULL,
+		number            INTEGER NOT NULL,
+		content           TEXT NOT NULL,
+		metadata          TEXT CHECK (metadata IS NULL OR json_valid(metadata)),
+		processing_result TEXT CHECK (processing_result IS NULL OR json_valid(processing_result)),
+		author            TEXT NOT NULL DEFAULT '',
+		created_at        INTEGER NOT NULL,
+		PRIMARY KEY (journal_id, number)
+	)`,
+	`INSERT INTO journal_revisions (journal_id, number, content, metadata, processing_result, author, created_at)
+		SELECT id, version, content, metadata, processing_result, updated_by, updated_at FROM journals`,
+	// Entity index over the extraction of each journal's processing result
+	`CREATE TABLE journal_entities (
+		journal_id TEXT NOT NULL,
+		kind       TEXT NOT NULL,
+		name       TEXT NOT NULL COLLATE NOCASE,
+		PRIMARY KEY (journal_id, kind, name)
+	)`,
+	`CREATE INDEX idx_journal_entities_name ON journal_entities (name)`,
+}
+
+// SQLiteStore provides durable, queryable journal storage using an embedded SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) a SQLite database in the given directory
+// and applies any pending schema migrations
+func NewSQLiteStore(dir string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	dsn := "file:" + filepath.Join(dir, sqliteFileName) +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialising connections avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	store := &SQLiteStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// migrate applies all migrations that have not been recorded yet
+func (s *SQLiteStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			version, time.Now().UnixNano()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// Store saves a journal entry, replacing any existing entry with the same ID
+func (s *SQLiteStore) Store(journal *models.Journal) error {
+	now := time.Now()
+	if journal.CreatedAt.IsZero() {
+		journal.CreatedAt = now
+	}
+	journal.UpdatedAt = now
+	if journal.Version == 0 {
+		journal.Version = 1
+	}
+
+	row, err := newJournalRow(journal)
+	if err != nil {
+		return err
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO journals
+			(`+journalColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				content = excluded.content,
+				processing_status = excluded.processing_status,
+				timestamp = excluded.timestamp,
+				created_at = excluded.created_at,
+				updated_at = excluded.updated_at,
+				metadata = excluded.metadata,
+				processing_result = excluded.processing_result,
+				version = excluded.version,
+				updated_by = excluded.updated_by`,
+			row.args()...)
+		if err != nil {
+			return fmt.Errorf("failed to store journal %s: %w", journal.ID, err)
+		}
+
+		// Storing starts a fresh history
+		if _, err := tx.Exec(`DELETE FROM journal_revisions WHERE journal_id = ?`, journal.ID); err != nil {
+			return fmt.Errorf("failed to reset revisions of journal %s: %w", journal.ID, err)
+		}
+
+		if err := replaceEntities(tx, journal); err != nil {
+			return err
+		}
+
+		return insertRevision(tx, row)
+	})
+}
+
+// Get retrieves a journal entry by ID
+func (s *SQLiteStore) Get(id string) (*models.Journal, error) {
+	row := s.db.QueryRow(`SELECT `+journalColumns+` FROM journals WHERE id = ?`, id)
+
+	journal, err := scanJournal(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+// GetAll returns all journal entries ordered by creation time
+func (s *SQLiteStore) GetAll() ([]*models.Journal, error) {
+	return s.queryJournals(`SELECT ` + journalColumns + ` FROM journals ORDER BY created_at, id`)
+}
+
+// queryJournals runs a SELECT over journalColumns and decodes every row
+func (s *SQLiteStore) queryJournals(statement string, args ...any) ([]*models.Journal, error) {
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journals: %w", err)
+	}
+	defer rows.Close()
+
+	journals := make([]*models.Journal, 0)
+	for rows.Next() {
+		journal, err := scanJournal(rows)
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, journal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate journals: %w", err)
+	}
+
+	return journals, nil
+}
+
+// List returns a filtered, sorted page of journal entries
+// Filters, ordering, the cursor and the page size are evaluated in SQL, and
+// Total is counted separately. Metadata filters, whose matching rules for
+// JSON values have no SQL equivalent here, narrow the rows in SQL and leave
+// the rest to ApplyQuery.
+func (s *SQLiteStore) List(query JournalQuery) (*JournalPage, error) {
+	conditions, args := journalFilters(query)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if len(query.Metadata) > 0 {
+		journals, err := s.queryJournals(`SELECT `+journalColumns+` FROM journals`+where, args...)
+		if err != nil {
+			return nil, err
+		}
+		return ApplyQuery(journals, query)
+	}
+
+	q, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM journals`+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count journals: %w", err)
+	}
+
+	key := sortExpressions[q.SortBy]
+	direction, before := "ASC", ">"
+	if q.Order == SortDesc {
+		direction, before = "DESC", "<"
+	}
+
+	if cursor != nil {
+		var after any = cursor.Nanos
+		if q.SortBy == SortBySentimentScore {
+			after = cursor.Score
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (?, ?)", key, before))
+		args = append(args, after, cursor.ID)
+	}
+
+	statement := `SELECT ` + journalColumns + ` FROM journals`
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", key, direction, direction)
+	args = append(args, q.Limit+1)
+
+	journals, err := s.queryJournals(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &JournalPage{Journals: journals, Total: total}
+	if len(journals) > q.Limit {
+		page.Journals = journals[:q.Limit]
+		page.NextCursor = encodeCursorKey(q, sqliteSortKey(page.Journals[q.Limit-1], q.SortBy))
+	}
+
+	return page, nil
+}
+
+// sortExpressions maps each sort field to the SQL expression it orders by
+// Unprocessed journals sort as missingScore, as they do in ApplyQuery.
+var sortExpressions = map[string]string{
+	SortByCreatedAt:      "created_at",
+	SortByTimestamp:      "timestamp",
+	SortBySentimentScore: fmt.Sprintf("COALESCE(sentiment_score, %.1f)", missingScore),
+}
+
+// sqliteSortKey is the sort key of a journal as stored, with the zero time
+// stored as 0
+func sqliteSortKey(journal *models.Journal, sortBy string) sortKey {
+	key := sortKeyOf(journal, sortBy)
+	switch sortBy {
+	case SortByCreatedAt:
+		key.nanos = toUnixNano(journal.CreatedAt)
+	case SortByTimestamp:
+		key.nanos = toUnixNano(journal.Timestamp)
+	}
+	return key
+}
+
+// journalFilters translates every query filter except metadata into SQL
+// conditions and their arguments
+func journalFilters(query JournalQuery) (conditions []string, args []any) {
+	if query.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, query.From.UnixNano())
+	}
+	if query.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, query.To.UnixNano())
+	}
+	if query.Status != "" {
+		conditions = append(conditions, "processing_status = ?")
+		args = append(args, string(query.Status))
+	}
+	if query.SentimentLabel != "" {
+		conditions = append(conditions, "sentiment_label = ? COLLATE NOCASE")
+		args = append(args, query.SentimentLabel)
+	}
+	if query.MinScore != nil {
+		conditions = append(conditions, "sentiment_score >= ?")
+		args = append(args, *query.MinScore)
+	}
+	if query.MaxScore != nil {
+		conditions = append(conditions, "sentiment_score <= ?")
+		args = append(args, *query.MaxScore)
+	}
+	if query.Entity != "" {
+		conditions = append(conditions, "id IN (SELECT journal_id FROM journal_entities WHERE name = ?)")
+		args = append(args, query.Entity)
+	}
+
+	if query.hasEmotionFilters() {
+		// Journals without an emotion analysis never match
+		conditions = append(conditions, "json_type(processing_result, '$.emotion_result') = 'object'")
+	}
+	if query.DominantEmotion != "" {
+		conditions = append(conditions, "json_extract(processing_result, '$.emotion_result.dominant_emotion') = ? COLLATE NOCASE")
+		args = append(args, query.DominantEmotion)
+	}
+	for name, minimum := range query.MinEmotions {
+		name = strings.ToLower(name)
+		if !slices.Contains(models.EmotionNames, name) {
+			conditions = append(conditions, "FALSE")
+			continue
+		}
+		conditions = append(conditions, "json_extract(processing_result, ?) >= ?")
+		args = append(args, "$.emotion_result.emotions."+name, minimum)
+	}
+	for _, bound := range []struct {
+		value     *float64
+		condition string
+	}{
+		{query.MinValence, "json_extract(processing_result, '$.emotion_result.valence') >= ?"},
+		{query.MaxValence, "json_extract(processing_result, '$.emotion_result.valence') <= ?"},
+		{query.MinArousal, "json_extract(processing_result, '$.emotion_result.arousal') >= ?"},
+		{query.MaxArousal, "json_extract(processing_result, '$.emotion_result.arousal') <= ?"},
+	} {
+		if bound.value != nil {
+			conditions = append(conditions, bound.condition)
+			args = append(args, *bound.value)
+		}
+	}
+
+	return conditions, args
+}
+
+// Update modifies an existing journal entry
+// A journal.Version of 0 overwrites whatever version is stored; any other
+// version must still be current or ErrVersionConflict is returned.
+func (s *SQLiteStore) Update(id string, journal *models.Journal) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		var createdAt int64
+		err := tx.QueryRow(`SELECT created_at FROM journals WHERE id = ?`, id).Scan(&createdAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load journal %s: %w", id, err)
+		}
+
+		// Preserve original creation time
+		journal.CreatedAt = fromUnixNano(createdAt)
+		journal.UpdatedAt = time.Now()
+		journal.ID = id
+
+		row, err := newJournalRow(journal)
+		if err != nil {
+			return err
+		}
+
+		// The version check lives in the WHERE clause so concurrent writers cannot
+		// both succeed against the same version
+		statement := `UPDATE journals SET
+				content = ?, processing_status = ?, timestamp = ?, updated_at = ?,
+				metadata = ?, processing_result = ?, updated_by = ?, version = version + 1
+			WHERE id = ?`
+		args := []any{row.content, row.processingStatus, row.timestamp, row.updatedAt,
+			row.metadata, row.processingResult, row.updatedBy, id}
+		if journal.Version != 0 {
+			statement += ` AND version = ?`
+			args = append(args, journal.Version)
+		}
+
+		err = tx.QueryRow(statement+` RETURNING version`, args...).Scan(&row.version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: journal %s is no longer at version %d", ErrVersionConflict, id, journal.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update journal %s: %w", id, err)
+		}
+
+		if err := insertRevision(tx, row); err != nil {
+			return err
+		}
+
+		if err := replaceEntities(tx, journal); err != nil {
+			return err
+		}
+
+		journal.Version = row.version
+		return nil
+	})
+}
+
+// Delete removes a journal entry and its revision history
+func (s *SQLiteStore) Delete(id string) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM journals WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete journal %s: %w", id, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete journal %s: %w", id, err)
+		}
+		if affected == 0 {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM journal_revisions WHERE journal_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete revisions of journal %s: %w", id, err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM journal_entities WHERE journal_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete entities of journal %s: %w", id, err)
+		}
+
+		return nil
+	})
+}
+
+// ListRevisions returns the revision history of a journal, oldest first
+func (s *SQLiteStore) ListRevisions(id string) ([]*models.Revision, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT `+revisionColumns+` FROM journal_revisions
+		WHERE journal_id = ? ORDER BY number`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions of journal %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*models.Revision, 0)
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate revisions of journal %s: %w", id, err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns a single revision of a journal
+func (s *SQLiteStore) GetRevision(id string, number int64) (*models.Revision, error) {
+	row := s.db.QueryRow(`SELECT `+revisionColumns+` FROM journal_revisions
+		WHERE journal_id = ? AND number = ?`, id, number)
+
+	revision, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: revision %d of journal %s", ErrNotFound, number, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds
+func (s *SQLiteStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Count returns the total number of journal entries
+func (s *SQLiteStore) Count() int {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM journals`).Scan(&count); err != nil {
+		return 0
+	}
+
+	return count
+}
+
+// GetStats returns statistics about stored journals computed with SQL aggregates
+func (s *SQLiteStore) GetStats() StorageStats {
+	stats := StorageStats{
+		Backend: BackendSQLite,
+	}
+
+	var (
+		avgProcessingNanos sql.NullFloat64
+		oldest, newest     sql.NullInt64
+	)
+
+	err := s.db.QueryRow(`SELECT
+			COUNT(*),
+			COALESCE(SUM(json_extract(processing_result, '$.status') = ?), 0),
+			AVG(CASE WHEN json_extract(processing_result, '$.status') = ?
+				THEN json_extract(processing_result, '$.processing_time') END),
+			MIN(created_at),
+			MAX(created_at)
+		FROM journals`,
+		models.ProcessingStatusCompleted, models.ProcessingStatusCompleted,
+	).Scan(&stats.TotalJournals, &stats.ProcessedJournals, &avgProcessingNanos, &oldest, &newest)
+	if err != nil {
+		return stats
+	}
+
+	if avgProcessingNanos.Valid {
+		stats.AvgProcessingTimeMS = avgProcessingNanos.Float64 / float64(time.Millisecond)
+	}
+
+	now := time.Now()
+	if oldest.Valid {
+		stats.OldestJournalAge = now.Sub(time.Unix(0, oldest.Int64)).String()
+	}
+	if newest.Valid {
+		stats.NewestJournalAge = now.Sub(time.Unix(0, newest.Int64)).String()
+	}
+
+	return stats
+}
+
+// Close closes the underlying database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+const journalColumns = `id, content, processing_status, timestamp, created_at, updated_at, metadata, processing_result, version, updated_by`
+
+const revisionColumns = `journal_id, number, content, metadata, processing_result, author, created_at`
+
+// journalRow holds a journal encoded for storage in the journals table
+type journalRow struct {
+	id               string
+	content          string
+	processingStatus string
+	timestamp        int64
+	createdAt        int64
+	updatedAt        int64
+	metadata         sql.NullString
+	processingResult sql.NullString
+	version          int64
+	updatedBy        string
+}
+
+// newJournalRow encodes a journal into its column representation
+func newJournalRow(journal *models.Journal) (*journalRow, error) {
+	row := &journalRow{
+		id:        journal.ID,
+		content:   journal.Content,
+		timestamp: toUnixNano(journal.Timestamp),
+		createdAt: toUnixNano(journal.CreatedAt),
+		updatedAt: toUnixNano(journal.UpdatedAt),
+		version:   journal.Version,
+		updatedBy: journal.UpdatedBy,
+	}
+
+	if journal.Metadata != nil {
+		data, err := json.Marshal(journal.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata for journal %s: %w", journal.ID, err)
+		}
+		row.metadata = sql.NullString{String: string(data), Valid: true}
+	}
+
+	if journal.ProcessingResult != nil {
+		data, err := json.Marshal(journal.ProcessingResult)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode processing result for journal %s: %w", journal.ID, err)
+		}
+		row.processingResult = sql.NullString{String: string(data), Valid: true}
+	}
+
+	// Keep the indexed status column in sync with the latest processing outcome
+	row.processingStatus = string(statusOf(journal))
+
+	return row, nil
+}
+
+// args returns the row values in journalColumns order
+func (r *journalRow) args() []any {
+	return []any{
+		r.id, r.content, r.processingStatus, r.timestamp,
+		r.createdAt, r.updatedAt, r.metadata, r.processingResult, r.version, r.updatedBy,
+	}
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJournal decodes a journals row into a journal
+func scanJournal(scanner rowScanner) (*models.Journal, error) {
+	var row journalRow
+	if err := scanner.Scan(&row.id, &row.content, &row.processingStatus, &row.timestamp,
+		&row.createdAt, &row.updatedAt, &row.metadata, &row.processingResult, &row.version, &row.updatedBy); err != nil {
+		return nil, err
+	}
+
+	journal := &models.Journal{
+		ID:               row.id,
+		Content:          row.content,
+		ProcessingStatus: models.ProcessingStatus(row.processingStatus),
+		Timestamp:        fromUnixNano(row.timestamp),
+		CreatedAt:        fromUnixNano(row.createdAt),
+		UpdatedAt:        fromUnixNano(row.updatedAt),
+		Version:          row.version,
+		UpdatedBy:        row.updatedBy,
+	}
+
+	var err error
+	if journal.Metadata, journal.ProcessingResult, err = decodeJSONColumns(row.id, row.metadata, row.processingResult); err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+// replaceEntities indexes the named entities of a journal's extraction in
+// place of those indexed before
+func replaceEntities(tx *sql.Tx, journal *models.Journal) error {
+	if _, err := tx.Exec(`DELETE FROM journal_entities WHERE journal_id = ?`, journal.ID); err != nil {
+		return fmt.Errorf("failed to reset entities of journal %s: %w", journal.ID, err)
+	}
+
+	extraction := extractionOf(journal)
+	if extraction == nil {
+		return nil
+	}
+
+	for _, entity := range extraction.Entities() {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO journal_entities (journal_id, kind, name) VALUES (?, ?, ?)`,
+			journal.ID, entity.Kind, entity.Name); err != nil {
+			return fmt.Errorf("failed to index entities of journal %s: %w", journal.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// insertRevision records the state held in row as a revision
+func insertRevision(tx *sql.Tx, row *journalRow) error {
+	_, err := tx.Exec(`INSERT INTO journal_revisions (`+revisionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		row.id, row.version, row.content, row.metadata, row.processingResult, row.updatedBy, row.updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record revision %d of journal %s: %w", row.version, row.id, err)
+	}
+
+	return nil
+}
+
+// scanRevision decodes a journal_revisions row into a revision
+func scanRevision(scanner rowScanner) (*models.Revision, error) {
+	var (
+		revision         models.Revision
+		metadata         sql.NullString
+		processingResult sql.NullString
+		createdAt        int64
+	)
+	if err := scanner.Scan(&revision.JournalID, &revision.Number, &revision.Content,
+		&metadata, &processingResult, &revision.Author, &createdAt); err != nil {
+		return nil, err
+	}
+
+	revision.CreatedAt = fromUnixNano(createdAt)
+
+	var err error
+	if revision.Metadata, revision.ProcessingResult, err = decodeJSONColumns(revision.JournalID, metadata, processingResult); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// decodeJSONColumns decodes the metadata and processing result JSON columns
+func decodeJSONColumns(id string, metadata, processingResult sql.NullString) (map[string]any, *models.ProcessingResult, error) {
+	var (
+		decodedMetadata map[string]any
+		decodedResult   *models.ProcessingResult
+	)
+
+	if metadata.Valid {
+		if err := json.Unmarshal([]byte(metadata.String), &decodedMetadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode metadata for journal %s: %w", id, err)
+		}
+	}
+
+	if processingResult.Valid {
+		decodedResult = &models.ProcessingResult{}
+		if err := json.Unmarshal([]byte(processingResult.String), decodedResult); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode processing result for journal %s: %w", id, err)
+		}
+	}
+
+	return decodedMetadata, decodedResult, nil
+}
+
+// toUnixNano converts a time to Unix nanoseconds, mapping the zero time to 0
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano converts Unix nanoseconds back to a time, mapping 0 to the zero time
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+func newTestSQLiteStore(t *testing.T, dir string) *SQLiteStore {
+	t.Helper()
+
+	store, err := NewSQLiteStore(dir)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+
+	return store
+}
+
+func TestSQLiteStore_CRUD(t *testing.T) {
+	store := newTestSQLiteStore(t, t.TempDir())
+	defer store.Close()
+
+	processedAt := time.Now()
+	processingTime := 1500 * time.Millisecond
+	journal := &models.Journal{
+		ID:        "sql-1",
+		Content:   "A journal entry stored in SQLite",
+		Timestamp: time.Now(),
+		Metadata: map[string]any{
+			"mood": float64(8),
+			"tags": []any{"work", "sql"},
+		},
+		ProcessingResult: &models.ProcessingResult{
+			Status: models.ProcessingStatusCompleted,
+			SentimentResult: &models.SentimentResult{
+				Score:      0.6,
+				Label:      "positive",
+				Confidence: 0.9,
+			},
+			ProcessedAt:    &processedAt,
+			ProcessingTime: &processingTime,
+		},
+	}
+
+	if err := store.Store(journal); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	got, err := store.Get("sql-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Content != journal.Content {
+		t.Errorf("Expected content %q, got %q", journal.Content, got.Content)
+	}
+	if got.Metadata["mood"] != float64(8) {
+		t.Errorf("Expected mood 8, got %v", got.Metadata["mood"])
+	}
+	if got.ProcessingResult == nil || got.ProcessingResult.SentimentResult == nil {
+		t.Fatal("Expected processing result to round-trip")
+	}
+	if got.ProcessingResult.SentimentResult.Label != "positive" {
+		t.Errorf("Expected label positive, got %s", got.ProcessingResult.SentimentResult.Label)
+	}
+	if !got.CreatedAt.Equal(journal.CreatedAt) {
+		t.Errorf("Expected CreatedAt %v, got %v", journal.CreatedAt, got.CreatedAt)
+	}
+
+	update := &models.Journal{Content: "Updated SQLite content"}
+	if err := store.Update("sql-1", update); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, _ = store.Get("sql-1")
+	if got.Content != "Updated SQLite content" {
+		t.Errorf("Expected updated content, got %q", got.Content)
+	}
+	if !got.CreatedAt.Equal(journal.CreatedAt) {
+		t.Error("Expected CreatedAt to be preserved on update")
+	}
+
+	if err := store.Update("missing", update); err == nil {
+		t.Error("Expected error updating missing journal")
+	}
+
+	if err := store.Delete("sql-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("sql-1"); err == nil {
+		t.Error("Expected error getting deleted journal")
+	}
+	if err := store.Delete("sql-1"); err == nil {
+		t.Error("Expected error deleting missing journal")
+	}
+}
+
+func TestSQLiteStore_PersistsAndMigratesOnce(t *testing.T) {
+	dir := t.TempDir()
+
+	store := newTestSQLiteStore(t, dir)
+	if err := store.Store(&models.Journal{ID: "persist", Content: "Survives a reopen"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	store.Close()
+
+	reopened := newTestSQLiteStore(t, dir)
+	defer reopened.Close()
+
+	if reopened.Count() != 1 {
+		t.Errorf("Expected 1 journal after reopen, got %d", reopened.Count())
+	}
+
+	var version int
+	if err := reopened.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("Failed to read schema version: %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("Expected schema version %d, got %d", len(migrations), version)
+	}
+}
+
+func TestSQLiteStore_GetStats(t *testing.T) {
+	store := newTestSQLiteStore(t, t.TempDir())
+	defer store.Close()
+
+	stats := store.GetStats()
+	if stats.Backend != BackendSQLite || stats.TotalJournals != 0 {
+		t.Errorf("Unexpected stats for empty store: %+v", stats)
+	}
+
+	for i, ms := range []int{100, 300} {
+		processingTime := time.Duration(ms) * time.Millisecond
+		journal := &models.Journal{
+			ID:      string(rune('a' + i)),
+			Content: "Processed journal entry",
+			ProcessingResult: &models.ProcessingResult{
+				Status:         models.ProcessingStatusCompleted,
+				ProcessingTime: &processingTime,
+			},
+		}
+		if err := store.Store(journal); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	if err := store.Store(&models.Journal{
+		ID:               "failed",
+		Content:          "Failed journal entry",
+		ProcessingResult: &models.ProcessingResult{Status: models.ProcessingStatusFailed},
+	}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	stats = store.GetStats()
+	if stats.TotalJournals != 3 {
+		t.Errorf("Expected 3 journals, got %d", stats.TotalJournals)
+	}
+	if stats.ProcessedJournals != 2 {
+		t.Errorf("Expected 2 processed journals, got %d", stats.ProcessedJournals)
+	}
+	if stats.AvgProcessingTimeMS != 200 {
+		t.Errorf("Expected average processing time 200ms, got %v", stats.AvgProcessingTimeMS)
+	}
+	if stats.OldestJournalAge == "" || stats.NewestJournalAge == "" {
+		t.Error("Expected journal ages to be set")
+	}
+}
+
+func TestSQLiteStore_IndexedColumns(t *testing.T) {
+	store := newTestSQLiteStore(t, t.TempDir())
+	defer store.Close()
+
+	if err := store.Store(&models.Journal{
+		ID:      "labelled",
+		Content: "Negative journal entry",
+		ProcessingResult: &models.ProcessingResult{
+			Status:          models.ProcessingStatusCompleted,
+			SentimentResult: &models.SentimentResult{Score: -0.4, Label: "negative"},
+		},
+	}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	var label, status string
+	var score float64
+	err := store.db.QueryRow(`SELECT sentiment_label, sentiment_score, processing_status
+		FROM journals WHERE id = ?`, "labelled").Scan(&label, &score, &status)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if label != "negative" || score != -0.4 || status != string(models.ProcessingStatusCompleted) {
+		t.Errorf("Unexpected derived columns: label=%s score=%v status=%s", label, score, status)
+	}
+}
+
+func TestSQLiteStore_EntityIndex(t *testing.T) {
+	store := newTestSQLiteStore(t, t.TempDir())
+	defer store.Close()
+
+	entities := func() []string {
+		rows, err := store.db.Query(`SELECT kind || ':' || name FROM journal_entities WHERE journal_id = ? ORDER BY kind, name`, "extracted")
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		defer rows.Close()
+
+		var found []string
+		for rows.Next() {
+			var entity string
+			rows.Scan(&entity)
+			found = append(found, entity)
+		}
+		return found
+	}
+
+	journal := &models.Journal{ID: "extracted", Content: "Lunch with Alice in Lisbon"}
+	journal.ProcessingResult = &models.ProcessingResult{
+		Status: models.ProcessingStatusCompleted,
+		Extraction: &models.ExtractionResult{
+			People: []models.ExtractedItem{{Name: "Alice", Confidence: 0.9}, {Name: "alice", Confidence: 0.5}},
+			Places: []models.ExtractedItem{{Name: "Lisbon", Confidence: 0.8}},
+		},
+	}
+	if err := store.Store(journal); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if got := entities(); len(got) != 2 || got[0] != "person:Alice" || got[1] != "place:Lisbon" {
+		t.Errorf("Unexpected indexed entities %v", got)
+	}
+
+	journal.ProcessingResult.Extraction = &models.ExtractionResult{People: []models.ExtractedItem{{Name: "Bob", Confidence: 0.9}}}
+	if err := store.Update(journal.ID, journal); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := entities(); len(got) != 1 || got[0] != "person:Bob" {
+		t.Errorf("Expected the update to replace indexed entities, got %v", got)
+	}
+
+	if err := store.Delete(journal.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := entities(); len(got) != 0 {
+		t.Errorf("Expected delete to drop indexed entities, got %v", got)
+	}
+}
+
+func TestSQLiteStore_VersionConflict(t *testing.T) {
+	store := newTestSQLiteStore(t, t.TempDir())
+	defer store.Close()
+
+	if err := store.Store(&models.Journal{ID: "v", Content: "Versioned entry"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	edit := &models.Journal{Content: "First edit", Version: 1}
+	if err := store.Update("v", edit); err != nil {
+		t.Fatalf("Conditional update failed: %v", err)
+	}
+	if edit.Version != 2 {
+		t.Errorf("Expected version 2 after update, got %d", edit.Version)
+	}
+	if err := store.Update("v", &models.Journal{Content: "Stale edit", Version: 1}); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, got %v", err)
+	}
+	if err := store.Update("v", &models.Journal{Content: "Unconditional edit"}); err != nil {
+		t.Fatalf("Unconditional update failed: %v", err)
+	}
+	if err := store.Delete("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	got, err := store.Get("v")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Version != 3 || got.Content != "Unconditional edit" {
+		t.Errorf("Expected version 3 with unconditional edit, got version %d %q", got.Version, got.Content)
+	}
+}
+
+func TestSQLiteStore_ConcurrentUnconditionalUpdates(t *testing.T) {
+	store := newTestSQLiteStore(t, t.TempDir())
+	defer store.Close()
+
+	if err := store.Store(&models.Journal{ID: "lww", Content: "Original"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	// Without an expected version the last write wins and none conflicts
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Update("lww", &models.Journal{Content: fmt.Sprintf("Edit %d", i)})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unconditional update failed: %v", err)
+		}
+	}
+	if got, err := store.Get("lww"); err != nil || got.Version != writers+1 {
+		t.Errorf("Expected version %d, got %+v (%v)", writers+1, got, err)
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+)
+
+// Supported storage backends
+const (
+	BackendMemory = "memory"
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+const (
+	defaultStoragePath      = "data"
+	defaultSnapshotInterval = 5 * time.Minute
+	defaultIndexFile        = "search_index.json"
+)
+
+// Errors returned by JournalStore implementations
+var (
+	ErrNotFound        = errors.New("journal not found")
+	ErrVersionConflict = errors.New("journal version conflict")
+)
+
+// JournalStore defines the persistence operations required by the handlers
+// Update is conditional when journal.Version is non-zero: it fails with
+// ErrVersionConflict unless the stored version matches, and on success the
+// stored version is incremented
+type JournalStore interface {
+	Store(journal *models.Journal) error
+	Get(id string) (*models.Journal, error)
+	GetAll() ([]*models.Journal, error)
+	List(query JournalQuery) (*JournalPage, error)
+	Update(id string, journal *models.Journal) error
+	Delete(id string) error
+	Count() int
+	GetStats() StorageStats
+
+	// Revision history; every Store and Update records a revision numbered
+	// after the resulting version, and Delete discards the history
+	ListRevisions(id string) ([]*models.Revision, error)
+	GetRevision(id string, number int64) (*models.Revision, error)
+}
+
+// Ensure implementations satisfy the JournalStore interface
+var (
+	_ JournalStore = (*MemoryStore)(nil)
+	_ JournalStore = (*FileStore)(nil)
+	_ JournalStore = (*SQLiteStore)(nil)
+)
+
+// Config holds storage configuration
+type Config struct {
+	Backend          string        // "memory", "file" or "sqlite"
+	Path             string        // Data directory for durable backends
+	SnapshotInterval time.Duration // How often the file backend compacts its log
+	IndexPath        string        // Search index file; empty keeps the index in memory
+}
+
+// NewStore creates a journal store for the configured backend
+// The store is wrapped with a full-text search index
+func NewStore(config Config) (JournalStore, error) {
+	store, err := newBackend(config)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed, err := NewIndexedStore(store, config.IndexPath)
+	if err != nil {
+		if closer, ok := store.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+
+	return indexed, nil
+}
+
+// newBackend creates the underlying journal store for the configured backend
+func newBackend(config Config) (JournalStore, error) {
+	switch strings.ToLower(config.Backend) {
+	case "", BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendFile:
+		path := config.Path
+		if path == "" {
+			path = defaultStoragePath
+		}
+		interval := config.SnapshotInterval
+		if interval <= 0 {
+			interval = defaultSnapshotInterval
+		}
+		return NewFileStore(path, interval)
+	case BackendSQLite:
+		path := config.Path
+		if path == "" {
+			path = defaultStoragePath
+		}
+		return NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", config.Backend)
+	}
+}
+
+// NewStoreFromEnv creates a journal store using environment variables
+func NewStoreFromEnv() (JournalStore, error) {
+	config := Config{
+		Backend: os.Getenv("STORAGE_BACKEND"),
+		Path:    os.Getenv("STORAGE_PATH"),
+	}
+
+	if raw := os.Getenv("STORAGE_SNAPSHOT_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STORAGE_SNAPSHOT_INTERVAL %q: %w", raw, err)
+		}
+		config.SnapshotInterval = interval
+	}
+
+	// Durable backends keep the search index next to their data by default
+	config.IndexPath = os.Getenv("SEARCH_INDEX_PATH")
+	if config.IndexPath == "" {
+		if dir, durable := DataDirFromEnv(); durable {
+			config.IndexPath = filepath.Join(dir, defaultIndexFile)
+		}
+	}
+
+	return NewStore(config)
+}
+
+// DataDirFromEnv returns the data directory of the configured backend and
+// whether that backend is durable, so other components can persist alongside it
+func DataDirFromEnv() (string, bool) {
+	backend := strings.ToLower(os.Getenv("STORAGE_BACKEND"))
+	if backend == "" || backend == BackendMemory {
+		return "", false
+	}
+
+	path := os.Getenv("STORAGE_PATH")
+	if path == "" {
+		path = defaultStoragePath
+	}
+
+	return path, true
+}
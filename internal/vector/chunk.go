@@ -0,0 +1,105 @@
+package vector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunking defaults: windows of ChunkWords words, each sharing ChunkOverlap
+// words with the previous one so a sentence cut at a boundary is whole in
+// one of the two chunks
+const (
+	ChunkWords   = 200
+	ChunkOverlap = 40
+)
+
+// Span is a chunk of content as byte offsets [Start, End)
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Split cuts content into overlapping windows of at most words words
+// Content shorter than one window is a single chunk; blank content has none.
+func Split(content string, words, overlap int) []Span {
+	if words <= 0 {
+		words = ChunkWords
+	}
+	if overlap < 0 || overlap >= words {
+		overlap = 0
+	}
+
+	bounds := wordBounds(content)
+	if len(bounds) == 0 {
+		return nil
+	}
+
+	var spans []Span
+	for start := 0; ; start += words - overlap {
+		end := min(start+words, len(bounds))
+		spans = append(spans, Span{Start: bounds[start].Start, End: bounds[end-1].End})
+		if end == len(bounds) {
+			return spans
+		}
+	}
+}
+
+// wordBounds returns the byte span of every whitespace-separated word
+func wordBounds(content string) []Span {
+	var (
+		bounds []Span
+		start  = -1
+	)
+
+	for i, r := range content {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				bounds = append(bounds, Span{Start: start, End: i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		bounds = append(bounds, Span{Start: start, End: len(content)})
+	}
+
+	return bounds
+}
+
+// Texts returns the text of each span of content
+func Texts(content string, spans []Span) []string {
+	texts := make([]string, len(spans))
+	for i, span := range spans {
+		texts[i] = content[span.Start:span.End]
+	}
+	return texts
+}
+
+// ContentHash identifies a version of journal content, so vectors of edited
+// journals can be told apart from current ones
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Excerpt returns the text of span in content, shortened to at most maxRunes
+// runes; a span that no longer fits the content yields its beginning instead
+func Excerpt(content string, span Span, maxRunes int) string {
+	if span.Start < 0 || span.End > len(content) || span.Start >= span.End {
+		span = Span{Start: 0, End: len(content)}
+	}
+
+	text := content[span.Start:span.End]
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+
+	runes := []rune(text)
+	return string(runes[:maxRunes]) + "..."
+}
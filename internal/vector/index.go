@@ -0,0 +1,356 @@
+// Package vector keeps journal embeddings in a local index and ranks them by
+// cosine similarity. Journals are embedded in chunks; a journal scores as its
+// best-matching chunk.
+package vector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// indexFormatVersion is bumped whenever the persisted layout changes; an
+// index in another format is discarded and journals are embedded again
+const indexFormatVersion = 1
+
+// ErrNotIndexed is returned when a journal has no vectors in the index
+var ErrNotIndexed = errors.New("journal has not been embedded")
+
+// Chunk is the vector of one span of a journal's content
+type Chunk struct {
+	Span
+	Vector []float32 `json:"vector"`
+}
+
+// Result is a journal ranked by similarity, with the span that matched best
+type Result struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+	Span  Span    `json:"span"`
+}
+
+// document holds the vectors of one journal
+type document struct {
+	Model     string    `json:"model"`
+	Hash      string    `json:"hash"` // ContentHash of the embedded content
+	Chunks    []Chunk   `json:"chunks"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// persistedIndex is the on-disk representation of an index
+type persistedIndex struct {
+	Version   int                  `json:"version"`
+	SavedAt   time.Time            `json:"saved_at"`
+	Documents map[string]*document `json:"documents"`
+}
+
+// Index holds normalized chunk vectors per journal
+// It can be saved to disk and reloaded, and is safe for concurrent use
+type Index struct {
+	mu      sync.RWMutex
+	docs    map[string]*document
+	changes uint64 // Incremented on every change to docs
+
+	saveMu sync.Mutex
+	saved  uint64 // changes as of the last save or load
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{docs: make(map[string]*document)}
+}
+
+// LoadIndex reads a previously saved index from path
+// A missing file or an outdated format yields an empty index
+func LoadIndex(path string) (*Index, error) {
+	index := NewIndex()
+	if path == "" {
+		return index, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vector index: %w", err)
+	}
+
+	var persisted persistedIndex
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, fmt.Errorf("failed to decode vector index: %w", err)
+	}
+
+	if persisted.Version != indexFormatVersion {
+		return index, nil
+	}
+
+	for id, doc := range persisted.Documents {
+		if doc != nil && len(doc.Chunks) > 0 {
+			index.docs[id] = doc
+		}
+	}
+
+	return index, nil
+}
+
+// Save writes the index to path atomically
+func (ix *Index) Save(path string) error {
+	ix.saveMu.Lock()
+	defer ix.saveMu.Unlock()
+
+	return ix.saveLocked(path)
+}
+
+// SaveIfChanged saves the index to path when it changed since it was loaded
+// or last saved, and reports whether it did
+func (ix *Index) SaveIfChanged(path string) (bool, error) {
+	ix.saveMu.Lock()
+	defer ix.saveMu.Unlock()
+
+	ix.mu.RLock()
+	changed := ix.changes != ix.saved
+	ix.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	if err := ix.saveLocked(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// saveLocked writes the index to path through a temporary file
+// The caller must hold ix.saveMu
+func (ix *Index) saveLocked(path string) error {
+	ix.mu.RLock()
+	changes := ix.changes
+	data, err := json.Marshal(persistedIndex{
+		Version:   indexFormatVersion,
+		SavedAt:   time.Now().UTC(),
+		Documents: ix.docs,
+	})
+	ix.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode vector index: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write vector index: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace vector index: %w", err)
+	}
+
+	ix.saved = changes
+	return nil
+}
+
+// Add stores the chunk vectors of a journal, replacing any previous ones
+// model names the embedding model and hash the ContentHash of the embedded
+// content. Vectors are normalized on the way in.
+func (ix *Index) Add(id, model, hash string, chunks []Chunk) error {
+	if len(chunks) == 0 {
+		return fmt.Errorf("journal %s has no chunks to index", id)
+	}
+
+	normalized := make([]Chunk, len(chunks))
+	for i, chunk := range chunks {
+		if len(chunk.Vector) != len(chunks[0].Vector) {
+			return fmt.Errorf("journal %s: chunk %d has %d dimensions, expected %d", id, i, len(chunk.Vector), len(chunks[0].Vector))
+		}
+		vector, ok := normalize(chunk.Vector)
+		if !ok {
+			return fmt.Errorf("journal %s: chunk %d has a zero vector", id, i)
+		}
+		normalized[i] = Chunk{Span: chunk.Span, Vector: vector}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.docs[id] = &document{Model: model, Hash: hash, Chunks: normalized, IndexedAt: time.Now().UTC()}
+	ix.changes++
+	return nil
+}
+
+// Remove drops a journal from the index
+func (ix *Index) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if _, ok := ix.docs[id]; ok {
+		delete(ix.docs, id)
+		ix.changes++
+	}
+}
+
+// RemoveStale drops a journal embedded from content other than the one hash
+// describes, and reports whether it did
+func (ix *Index) RemoveStale(id, hash string) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	doc, ok := ix.docs[id]
+	if !ok || doc.Hash == hash {
+		return false
+	}
+	delete(ix.docs, id)
+	ix.changes++
+	return true
+}
+
+// Has reports whether a journal has vectors in the index
+func (ix *Index) Has(id string) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	_, ok := ix.docs[id]
+	return ok
+}
+
+// Len returns the number of indexed journals
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.docs)
+}
+
+// Retain drops journals missing from hashes or indexed with other content,
+// and returns how many were dropped; hashes maps journal IDs to the
+// ContentHash of their current content
+func (ix *Index) Retain(hashes map[string]string) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	dropped := 0
+	for id, doc := range ix.docs {
+		if hash, ok := hashes[id]; !ok || hash != doc.Hash {
+			delete(ix.docs, id)
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		ix.changes++
+	}
+	return dropped
+}
+
+// Search ranks journals embedded with model by their best chunk's cosine
+// similarity to query and returns up to limit results
+func (ix *Index) Search(model string, query []float32, limit int) []Result {
+	normalized, ok := normalize(query)
+	if !ok {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return ix.rankLocked(model, normalized, "", limit)
+}
+
+// Similar ranks the other journals embedded with the same model as id by
+// their similarity to it, using the centroid of its chunks as the query
+func (ix *Index) Similar(id string, limit int) ([]Result, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	doc, ok := ix.docs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotIndexed, id)
+	}
+
+	centroid := make([]float32, len(doc.Chunks[0].Vector))
+	for _, chunk := range doc.Chunks {
+		for i, v := range chunk.Vector {
+			centroid[i] += v
+		}
+	}
+	query, ok := normalize(centroid)
+	if !ok {
+		// Chunks pointing in opposite directions; fall back to the first
+		query = doc.Chunks[0].Vector
+	}
+
+	return ix.rankLocked(doc.Model, query, id, limit), nil
+}
+
+// rankLocked scores every journal of model except exclude against a
+// normalized query, best first
+// The caller must hold ix.mu
+func (ix *Index) rankLocked(model string, query []float32, exclude string, limit int) []Result {
+	results := make([]Result, 0, len(ix.docs))
+	for id, doc := range ix.docs {
+		if id == exclude || doc.Model != model || len(doc.Chunks[0].Vector) != len(query) {
+			continue
+		}
+
+		best := Result{ID: id, Score: math.Inf(-1)}
+		for _, chunk := range doc.Chunks {
+			if score := dot(query, chunk.Vector); score > best.Score {
+				best.Score, best.Span = score, chunk.Span
+			}
+		}
+		best.Score = math.Round(best.Score*1e4) / 1e4
+		results = append(results, best)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Cosine returns the cosine similarity of two vectors of equal length, or 0
+// when either is zero
+func Cosine(a, b []float32) float64 {
+	na, okA := normalize(a)
+	nb, okB := normalize(b)
+	if !okA || !okB || len(a) != len(b) {
+		return 0
+	}
+	return dot(na, nb)
+}
+
+// normalize returns v scaled to unit length; ok is false for a zero vector
+func normalize(v []float32) (normalized []float32, ok bool) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 || math.IsNaN(sum) || math.IsInf(sum, 0) {
+		return nil, false
+	}
+
+	norm := math.Sqrt(sum)
+	normalized = make([]float32, len(v))
+	for i, x := range v {
+		normalized[i] = float32(float64(x) / norm)
+	}
+	return normalized, true
+}
+
+// dot returns the dot product of two vectors of equal length
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,220 @@
+package vector
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	if spans := Split("   ", 10, 2); len(spans) != 0 {
+		t.Errorf("Expected no chunks for blank content, got %v", spans)
+	}
+
+	short := "  A short entry.  "
+	if spans := Split(short, 10, 2); len(spans) != 1 || Texts(short, spans)[0] != "A short entry." {
+		t.Errorf("Expected one trimmed chunk, got %v", spans)
+	}
+
+	content := "one two three four five six seven eight nine ten"
+	texts := Texts(content, Split(content, 4, 1))
+	expected := []string{"one two three four", "four five six seven", "seven eight nine ten"}
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected overlapping windows %q, got %q", expected, texts)
+	}
+
+	// An overlap as large as the window would never advance
+	if spans := Split(content, 5, 5); len(spans) != 2 {
+		t.Errorf("Expected the overlap to be ignored, got %v", spans)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	content := "Morning run. Afternoon of meetings."
+	if got := Excerpt(content, Span{Start: 13, End: 35}, 100); got != "Afternoon of meetings." {
+		t.Errorf("Unexpected excerpt %q", got)
+	}
+	if got := Excerpt(content, Span{Start: 0, End: 12}, 7); got != "Morning..." {
+		t.Errorf("Expected a shortened excerpt, got %q", got)
+	}
+	if got := Excerpt("Edited", Span{Start: 10, End: 40}, 100); got != "Edited" {
+		t.Errorf("Expected stale spans to fall back to the content, got %q", got)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		a, b     []float32
+		expected float64
+	}{
+		{[]float32{1, 0}, []float32{2, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 3}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := Cosine(tt.a, tt.b); math.Abs(got-tt.expected) > 1e-6 {
+			t.Errorf("Cosine(%v, %v) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+
+	index := NewIndex()
+	docs := map[string][]Chunk{
+		"running": {{Span: Span{0, 10}, Vector: []float32{1, 0.1, 0}}},
+		"cycling": {{Span: Span{0, 10}, Vector: []float32{0.8, 0.4, 0}}},
+		// The second chunk matches "running" better than the first
+		"mixed": {
+			{Span: Span{0, 20}, Vector: []float32{0, 0, 1}},
+			{Span: Span{15, 40}, Vector: []float32{0.9, 0, 0.1}},
+		},
+		"cooking": {{Span: Span{0, 10}, Vector: []float32{0, 1, 0}}},
+	}
+	for id, chunks := range docs {
+		if err := index.Add(id, "model-a", "hash-"+id, chunks); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if err := index.Add("other-model", "model-b", "hash", []Chunk{{Vector: []float32{1, 0, 0}}}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	return index
+}
+
+func resultIDs(results []Result) string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestIndex_Search(t *testing.T) {
+	index := newTestIndex(t)
+
+	results := index.Search("model-a", []float32{10, 0, 0}, 0)
+	if got := resultIDs(results); got != "running,mixed,cycling,cooking" {
+		t.Errorf("Unexpected ranking %s", got)
+	}
+	if results[1].Span != (Span{15, 40}) {
+		t.Errorf("Expected the best chunk's span, got %+v", results[1].Span)
+	}
+	if results[0].Score <= results[1].Score || results[3].Score != 0 {
+		t.Errorf("Unexpected scores %+v", results)
+	}
+
+	if got := resultIDs(index.Search("model-a", []float32{1, 0, 0}, 2)); got != "running,mixed" {
+		t.Errorf("Expected the limit to apply, got %s", got)
+	}
+	if got := index.Search("model-b", []float32{1, 0}, 0); len(got) != 0 {
+		t.Errorf("Expected vectors of another size to be skipped, got %v", got)
+	}
+	if got := index.Search("model-a", []float32{0, 0, 0}, 0); len(got) != 0 {
+		t.Errorf("Expected no results for a zero query, got %v", got)
+	}
+}
+
+func TestIndex_Similar(t *testing.T) {
+	index := newTestIndex(t)
+
+	results, err := index.Similar("running", 0)
+	if err != nil {
+		t.Fatalf("Similar failed: %v", err)
+	}
+	if got := resultIDs(results); got != "mixed,cycling,cooking" {
+		t.Errorf("Expected other journals of the same model, got %s", got)
+	}
+
+	if _, err := index.Similar("missing", 5); !errors.Is(err, ErrNotIndexed) {
+		t.Errorf("Expected ErrNotIndexed, got %v", err)
+	}
+}
+
+func TestIndex_AddRejectsInvalidVectors(t *testing.T) {
+	index := NewIndex()
+
+	for name, chunks := range map[string][]Chunk{
+		"no chunks":       nil,
+		"zero vector":     {{Vector: []float32{0, 0}}},
+		"mixed dimension": {{Vector: []float32{1, 0}}, {Vector: []float32{1, 0, 0}}},
+	} {
+		if err := index.Add("journal", "model", "hash", chunks); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+	if index.Len() != 0 {
+		t.Errorf("Expected an empty index, got %d journals", index.Len())
+	}
+}
+
+func TestIndex_Retain(t *testing.T) {
+	index := newTestIndex(t)
+
+	dropped := index.Retain(map[string]string{
+		"running":     "hash-running",
+		"cycling":     "edited",
+		"mixed":       "hash-mixed",
+		"other-model": "hash",
+	})
+	if dropped != 2 || index.Len() != 3 {
+		t.Errorf("Expected deleted and edited journals to be dropped, dropped %d and kept %d", dropped, index.Len())
+	}
+}
+
+func TestIndex_RemoveStale(t *testing.T) {
+	index := newTestIndex(t)
+
+	if index.RemoveStale("running", "hash-running") || !index.Has("running") {
+		t.Error("Expected vectors of the current content to be kept")
+	}
+	if !index.RemoveStale("running", "edited") || index.Has("running") {
+		t.Error("Expected vectors of edited content to be dropped")
+	}
+	if index.RemoveStale("missing", "hash") {
+		t.Error("Expected nothing to drop for a journal that is not indexed")
+	}
+}
+
+func TestIndex_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vectors.json")
+	index := newTestIndex(t)
+	index.Remove("cooking")
+
+	if err := index.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex failed: %v", err)
+	}
+	if loaded.Len() != 4 {
+		t.Errorf("Expected 4 journals, got %d", loaded.Len())
+	}
+	if got := resultIDs(loaded.Search("model-a", []float32{1, 0, 0}, 0)); got != "running,mixed,cycling" {
+		t.Errorf("Unexpected ranking after reload %s", got)
+	}
+
+	if saved, err := loaded.SaveIfChanged(path); err != nil || saved {
+		t.Errorf("Expected an unchanged index not to be saved, got %v (%v)", saved, err)
+	}
+	loaded.Remove("running")
+	if saved, err := loaded.SaveIfChanged(path); err != nil || !saved {
+		t.Errorf("Expected a changed index to be saved, got %v (%v)", saved, err)
+	}
+	if reloaded, err := LoadIndex(path); err != nil || reloaded.Len() != 3 || reloaded.Has("running") {
+		t.Errorf("Expected the removal to be saved, got %v (%v)", reloaded, err)
+	}
+
+	empty, err := LoadIndex(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || empty.Len() != 0 {
+		t.Errorf("Expected an empty index for a missing file, got %v (%v)", empty, err)
+	}
+}
@@ -0,0 +1,784 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/garnizeh/englog/internal/logging"
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+)
+
+// Pool defaults
+const (
+	defaultPoolWorkers      = 2
+	defaultPoolQueueSize    = 100
+	defaultMaxAttempts      = 5
+	defaultMaxParseAttempts = 2
+	defaultBaseBackoff      = 2 * time.Second
+	defaultMaxBackoff       = 5 * time.Minute
+)
+
+// ProcessorAuthor is recorded as the author of revisions written by the pool
+const ProcessorAuthor = "ai-worker"
+
+// maxWriteAttempts bounds retries when a result races a concurrent edit
+const maxWriteAttempts = 3
+
+// Errors returned by Pool methods
+var (
+	ErrQueueFull   = errors.New("processing queue is full")
+	ErrPoolClosed  = errors.New("processing pool is shut down")
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// JobState is the lifecycle state of a processing job
+type JobState string
+
+const (
+	JobStateQueued     JobState = "queued"
+	JobStateProcessing JobState = "processing"
+	JobStateDead       JobState = "dead"
+)
+
+// FailureKind classifies why a processing attempt failed
+type FailureKind string
+
+const (
+	// FailureTransport covers errors reaching the AI service, including timeouts
+	FailureTransport FailureKind = "transport"
+	// FailureInvalidResponse covers answers that could not be parsed or validated
+	FailureInvalidResponse FailureKind = "invalid_response"
+)
+
+// JobStatus describes a processing job that has not finished yet, or one
+// that was moved to the dead-letter list
+type JobStatus struct {
+	JournalID     string      `json:"journal_id"`
+	State         JobState    `json:"state"`
+	Attempts      int         `json:"attempts"`
+	EnqueuedAt    time.Time   `json:"enqueued_at"`
+	StartedAt     *time.Time  `json:"started_at,omitempty"`
+	NextAttemptAt *time.Time  `json:"next_attempt_at,omitempty"`
+	LastError     string      `json:"last_error,omitempty"`
+	LastFailure   FailureKind `json:"last_failure,omitempty"`
+	DeadAt        *time.Time  `json:"dead_at,omitempty"`
+}
+
+// PoolStats reports the current load of the pool
+type PoolStats struct {
+	Workers       int  `json:"workers"`
+	QueueCapacity int  `json:"queue_capacity"`
+	Queued        int  `json:"queued"`
+	Retrying      int  `json:"retrying"`
+	Processing    int  `json:"processing"`
+	DeadLetters   int  `json:"dead_letters"`
+	Durable       bool `json:"durable"`
+}
+
+// PoolConfig holds worker pool configuration
+type PoolConfig struct {
+	Workers          int           // Number of concurrent processing goroutines
+	QueueSize        int           // Maximum number of jobs waiting for a worker
+	QueuePath        string        // File that persists jobs; empty keeps them in memory
+	MaxAttempts      int           // Attempts before a job failing with transport errors is dead-lettered
+	MaxParseAttempts int           // Attempts before a job failing with invalid responses is dead-lettered
+	BaseBackoff      time.Duration // Delay before the first retry
+	MaxBackoff       time.Duration // Upper bound for retry delays
+}
+
+// PoolConfigFromEnv reads the WORKER_* environment variables
+func PoolConfigFromEnv() (PoolConfig, error) {
+	config := PoolConfig{
+		Workers:          defaultPoolWorkers,
+		QueueSize:        defaultPoolQueueSize,
+		QueuePath:        os.Getenv("WORKER_QUEUE_PATH"),
+		MaxAttempts:      defaultMaxAttempts,
+		MaxParseAttempts: defaultMaxParseAttempts,
+		BaseBackoff:      defaultBaseBackoff,
+		MaxBackoff:       defaultMaxBackoff,
+	}
+
+	for name, target := range map[string]*int{
+		"WORKER_POOL_SIZE":          &config.Workers,
+		"WORKER_QUEUE_SIZE":         &config.QueueSize,
+		"WORKER_MAX_ATTEMPTS":       &config.MaxAttempts,
+		"WORKER_MAX_PARSE_ATTEMPTS": &config.MaxParseAttempts,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return config, fmt.Errorf("invalid %s %q: must be a positive integer", name, raw)
+		}
+		*target = value
+	}
+
+	for name, target := range map[string]*time.Duration{
+		"WORKER_RETRY_BASE_DELAY": &config.BaseBackoff,
+		"WORKER_RETRY_MAX_DELAY":  &config.MaxBackoff,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(raw)
+		if err != nil || value <= 0 {
+			return config, fmt.Errorf("invalid %s %q: must be a positive duration", name, raw)
+		}
+		*target = value
+	}
+
+	return config, nil
+}
+
+// JournalStore is the subset of storage the pool needs to write results back
+type JournalStore interface {
+	Get(id string) (*models.Journal, error)
+	Update(id string, journal *models.Journal) error
+}
+
+// Pool processes journals asynchronously with a fixed number of goroutines
+// draining a bounded queue. Results are written back through the store.
+//
+// When QueuePath is set, every job is written to disk before Submit returns
+// and jobs that were in flight when the process stopped are leased again on
+// startup. Failed attempts are retried with backoff and jitter until they
+// run out of attempts and move to the dead-letter list.
+type Pool struct {
+	worker *InMemoryWorker
+	store  JournalStore
+	file   *queueFile // nil when jobs are kept in memory
+	logger *logging.Logger
+	config PoolConfig
+
+	mu     sync.Mutex
+	jobs   map[string]*JobStatus // unfinished jobs by journal ID
+	dead   map[string]*JobStatus // dead-lettered jobs by journal ID
+	wake   chan struct{}         // closed and replaced whenever a job may have become ready
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a worker pool, recovers persisted jobs and starts its goroutines
+func NewPool(worker *InMemoryWorker, store JournalStore, config PoolConfig, logger *logging.Logger) (*Pool, error) {
+	if config.Workers < 1 {
+		config.Workers = defaultPoolWorkers
+	}
+	if config.QueueSize < 1 {
+		config.QueueSize = defaultPoolQueueSize
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.MaxParseAttempts < 1 {
+		config.MaxParseAttempts = defaultMaxParseAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaultBaseBackoff
+	}
+	if config.MaxBackoff < config.BaseBackoff {
+		config.MaxBackoff = max(defaultMaxBackoff, config.BaseBackoff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		worker: worker,
+		store:  store,
+		logger: logger,
+		config: config,
+		jobs:   make(map[string]*JobStatus),
+		dead:   make(map[string]*JobStatus),
+		wake:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if config.QueuePath != "" {
+		if err := p.recover(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	p.wg.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go p.run()
+	}
+
+	return p, nil
+}
+
+// recover loads persisted jobs, returning in-flight ones to the queue
+func (p *Pool) recover() error {
+	file, state, err := openQueueFile(p.config.QueuePath)
+	if err != nil {
+		return err
+	}
+	p.file = file
+
+	released := 0
+	for _, job := range state.Jobs {
+		if job.State == JobStateProcessing {
+			// In flight when the process stopped; the attempt stays counted so
+			// a job that keeps crashing the process is eventually dead-lettered
+			job.State = JobStateQueued
+			job.StartedAt = nil
+			released++
+		}
+		p.jobs[job.JournalID] = job
+	}
+	for _, job := range state.DeadLetters {
+		p.dead[job.JournalID] = job
+	}
+
+	if len(state.Jobs) > 0 || len(state.DeadLetters) > 0 {
+		p.logger.Info("recovered persisted processing jobs",
+			"queued", len(state.Jobs),
+			"released", released,
+			"dead_letters", len(state.DeadLetters))
+	}
+
+	return p.persistLocked()
+}
+
+// Submit queues a journal for processing without blocking
+// A journal that is already waiting in the queue is not queued twice, since
+// the worker always processes the latest stored content. The job is on disk
+// before Submit returns when the pool is durable.
+func (p *Pool) Submit(journalID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	if existing, exists := p.jobs[journalID]; exists && existing.State == JobStateQueued {
+		// New content deserves a fresh set of attempts without waiting out a backoff
+		previous := *existing
+		existing.Attempts = 0
+		existing.NextAttemptAt = nil
+		if err := p.persistLocked(); err != nil {
+			*existing = previous
+			return err
+		}
+		p.notifyLocked()
+		return nil
+	}
+
+	if p.waitingLocked() >= p.config.QueueSize {
+		return ErrQueueFull
+	}
+
+	job := &JobStatus{
+		JournalID:  journalID,
+		State:      JobStateQueued,
+		EnqueuedAt: time.Now().UTC(),
+	}
+
+	previous, hadPrevious := p.jobs[journalID]
+	dead := p.dead[journalID]
+	p.jobs[journalID] = job
+	delete(p.dead, journalID)
+
+	if err := p.persistLocked(); err != nil {
+		if hadPrevious {
+			p.jobs[journalID] = previous
+		} else {
+			delete(p.jobs, journalID)
+		}
+		if dead != nil {
+			p.dead[journalID] = dead
+		}
+		return err
+	}
+
+	p.notifyLocked()
+	return nil
+}
+
+// Status returns the state of an unfinished or dead-lettered job for a journal
+func (p *Pool) Status(journalID string) (JobStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if job, exists := p.jobs[journalID]; exists {
+		return *job, true
+	}
+	if job, exists := p.dead[journalID]; exists {
+		return *job, true
+	}
+	return JobStatus{}, false
+}
+
+// Stats returns the current load of the pool
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := PoolStats{
+		Workers:       p.config.Workers,
+		QueueCapacity: p.config.QueueSize,
+		DeadLetters:   len(p.dead),
+		Durable:       p.file != nil,
+	}
+
+	now := time.Now()
+	for _, job := range p.jobs {
+		switch {
+		case job.State == JobStateProcessing:
+			stats.Processing++
+		case job.NextAttemptAt != nil && job.NextAttemptAt.After(now):
+			stats.Retrying++
+		default:
+			stats.Queued++
+		}
+	}
+
+	return stats
+}
+
+// DeadLetters returns the dead-lettered jobs, oldest first
+func (p *Pool) DeadLetters() []JobStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jobs := make([]JobStatus, 0, len(p.dead))
+	for _, job := range p.dead {
+		jobs = append(jobs, *job)
+	}
+	slices.SortFunc(jobs, func(a, b JobStatus) int {
+		return a.DeadAt.Compare(*b.DeadAt)
+	})
+
+	return jobs
+}
+
+// RetryDeadLetter moves a dead-lettered job back to the queue with a fresh
+// set of attempts
+func (p *Pool) RetryDeadLetter(journalID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	job, exists := p.dead[journalID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, journalID)
+	}
+
+	previous := *job
+	job.State = JobStateQueued
+	job.Attempts = 0
+	job.EnqueuedAt = time.Now().UTC()
+	job.NextAttemptAt = nil
+	job.DeadAt = nil
+	delete(p.dead, journalID)
+	p.jobs[journalID] = job
+
+	if err := p.persistLocked(); err != nil {
+		*job = previous
+		delete(p.jobs, journalID)
+		p.dead[journalID] = job
+		return err
+	}
+
+	p.notifyLocked()
+	return nil
+}
+
+// RetryDeadLetters moves every dead-lettered job back to the queue
+func (p *Pool) RetryDeadLetters() (int, error) {
+	retried := 0
+	for _, job := range p.DeadLetters() {
+		err := p.RetryDeadLetter(job.JournalID)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return retried, err
+		}
+		retried++
+	}
+
+	return retried, nil
+}
+
+// PurgeDeadLetter discards a dead-lettered job
+func (p *Pool) PurgeDeadLetter(journalID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job, exists := p.dead[journalID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, journalID)
+	}
+
+	delete(p.dead, journalID)
+	if err := p.persistLocked(); err != nil {
+		p.dead[journalID] = job
+		return err
+	}
+
+	return nil
+}
+
+// PurgeDeadLetters discards every dead-lettered job
+func (p *Pool) PurgeDeadLetters() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	purged := p.dead
+	p.dead = make(map[string]*JobStatus)
+	if err := p.persistLocked(); err != nil {
+		p.dead = purged
+		return 0, err
+	}
+
+	return len(purged), nil
+}
+
+// EmbedMissing embeds the completed journals that have no vectors in the
+// index with the pool's worker; see InMemoryWorker.EmbedMissing
+func (p *Pool) EmbedMissing(ctx context.Context, journals []*models.Journal) int {
+	return p.worker.EmbedMissing(ctx, journals)
+}
+
+// Shutdown stops accepting jobs and waits for ready and in-flight jobs to
+// finish. Jobs waiting out a retry delay stay persisted for the next start.
+// If ctx expires first, in-flight jobs are cancelled and returned to the
+// queue, and ctx.Err() is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		p.notifyLocked()
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.cancel()
+	<-done
+
+	if remaining := p.Stats(); remaining.Queued+remaining.Retrying > 0 {
+		p.logger.Warn("processing jobs left in the queue at shutdown",
+			"queued", remaining.Queued,
+			"retrying", remaining.Retrying,
+			"durable", remaining.Durable)
+	}
+
+	return err
+}
+
+// run processes jobs until the pool is shut down and no job is ready
+func (p *Pool) run() {
+	defer p.wg.Done()
+
+	for p.ctx.Err() == nil {
+		job, wake, wait, ok := p.lease()
+		if !ok {
+			return
+		}
+		if job != nil {
+			p.process(job)
+			continue
+		}
+
+		p.idle(wake, wait)
+	}
+}
+
+// idle blocks until the queue changes, a retry becomes due or the pool stops
+func (p *Pool) idle(wake <-chan struct{}, wait time.Duration) {
+	var due <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		due = timer.C
+	}
+
+	select {
+	case <-wake:
+	case <-due:
+	case <-p.ctx.Done():
+	}
+}
+
+// lease claims the oldest job that is ready to run. When none is ready it
+// returns a channel that is closed on the next change and, if a retry is
+// scheduled, how long until it is due. ok is false once the pool is shut
+// down and nothing is ready.
+func (p *Pool) lease() (job *JobStatus, wake <-chan struct{}, wait time.Duration, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, candidate := range p.jobs {
+		if candidate.State != JobStateQueued {
+			continue
+		}
+		if candidate.NextAttemptAt != nil && candidate.NextAttemptAt.After(now) {
+			if until := candidate.NextAttemptAt.Sub(now); wait == 0 || until < wait {
+				wait = until
+			}
+			continue
+		}
+		if job == nil || candidate.EnqueuedAt.Before(job.EnqueuedAt) {
+			job = candidate
+		}
+	}
+
+	if job == nil {
+		return nil, p.wake, wait, !p.closed
+	}
+
+	startedAt := now.UTC()
+	job.State = JobStateProcessing
+	job.StartedAt = &startedAt
+	job.NextAttemptAt = nil
+	job.Attempts++
+	if err := p.persistLocked(); err != nil {
+		p.logger.Error("failed to persist job lease", "journal_id", job.JournalID, "error", err)
+	}
+
+	return job, nil, 0, true
+}
+
+// process runs AI processing for one job and stores the result
+func (p *Pool) process(job *JobStatus) {
+	journalID := job.JournalID
+
+	journal, err := p.store.Get(journalID)
+	if err != nil {
+		p.logger.Warn("skipping processing for missing journal", "journal_id", journalID, "error", err)
+		p.complete(job)
+		return
+	}
+
+	work := *journal
+	if err := p.worker.ProcessJournalWithGracefulFailure(p.ctx, &work); err != nil {
+		if p.ctx.Err() != nil {
+			p.release(job)
+			return
+		}
+		p.fail(job, &work, err)
+		return
+	}
+
+	if err := p.storeResult(&work); err != nil {
+		p.logger.Error("failed to store processing result",
+			"journal_id", journalID,
+			"error", err)
+	}
+	p.complete(job)
+}
+
+// fail schedules a retry for a failed attempt, or dead-letters the job once
+// it has used up its attempts. Invalid responses get fewer attempts and a
+// flat delay: the service is reachable, so waiting longer does not make the
+// next answer more likely to parse.
+func (p *Pool) fail(job *JobStatus, processed *models.Journal, err error) {
+	kind := FailureTransport
+	limit := p.config.MaxAttempts
+	if errors.Is(err, models.ErrInvalidAIResponse) {
+		kind = FailureInvalidResponse
+		limit = p.config.MaxParseAttempts
+	}
+
+	p.mu.Lock()
+	if p.jobs[job.JournalID] != job {
+		// A newer job for the same journal replaced this one
+		p.mu.Unlock()
+		return
+	}
+
+	job.LastError = err.Error()
+	job.LastFailure = kind
+	job.StartedAt = nil
+
+	if job.Attempts >= limit {
+		deadAt := time.Now().UTC()
+		job.State = JobStateDead
+		job.DeadAt = &deadAt
+		delete(p.jobs, job.JournalID)
+		p.dead[job.JournalID] = job
+		if persistErr := p.persistLocked(); persistErr != nil {
+			p.logger.Error("failed to persist dead-lettered job", "journal_id", job.JournalID, "error", persistErr)
+		}
+		p.mu.Unlock()
+
+		p.logger.Error("processing job moved to dead-letter list",
+			"journal_id", job.JournalID,
+			"attempts", job.Attempts,
+			"failure", kind,
+			"error", err)
+
+		// Record the failure on the journal so readers stop waiting for a result
+		if storeErr := p.storeResult(processed); storeErr != nil {
+			p.logger.Error("failed to store processing failure", "journal_id", job.JournalID, "error", storeErr)
+		}
+		return
+	}
+
+	delay := p.backoff(kind, job.Attempts)
+	nextAttemptAt := time.Now().Add(delay).UTC()
+	job.State = JobStateQueued
+	job.NextAttemptAt = &nextAttemptAt
+	if persistErr := p.persistLocked(); persistErr != nil {
+		p.logger.Error("failed to persist job retry", "journal_id", job.JournalID, "error", persistErr)
+	}
+	p.notifyLocked()
+	p.mu.Unlock()
+
+	p.logger.Warn("processing attempt failed, retrying",
+		"journal_id", job.JournalID,
+		"attempt", job.Attempts,
+		"max_attempts", limit,
+		"failure", kind,
+		"retry_in", delay,
+		"error", err)
+}
+
+// backoff returns the delay before the next attempt: exponential in the
+// number of attempts for transport errors, flat for invalid responses. Half
+// of the delay is random so retries from many jobs spread out.
+func (p *Pool) backoff(kind FailureKind, attempts int) time.Duration {
+	delay := p.config.BaseBackoff
+	if kind == FailureTransport {
+		for i := 1; i < attempts && delay < p.config.MaxBackoff; i++ {
+			delay *= 2
+		}
+	}
+	delay = min(delay, p.config.MaxBackoff)
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// release returns a job cancelled by shutdown to the queue without counting
+// the attempt, so it runs again on the next start
+func (p *Pool) release(job *JobStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.jobs[job.JournalID] != job {
+		return
+	}
+
+	job.State = JobStateQueued
+	job.StartedAt = nil
+	job.Attempts--
+	if err := p.persistLocked(); err != nil {
+		p.logger.Error("failed to persist released job", "journal_id", job.JournalID, "error", err)
+	}
+}
+
+// storeResult writes a processing result onto the latest version of the
+// journal, as long as its content is still the content that was processed
+func (p *Pool) storeResult(processed *models.Journal) error {
+	for attempt := 1; attempt <= maxWriteAttempts; attempt++ {
+		latest, err := p.store.Get(processed.ID)
+		if err != nil {
+			return err
+		}
+
+		if latest.Content != processed.Content {
+			// Edited while processing; the edit queued its own job
+			p.logger.Info("discarding processing result for outdated content", "journal_id", processed.ID)
+			return nil
+		}
+
+		updated := *latest
+		updated.ProcessingResult = processed.ProcessingResult
+		updated.UpdatedBy = ProcessorAuthor
+
+		err = p.store.Update(processed.ID, &updated)
+		if !errors.Is(err, storage.ErrVersionConflict) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("journal %s kept changing while storing its processing result", processed.ID)
+}
+
+// complete forgets a job unless a newer job for the same journal replaced it
+func (p *Pool) complete(job *JobStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.jobs[job.JournalID] != job {
+		return
+	}
+
+	delete(p.jobs, job.JournalID)
+	if err := p.persistLocked(); err != nil {
+		p.logger.Error("failed to persist completed job", "journal_id", job.JournalID, "error", err)
+	}
+}
+
+// waitingLocked counts jobs that have not started yet
+func (p *Pool) waitingLocked() int {
+	waiting := 0
+	for _, job := range p.jobs {
+		if job.State == JobStateQueued {
+			waiting++
+		}
+	}
+	return waiting
+}
+
+// notifyLocked wakes every idle worker
+func (p *Pool) notifyLocked() {
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+// persistLocked writes all jobs to the queue file, if the pool is durable
+func (p *Pool) persistLocked() error {
+	if p.file == nil {
+		return nil
+	}
+
+	state := queueState{
+		Jobs:        make([]*JobStatus, 0, len(p.jobs)),
+		DeadLetters: make([]*JobStatus, 0, len(p.dead)),
+	}
+	for _, job := range p.jobs {
+		state.Jobs = append(state.Jobs, job)
+	}
+	for _, job := range p.dead {
+		state.DeadLetters = append(state.DeadLetters, job)
+	}
+
+	byEnqueuedAt := func(a, b *JobStatus) int { return a.EnqueuedAt.Compare(b.EnqueuedAt) }
+	slices.SortFunc(state.Jobs, byEnqueuedAt)
+	slices.SortFunc(state.DeadLetters, byEnqueuedAt)
+
+	return p.file.save(state)
+}
@@ -0,0 +1,442 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/storage"
+	"github.com/garnizeh/englog/internal/worker"
+)
+
+// blockingAIProcessor holds every call until released
+type blockingAIProcessor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (b *blockingAIProcessor) ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+	b.started <- journal.Content
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &models.SentimentResult{Score: 0.5, Label: "positive", Confidence: 0.8}, nil
+}
+
+// flakyAIProcessor returns the queued errors in order, then succeeds
+type flakyAIProcessor struct {
+	mu       sync.Mutex
+	failures []error
+	calls    int
+}
+
+func (f *flakyAIProcessor) ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return nil, err
+	}
+	return &models.SentimentResult{Score: 0.1, Label: "neutral", Confidence: 0.6}, nil
+}
+
+func (f *flakyAIProcessor) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+var (
+	errTransport = errors.New("connection refused")
+	errParse     = fmt.Errorf("%w: failed to parse sentiment JSON", models.ErrInvalidAIResponse)
+)
+
+func newBlockingProcessor() *blockingAIProcessor {
+	return &blockingAIProcessor{started: make(chan string, 10), release: make(chan struct{})}
+}
+
+func newPool(t *testing.T, processor worker.AIProcessor, store worker.JournalStore, config worker.PoolConfig) *worker.Pool {
+	t.Helper()
+	pool, err := worker.NewPool(worker.NewInMemoryWorker(processor, logger()), store, config, logger())
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	return pool
+}
+
+func storeJournal(t *testing.T, store storage.JournalStore, id, content string) {
+	t.Helper()
+	journal := &models.Journal{
+		ID:               id,
+		Content:          content,
+		ProcessingResult: &models.ProcessingResult{Status: models.ProcessingStatusPending},
+	}
+	if err := store.Store(journal); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+}
+
+func waitForStatus(t *testing.T, store storage.JournalStore, id string, want models.ProcessingStatus) *models.Journal {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		journal, err := store.Get(id)
+		if err == nil && journal.ProcessingResult != nil && journal.ProcessingResult.Status == want {
+			return journal
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for journal %s to reach %s", id, want)
+	return nil
+}
+
+func TestPool_ProcessesAndStoresResults(t *testing.T) {
+	store := storage.NewMemoryStore()
+	pool := newPool(t, &mockAIProcessor{}, store, worker.PoolConfig{Workers: 2, QueueSize: 4})
+	defer pool.Shutdown(context.Background())
+
+	storeJournal(t, store, "a", "A journal waiting for analysis")
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	journal := waitForStatus(t, store, "a", models.ProcessingStatusCompleted)
+	if journal.ProcessingResult.SentimentResult == nil {
+		t.Error("Expected sentiment result to be stored")
+	}
+	if journal.UpdatedBy != worker.ProcessorAuthor || journal.Version != 2 {
+		t.Errorf("Expected result written as version 2 by %s, got version %d by %q", worker.ProcessorAuthor, journal.Version, journal.UpdatedBy)
+	}
+
+	if _, queued := pool.Status("a"); queued {
+		t.Error("Expected finished job to be forgotten")
+	}
+}
+
+func TestPool_RejectsWhenQueueIsFull(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := newBlockingProcessor()
+	pool := newPool(t, processor, store, worker.PoolConfig{Workers: 1, QueueSize: 1})
+
+	for _, id := range []string{"a", "b", "c"} {
+		storeJournal(t, store, id, "Journal "+id+" waiting for analysis")
+	}
+
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-processor.started // a is in flight, the queue is empty
+
+	if err := pool.Submit("b"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if err := pool.Submit("b"); err != nil {
+		t.Errorf("Expected duplicate queued submit to be coalesced, got %v", err)
+	}
+	if err := pool.Submit("c"); !errors.Is(err, worker.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	if status, _ := pool.Status("a"); status.State != worker.JobStateProcessing {
+		t.Errorf("Expected a to be processing, got %s", status.State)
+	}
+	if status, _ := pool.Status("b"); status.State != worker.JobStateQueued {
+		t.Errorf("Expected b to be queued, got %s", status.State)
+	}
+	if stats := pool.Stats(); stats.Queued != 1 || stats.Processing != 1 || stats.QueueCapacity != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	close(processor.release)
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// Shutdown drains queued work before returning
+	waitForStatus(t, store, "b", models.ProcessingStatusCompleted)
+	if err := pool.Submit("c"); !errors.Is(err, worker.ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed after shutdown, got %v", err)
+	}
+}
+
+func TestPool_DiscardsResultForEditedContent(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := newBlockingProcessor()
+	pool := newPool(t, processor, store, worker.PoolConfig{Workers: 1, QueueSize: 2})
+	defer pool.Shutdown(context.Background())
+
+	storeJournal(t, store, "a", "Original content")
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-processor.started
+
+	// Edit while the first job is running, then queue the reprocessing job
+	edited := &models.Journal{Content: "Edited content", ProcessingResult: &models.ProcessingResult{Status: models.ProcessingStatusStale}}
+	if err := store.Update("a", edited); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	close(processor.release)
+	if content := <-processor.started; content != "Edited content" {
+		t.Errorf("Expected second job to process edited content, got %q", content)
+	}
+
+	journal := waitForStatus(t, store, "a", models.ProcessingStatusCompleted)
+	if journal.Content != "Edited content" {
+		t.Errorf("Expected edited content to be kept, got %q", journal.Content)
+	}
+}
+
+func TestPoolConfigFromEnv(t *testing.T) {
+	t.Setenv("WORKER_POOL_SIZE", "3")
+	t.Setenv("WORKER_QUEUE_SIZE", "7")
+
+	config, err := worker.PoolConfigFromEnv()
+	if err != nil {
+		t.Fatalf("PoolConfigFromEnv failed: %v", err)
+	}
+	if config.Workers != 3 || config.QueueSize != 7 {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+
+	t.Setenv("WORKER_RETRY_BASE_DELAY", "500ms")
+	t.Setenv("WORKER_QUEUE_PATH", "/tmp/jobs.json")
+	config, err = worker.PoolConfigFromEnv()
+	if err != nil {
+		t.Fatalf("PoolConfigFromEnv failed: %v", err)
+	}
+	if config.BaseBackoff != 500*time.Millisecond || config.QueuePath != "/tmp/jobs.json" || config.MaxAttempts != 5 {
+		t.Errorf("Unexpected retry config: %+v", config)
+	}
+
+	t.Setenv("WORKER_RETRY_MAX_DELAY", "soon")
+	if _, err := worker.PoolConfigFromEnv(); err == nil {
+		t.Error("Expected error for invalid retry delay")
+	}
+
+	t.Setenv("WORKER_RETRY_MAX_DELAY", "")
+	t.Setenv("WORKER_QUEUE_SIZE", "none")
+	if _, err := worker.PoolConfigFromEnv(); err == nil {
+		t.Error("Expected error for invalid queue size")
+	}
+}
+
+func TestPool_RetriesTransportErrors(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := &flakyAIProcessor{failures: []error{errTransport, errTransport}}
+	pool := newPool(t, processor, store, worker.PoolConfig{Workers: 1, QueueSize: 2, BaseBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond})
+	defer pool.Shutdown(context.Background())
+
+	storeJournal(t, store, "a", "Journal behind a flaky connection")
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	journal := waitForStatus(t, store, "a", models.ProcessingStatusCompleted)
+	if journal.ProcessingResult.SentimentResult == nil {
+		t.Error("Expected sentiment result after retries")
+	}
+	if calls := processor.callCount(); calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+	if stats := pool.Stats(); stats.DeadLetters != 0 {
+		t.Errorf("Expected no dead letters, got %d", stats.DeadLetters)
+	}
+}
+
+func TestPool_SchedulesRetryWithBackoff(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := &flakyAIProcessor{failures: []error{errTransport}}
+	pool := newPool(t, processor, store, worker.PoolConfig{Workers: 1, QueueSize: 2, BaseBackoff: time.Hour, MaxBackoff: 2 * time.Hour})
+	defer pool.Shutdown(context.Background())
+
+	storeJournal(t, store, "a", "Journal waiting for a retry")
+	before := time.Now()
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Stats().Retrying == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for retry to be scheduled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	job, _ := pool.Status("a")
+	if job.State != worker.JobStateQueued || job.Attempts != 1 || job.LastFailure != worker.FailureTransport {
+		t.Errorf("Unexpected job after failed attempt: %+v", job)
+	}
+	// Jitter keeps the first delay between half and all of the base delay
+	if job.NextAttemptAt == nil || job.NextAttemptAt.Before(before.Add(30*time.Minute)) || job.NextAttemptAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("Unexpected next attempt time: %v", job.NextAttemptAt)
+	}
+
+	// New content skips the wait
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	waitForStatus(t, store, "a", models.ProcessingStatusCompleted)
+}
+
+func TestPool_DeadLettersAfterMaxAttempts(t *testing.T) {
+	store := storage.NewMemoryStore()
+	processor := &flakyAIProcessor{failures: []error{errParse, errParse, errTransport, errTransport, errTransport}}
+	pool := newPool(t, processor, store, worker.PoolConfig{
+		Workers:          1,
+		QueueSize:        2,
+		MaxAttempts:      3,
+		MaxParseAttempts: 2,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       time.Millisecond,
+	})
+	defer pool.Shutdown(context.Background())
+
+	storeJournal(t, store, "parse", "The model keeps answering with prose")
+	if err := pool.Submit("parse"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	// Invalid responses use the smaller parse budget
+	journal := waitForStatus(t, store, "parse", models.ProcessingStatusFailed)
+	if journal.ProcessingResult.Error == "" {
+		t.Error("Expected failure to be recorded on the journal")
+	}
+	job, found := pool.Status("parse")
+	if !found || job.State != worker.JobStateDead || job.Attempts != 2 || job.LastFailure != worker.FailureInvalidResponse {
+		t.Errorf("Unexpected dead-lettered job: %+v", job)
+	}
+
+	storeJournal(t, store, "transport", "The model server is down")
+	if err := pool.Submit("transport"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	waitForStatus(t, store, "transport", models.ProcessingStatusFailed)
+	if job, _ := pool.Status("transport"); job.Attempts != 3 || job.LastFailure != worker.FailureTransport {
+		t.Errorf("Unexpected dead-lettered job: %+v", job)
+	}
+
+	dead := pool.DeadLetters()
+	if len(dead) != 2 || dead[0].JournalID != "parse" || dead[1].JournalID != "transport" {
+		t.Fatalf("Unexpected dead letters: %+v", dead)
+	}
+
+	t.Run("Retry", func(t *testing.T) {
+		if err := pool.RetryDeadLetter("parse"); err != nil {
+			t.Fatalf("RetryDeadLetter failed: %v", err)
+		}
+		waitForStatus(t, store, "parse", models.ProcessingStatusCompleted)
+		if err := pool.RetryDeadLetter("parse"); !errors.Is(err, worker.ErrJobNotFound) {
+			t.Errorf("Expected ErrJobNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		if err := pool.PurgeDeadLetter("transport"); err != nil {
+			t.Fatalf("PurgeDeadLetter failed: %v", err)
+		}
+		if len(pool.DeadLetters()) != 0 {
+			t.Error("Expected dead-letter list to be empty")
+		}
+		if err := pool.PurgeDeadLetter("transport"); !errors.Is(err, worker.ErrJobNotFound) {
+			t.Errorf("Expected ErrJobNotFound, got %v", err)
+		}
+	})
+}
+
+func TestPool_ShutdownDeadlineKeepsInFlightJobs(t *testing.T) {
+	store := storage.NewMemoryStore()
+	queuePath := filepath.Join(t.TempDir(), "jobs.json")
+	processor := newBlockingProcessor()
+	pool := newPool(t, processor, store, worker.PoolConfig{Workers: 1, QueueSize: 2, QueuePath: queuePath})
+
+	storeJournal(t, store, "a", "Journal that is in flight at shutdown")
+	storeJournal(t, store, "b", "Journal still waiting at shutdown")
+	if err := pool.Submit("a"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-processor.started
+	if err := pool.Submit("b"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if journal, _ := store.Get("a"); journal.ProcessingResult.Status != models.ProcessingStatusPending {
+		t.Errorf("Expected cancelled job not to record a failure, got %s", journal.ProcessingResult.Status)
+	}
+
+	// A new pool on the same queue file picks up both jobs
+	restarted := newPool(t, &mockAIProcessor{}, store, worker.PoolConfig{Workers: 1, QueueSize: 2, QueuePath: queuePath})
+	defer restarted.Shutdown(context.Background())
+
+	waitForStatus(t, store, "a", models.ProcessingStatusCompleted)
+	waitForStatus(t, store, "b", models.ProcessingStatusCompleted)
+}
+
+func TestPool_ReleasesJobsInFlightAtCrash(t *testing.T) {
+	store := storage.NewMemoryStore()
+	queuePath := filepath.Join(t.TempDir(), "jobs.json")
+	storeJournal(t, store, "a", "Journal whose worker died mid-attempt")
+
+	// Queue file left behind by a process that died while processing "a"
+	state := `{"jobs": [{"journal_id": "a", "state": "processing", "attempts": 1, "enqueued_at": "2025-08-01T10:00:00Z", "started_at": "2025-08-01T10:00:01Z"}], "dead_letters": []}`
+	if err := os.WriteFile(queuePath, []byte(state), 0o644); err != nil {
+		t.Fatalf("Failed to write queue file: %v", err)
+	}
+
+	processor := &flakyAIProcessor{failures: []error{errParse}}
+	pool := newPool(t, processor, store, worker.PoolConfig{Workers: 1, QueueSize: 2, QueuePath: queuePath, MaxParseAttempts: 2})
+	defer pool.Shutdown(context.Background())
+
+	// The interrupted attempt still counts, so one more failure dead-letters it
+	waitForStatus(t, store, "a", models.ProcessingStatusFailed)
+	if job, _ := pool.Status("a"); job.State != worker.JobStateDead || job.Attempts != 2 {
+		t.Errorf("Unexpected job after recovery: %+v", job)
+	}
+	if calls := processor.callCount(); calls != 1 {
+		t.Errorf("Expected one attempt after recovery, got %d", calls)
+	}
+
+	// Dead letters survive a restart too
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	restarted := newPool(t, &mockAIProcessor{}, store, worker.PoolConfig{QueuePath: queuePath})
+	defer restarted.Shutdown(context.Background())
+	if dead := restarted.DeadLetters(); len(dead) != 1 || dead[0].JournalID != "a" {
+		t.Errorf("Expected dead letter to be recovered, got %+v", dead)
+	}
+}
+
+func TestNewPool_RejectsCorruptQueueFile(t *testing.T) {
+	queuePath := filepath.Join(t.TempDir(), "jobs.json")
+	if err := os.WriteFile(queuePath, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("Failed to write queue file: %v", err)
+	}
+
+	_, err := worker.NewPool(worker.NewInMemoryWorker(&mockAIProcessor{}, logger()), storage.NewMemoryStore(), worker.PoolConfig{QueuePath: queuePath}, logger())
+	if err == nil {
+		t.Error("Expected error for corrupt queue file")
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// queueState is the persisted form of the pool's jobs
+type queueState struct {
+	SavedAt     time.Time    `json:"saved_at"`
+	Jobs        []*JobStatus `json:"jobs"`
+	DeadLetters []*JobStatus `json:"dead_letters"`
+}
+
+// queueFile persists the pool's jobs as a JSON document that is replaced
+// atomically on every change, so a job is on disk before it is acknowledged
+type queueFile struct {
+	path string
+}
+
+// openQueueFile opens the queue file at path, creating its directory, and
+// returns the jobs it holds
+func openQueueFile(path string) (*queueFile, *queueState, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create job queue directory: %w", err)
+	}
+
+	state := &queueState{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to read job queue: %w", err)
+	default:
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode job queue %s: %w", path, err)
+		}
+	}
+
+	return &queueFile{path: path}, state, nil
+}
+
+// save writes the jobs to disk, syncing before replacing the previous file
+func (q *queueFile) save(state queueState) error {
+	state.SavedAt = time.Now().UTC()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode job queue: %w", err)
+	}
+
+	tmpPath := q.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write job queue: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write job queue: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync job queue: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write job queue: %w", err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace job queue: %w", err)
+	}
+
+	return nil
+}
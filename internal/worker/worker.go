@@ -2,36 +2,175 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/vector"
 )
 
+// Default deadlines of the tasks run for every journal
+const (
+	defaultSentimentTimeout  = 15 * time.Second
+	defaultEmotionTimeout    = 15 * time.Second
+	defaultExtractionTimeout = 15 * time.Second
+	defaultEmbeddingTimeout  = 15 * time.Second
+)
+
+// TaskTimeouts bounds each AI task ProcessJournal runs
+// Every task gets its own deadline from the caller's context, so a slow
+// sentiment analysis does not use up the time of the tasks after it.
+type TaskTimeouts struct {
+	Sentiment  time.Duration
+	Emotion    time.Duration
+	Extraction time.Duration
+	Embedding  time.Duration
+}
+
+// DefaultTaskTimeouts returns the task deadlines used when nothing is configured
+func DefaultTaskTimeouts() TaskTimeouts {
+	return TaskTimeouts{
+		Sentiment:  defaultSentimentTimeout,
+		Emotion:    defaultEmotionTimeout,
+		Extraction: defaultExtractionTimeout,
+		Embedding:  defaultEmbeddingTimeout,
+	}
+}
+
+// TaskTimeoutsFromEnv reads AI_SENTIMENT_TIMEOUT, AI_EMOTION_TIMEOUT,
+// AI_EXTRACTION_TIMEOUT and AI_EMBEDDING_TIMEOUT
+func TaskTimeoutsFromEnv() (TaskTimeouts, error) {
+	timeouts := DefaultTaskTimeouts()
+
+	for name, target := range map[string]*time.Duration{
+		"AI_SENTIMENT_TIMEOUT":  &timeouts.Sentiment,
+		"AI_EMOTION_TIMEOUT":    &timeouts.Emotion,
+		"AI_EXTRACTION_TIMEOUT": &timeouts.Extraction,
+		"AI_EMBEDDING_TIMEOUT":  &timeouts.Embedding,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(raw)
+		if err != nil || value <= 0 {
+			return timeouts, fmt.Errorf("invalid %s %q: must be a positive duration", name, raw)
+		}
+		*target = value
+	}
+
+	return timeouts, nil
+}
+
 // AIProcessor interface defines the contract for AI processing services
 type AIProcessor interface {
 	ProcessJournalSentiment(ctx context.Context, journal *models.Journal) (*models.SentimentResult, error)
 }
 
+// emotionAnalyzer is implemented by AI services that can analyze emotions
+// beyond a single sentiment score
+type emotionAnalyzer interface {
+	ProcessJournalEmotions(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error)
+}
+
+// entityExtractor is implemented by AI services that can extract entities
+// and topics from journals
+type entityExtractor interface {
+	ProcessJournalExtraction(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error)
+}
+
+// embedder is implemented by AI services that can embed text as vectors
+type embedder interface {
+	Embed(ctx context.Context, texts []string) (*models.Embeddings, error)
+}
+
+// reasoningTracer is implemented by errors that carry the model's reasoning
+type reasoningTracer interface {
+	ReasoningTrace() string
+}
+
 // InMemoryWorker handles synchronous AI processing of journal entries
 type InMemoryWorker struct {
-	aiService AIProcessor
-	logger    *logging.Logger
+	aiService       AIProcessor
+	logger          *logging.Logger
+	storeReasoning  bool
+	analyzeEmotions bool
+	extractEntities bool
+	embedJournals   bool
+	index           *vector.Index
+	timeouts        TaskTimeouts
 }
 
 // NewInMemoryWorker creates a new in-memory worker instance
+// Emotions are analyzed and entities extracted along with sentiment when
+// aiService supports it.
 func NewInMemoryWorker(aiService AIProcessor, logger *logging.Logger) *InMemoryWorker {
 	return &InMemoryWorker{
-		aiService: aiService,
-		logger:    logger,
+		aiService:       aiService,
+		logger:          logger,
+		analyzeEmotions: true,
+		extractEntities: true,
+		embedJournals:   true,
+		timeouts:        DefaultTaskTimeouts(),
 	}
 }
 
+// SetTaskTimeouts sets the deadline of each AI task; zero fields keep the
+// defaults
+func (w *InMemoryWorker) SetTaskTimeouts(timeouts TaskTimeouts) {
+	defaults := DefaultTaskTimeouts()
+	if timeouts.Sentiment <= 0 {
+		timeouts.Sentiment = defaults.Sentiment
+	}
+	if timeouts.Emotion <= 0 {
+		timeouts.Emotion = defaults.Emotion
+	}
+	if timeouts.Extraction <= 0 {
+		timeouts.Extraction = defaults.Extraction
+	}
+	if timeouts.Embedding <= 0 {
+		timeouts.Embedding = defaults.Embedding
+	}
+	w.timeouts = timeouts
+}
+
+// SetStoreReasoning controls whether the model's chain of thought is kept in
+// ProcessingResult.Reasoning, for successful and unparseable responses alike
+func (w *InMemoryWorker) SetStoreReasoning(enabled bool) {
+	w.storeReasoning = enabled
+}
+
+// SetEmotionAnalysis controls whether emotions are analyzed after sentiment
+func (w *InMemoryWorker) SetEmotionAnalysis(enabled bool) {
+	w.analyzeEmotions = enabled
+}
+
+// SetExtraction controls whether entities and topics are extracted after sentiment
+func (w *InMemoryWorker) SetExtraction(enabled bool) {
+	w.extractEntities = enabled
+}
+
+// SetVectorIndex sets the index that processed journals are embedded into
+// Without one, journals are not embedded.
+func (w *InMemoryWorker) SetVectorIndex(index *vector.Index) {
+	w.index = index
+}
+
+// SetEmbeddings controls whether journals are embedded into the vector index
+func (w *InMemoryWorker) SetEmbeddings(enabled bool) {
+	w.embedJournals = enabled
+}
+
 // ProcessJournal performs synchronous AI processing on a journal entry
-func (w *InMemoryWorker) ProcessJournal(ctx context.Context, journal *models.Journal) {
+// The outcome is recorded in journal.ProcessingResult; the AI error, if any,
+// is also returned so callers can decide whether to retry
+func (w *InMemoryWorker) ProcessJournal(ctx context.Context, journal *models.Journal) error {
 	if journal == nil {
 		w.logger.Error("cannot process nil journal")
-		return
+		return errors.New("cannot process nil journal")
 	}
 
 	w.logger.Info("starting journal processing",
@@ -45,12 +184,19 @@ func (w *InMemoryWorker) ProcessJournal(ctx context.Context, journal *models.Jou
 		Status: models.ProcessingStatusPending,
 	}
 
-	// Set timeout for AI processing to prevent hanging requests
-	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-
-	// Perform sentiment analysis
-	sentimentResult, err := w.aiService.ProcessJournalSentiment(ctx, journal)
+	// Perform sentiment analysis, then emotion analysis and extraction, each
+	// under its own timeout to prevent hanging requests
+	sentimentCtx, cancel := context.WithTimeout(ctx, w.timeouts.Sentiment)
+	sentimentResult, err := w.aiService.ProcessJournalSentiment(sentimentCtx, journal)
+	cancel()
+	var (
+		emotionResult *models.EmotionResult
+		extraction    *models.ExtractionResult
+	)
+	if err == nil {
+		emotionResult = w.processEmotions(ctx, journal)
+		extraction = w.processExtraction(ctx, journal)
+	}
 	processingTime := time.Since(start)
 
 	if err != nil {
@@ -64,7 +210,12 @@ func (w *InMemoryWorker) ProcessJournal(ctx context.Context, journal *models.Jou
 		journal.ProcessingResult.Error = err.Error()
 		processingTimePtr := processingTime
 		journal.ProcessingResult.ProcessingTime = &processingTimePtr
-		return
+
+		var traced reasoningTracer
+		if w.storeReasoning && errors.As(err, &traced) {
+			journal.ProcessingResult.Reasoning = traced.ReasoningTrace()
+		}
+		return err
 	}
 
 	// Update processing result with success
@@ -75,19 +226,163 @@ func (w *InMemoryWorker) ProcessJournal(ctx context.Context, journal *models.Jou
 		SentimentResult: sentimentResult,
 		ProcessedAt:     &processedAt,
 		ProcessingTime:  &processingTimePtr,
+		Provider:        sentimentResult.Provider,
+		Prompt:          sentimentResult.Prompt,
+		Options:         sentimentResult.Options,
+		EmotionResult:   emotionResult,
+		Extraction:      extraction,
 	}
+	if w.storeReasoning {
+		journal.ProcessingResult.Reasoning = sentimentResult.Reasoning
+	}
+
+	embedCtx, cancel := context.WithTimeout(ctx, w.timeouts.Embedding)
+	w.embed(embedCtx, journal)
+	cancel()
 
 	w.logger.Info("journal processing completed successfully",
 		"journal_id", journal.ID,
 		"sentiment_score", sentimentResult.Score,
 		"sentiment_label", sentimentResult.Label,
 		"confidence", sentimentResult.Confidence,
+		"provider", sentimentResult.Provider,
+		"prompt", sentimentResult.Prompt,
+		"emotions", emotionResult != nil,
+		"extraction", extraction != nil,
 		"processing_time", processingTime)
+
+	return nil
+}
+
+// processEmotions analyzes the emotions of a journal; it returns no result
+// when emotion analysis is disabled, unsupported or fails
+// Emotions are supplementary to the sentiment, so a failure is logged rather
+// than failing the journal.
+func (w *InMemoryWorker) processEmotions(ctx context.Context, journal *models.Journal) *models.EmotionResult {
+	analyzer, ok := w.aiService.(emotionAnalyzer)
+	if !ok || !w.analyzeEmotions {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeouts.Emotion)
+	defer cancel()
+
+	result, err := analyzer.ProcessJournalEmotions(ctx, journal)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		w.logger.Warn("emotion analysis failed",
+			"journal_id", journal.ID,
+			"error", err)
+		return nil
+	}
+	return result
+}
+
+// processExtraction extracts the entities and topics of a journal; it returns
+// no result when extraction is disabled, unsupported or fails
+// Like emotions, a failed extraction is logged rather than failing the journal.
+func (w *InMemoryWorker) processExtraction(ctx context.Context, journal *models.Journal) *models.ExtractionResult {
+	extractor, ok := w.aiService.(entityExtractor)
+	if !ok || !w.extractEntities {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeouts.Extraction)
+	defer cancel()
+
+	result, err := extractor.ProcessJournalExtraction(ctx, journal)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		w.logger.Warn("extraction failed",
+			"journal_id", journal.ID,
+			"error", err)
+		return nil
+	}
+	return result
+}
+
+// EmbedMissing embeds the completed journals that have no vectors in the
+// index, such as those whose vectors were dropped as outdated or lost when
+// the server stopped before saving the index, and returns how many it embedded
+// It stops early when ctx is canceled.
+func (w *InMemoryWorker) EmbedMissing(ctx context.Context, journals []*models.Journal) int {
+	if _, ok := w.aiService.(embedder); !ok || !w.embedJournals || w.index == nil {
+		return 0
+	}
+
+	embedded := 0
+	for _, journal := range journals {
+		if ctx.Err() != nil {
+			break
+		}
+		if journal.ProcessingResult == nil || journal.ProcessingResult.Status != models.ProcessingStatusCompleted || w.index.Has(journal.ID) {
+			continue
+		}
+
+		journalCtx, cancel := context.WithTimeout(ctx, w.timeouts.Embedding)
+		if w.embed(journalCtx, journal) {
+			embedded++
+		}
+		cancel()
+	}
+	return embedded
+}
+
+// embed stores the chunk vectors of a journal in the vector index and
+// reports whether it did
+// The index is derived data, so a failure is logged rather than failing the
+// journal; it is embedded again the next time it is processed or the server
+// starts.
+func (w *InMemoryWorker) embed(ctx context.Context, journal *models.Journal) bool {
+	embedder, ok := w.aiService.(embedder)
+	if !ok || !w.embedJournals || w.index == nil {
+		return false
+	}
+
+	spans := vector.Split(journal.Content, vector.ChunkWords, vector.ChunkOverlap)
+	if len(spans) == 0 {
+		w.index.Remove(journal.ID)
+		return false
+	}
+
+	result, err := embedder.Embed(ctx, vector.Texts(journal.Content, spans))
+	if errors.Is(err, errors.ErrUnsupported) {
+		return false
+	}
+	if err == nil && len(result.Vectors) != len(spans) {
+		err = fmt.Errorf("got %d vectors for %d chunks", len(result.Vectors), len(spans))
+	}
+	if err == nil {
+		chunks := make([]vector.Chunk, len(spans))
+		for i, span := range spans {
+			chunks[i] = vector.Chunk{Span: span, Vector: result.Vectors[i]}
+		}
+		err = w.index.Add(journal.ID, result.Model, vector.ContentHash(journal.Content), chunks)
+	}
+	if err != nil {
+		// Stale vectors would match content the journal no longer has
+		w.index.Remove(journal.ID)
+		w.logger.Warn("journal embedding failed",
+			"journal_id", journal.ID,
+			"error", err)
+		return false
+	}
+
+	w.logger.Debug("journal embedded",
+		"journal_id", journal.ID,
+		"model", result.Model,
+		"chunks", len(spans))
+	return true
 }
 
 // ProcessJournalWithGracefulFailure processes a journal entry with graceful degradation
 // If processing fails, the journal is still considered valid but without AI results
-func (w *InMemoryWorker) ProcessJournalWithGracefulFailure(ctx context.Context, journal *models.Journal) {
+// A panic is recovered and reported as an error
+func (w *InMemoryWorker) ProcessJournalWithGracefulFailure(ctx context.Context, journal *models.Journal) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			w.logger.Error("journal processing panicked",
@@ -100,8 +395,9 @@ func (w *InMemoryWorker) ProcessJournalWithGracefulFailure(ctx context.Context,
 			}
 			journal.ProcessingResult.Status = models.ProcessingStatusFailed
 			journal.ProcessingResult.Error = "processing panicked"
+			err = fmt.Errorf("processing panicked: %v", r)
 		}
 	}()
 
-	w.ProcessJournal(ctx, journal)
+	return w.ProcessJournal(ctx, journal)
 }
@@ -3,11 +3,15 @@ package worker_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/garnizeh/englog/internal/ai"
+	"github.com/garnizeh/englog/internal/ai/parse"
 	"github.com/garnizeh/englog/internal/logging"
 	"github.com/garnizeh/englog/internal/models"
+	"github.com/garnizeh/englog/internal/vector"
 	"github.com/garnizeh/englog/internal/worker"
 	"github.com/google/uuid"
 )
@@ -15,6 +19,7 @@ import (
 // mockAIProcessor is a mock implementation of AIProcessor for testing
 type mockAIProcessor struct {
 	shouldFail      bool
+	failErr         error
 	delay           time.Duration
 	sentimentResult *models.SentimentResult
 }
@@ -30,6 +35,10 @@ func (m *mockAIProcessor) ProcessJournalSentiment(ctx context.Context, journal *
 		}
 	}
 
+	if m.failErr != nil {
+		return nil, m.failErr
+	}
+
 	if m.shouldFail {
 		return nil, errors.New("mock AI processing error")
 	}
@@ -49,12 +58,15 @@ func (m *mockAIProcessor) ProcessJournalSentiment(ctx context.Context, journal *
 
 func TestInMemoryWorker_ProcessJournal_Success(t *testing.T) {
 	// Arrange
+	seed := 42
 	mockAI := &mockAIProcessor{
 		sentimentResult: &models.SentimentResult{
 			Score:       0.8,
 			Label:       "positive",
 			Confidence:  0.9,
 			ProcessedAt: time.Now(),
+			Prompt:      &models.PromptRef{Name: "sentiment", Version: "v2"},
+			Options:     &models.GenerationOptions{Seed: &seed},
 		},
 	}
 	worker := worker.NewInMemoryWorker(mockAI, logger())
@@ -95,6 +107,14 @@ func TestInMemoryWorker_ProcessJournal_Success(t *testing.T) {
 	if journal.ProcessingResult.ProcessingTime == nil {
 		t.Error("Expected processing_time to be set")
 	}
+
+	if journal.ProcessingResult.Prompt == nil || journal.ProcessingResult.Prompt.String() != "sentiment@v2" {
+		t.Errorf("Expected prompt sentiment@v2, got %v", journal.ProcessingResult.Prompt)
+	}
+
+	if journal.ProcessingResult.Options == nil || *journal.ProcessingResult.Options.Seed != 42 {
+		t.Errorf("Expected the generation options to be recorded, got %+v", journal.ProcessingResult.Options)
+	}
 }
 
 func TestInMemoryWorker_ProcessJournal_Failure(t *testing.T) {
@@ -134,6 +154,312 @@ func TestInMemoryWorker_ProcessJournal_Failure(t *testing.T) {
 	}
 }
 
+func TestInMemoryWorker_StoreReasoning(t *testing.T) {
+	reasoned := &mockAIProcessor{
+		sentimentResult: &models.SentimentResult{
+			Score:      0.6,
+			Label:      "positive",
+			Confidence: 0.8,
+			Reasoning:  "The writer mentions a promotion.",
+		},
+	}
+
+	journal := &models.Journal{ID: uuid.New().String(), Content: "Got the promotion today"}
+
+	w := worker.NewInMemoryWorker(reasoned, logger())
+	w.ProcessJournal(context.Background(), journal)
+	if journal.ProcessingResult.Reasoning != "" {
+		t.Errorf("Expected reasoning to be dropped by default, got %q", journal.ProcessingResult.Reasoning)
+	}
+
+	w.SetStoreReasoning(true)
+	w.ProcessJournal(context.Background(), journal)
+	if journal.ProcessingResult.Reasoning != "The writer mentions a promotion." {
+		t.Errorf("Expected reasoning to be stored, got %q", journal.ProcessingResult.Reasoning)
+	}
+
+	// Reasoning is kept for unparseable answers too, where it helps most
+	_, parseErr := parse.Sentiment(`<think>Maybe neutral?</think>{"score": "high"}`)
+	unparseable := &mockAIProcessor{failErr: fmt.Errorf("sentiment analysis failed: %w", parseErr)}
+
+	w = worker.NewInMemoryWorker(unparseable, logger())
+	w.SetStoreReasoning(true)
+	w.ProcessJournal(context.Background(), journal)
+	if journal.ProcessingResult.Status != models.ProcessingStatusFailed || journal.ProcessingResult.Reasoning != "Maybe neutral?" {
+		t.Errorf("Expected failed result with reasoning, got %+v", journal.ProcessingResult)
+	}
+}
+
+// emotionProcessor adds emotion analysis to mockAIProcessor
+type emotionProcessor struct {
+	mockAIProcessor
+	emotionResult *models.EmotionResult
+	emotionErr    error
+	calls         int
+}
+
+func (m *emotionProcessor) ProcessJournalEmotions(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+	m.calls++
+	return m.emotionResult, m.emotionErr
+}
+
+func TestInMemoryWorker_ProcessJournal_Emotions(t *testing.T) {
+	emotions := &models.EmotionResult{
+		Emotions:        models.Emotions{Joy: 0.8, Anticipation: 0.3},
+		Valence:         0.7,
+		Arousal:         0.6,
+		DominantEmotion: models.EmotionJoy,
+		Provider:        "ollama",
+	}
+
+	t.Run("recorded next to sentiment", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Today was a wonderful day!"}
+		w := worker.NewInMemoryWorker(&emotionProcessor{emotionResult: emotions}, logger())
+
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("ProcessJournal failed: %v", err)
+		}
+		result := journal.ProcessingResult
+		if result.Status != models.ProcessingStatusCompleted || result.SentimentResult == nil || result.EmotionResult != emotions {
+			t.Errorf("Expected sentiment and emotions, got %+v", result)
+		}
+	})
+
+	t.Run("failure keeps the sentiment", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Today was a wonderful day!"}
+		w := worker.NewInMemoryWorker(&emotionProcessor{emotionErr: errors.New("model unavailable")}, logger())
+
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("Expected the emotion error not to fail the journal, got %v", err)
+		}
+		result := journal.ProcessingResult
+		if result.Status != models.ProcessingStatusCompleted || result.SentimentResult == nil || result.EmotionResult != nil || result.Error != "" {
+			t.Errorf("Expected a completed result with sentiment only, got %+v", result)
+		}
+	})
+
+	t.Run("unsupported is skipped", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Today was a wonderful day!"}
+		unsupported := fmt.Errorf("emotion analysis: %w", errors.ErrUnsupported)
+		w := worker.NewInMemoryWorker(&emotionProcessor{emotionErr: unsupported}, logger())
+
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("ProcessJournal failed: %v", err)
+		}
+		if journal.ProcessingResult.Status != models.ProcessingStatusCompleted || journal.ProcessingResult.EmotionResult != nil {
+			t.Errorf("Expected sentiment only, got %+v", journal.ProcessingResult)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Today was a wonderful day!"}
+		processor := &emotionProcessor{emotionResult: emotions}
+		w := worker.NewInMemoryWorker(processor, logger())
+		w.SetEmotionAnalysis(false)
+
+		w.ProcessJournal(context.Background(), journal)
+		if processor.calls != 0 || journal.ProcessingResult.EmotionResult != nil {
+			t.Errorf("Expected no emotion analysis, got %d calls", processor.calls)
+		}
+	})
+}
+
+// extractionProcessor adds entity extraction to mockAIProcessor
+type extractionProcessor struct {
+	mockAIProcessor
+	extraction    *models.ExtractionResult
+	extractionErr error
+	calls         int
+}
+
+func (m *extractionProcessor) ProcessJournalExtraction(ctx context.Context, journal *models.Journal) (*models.ExtractionResult, error) {
+	m.calls++
+	return m.extraction, m.extractionErr
+}
+
+func TestInMemoryWorker_ProcessJournal_Extraction(t *testing.T) {
+	extraction := &models.ExtractionResult{
+		People: []models.ExtractedItem{{Name: "Alice", Confidence: 0.9}},
+		Themes: []models.ExtractedItem{{Name: "friendship", Confidence: 0.8}},
+	}
+
+	t.Run("recorded next to sentiment", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Coffee with Alice"}
+		w := worker.NewInMemoryWorker(&extractionProcessor{extraction: extraction}, logger())
+
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("ProcessJournal failed: %v", err)
+		}
+		if result := journal.ProcessingResult; result.SentimentResult == nil || result.Extraction != extraction {
+			t.Errorf("Expected sentiment and extraction, got %+v", result)
+		}
+	})
+
+	t.Run("failure keeps the sentiment", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Coffee with Alice"}
+		w := worker.NewInMemoryWorker(&extractionProcessor{extractionErr: errors.New("model unavailable")}, logger())
+
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("Expected the extraction error not to fail the journal, got %v", err)
+		}
+		result := journal.ProcessingResult
+		if result.Status != models.ProcessingStatusCompleted || result.SentimentResult == nil || result.Extraction != nil {
+			t.Errorf("Expected a completed result without extraction, got %+v", result)
+		}
+	})
+
+	t.Run("unsupported is skipped", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Coffee with Alice"}
+		unsupported := fmt.Errorf("extraction: %w", errors.ErrUnsupported)
+		w := worker.NewInMemoryWorker(&extractionProcessor{extractionErr: unsupported}, logger())
+
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("ProcessJournal failed: %v", err)
+		}
+		if journal.ProcessingResult.Extraction != nil {
+			t.Errorf("Expected no extraction, got %+v", journal.ProcessingResult.Extraction)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		journal := &models.Journal{ID: uuid.New().String(), Content: "Coffee with Alice"}
+		processor := &extractionProcessor{extraction: extraction}
+		w := worker.NewInMemoryWorker(processor, logger())
+		w.SetExtraction(false)
+
+		w.ProcessJournal(context.Background(), journal)
+		if processor.calls != 0 || journal.ProcessingResult.Extraction != nil {
+			t.Errorf("Expected no extraction, got %d calls", processor.calls)
+		}
+	})
+}
+
+// embeddingProcessor adds the deterministic mock embedder to mockAIProcessor
+type embeddingProcessor struct {
+	mockAIProcessor
+	embedErr error
+	block    bool
+}
+
+func (m *embeddingProcessor) Embed(ctx context.Context, texts []string) (*models.Embeddings, error) {
+	if m.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if m.embedErr != nil {
+		return nil, m.embedErr
+	}
+	return ai.NewMockAIProvider().Embed(ctx, texts)
+}
+
+func TestInMemoryWorker_ProcessJournal_Embeddings(t *testing.T) {
+	t.Run("indexed after analysis", func(t *testing.T) {
+		index := vector.NewIndex()
+		w := worker.NewInMemoryWorker(&embeddingProcessor{}, logger())
+		w.SetVectorIndex(index)
+
+		journals := []*models.Journal{
+			{ID: "run", Content: "Long run along the river before work"},
+			{ID: "bread", Content: "Baked sourdough bread with the kids"},
+		}
+		for _, journal := range journals {
+			if err := w.ProcessJournal(context.Background(), journal); err != nil {
+				t.Fatalf("ProcessJournal failed: %v", err)
+			}
+		}
+
+		if index.Len() != 2 {
+			t.Fatalf("Expected 2 embedded journals, got %d", index.Len())
+		}
+		query, _ := ai.NewMockAIProvider().Embed(context.Background(), []string{"river run"})
+		results := index.Search(ai.MockEmbeddingModel, query.Vectors[0], 1)
+		if len(results) != 1 || results[0].ID != "run" {
+			t.Errorf("Expected the run journal to match, got %+v", results)
+		}
+	})
+
+	t.Run("failure keeps the analysis", func(t *testing.T) {
+		index := vector.NewIndex()
+		index.Add("run", "old-model", "old", []vector.Chunk{{Vector: []float32{1}}})
+		w := worker.NewInMemoryWorker(&embeddingProcessor{embedErr: errors.New("model not found")}, logger())
+		w.SetVectorIndex(index)
+
+		journal := &models.Journal{ID: "run", Content: "Long run along the river"}
+		if err := w.ProcessJournal(context.Background(), journal); err != nil {
+			t.Fatalf("Expected embedding errors not to fail the journal, got %v", err)
+		}
+		if journal.ProcessingResult.Status != models.ProcessingStatusCompleted || index.Len() != 0 {
+			t.Errorf("Expected a completed journal without stale vectors, got %+v and %d vectors", journal.ProcessingResult, index.Len())
+		}
+	})
+
+	t.Run("hung embedder times out", func(t *testing.T) {
+		index := vector.NewIndex()
+		w := worker.NewInMemoryWorker(&embeddingProcessor{block: true}, logger())
+		w.SetVectorIndex(index)
+		w.SetTaskTimeouts(worker.TaskTimeouts{Embedding: 50 * time.Millisecond})
+
+		done := make(chan error, 1)
+		journal := &models.Journal{ID: "run", Content: "Long run along the river"}
+		go func() { done <- w.ProcessJournal(context.Background(), journal) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Expected a timed out embedding not to fail the journal, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the embedding deadline to end a hung embed call")
+		}
+		if journal.ProcessingResult.Status != models.ProcessingStatusCompleted || index.Len() != 0 {
+			t.Errorf("Expected a completed journal without vectors, got %+v and %d vectors", journal.ProcessingResult, index.Len())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		index := vector.NewIndex()
+		w := worker.NewInMemoryWorker(&embeddingProcessor{}, logger())
+		w.SetVectorIndex(index)
+		w.SetEmbeddings(false)
+
+		w.ProcessJournal(context.Background(), &models.Journal{ID: "run", Content: "Long run"})
+		if index.Len() != 0 {
+			t.Errorf("Expected no embeddings, got %d", index.Len())
+		}
+	})
+}
+
+func TestInMemoryWorker_EmbedMissing(t *testing.T) {
+	completed := func(id, content string) *models.Journal {
+		return &models.Journal{ID: id, Content: content, ProcessingResult: &models.ProcessingResult{Status: models.ProcessingStatusCompleted}}
+	}
+	journals := []*models.Journal{
+		completed("run", "Long run along the river before work"),
+		completed("bread", "Baked sourdough bread with the kids"),
+		{ID: "pending", Content: "Not analyzed yet", ProcessingResult: &models.ProcessingResult{Status: models.ProcessingStatusPending}},
+		{ID: "new", Content: "Not analyzed at all"},
+	}
+
+	index := vector.NewIndex()
+	index.Add("run", ai.MockEmbeddingModel, vector.ContentHash(journals[0].Content), []vector.Chunk{{Vector: []float32{1}}})
+	w := worker.NewInMemoryWorker(&embeddingProcessor{}, logger())
+	w.SetVectorIndex(index)
+
+	if embedded := w.EmbedMissing(context.Background(), journals); embedded != 1 {
+		t.Errorf("Expected only the completed journal without vectors to be embedded, got %d", embedded)
+	}
+	if !index.Has("bread") || index.Has("pending") || index.Has("new") || index.Len() != 2 {
+		t.Errorf("Unexpected index contents, %d journals", index.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	index.Remove("bread")
+	if embedded := w.EmbedMissing(ctx, journals); embedded != 0 || index.Has("bread") {
+		t.Errorf("Expected a canceled backfill to embed nothing, got %d", embedded)
+	}
+}
+
 func TestInMemoryWorker_ProcessJournal_Timeout(t *testing.T) {
 	// Arrange
 	mockAI := &mockAIProcessor{
@@ -170,6 +496,69 @@ func TestInMemoryWorker_ProcessJournal_Timeout(t *testing.T) {
 	}
 }
 
+// slowEmotionProcessor answers sentiment after a delay and reports whether
+// emotion analysis still had time left
+type slowEmotionProcessor struct {
+	mockAIProcessor
+	emotionDeadline time.Duration
+}
+
+func (m *slowEmotionProcessor) ProcessJournalEmotions(ctx context.Context, journal *models.Journal) (*models.EmotionResult, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, errors.New("no deadline")
+	}
+	m.emotionDeadline = time.Until(deadline)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &models.EmotionResult{DominantEmotion: models.EmotionJoy}, nil
+}
+
+func TestInMemoryWorker_TaskTimeouts(t *testing.T) {
+	processor := &slowEmotionProcessor{mockAIProcessor: mockAIProcessor{delay: 150 * time.Millisecond}}
+	w := worker.NewInMemoryWorker(processor, logger())
+	w.SetTaskTimeouts(worker.TaskTimeouts{Sentiment: 200 * time.Millisecond, Emotion: time.Second})
+
+	journal := &models.Journal{ID: uuid.New().String(), Content: "Today was a wonderful day!"}
+	if err := w.ProcessJournal(context.Background(), journal); err != nil {
+		t.Fatalf("ProcessJournal failed: %v", err)
+	}
+	if journal.ProcessingResult.EmotionResult == nil {
+		t.Errorf("Expected emotion analysis to get its own deadline, got %+v", journal.ProcessingResult)
+	}
+	if processor.emotionDeadline < 900*time.Millisecond {
+		t.Errorf("Expected an emotion deadline of about 1s, got %v", processor.emotionDeadline)
+	}
+
+	w.SetTaskTimeouts(worker.TaskTimeouts{Sentiment: 50 * time.Millisecond})
+	journal = &models.Journal{ID: uuid.New().String(), Content: "Today was a wonderful day!"}
+	if err := w.ProcessJournal(context.Background(), journal); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the sentiment deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestTaskTimeoutsFromEnv(t *testing.T) {
+	if timeouts, err := worker.TaskTimeoutsFromEnv(); err != nil || timeouts != worker.DefaultTaskTimeouts() {
+		t.Errorf("Expected the defaults, got %+v, %v", timeouts, err)
+	}
+
+	t.Setenv("AI_SENTIMENT_TIMEOUT", "30s")
+	t.Setenv("AI_EMOTION_TIMEOUT", "45s")
+	t.Setenv("AI_EXTRACTION_TIMEOUT", "20s")
+	t.Setenv("AI_EMBEDDING_TIMEOUT", "1m")
+	timeouts, err := worker.TaskTimeoutsFromEnv()
+	want := worker.TaskTimeouts{Sentiment: 30 * time.Second, Emotion: 45 * time.Second, Extraction: 20 * time.Second, Embedding: time.Minute}
+	if err != nil || timeouts != want {
+		t.Errorf("Expected %+v, got %+v, %v", want, timeouts, err)
+	}
+
+	t.Setenv("AI_EMOTION_TIMEOUT", "0s")
+	if _, err := worker.TaskTimeoutsFromEnv(); err == nil {
+		t.Error("Expected an error for a zero timeout")
+	}
+}
+
 func TestInMemoryWorker_ProcessJournal_NilJournal(t *testing.T) {
 	// Arrange
 	mockAI := &mockAIProcessor{}